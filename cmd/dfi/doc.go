@@ -11,12 +11,25 @@ Usage:
 File system objects can be searched by criteria:
 
  * Names and fragments of the full path, including full-text search (the result depends on the used DBMS)
+ * Full paths matched by shell patterns and names matched by regular expressions, the same on all DBMS
  * Size and modification time of the object, search by ranges and sets of values is possible
- * Type - file, directory, symbolic link
+ * Type - file, directory, symbolic link, member of zip or tar archive
+ * Resolved targets of symbolic links, search for dangling links and links pointing into some path
  * Host where the object is located
  * File checksum
- * File identifier to search for duplicates
+ * Owner, group and permission bits, including search by permission masks
+ * Inode change time, inode number and number of hard links
+ * Content type (MIME type) of regular files detected by magic bytes
+ * Capture time and camera of photos and videos
+ * Text content of documents - plain text, Markdown, HTML, PDF, OpenDocument
+ * File identifier to search for duplicates, hard links are recognized and can replace duplicates
+ * Any of the criteria above to report disk usage of found objects by directories, hosts, types or tags
+ * Liveness of agents - objects of hosts with stale agents are marked or excluded
+ * History of changes of objects - when and where the object was created, modified or deleted
+ * Live notifications about new and changed objects that match any of the criteria above
  * Additional information items values (tags, descriptions)
+ * Query expressions combining any of the criteria above by AND, OR, NOT and parentheses,
+   including matching of names and paths by shell patterns
 
 # Setting additional information items to objects
 
@@ -50,10 +63,22 @@ Search by native properties of file system objects:
   # Search for all objects with a size of 10 GB or greater, print the object identifiers:
   dfi -i --size 10G..
 
+  # Search for ISO images larger than 1 GB that were modified before 2020
+  # or are tagged "archive", except images stored on host nas2:
+  dfi -Q 'size>1G and (mtime<2020-01-01 or tag:archive) and not host:nas2 and name~"*.iso"'
+
+  # Search for JPEG photos directly in yearly directories of /data/photos,
+  # whose names start with "IMG_" followed by digits:
+  dfi --path-glob '/data/photos/20??/*.jpg' --name-regex '^IMG_\d+\.jpe?g$'
+
 Search for duplicates of object with ID b172..(cut)..45d8
 
   dfi --dupes b172..(cut)..45d8
 
+Print 10 largest directories of the second level on host fileserver:
+
+  dfi --du path --du-depth 2 --du-top 10 --host fileserver
+
 Set additional information items (AII) for objects:
 
   # Set the "big-file" tag for objects with IDs 2a8a..(cut)..3add and a123..(cut)..ccaf
@@ -73,6 +98,19 @@ Search for additional information items of objects:
   # Search for objects with the big-file tag:
   dfi --only-tags big-file
 
+List hosts registered by agents, stale agents are reported by warnings:
+
+  dfi --hosts
+
+Find out when the file disappeared and from which host, show changes of the last two days on the host fileserver:
+
+  dfi --history /data/reports/2022.pdf
+  dfi --changes --since 2d --host fileserver
+
+Print ISO images larger than 4 GB as soon as they appear on any host:
+
+  dfi --follow --size 4G.. --only-name iso
+
 # Configuration file
 
 By default, dfi looks for the configuration file in ${HOME}/.dfi/cli.json.
@@ -0,0 +1,136 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/r-che/dfi/cmd/dfi/internal/cfg"
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+)
+
+func Do(dbc dbms.Client) *types.CmdRV {
+	// Get configuration
+	c := cfg.Config()
+
+	rv := types.NewCmdRV()
+
+	hr, err := dbc.GetHistory(c.HA)
+	if err != nil {
+		return rv.AddErr("cannot get history of changes: %v", err)
+	}
+
+	if c.JSONOut {
+		if err = printJSON(hr); err != nil {
+			return rv.AddErr("cannot print history of changes: %v", err)
+		}
+	} else {
+		printRecords(hr)
+	}
+
+	return rv.AddFound(int64(len(hr)))
+}
+
+func printRecords(hr dbms.HistResults) {
+	// Get configuration
+	c := cfg.Config()
+
+	for _, rec := range hr {
+		if c.ShowID {
+			fmt.Printf("%s ", rec.ID)
+		}
+
+		fmt.Printf("%s %-8s %s:%s  %s\n",
+			time.Unix(rec.Time, 0).Format("2006-01-02 15:04:05 MST"), rec.Change, rec.Host, rec.FPath, details(rec))
+	}
+}
+
+// details returns sizes and checksums of the object affected by the change
+func details(rec *dbms.HistRecord) string {
+	switch rec.Change {
+	case dbms.HistCreated:
+		return stateStr(rec.NewSize, rec.NewChecksum)
+	case dbms.HistDeleted:
+		return stateStr(rec.OldSize, rec.OldChecksum)
+	}
+
+	// Modified object, show only changed values
+	out := fmt.Sprintf("size %d", rec.NewSize)
+	if rec.OldSize != rec.NewSize {
+		out = fmt.Sprintf("size %d -> %d", rec.OldSize, rec.NewSize)
+	}
+
+	switch {
+	case rec.OldChecksum != rec.NewChecksum:
+		out += fmt.Sprintf(", checksum %s -> %s", csumStr(rec.OldChecksum), csumStr(rec.NewChecksum))
+	case rec.NewChecksum != "":
+		out += ", checksum " + rec.NewChecksum
+	}
+
+	return out
+}
+
+func stateStr(size int64, csum string) string {
+	if csum == "" {
+		return fmt.Sprintf("size %d", size)
+	}
+
+	return fmt.Sprintf("size %d, checksum %s", size, csum)
+}
+
+func csumStr(csum string) string {
+	if csum == "" {
+		return "none"
+	}
+
+	return csum
+}
+
+func printJSON(hr dbms.HistResults) error {
+	// Get configuration
+	c := cfg.Config()
+
+	type recJSON struct {
+		Time		int64	`json:"time"`
+		Change		string	`json:"change"`
+		ID			string	`json:"id"`
+		Host		string	`json:"host"`
+		FPath		string	`json:"fpath"`
+		OldSize		int64	`json:"oldSize"`
+		OldChecksum	string	`json:"oldChecksum"`
+		NewSize		int64	`json:"newSize"`
+		NewChecksum	string	`json:"newChecksum"`
+	}
+
+	items := make([]recJSON, 0, len(hr))
+	for _, rec := range hr {
+		items = append(items, recJSON{
+			Time:			rec.Time,
+			Change:			rec.Change,
+			ID:				rec.ID,
+			Host:			rec.Host,
+			FPath:			rec.FPath,
+			OldSize:		rec.OldSize,
+			OldChecksum:	rec.OldChecksum,
+			NewSize:		rec.NewSize,
+			NewChecksum:	rec.NewChecksum,
+		})
+	}
+
+	var data []byte
+	var err error
+	if c.OneLine {
+		data, err = json.Marshal(items)
+	} else {
+		data, err = json.MarshalIndent(items, "", "    ")
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(data))
+
+	// OK
+	return nil
+}
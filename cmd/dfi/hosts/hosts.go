@@ -0,0 +1,215 @@
+package hosts
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/r-che/dfi/cmd/dfi/internal/cfg"
+	"github.com/r-che/dfi/common/tools"
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+)
+
+func Do(dbc dbms.Client) *types.CmdRV {
+	// Get configuration
+	c := cfg.Config()
+
+	rv := types.NewCmdRV()
+
+	hosts, err := dbc.GetHosts()
+	if err != nil {
+		return rv.AddErr("cannot get list of hosts: %v", err)
+	}
+
+	// Keep only requested hosts if any
+	if len(c.CmdArgs) != 0 {
+		requested := tools.NewSet(c.CmdArgs...)
+		for i := 0; i < len(hosts); {
+			if !requested.Includes(hosts[i].Host) {
+				hosts = append(hosts[:i], hosts[i+1:]...)
+				continue
+			}
+			i++
+		}
+	}
+
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].Host < hosts[j].Host
+	})
+
+	isStale := isStaleFunc()
+
+	switch {
+	case c.JSONOut:
+		if err = printJSON(hosts, isStale); err != nil {
+			return rv.AddErr("cannot print list of hosts: %v", err)
+		}
+	case c.OneLine:
+		printOneLine(hosts, isStale)
+	default:
+		printVerbose(hosts, isStale)
+	}
+
+	// Stale agents are not an error, but they should not be missed
+	for _, hi := range hosts {
+		if isStale(hi) {
+			rv.AddWarn("agent on host %q is stale, last heartbeat: %s", hi.Host, timeStr(hi.Heartbeat))
+		}
+	}
+
+	return rv.AddFound(int64(len(hosts)))
+}
+
+// StaleHosts returns the set of hosts whose agents are stale
+func StaleHosts(dbc dbms.Client) (tools.Set[string], error) {
+	hosts, err := dbc.GetHosts()
+	if err != nil {
+		return nil, err
+	}
+
+	isStale := isStaleFunc()
+
+	stale := tools.NewSet[string]()
+	for _, hi := range hosts {
+		if isStale(hi) {
+			stale.Add(hi.Host)
+		}
+	}
+
+	return stale, nil
+}
+
+// WarnStale warns about found objects of hosts with stale agents, used by outputs that cannot mark them
+func WarnStale(qr dbms.QueryResults, stale tools.Set[string]) {
+	found := tools.NewSet[string]()
+	for objKey := range qr {
+		if stale.Includes(objKey.Host) {
+			found.Add(objKey.Host)
+		}
+	}
+
+	if !found.Empty() {
+		log.W("Found objects of hosts with stale agents: %s", strings.Join(found.Sorted(), ", "))
+	}
+}
+
+// isStaleFunc returns the function that reports whether the agent of the host is stale now,
+// using the window configured by the command line
+func isStaleFunc() func(*dbms.HostInfo) bool {
+	now := time.Now().Unix()
+	window := int64(cfg.Config().StaleAfter / time.Second)
+
+	return func(hi *dbms.HostInfo) bool {
+		return hi.Stale(now, window)
+	}
+}
+
+func printVerbose(hosts []*dbms.HostInfo, isStale func(*dbms.HostInfo) bool) {
+	for i, hi := range hosts {
+		if i != 0 {
+			fmt.Println()
+		}
+
+		fmt.Printf("Host:       %s\n", hi.Host)
+		fmt.Printf("Status:     %s\n", status(hi, isStale(hi)))
+		fmt.Printf("Version:    %s\n", tools.Tern(hi.Version == "", "unknown", hi.Version))
+		fmt.Printf("Paths:      %s\n", strings.Join(hi.IdxPaths, ", "))
+
+		switch {
+		case !hi.CalcSums:
+			fmt.Printf("Checksums:  disabled\n")
+		case hi.MaxSumSize == 0:
+			fmt.Printf("Checksums:  enabled\n")
+		default:
+			fmt.Printf("Checksums:  enabled for files up to %d bytes\n", hi.MaxSumSize)
+		}
+
+		fmt.Printf("Objects:    %d\n", hi.Objects)
+		fmt.Printf("Started:    %s\n", timeStr(hi.Started))
+		fmt.Printf("Heartbeat:  %s\n", timeStr(hi.Heartbeat))
+		fmt.Printf("Last flush: %s\n", timeStr(hi.LastFlush))
+	}
+}
+
+func printOneLine(hosts []*dbms.HostInfo, isStale func(*dbms.HostInfo) bool) {
+	for _, hi := range hosts {
+		fmt.Printf("%s %s %d %s\n", hi.Host, tools.Tern(isStale(hi), "stale", "alive"),
+			hi.Objects, timeStr(hi.Heartbeat))
+	}
+}
+
+func printJSON(hosts []*dbms.HostInfo, isStale func(*dbms.HostInfo) bool) error {
+	// Get configuration
+	c := cfg.Config()
+
+	type hostJSON struct {
+		Host		string		`json:"host"`
+		Stale		bool		`json:"stale"`
+		Version		string		`json:"version"`
+		IdxPaths	[]string	`json:"paths"`
+		CalcSums	bool		`json:"checksums"`
+		MaxSumSize	int64		`json:"maxChecksumSize"`
+		Objects		int64		`json:"objects"`
+		Started		int64		`json:"started"`
+		Heartbeat	int64		`json:"heartbeat"`
+		HBPeriod	int64		`json:"heartbeatPeriod"`
+		LastFlush	int64		`json:"lastFlush"`
+	}
+
+	items := make([]hostJSON, 0, len(hosts))
+	for _, hi := range hosts {
+		items = append(items, hostJSON{
+			Host:		hi.Host,
+			Stale:		isStale(hi),
+			Version:	hi.Version,
+			IdxPaths:	hi.IdxPaths,
+			CalcSums:	hi.CalcSums,
+			MaxSumSize:	hi.MaxSumSize,
+			Objects:	hi.Objects,
+			Started:	hi.Started,
+			Heartbeat:	hi.Heartbeat,
+			HBPeriod:	hi.HBPeriod,
+			LastFlush:	hi.LastFlush,
+		})
+	}
+
+	var data []byte
+	var err error
+	if c.OneLine {
+		data, err = json.Marshal(items)
+	} else {
+		data, err = json.MarshalIndent(items, "", "    ")
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(data))
+
+	// OK
+	return nil
+}
+
+func status(hi *dbms.HostInfo, stale bool) string {
+	if hi.Heartbeat == 0 {
+		return "STALE (the agent does not send heartbeats)"
+	}
+	if stale {
+		return fmt.Sprintf("STALE (no heartbeats for %v)", time.Since(time.Unix(hi.Heartbeat, 0)).Round(time.Second))
+	}
+
+	return "alive"
+}
+
+func timeStr(ts int64) string {
+	if ts == 0 {
+		return "never"
+	}
+
+	return time.Unix(ts, 0).Format("2006-01-02 15:04:05 MST")
+}
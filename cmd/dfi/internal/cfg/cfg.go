@@ -41,6 +41,10 @@ func Init(name, nameLong, vers string) {
 	p.AddBool(`show`, `enable show mode`, &config.Show, false)
 	p.AddBool(`set`, `enable set mode`, &config.Set, false)
 	p.AddBool(`del`, `enable deletion mode`, &config.Del, false)
+	p.AddBool(`hosts`, `enable hosts mode, list hosts known by the index`, &config.Hosts, false)
+	p.AddBool(`history`,
+		`enable history mode, show changes of objects given by identifiers or paths`, &config.History, false)
+	p.AddBool(`changes`, `enable changes mode, show changes of all objects`, &config.Changes, false)
 	// TODO p.AddBool(`admin`, `enable admin mode`, &config.Admin, false)
 
 	// Modes options
@@ -63,6 +67,43 @@ func Init(name, nameLong, vers string) {
 		strings.Join(types.ObjTypes(), ", "), &config.oTypes, anyVal)
 	p.AddString(`checksum`, `set of objects checksums`, &config.csums, anyVal)
 	p.AddString(`host`, `set of hosts when object may be located`, &config.hosts, anyVal)
+	p.AddString(`ctime`,
+		`range of object inode change time, see "--docs timestamp" for details`,
+		&config.strCtime, anyVal)
+	p.AddString(`user`, `set of object owner names`, &config.users, anyVal)
+	p.AddString(`uid`, `set of object owner user identifiers`, &config.uids, anyVal)
+	p.AddString(`group`, `set of object owner group names`, &config.groups, anyVal)
+	p.AddString(`gid`, `set of object owner group identifiers`, &config.gids, anyVal)
+	p.AddString(`perm`,
+		`octal permission bits of object, use "-MODE" to match objects with all bits of MODE set, ` +
+		`see "--docs perm" for details`, &config.perm, anyVal)
+	p.AddString(`inode`, `set of inode numbers`, &config.inodes, anyVal)
+	p.AddString(`nlink`, `range of number of hard links to object`, &config.strNLink, anyVal)
+	p.AddString(`taken`,
+		`range of capture time of photos and videos, see "--docs timestamp" for details`,
+		&config.strTaken, anyVal)
+	p.AddString(`camera`, `set of camera makes or models, case-insensitive`, &config.cameras, anyVal)
+	p.AddString(`mime`,
+		`set of content types of regular files, use "type/*" to match all subtypes, ` +
+		`see "--docs mime" for details`, &config.mimes, anyVal)
+	p.AddBool(`dangling`,
+		`match symbolic links whose targets do not exist or cannot be resolved due to a loop`,
+		&config.QA.Dangling, false)
+	p.AddString(`points-to`,
+		`absolute path, match symbolic links pointing to this path or into it, ` +
+		`see "--docs search" for details`, &config.pointsTo, anyVal)
+	p.AddString(`path-glob`,
+		`shell pattern matched to the whole path of objects, wildcards do not match "/", ` +
+		`e.g. "/data/*/photos/*.jpg", see "--docs search" for details`, &config.pathGlob, anyVal)
+	p.AddString(`name-regex`,
+		`regular expression in the Go syntax matched to any part of the object name, ` +
+		`e.g. '^IMG_\d+\.jpe?g$', see "--docs search" for details`, &config.nameRegex, anyVal)
+	p.AddString(`query|Q`,
+		`query expression joined with other conditions by AND, e.g. 'size>1G and (tag:backup or name~"*.iso")', ` +
+		`see "--docs query" for details`, &config.query, anyVal)
+	p.AddString(`content`,
+		`phrase to search in the text of documents, all words of the phrase should be found, ` +
+		`see "--docs search" for details`, &config.QA.Content, "")
 	p.AddString(`aii-filled|F`,
 		`set of filled additional information item fields, possible values: ` +
 		strings.Join(dbms.UVAIIFields(), ", "), &config.aiiFields, anyVal)
@@ -75,11 +116,34 @@ func Init(name, nameLong, vers string) {
 	p.AddBool(`only-descr`,
 		`use only description field to match search phrases, implicitly enables --descr`,
 		&config.QA.OnlyDescr, false)
+	p.AddBool(`literal|L`,
+		`match search phrases as is - all words in the same order, instead of all words in any order, ` +
+		`see "--docs search" for details`, &config.QA.LiteralSP, false)
+	p.AddBool(`raw-query`,
+		`search phrases are queries in the native syntax of the DBMS passed without escaping, ` +
+		`see "--docs search" for details`, &config.QA.RawQuery, false)
 	p.AddBool(`deep|D`, `use additional DBMS dependent search features, ` +
 		`can slow down search`, &config.QA.DeepSearch, false)
 	p.AddBool(`dupes`,
 		`search for duplicates, command line arguments will be treated as objects identifiers`,
 		&config.SearchDupes, false)
+	p.AddBool(`link-plan`,
+		`with --dupes, print shell commands to replace duplicates by hard links to the requested objects`,
+		&config.LinkPlan, false)
+	p.AddString(`du`,
+		`print disk usage of found objects grouped by one of: ` + strings.Join(dbms.DUGroups(), ", ") +
+		`, see "--docs search" for details`, &config.duGroup, "")
+	p.AddInt(`du-depth`,
+		`with --du path, maximum number of directory levels from the root used to group objects, 0 - no limit`,
+		&config.DUDepth, 0)
+	p.AddInt(`du-top`, `with --du, print only the specified number of the largest groups, 0 - no limit`,
+		&config.DUTop, 0)
+	p.AddBool(`follow|f`,
+		`wait for changes committed by agents and print objects matched the search conditions as they happen, ` +
+		`see "--docs search" for details`, &config.Follow, false)
+	p.AddBool(`no-stale`,
+		`exclude objects of hosts with stale agents from the search results, see "--docs hosts" for details`,
+		&config.NoStale, false)
 	p.AddBool(`or`, `use OR instead of AND between conditions`, &config.QA.OrExpr, false)
 	p.AddBool(`not`, `use negative value of search conditions`, &config.QA.NegExpr, false)
 	// Output related options
@@ -101,6 +165,16 @@ func Init(name, nameLong, vers string) {
 	p.AddBool(`no-newline|n`, `use "; " instead of new line to join new value ` +
 		`to description (affects --add)`, &config.NoNL, false)
 
+	// History and changes modes options
+	p.AddSeparator(``,
+		`>> History and changes modes options`,
+		`# NOTE: Use "--docs history" to get additional information how use these modes`,
+		`# The --host option of the search mode can be used to select changes on particular hosts`,
+	)
+	p.AddString(`since`,
+		`show changes since the time given by the age like 2d, 1w, 12h or by the timestamp`,
+		&config.since, anyVal)
+
 	// Deletion mode opitions
 	p.AddSeparator(``,
 		`>> Deletion mode options`,
@@ -117,6 +191,9 @@ func Init(name, nameLong, vers string) {
 	p.AddBool(`one-line|o`, `print information about each object in one line, ` +
 		`implicitly enables --quiet`, &config.OneLine, false)
 	p.AddBool(`json|j`, `make JSON output, implicitly enables --quiet`, &config.JSONOut, false)
+	p.AddDuration(`stale-after`,
+		`time without heartbeats after which the agent of the host is stale, ` +
+		`0 - ` + fmt.Sprint(dbms.HostStaleHeartbeats) + ` heartbeat periods of the agent`, &config.StaleAfter, 0)
 	p.AddBool(`tags|t`, `enable tags-related operations`, &config.UseTags, false)
 	p.AddBool(`descr`, `enable description-related operations, ` +
 		`requires at least one command line argument`, &config.UseDescr, false)
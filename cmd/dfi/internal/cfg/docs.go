@@ -48,15 +48,29 @@ Special cases of using search phrases:
 In cases where objects have been found using the --descr, --tags or --dupes options,
 they are treated as if they were found by search phrases.
 
+Special characters of the database query syntax are escaped, so any characters
+can be used in search phrases. The way search phrases are matched can be changed:
+  * --literal (-L) - each search phrase is matched as the exact phrase, its words
+                     must follow in the same order
+  * --raw-query    - each search phrase is a query in the native syntax of the
+                     database: RediSearch query syntax for Redis or the search
+                     string of the $text operator for MongoDB. Raw queries are
+                     not escaped, incorrect queries lead to errors of the database.
+                     This option cannot be used with --literal, --deep, --only-name,
+                     --dupes, --tags and --descr
+
 >>> Search mode options <<<
 
 The following options used as search conditions:
-  --mtime ..., --size ..., --type ..., --checksum ..., --host ..., --aii-filled
+  --mtime ..., --size ..., --type ..., --checksum ..., --host ..., --aii-filled,
+  --ctime ..., --user ..., --uid ..., --group ..., --gid ..., --perm ..., --inode ...,
+  --nlink ..., --mime ..., --taken ..., --camera ...
 
 Each of them can return a logical TRUE or FALSE.
 
 By default, a set of conditions are joined with a logical AND, unless the
 option --or is set, in that case them will be joined with a logical OR.
+More complex conditions can be set by the query expression, see "--docs query".
 
 >>> Logical combining between search phrases and options in the search condition <<<
 
@@ -82,6 +96,115 @@ Will be treated as:
  AND NOT(size == 1G AND mtime == 2000.01.01)
 
 Certainly, you can use --or and --not options at the same time.
+
+>>> Search by content of documents <<<
+
+If the agent extracts the text of documents (plain text, Markdown, HTML, PDF,
+OpenDocument), documents can be found by their content using --content:
+ $ %[1]s --content "quarterly revenue report" --type f
+Will be found documents containing all words of the phrase in their text or title.
+The --content condition restricts the results of the rest of the search
+conditions, it is not affected by --or and --not:
+ $ %[1]s --content "revenue" --not --mtime 2000.01.01 "reports"
+Will be treated as:
+ (content contains "revenue")
+ AND (path contains "reports")
+ AND NOT(mtime == 2000.01.01)
+
+>>> Search for duplicates <<<
+
+The --dupes option uses checksums calculated by the agent to find copies of
+the objects with the identifiers given by command line arguments:
+ $ %[1]s --dupes 5f04497f12286af5d709941e0c26ccee8467a9e4
+Hard links to the same file are not separate copies: only the first object of
+each physical file is counted as a duplicate, the rest are labeled as hard links
+to it. The reclaimable space shows how much space can be freed by removing
+the duplicates, hard links and archive members are not taken into account.
+
+Using --link-plan, the list of duplicates is replaced by shell commands that
+replace duplicates by hard links to the requested objects. Only duplicates on
+the same host and on the same filesystem as the requested object are included,
+commands are grouped by host. Note that the replaced files lose their own
+owner, permissions and modification time.
+
+>>> Disk usage <<<
+
+The --du option prints the total size and the number of found objects grouped
+by directory ("path"), host, type or tag instead of the list of objects. Any
+search conditions can be used to select objects:
+ $ %[1]s --du path --du-depth 2 --host fileserver --type reg
+Will be printed the sizes of directories of the second level, such as
+fileserver:/data/photos, including all nested objects. Objects placed upper
+than the depth are counted in their own directories. Without --du-depth
+objects are grouped by the directories where they are placed. The output
+lines contain the size in bytes, the number of objects and the group key
+sorted by size, --du-top limits the output by the largest groups:
+ $ %[1]s --du tag --du-top 10 --mtime 2020.01.01..
+Objects without tags are grouped as "(untagged)", objects with several tags
+are counted in each group. Hard links to the same file are counted once in
+each group, members of archives are not counted because the archives are.
+
+>>> Hosts with stale agents <<<
+
+Found objects of hosts whose agents did not send heartbeats for a while are marked
+by "[stale]", use --no-stale to exclude them from the search. See "--docs hosts"
+for details.
+
+>>> Following changes <<<
+
+The --follow (-f) option turns the search into a live notification: instead of
+searching for existing objects, %[1]s waits for changes committed by agents and
+prints objects that match the search conditions as they appear or are updated,
+until it is interrupted. For example, to know when a large ISO image appears anywhere:
+
+ $ %[1]s --follow --size 4G.. --only-name iso
+ + fileserver:/data/images/debian-12.iso
+
+Lines of matched objects start with "+", if the matched object is deleted later,
+it is reported by the line starting with "-". The --show-ids (-i) and the
+--show-only-ids (-I) options work as in the regular search. The Redis backend
+receives changes published by agents, the MongoDB backend uses change streams
+that are available only on replica sets and sharded clusters.
+
+>>> Search by symbolic links <<<
+
+The agent stores the fully resolved absolute path of the target of each
+symbolic link as its real path. Links whose targets do not exist or cannot be
+resolved due to a loop of symbolic links can be found using --dangling:
+ $ %[1]s --dangling --host fileserver
+Links pointing to some path or into it can be found using --points-to:
+ $ %[1]s --points-to /data/photos
+Will be found links to /data/photos and to any object inside of it, e.g.
+links to /data/photos/2020/img001.jpg, but not to /data/photos-old.
+
+>>> Patterns of paths and names <<<
+
+Found paths of objects can be matched by the shell pattern using --path-glob.
+The pattern must match the whole absolute path, the "*" and "?" wildcards do not
+match the "/" character, character classes like "[a-z]" are supported and special
+characters can be escaped by a backslash:
+ $ %[1]s --path-glob '/data/*/photos/*.jpg'
+Will be found /data/2020/photos/img001.jpg, but not /data/photos/img001.jpg and
+/data/2020/photos/trip/img001.jpg.
+
+Names of objects can be matched by the regular expression in the Go syntax (see
+https://pkg.go.dev/regexp/syntax) using --name-regex. The expression matches any
+part of the name unless it is anchored by "^" and "$", matching is case-sensitive,
+use the "(?i)" flag to ignore case:
+ $ %[1]s --name-regex '(?i)^img_\d+\.jpe?g$'
+
+Patterns are filters - they are always joined with other conditions using logical
+AND, regardless of the --or and --not options. Results are the same on all database
+backends: MongoDB matches patterns itself, the Redis backend uses words of shell
+patterns to narrow down the search and checks patterns on the client side.
+
+>>> Search in archives <<<
+
+If the agent indexes members of archives (zip, tar, tar.gz, tar.zst), each
+regular file stored in an archive is an object of the arc-member type with
+the path in the ARCHIVE!/MEMBER format. Members can be found as regular files:
+ $ %[1]s --type arc-member "etc/hosts"
+Will be found all archives containing etc/hosts, e.g. /backups/x.tar.gz!/etc/hosts
 `,
 
 // Documentation about show
@@ -101,6 +224,14 @@ by command line arguments. The displayed information includes:
  * Type of the object, one of: ` + strings.Join(types.ObjTypes(), ", ")  + `
  * Size of the object in bytes
  * Modification time in human-readable and in Unix timestamp formats
+ * Owner and group - names (if were resolved by the agent) and numeric identifiers
+ * Permission bits in octal and symbolic formats
+ * Inode change time in human-readable and in Unix timestamp formats
+ * Inode number, device identifier and number of hard links
+ * Content type (MIME type) of regular files
+ * Metadata of photos and videos if extracted by the agent: capture time, camera,
+   location, duration and resolution
+ * Path of the archive containing the object, only for archive members
  * Additional information if set:
    * Tags - comma-separated set of tags
    * Description - text description of the object, can be multiline
@@ -202,11 +333,84 @@ By performing the delete description operation, the description is deleted in it
 There is no option to partially delete a description.
 `,
 
+// Documentation about hosts
+"hosts":
+`>>>> Hosts mode <<<<
+
+Usage:
+
+ $ %[1]s --hosts [HOST1 HOST2 ...]
+
+The --hosts mode lists hosts registered in the database by agents. If hosts
+are given by command line arguments, only these hosts are listed. The displayed
+information includes:
+
+ * Status of the agent - alive or stale
+ * Version of the agent
+ * Indexing paths and checksum settings of the agent
+ * Number of indexed objects of the host
+ * Start time of the agent, times of the last heartbeat and the last batch of
+   changes committed to the database
+
+Agents update this information periodically by heartbeats. The agent is stale if
+it has not sent heartbeats for ` + fmt.Sprint(dbms.HostStaleHeartbeats) + ` heartbeat periods or, if the --stale-after option
+is set, during the specified time:
+ $ %[1]s --hosts --stale-after 1h
+For stale agents a warning is printed and %[1]s exits with the warnings status.
+Hosts indexed by old agents that do not send heartbeats are always stale.
+
+Objects of hosts with stale agents may be outdated, so the search and show modes
+mark them by "[stale]" after the path and by a note after the host respectively.
+Outputs that cannot be marked (JSON, identifiers only) are followed by a warning.
+The --no-stale option excludes such objects from the search results entirely,
+regardless of --or and --not:
+ $ %[1]s --no-stale --stale-after 24h "reports"
+
+The --one-line (-o) option prints each host in a single line in the format:
+
+ HOSTNAME STATUS OBJECTS LAST-HEARTBEAT
+
+The --json (-j) option makes JSON output with times in Unix timestamp format.
+`,
+
+"history":
+`>>>> History and changes modes <<<<
+
+Usage:
+
+ $ %[1]s --history [--since AGE|TIMESTAMP] [--host HOSTS] {ID|/PATH} [ID|/PATH ...]
+ $ %[1]s --changes [--since AGE|TIMESTAMP] [--host HOSTS]
+
+Agents started with the --history option record each change of indexed objects
+committed to the database: creation, modification and deletion. Each record
+contains the time of the change, the host and the path of the object, sizes and
+checksums of the object before and after the change. Records older than the
+retention period of the agent (--history-keep, 30 days by default) are removed.
+
+The --history mode shows changes of objects given by identifiers or by absolute
+paths, paths are matched on all hosts unless --host is set. For example, to find
+out when the file disappeared and where it was located:
+
+ $ %[1]s --history /data/reports/2022.pdf
+ 2023-03-01 10:15:02 UTC created  fs1:/data/reports/2022.pdf  size 183422
+ 2023-05-12 18:40:57 UTC deleted  fs1:/data/reports/2022.pdf  size 183422
+
+The --changes mode shows changes of all objects, usually limited by time and hosts:
+
+ $ %[1]s --changes --since 2d --host fs1,fs2
+
+The --since option takes either the age - a number with one of units w (weeks),
+d (days), h, m, s - or the timestamp, see "--docs timestamp" for supported formats.
+
+The --show-ids (-i) option prints identifiers of objects at the beginning of lines,
+the --json (-j) option makes JSON output with times in Unix timestamp format.
+`,
+
 // Documentation about values range
 "range":
 `>>>> Range of values <<<<
 
-The --mtime and --size parameters take range of values as arguments.
+The --mtime, --ctime, --taken, --size and --nlink parameters take range of values as arguments.
 
 General range format is:
 
@@ -257,7 +461,7 @@ Files with the exact size of 1 gigabyte will be found.
 "timestamp":
 `>>>> Supported timestamp formats <<<<
 
-The option --mtime accepts a range (see "--docs ranges") bounded by timestamps.
+The options --mtime, --ctime and --taken accept a range (see "--docs ranges") bounded by timestamps.
 
 Allowed timestamp formats are:
 
@@ -278,6 +482,113 @@ you want to specify a timestamp like "Monday, 13-Aug-18 16:24:41 UTC", you must
  $ %[1]s --mtime "Monday\, 13-Aug-18 16:24:41 UTC"
 
 `,
+
+// Documentation about permissions
+"perm":
+`>>>> Search by permissions <<<<
+
+The --perm option takes permission bits of the object in octal format,
+including setuid (4000), setgid (2000) and sticky (1000) bits. Like find(1)
+does, two forms of the value are supported:
+
+  MODE  - permission bits of the object are exactly MODE
+  -MODE - all of the permission bits MODE are set for the object
+
+For example:
+
+ $ %[1]s --perm 644
+
+Objects with permissions exactly equal to rw-r--r-- will be found.
+
+ $ %[1]s --perm -002
+
+World-writable objects will be found.
+
+ $ %[1]s --perm -4000 --uid 0
+
+Objects owned by root with the setuid bit set will be found.
+`,
+
+// Documentation about content types
+"mime":
+`>>>> Search by content type <<<<
+
+The agent detects content types (MIME types) of regular files using the
+magic bytes at the beginning of the files, extensions of files are not used.
+Empty files have the "inode/x-empty" content type.
+
+The --mime option takes a comma-separated set of content types. Each item of
+the set can be specified in one of the forms:
+
+  type/subtype - exact content type, e.g. "video/mp4"
+  type/*       - any subtype of the type, e.g. "video/*"
+
+Content types are case-insensitive. For example:
+
+ $ %[1]s --mime "image/*"
+
+All images will be found.
+
+ $ %[1]s --mime "video/*" --mtime 2023.01.01..
+
+All videos modified since the beginning of 2023 will be found.
+`,
+
+// Documentation about query expressions
+"query":
+`>>>> Query expressions <<<<
+
+The --query (-Q) option takes an expression that can combine conditions in ways
+that the options --or and --not cannot. The expression is joined with the search
+phrases and conditions created from other options using logical AND.
+
+Conditions have the form FIELD OPERATOR VALUE. Supported operators are:
+
+  : or =    - the field is equal to the value, the value can be a comma-separated
+              set or a range with the same syntax as the corresponding option has
+  !=        - the field is not equal to the value
+  < <= > >= - comparison of sizes, timestamps and numeric fields
+  ~         - names or paths are matched by the shell pattern, the "*" and "?"
+              wildcards do not match the "/" character
+  =~        - names or paths are matched by the regular expression in the Go
+              syntax like the --name-regex option does
+
+Supported fields:
+  ` + strings.Join(dbms.ExprFields(), ", ") + `
+
+The fields "name", "path" and "camera" (and the --camera option) are matched by
+whole words of phrases: the field matches the phrase if it contains all words of
+the phrase in any order, case-insensitively. Words are separated by white spaces
+and punctuation characters except the underscore, e.g. camera:"eos canon" matches
+"Canon EOS 5D", but camera:canon does not match "Canonet QL17". Parts of words
+of names and paths can be matched by patterns. Results are the same on all database
+backends, except that Redis also matches other forms of words by stemming of the
+language of its index. "path" matches both found and real paths of objects. The fields "tag", "descr" and
+"content" match additional information items and content of documents. The field
+"perm" accepts the same values as the --perm option, "target" accepts the same
+values as the --points-to option.
+
+Conditions are joined by the "and", "or" and "not" operators (case does not matter)
+and can be grouped by parentheses. Conditions without an operator between them are
+joined by "and", which takes precedence over "or". Values containing white spaces,
+parentheses or quotes should be enclosed in double quotes, quotes and backslashes
+inside of them are escaped by a backslash. For example:
+
+ $ %[1]s -Q 'size>1G and (mtime<2020-01-01 or tag:archive) and not host:nas2'
+
+Objects larger than 1 gigabyte that were modified before 2020 or have the "archive"
+tag will be found, except objects of the host "nas2".
+
+ $ %[1]s -Q 'name~"*.iso" or name~"*.img"' --host nas1
+
+Images of disks on the host "nas1" will be found.
+
+Note: conditions with the "~" and "=~" operators can be joined with other conditions
+only by "and", e.g. 'name~"*.iso" or name~"*.img"' is correct, but 'size>1G or
+name~"*.iso"' is not. Some backends cannot match shell patterns and regular
+expressions, they are checked on the client side, so the same restriction is
+applied on all backends.
+`,
 }
 
 func docs(name, nameLong string, topics []string) {
@@ -290,10 +601,15 @@ func docs(name, nameLong string, topics []string) {
 			`show`,
 			`set`,
 			`del`,
+			`hosts`,
+			`history`,
 			// TODO `admin`
 			// Values
 			`range`,
 			`timestamp`,
+			`perm`,
+			`mime`,
+			`query`,
 		}
 	}
 
@@ -8,13 +8,15 @@ import (
 	"os"
 
 	"github.com/r-che/dfi/common/fschecks"
+	"github.com/r-che/dfi/common/secrets"
 	"github.com/r-che/dfi/types/dbms"
 
 	"github.com/r-che/log"
 )
 
 type fileCfg struct {
-	DB	*dbms.DBConfig
+	DB				*dbms.DBConfig
+	PrivCfgSource	string	// source of DB.PrivCfg instead of the configuration file, see secrets.Load
 }
 
 func (pc *progConfig) loadConf() error {
@@ -49,6 +51,20 @@ func (pc *progConfig) loadConf() error {
 		return fmt.Errorf("cannot decode configuration %q: %w", pc.confPath, err)
 	}
 
+	// Load private configuration of the database from the external source if set
+	if src := pc.fConf.PrivCfgSource; src != "" {
+		switch {
+		case pc.fConf.DB == nil:
+			return fmt.Errorf("invalid configuration %q: PrivCfgSource is set, but DB section is not", pc.confPath)
+		case pc.fConf.DB.PrivCfg != nil:
+			return fmt.Errorf("invalid configuration %q: both PrivCfgSource and DB.PrivCfg are set", pc.confPath)
+		}
+
+		if pc.fConf.DB.PrivCfg, err = secrets.Load(src); err != nil {
+			return fmt.Errorf("cannot load private database configuration: %w", err)
+		}
+	}
+
 	// OK
 	return nil
 }
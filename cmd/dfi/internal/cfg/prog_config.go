@@ -5,7 +5,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/r-che/dfi/common/parse"
 	"github.com/r-che/dfi/types"
 	"github.com/r-che/dfi/types/dbms"
 
@@ -20,6 +22,9 @@ type progConfig struct {
 	Show	bool
 	Set		bool
 	Del		bool
+	Hosts	bool
+	History	bool
+	Changes	bool
 	// TODO Admin	bool
 
 	// Search mode options
@@ -28,15 +33,39 @@ type progConfig struct {
 	oTypes		string
 	csums		string
 	hosts		string
+	strCtime	string
+	users		string
+	uids		string
+	groups		string
+	gids		string
+	perm		string
+	inodes		string
+	strNLink	string
+	mimes		string
+	strTaken	string
+	cameras		string
+	pointsTo	string
+	pathGlob	string
+	nameRegex	string
+	query		string
 	aiiFields	string
 	ShowOnlyIds	bool
 	ShowID		bool
 	HostGroups	bool
 	SearchDupes	bool
+	LinkPlan	bool
+	duGroup		string
+	DUDepth		int
+	DUTop		int
+	NoStale		bool
+	Follow		bool
 
 	// Set mode options
 	NoNL		bool
 
+	// History and changes modes options
+	since		string
+
 	// Show mdoe options
 
 	//
@@ -46,6 +75,7 @@ type progConfig struct {
 	SetAdd		bool
 	OneLine		bool
 	JSONOut		bool
+	StaleAfter	time.Duration	// Time without heartbeats after which the agent is stale, 0 - by heartbeat periods
 
 	//
 	// Other options
@@ -67,6 +97,10 @@ type progConfig struct {
 
 	// Query arguments
 	QA			*dbms.QueryArgs
+	// Disk usage report arguments, nil if the report is not requested
+	DU			*dbms.DUArgs
+	// History query arguments, nil if neither history nor changes are requested
+	HA			*dbms.HistArgs
 	// Program configuration loaded from file
 	fConf		fileCfg
 }
@@ -96,6 +130,19 @@ func (pc *progConfig) clone() *progConfig {
 		rv.QA = pc.QA.Clone()
 	}
 
+	if pc.DU != nil {
+		du := *pc.DU
+		rv.DU = &du
+	}
+
+	if pc.HA != nil {
+		ha := *pc.HA
+		ha.Ids = append([]string{}, pc.HA.Ids...)
+		ha.Paths = append([]string{}, pc.HA.Paths...)
+		ha.Hosts = append([]string{}, pc.HA.Hosts...)
+		rv.HA = &ha
+	}
+
 	return &rv
 }
 
@@ -113,6 +160,14 @@ func (pc *progConfig) prepare(cmdArgs []string) error {
 		return err
 	}
 
+	if pc.since != anyVal && pc.HA == nil {
+		return fmt.Errorf("--since can be used only with --history or --changes")
+	}
+
+	if pc.StaleAfter < 0 {
+		return fmt.Errorf("invalid value of --stale-after %v, must be non-negative", pc.StaleAfter)
+	}
+
 	// Is program configuration was not set?
 	if pc.confPath == progConfigDefault {
 		// Try to define default path
@@ -153,6 +208,18 @@ func (pc *progConfig) prepareMode() error {
 		mn++
 		prepFunc = pc.prepareDel
 	}
+	if pc.Hosts {
+		mn++
+		prepFunc = pc.prepareHosts
+	}
+	if pc.History {
+		mn++
+		prepFunc = pc.prepareHistory
+	}
+	if pc.Changes {
+		mn++
+		prepFunc = pc.prepareChanges
+	}
 	// TODO if pc.Admin { mn++ }
 
 	if mn > 1 {
@@ -206,6 +273,21 @@ func (pc *progConfig) prepareSearch() error {
 		return err
 	}
 
+	// Plan of hard links is made only for found duplicates
+	if pc.LinkPlan && !pc.SearchDupes {
+		return fmt.Errorf("--link-plan requires --dupes")
+	}
+
+	// Prepare disk usage report options
+	if err := pc.prepareSearchDU(); err != nil {
+		return err
+	}
+
+	// Matched objects are printed one by one as changes happen
+	if pc.Follow && (pc.SearchDupes || pc.DU != nil || pc.JSONOut || pc.HostGroups) {
+		return fmt.Errorf("--follow cannot be used with --dupes, --du, --json and --hosts-groups")
+	}
+
 	//
 	// Prepare boolean flags
 	//
@@ -218,6 +300,12 @@ func (pc *progConfig) prepareSearch() error {
 		pc.CommonFlags.UseTags = true
 	}
 
+	// Raw queries are passed to the DBMS as is, they cannot be matched to other fields
+	if pc.QA.RawQuery && (pc.QA.LiteralSP || pc.QA.DeepSearch || pc.QA.OnlyName || pc.SearchDupes ||
+			pc.CommonFlags.UseTags || pc.CommonFlags.UseDescr) {
+		return fmt.Errorf("--raw-query cannot be used with --literal, --deep, --only-name, --dupes, --tags and --descr")
+	}
+
 	//
 	// Pass common flags from command line to query arguments
 	//
@@ -234,10 +322,43 @@ func (pc *progConfig) prepareSearch() error {
 	return nil
 }
 
+func (pc *progConfig) prepareSearchDU() error {
+	if pc.duGroup == "" {
+		// Report is not requested, its options are meaningless
+		if pc.DUDepth != 0 || pc.DUTop != 0 {
+			return fmt.Errorf("--du-depth and --du-top require --du")
+		}
+
+		// OK
+		return nil
+	}
+
+	if pc.SearchDupes {
+		return fmt.Errorf("--du cannot be used with --dupes")
+	}
+
+	group, err := dbms.ParseDUGroupBy(pc.duGroup)
+	if err != nil {
+		return err
+	}
+
+	if pc.DUDepth < 0 || pc.DUTop < 0 {
+		return fmt.Errorf("values of --du-depth and --du-top cannot be negative")
+	}
+	if pc.DUDepth != 0 && group != dbms.DUByPath {
+		return fmt.Errorf("--du-depth can be used only with --du %s", dbms.DUByPath)
+	}
+
+	pc.DU = &dbms.DUArgs{GroupBy: group, Depth: pc.DUDepth}
+
+	// OK
+	return nil
+}
+
 func (pc *progConfig) prepareSearchCmdArgs() error {
 	// Check for required command line arguments
-	if (pc.QA.DeepSearch || pc.QA.UseTags || pc.QA.OnlyTags ||
-		pc.QA.UseDescr || pc.QA.OnlyDescr || pc.QA.OnlyName || pc.SearchDupes) &&
+	if (pc.QA.DeepSearch || pc.QA.UseTags || pc.QA.OnlyTags || pc.QA.UseDescr || pc.QA.OnlyDescr ||
+		pc.QA.OnlyName || pc.QA.LiteralSP || pc.QA.RawQuery || pc.SearchDupes) &&
 		len(pc.CmdArgs) == 0 {
 		return fmt.Errorf("one of command line options requires at least one command line argument")
 	}
@@ -289,6 +410,42 @@ func (pc *progConfig) prepareSearchOptsArgs() error {
 		}
 	}
 
+	// Prepare options related to the extended metadata of objects
+	return pc.prepareSearchStatArgs()
+}
+
+func (pc *progConfig) prepareSearchStatArgs() error {
+	// Options values with related parsers
+	for _, opt := range []struct {
+		val		string
+		parser	func(string) error
+	}{
+		{ pc.strCtime,	pc.QA.ParseCtimes },
+		{ pc.users,		pc.QA.ParseUsers },
+		{ pc.uids,		pc.QA.ParseUIDs },
+		{ pc.groups,	pc.QA.ParseGroups },
+		{ pc.gids,		pc.QA.ParseGIDs },
+		{ pc.perm,		pc.QA.ParsePerm },
+		{ pc.inodes,	pc.QA.ParseInodes },
+		{ pc.strNLink,	pc.QA.ParseNLinks },
+		{ pc.mimes,		pc.QA.ParseMIMEs },
+		{ pc.strTaken,	pc.QA.ParseTakens },
+		{ pc.cameras,	pc.QA.ParseCameras },
+		{ pc.pointsTo,	pc.QA.ParsePointsTo },
+		{ pc.pathGlob,	pc.QA.ParsePathGlob },
+		{ pc.nameRegex,	pc.QA.ParseNameRegex },
+		{ pc.query,		pc.QA.ParseExpr },
+	} {
+		if opt.val == anyVal {
+			// Option was not set
+			continue
+		}
+
+		if err := opt.parser(opt.val); err != nil {
+			return err
+		}
+	}
+
 	// OK
 	return nil
 }
@@ -332,6 +489,65 @@ func (pc *progConfig) prepareDel() error {
 	return nil
 }
 
+func (pc *progConfig) prepareHosts() error {
+	// Hostnames are stored in lower case by agents
+	for i := range pc.CmdArgs {
+		pc.CmdArgs[i] = strings.ToLower(pc.CmdArgs[i])
+	}
+
+	// OK
+	return nil
+}
+
+func (pc *progConfig) prepareHistory() error {
+	if len(pc.CmdArgs) == 0 {
+		return fmt.Errorf("insufficient arguments for --history command - no object identifiers or paths provided")
+	}
+
+	pc.HA = &dbms.HistArgs{}
+
+	// Absolute paths are found paths of objects, other arguments are identifiers
+	for _, arg := range pc.CmdArgs {
+		if filepath.IsAbs(arg) {
+			pc.HA.Paths = append(pc.HA.Paths, filepath.Clean(arg))
+		} else {
+			pc.HA.Ids = append(pc.HA.Ids, arg)
+		}
+	}
+
+	return pc.prepareHistArgs()
+}
+
+func (pc *progConfig) prepareChanges() error {
+	if len(pc.CmdArgs) != 0 {
+		return fmt.Errorf("--changes does not accept arguments, use --history to show changes of particular objects")
+	}
+
+	pc.HA = &dbms.HistArgs{}
+
+	return pc.prepareHistArgs()
+}
+
+func (pc *progConfig) prepareHistArgs() error {
+	if pc.since != anyVal {
+		since, err := dbms.ParseSince(pc.since, time.Now())
+		if err != nil {
+			return err
+		}
+		pc.HA.Since = since
+	}
+
+	if pc.hosts != anyVal {
+		// Hostnames are stored in lower case by agents
+		if err := parse.StringsSet(&pc.HA.Hosts, dbms.FieldHost, strings.ToLower(pc.hosts)); err != nil {
+			return err
+		}
+	}
+
+	// OK
+	return nil
+}
+
 func (pc *progConfig) prepareShow() error {
 	// Check for list of identifiers exists
 	if len(pc.CmdArgs) == 0  && !pc.UseTags {
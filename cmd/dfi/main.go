@@ -10,6 +10,8 @@ import (
 	"github.com/r-che/dfi/dbi"
 
 	"github.com/r-che/dfi/cmd/dfi/del"
+	"github.com/r-che/dfi/cmd/dfi/history"
+	"github.com/r-che/dfi/cmd/dfi/hosts"
 	"github.com/r-che/dfi/cmd/dfi/search"
 	"github.com/r-che/dfi/cmd/dfi/set"
 	"github.com/r-che/dfi/cmd/dfi/show"
@@ -72,6 +74,10 @@ func main() {
 		rv = set.Do(dbc)
 	case c.Del:
 		rv = del.Do(dbc)
+	case c.Hosts:
+		rv = hosts.Do(dbc)
+	case c.History || c.Changes:
+		rv = history.Do(dbc)
 	// TODO case c.Admin:
 	// 	err = fmt.Errorf("not implemented")
 	default:
@@ -95,8 +101,8 @@ func printStatus(rv *types.CmdRV) int {
 	}
 
 	if !c.Quiet {
-		// All modes except show and search are edit modes
-		editMode := !(c.Show || c.Search)
+		// All modes except show, search, hosts, history and changes are edit modes
+		editMode := !(c.Show || c.Search || c.Hosts || c.HA != nil)
 		// The show mode can work in special modes: one-line output and show-tags (instead of objects)
 		showSpecialMode := c.Show && (c.OneLine || c.UseTags)
 
@@ -106,9 +112,20 @@ func printStatus(rv *types.CmdRV) int {
 		if editMode {
 			fmt.Printf("%s%d changed\n", pref, rv.Changed())
 		} else
-		// Read-only mode - search or show, skip output in the show special mode
+		// Read-only mode - search, show, hosts, history or changes, skip output in the show special mode
 		if !showSpecialMode {
-			fmt.Printf("%s%d objects found\n", pref, rv.Found())
+			// Found items are objects except the disk usage report, the hosts, history and changes modes
+			found := "objects"
+			switch {
+			case c.Hosts:
+				found = "hosts"
+			case c.HA != nil:
+				found = "records"
+			case c.DU != nil:
+				found = "groups"
+			}
+
+			fmt.Printf("%s%d %s found\n", pref, rv.Found(), found)
 		}
 	}
 
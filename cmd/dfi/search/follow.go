@@ -0,0 +1,114 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"github.com/r-che/dfi/cmd/dfi/internal/cfg"
+	"github.com/r-che/dfi/cmd/internal/query"
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+)
+
+// Marks of lines printed by the follow mode
+const (
+	followMatched	=	"+"	// object was created or updated and matches the search conditions
+	followDeleted	=	"-"	// previously matched object was deleted
+)
+
+func follow(dbc dbms.Client, qa *dbms.QueryArgs) *types.CmdRV {
+	rv := types.NewCmdRV()
+
+	// Follow changes until the user interrupts it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Objects matched during following to report their deletion, indexed by identifiers
+	matched := map[string]types.ObjKey{}
+
+	log.D("Following changes of objects...")
+
+	err := dbc.Subscribe(ctx, func(changes []*dbms.ObjChange) error {
+		updated := make([]string, 0, len(changes))
+		for _, ch := range changes {
+			if ch.Op == dbms.Update {
+				updated = append(updated, ch.ID)
+				continue
+			}
+
+			// Deleted objects cannot be matched, so only deletion of already matched objects is reported
+			if key, ok := matched[ch.ID]; ok {
+				printFollow(followDeleted, ch.ID, key)
+				delete(matched, ch.ID)
+			}
+		}
+
+		if len(updated) == 0 {
+			// OK
+			return nil
+		}
+
+		qr, err := queryChanged(dbc, qa, updated)
+		if err != nil {
+			// Probably, a temporary problem, continue to follow
+			log.E("Cannot check changed objects: %v", err)
+			return nil
+		}
+
+		// Make sorted list of query result keys
+		objKeys := make([]types.ObjKey, 0, len(qr))
+		for k := range qr {
+			objKeys = append(objKeys, k)
+		}
+		sort.Slice(objKeys, func(i, j int) bool {
+			return objKeys[i].Less(objKeys[j])
+		})
+
+		for _, key := range objKeys {
+			id := fmt.Sprint(qr[key][dbms.FieldID])
+			matched[id] = key
+			printFollow(followMatched, id, key)
+		}
+		rv.AddFound(int64(len(qr)))
+
+		// OK
+		return nil
+	})
+	if err != nil {
+		return rv.AddErr("cannot follow changes: %v", err)
+	}
+
+	// OK
+	return rv
+}
+
+// queryChanged returns objects with identifiers ids matched by qa
+func queryChanged(dbc dbms.Client, qa *dbms.QueryArgs, ids []string) (dbms.QueryResults, error) {
+	// Conditions searched separately from objects may be changed too, so they are resolved for each change
+	qa = qa.Clone()
+	if ok, err := query.ResolveIds(dbc, qa); err != nil || !ok {
+		return nil, err
+	}
+
+	return dbc.Query(qa.SetOnlyIds(ids...), []string{dbms.FieldID})
+}
+
+func printFollow(mark, id string, key types.ObjKey) {
+	// Get configuration
+	c := cfg.Config()
+
+	switch {
+	case c.ShowOnlyIds:
+		fmt.Printf("%s %s\n", mark, id)
+	case c.ShowID:
+		fmt.Printf("%s %s %s:%s\n", mark, id, key.Host, key.Path)
+	default:
+		fmt.Printf("%s %s:%s\n", mark, key.Host, key.Path)
+	}
+}
@@ -3,12 +3,13 @@ package search
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/r-che/dfi/cmd/dfi/internal/cfg"
-	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/cmd/internal/query"
 )
 
-func printDupes(refObjs map[string]*types.FSObject, dm map[string][]dupeInfo) {
+func printDupes(refObjs map[string]*query.DupeRef, dm map[string][]query.DupeInfo) {
 	// Get configuration
 	c := cfg.Config()
 	// Get list of requested identifiers to print results in the same order
@@ -28,6 +29,10 @@ func printDupes(refObjs map[string]*types.FSObject, dm map[string][]dupeInfo) {
 
 	// Print results
 	switch {
+	// Plan to replace duplicates by hard links
+	case c.LinkPlan:
+		printLinkPlan(ids, refObjs, dm)
+
 	// JSON output
 	case c.JSONOut:
 		printJSONDupes(ids, dm)
@@ -42,47 +47,50 @@ func printDupes(refObjs map[string]*types.FSObject, dm map[string][]dupeInfo) {
 	}
 }
 
-func printDupesOL(ids []string, dm map[string][]dupeInfo) {
+func printDupesOL(ids []string, dm map[string][]query.DupeInfo) {
 	for _, id := range ids {
-		// Get duplicates for id
+		// Get duplicates for id, already sorted by object keys
 		dupes := dm[id]
-		// Sort by object keys
-		sort.Slice(dupes, func(i, j int) bool {
-			return dupes[i].objKey.Less(dupes[j].objKey)
-		})
 
 		// Print reference identifier
 		fmt.Print(id)
 		// Print all dupes of id
 		for _, did := range dupes {
-			fmt.Printf(" %s", did.id)
+			fmt.Printf(" %s", did.ID)
 		}
 		// Print new line
 		fmt.Println()
 	}
 }
 
-func printDupesVerb(ids []string, refObjs map[string]*types.FSObject, dm map[string][]dupeInfo) {
+func printDupesVerb(ids []string, refObjs map[string]*query.DupeRef, dm map[string][]query.DupeInfo) {
+	// Total reclaimable space, each checksum is counted once because requested objects may be duplicates of each other
+	total := int64(0)
+	counted := map[string]bool{}
+
 	for i, id := range ids {
-		// Get duplicates for this object
+		// Get duplicates for this object, already sorted by object keys
 		dupes := dm[id]
-		// Sort duplicates by object keys
-		sort.Slice(dupes, func(i, j int) bool {
-			return dupes[i].objKey.Less(dupes[j].objKey)
-		})
 
-		// Get key of reference object
-		objKey := refObjs[id].FPath	// XXX loadDupesRefs() kept object key in this field
+		// Get reference object
+		ref := refObjs[id]
 
 		// Is no duplicates were found
 		if len(dupes) == 0 {
-			fmt.Printf("%s %s: No duplicates were found\n", id, objKey)
+			fmt.Printf("%s %s: No duplicates were found\n", id, ref.ObjKey)
 		} else {
 			// Print all dupes of reference object
-			fmt.Printf("%s %s (%d):\n", id, objKey, len(dupes))
+			fmt.Printf("%s %s (%d):\n", id, ref.ObjKey, len(dupes))
 			for _, did := range dupes {
 				fmt.Printf("  %s\n", did)
 			}
+
+			rs := query.Reclaimable(ref, dupes)
+			fmt.Printf("  Reclaimable space: %d bytes\n", rs)
+			if !counted[ref.CSum] {
+				counted[ref.CSum] = true
+				total += rs
+			}
 		}
 
 		if i != len(ids) - 1 {
@@ -90,9 +98,77 @@ func printDupesVerb(ids []string, refObjs map[string]*types.FSObject, dm map[str
 		}
 		fmt.Println()
 	}
+
+	if len(counted) > 1 {
+		fmt.Printf("Total reclaimable space: %d bytes\n", total)
+	}
+}
+
+// printLinkPlan prints shell commands to replace duplicates by hard links to the referred objects
+func printLinkPlan(ids []string, refObjs map[string]*query.DupeRef, dm map[string][]query.DupeInfo) {
+	plan := makeLinkPlan(ids, refObjs, dm)
+
+	if len(plan) == 0 {
+		fmt.Println("# No duplicates that can be replaced by hard links")
+		return
+	}
+
+	hosts := make([]string, 0, len(plan))
+	for host := range plan {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	for i, host := range hosts {
+		if i != 0 {
+			fmt.Println()
+		}
+		fmt.Printf("# Host: %s\n", host)
+		for _, cmd := range plan[host] {
+			fmt.Println(cmd)
+		}
+	}
+}
+
+// makeLinkPlan returns shell commands to replace duplicates by hard links to the referred objects grouped by hosts.
+// Only files on the same host and on the same filesystem as the referred object can be linked
+func makeLinkPlan(ids []string, refObjs map[string]*query.DupeRef, dm map[string][]query.DupeInfo) map[string][]string {
+	// Commands grouped by host
+	plan := map[string][]string{}
+	// Objects already replaced by links or used as targets of links, requested objects may be duplicates of each other
+	done := map[string]bool{}
+
+	for _, id := range ids {
+		ref := refObjs[id]
+		if done[id] || !ref.Phys.Valid() {
+			continue
+		}
+		done[id] = true
+
+		for _, di := range dm[id] {
+			if done[di.ID] || !di.Phys.Valid() {
+				continue
+			}
+			// Skip hard links to the referred object and objects on other filesystems
+			if di.Phys == ref.Phys || di.Phys.Host != ref.Phys.Host || di.Phys.Dev != ref.Phys.Dev {
+				continue
+			}
+			done[di.ID] = true
+
+			plan[ref.ObjKey.Host] = append(plan[ref.ObjKey.Host],
+				"ln -f -- " + shellQuote(ref.ObjKey.Path) + " " + shellQuote(di.ObjKey.Path))
+		}
+	}
+
+	return plan
+}
+
+// shellQuote quotes the string to be used as a single word by POSIX shell
+func shellQuote(s string) string {
+	return `'` + strings.ReplaceAll(s, `'`, `'\''`) + `'`
 }
 
-func printJSONDupes(ids []string, dm map[string][]dupeInfo) {
+func printJSONDupes(ids []string, dm map[string][]query.DupeInfo) {
 	// Get configuration
 	c := cfg.Config()
 
@@ -116,12 +192,8 @@ func printJSONDupes(ids []string, dm map[string][]dupeInfo) {
 
 	// Print items
 	for i, id := range ids {
-		// Get duplicates for this object
+		// Get duplicates for this object, already sorted by object keys
 		dupes := dm[id]
-		// Sort duplicates by object keys
-		sort.Slice(dupes, func(i, j int) bool {
-			return dupes[i].objKey.Less(dupes[j].objKey)
-		})
 
 		// Is no duplicates were found
 		if len(dupes) == 0 {
@@ -131,7 +203,7 @@ func printJSONDupes(ids []string, dm map[string][]dupeInfo) {
 			fmt.Printf(ind + `%q: {` + nl, id)
 			// Print all dupes of reference object
 			for j, dinf := range dupes {
-				fmt.Printf(ind + ind + `%q: %q`, dinf.id, dinf.objKey)
+				fmt.Printf(ind + ind + `%q: %q`, dinf.ID, dinf.ObjKey)
 				if j != len(dupes) - 1 {
 					fmt.Print(`,`)
 				}
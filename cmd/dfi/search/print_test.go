@@ -0,0 +1,112 @@
+package search
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/r-che/dfi/cmd/internal/query"
+	"github.com/r-che/dfi/types"
+)
+
+func testDupe(id, host, path string, dev, inode int64) query.DupeInfo {
+	di := query.DupeInfo{ID: id, ObjKey: types.ObjKey{Host: host, Path: path}}
+	if inode != 0 {
+		di.Phys = query.PhysID{Host: host, Dev: dev, Inode: inode}
+	}
+
+	return di
+}
+
+func TestMakeLinkPlan(t *testing.T) {
+	ref := testDupe("r", "nas1", "/data/ref", 1, 10)
+	ref2 := testDupe("r2", "nas2", "/data/ref2", 1, 20)
+	arc := testDupe("a", "nas1", "/data/arc.zip/file", 0, 0)
+
+	tests := []struct {
+		ids		[]string
+		refs	[]query.DupeInfo
+		dupes	map[string][]query.DupeInfo	// reference identifier => duplicates
+		want	map[string][]string
+	} {
+		// Only separate files on the same host and on the same device are linked
+		{
+			ids:	[]string{"r"},
+			refs:	[]query.DupeInfo{ref},
+			dupes:	map[string][]query.DupeInfo{"r": {
+				testDupe("d1", "nas1", "/data/d1", 1, 11),
+				testDupe("d2", "nas1", "/data/d2", 1, 11),	// hard link of d1, linked to the reference too
+				testDupe("d3", "nas1", "/data/d3", 1, 10),	// already hard link of the reference
+				testDupe("d4", "nas1", "/data/d4", 2, 12),	// other device
+				testDupe("m1", "nas1", "/data/z.zip/m1", 0, 0),	// archive member
+				testDupe("d5", "nas2", "/data/d5", 1, 13),	// other host
+			}},
+			want:	map[string][]string{"nas1": {
+				`ln -f -- '/data/ref' '/data/d1'`,
+				`ln -f -- '/data/ref' '/data/d2'`,
+			}},
+		},
+		// References without physical identity cannot be linked
+		{
+			ids:	[]string{"a"},
+			refs:	[]query.DupeInfo{arc},
+			dupes:	map[string][]query.DupeInfo{"a": {testDupe("d1", "nas1", "/data/d1", 1, 11)}},
+			want:	map[string][]string{},
+		},
+		// References that are duplicates of each other, each object is linked once
+		{
+			ids:	[]string{"r", "d1", "r2"},
+			refs:	[]query.DupeInfo{ref, testDupe("d1", "nas1", "/data/d1", 1, 11), ref2},
+			dupes:	map[string][]query.DupeInfo{
+				"r":	{testDupe("d1", "nas1", "/data/d1", 1, 11), testDupe("d2", "nas1", "/data/d2", 1, 12)},
+				"d1":	{testDupe("d2", "nas1", "/data/d2", 1, 12), ref},
+				"r2":	{testDupe("d6", "nas2", "/data/it's\nname", 1, 21)},
+			},
+			want:	map[string][]string{
+				"nas1": {`ln -f -- '/data/ref' '/data/d1'`, `ln -f -- '/data/ref' '/data/d2'`},
+				"nas2": {"ln -f -- '/data/ref2' '/data/it'\\''s\nname'"},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		refObjs := make(map[string]*query.DupeRef, len(test.refs))
+		for _, di := range test.refs {
+			refObjs[di.ID] = &query.DupeRef{ObjKey: di.ObjKey, CSum: "c1", Size: 100, Phys: di.Phys}
+		}
+
+		if got := makeLinkPlan(test.ids, refObjs, test.dupes); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d] makeLinkPlan(%v) - want %q, got %q", i, test.ids, test.want, got)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skipf("Shell is not available: %v", err)
+	}
+
+	tests := []string{
+		"",
+		"plain",
+		"with space",
+		"it's",
+		"''",
+		"new\nline",
+		`$HOME "quoted" \back * ? ; & | $(cmd) ` + "`cmd`",
+		"-starts-with-dash",
+	}
+
+	for i, test := range tests {
+		// The shell must print the original string
+		out, err := exec.Command(sh, "-c", "printf '%s' " + shellQuote(test)).Output()
+		if err != nil {
+			t.Errorf("[%d] shell failed on quoted %q: %v", i, test, err)
+			continue
+		}
+		if string(out) != test {
+			t.Errorf("[%d] shellQuote(%q) - want shell word %q, got %q", i, test, test, out)
+		}
+	}
+}
@@ -4,20 +4,39 @@ import (
 	"fmt"
 	"sort"
 
+	"github.com/r-che/dfi/cmd/dfi/hosts"
 	"github.com/r-che/dfi/cmd/dfi/internal/cfg"
+	"github.com/r-che/dfi/cmd/internal/query"
+	"github.com/r-che/dfi/common/tools"
 	"github.com/r-che/dfi/types"
 	"github.com/r-che/dfi/types/dbms"
 )
 
+// Mark of objects of hosts with stale agents
+const staleHostMark = "[stale]"
+
 //nolint:cyclop // Here simplification of the code does not make it clear
 func Do(dbc dbms.Client) *types.CmdRV {
 	// Get configuration
 	c := cfg.Config()
 
+	if c.NoStale {
+		// Exclude objects of hosts with stale agents from any kind of search
+		stale, err := hosts.StaleHosts(dbc)
+		if err != nil {
+			return types.NewCmdRV().AddErr("cannot get hosts with stale agents: %v", err)
+		}
+		c.QA.SetExclHosts(stale.Sorted()...)
+	}
+
 	if c.SearchDupes {
 		return searchDupes(dbc, c.QA)
 	}
 
+	if c.Follow {
+		return follow(dbc, c.QA)
+	}
+
 	// Set of requested fields
 	rqFields := []string{}
 	if c.NeedID() {
@@ -27,36 +46,17 @@ func Do(dbc dbms.Client) *types.CmdRV {
 
 	rv := types.NewCmdRV()
 
-	if c.QA.IsAIIFields() {
-		// Search for identifiers of objects that have filled requested AII fields
-		ids, err := dbc.GetAIIIds(c.QA.AIIFields)
-		if err != nil {
-			return rv.AddErr("cannot search for objects with filled fields %v: %v", c.QA.AIIFields, err)
-		}
-
-		c.QA.AddIds(ids...)
+	// Resolve conditions that are searched separately from objects
+	if ok, err := query.ResolveIds(dbc, c.QA); err != nil {
+		return rv.AddErr(err)
+	} else if !ok {
+		// Nothing can be found, return empty result
+		return rv
 	}
 
-	if c.QA.UseAII() {
-		// Search by AII fields
-		ids, err := dbc.QueryAIIIds(c.QA)
-		if err != nil {
-			return rv.AddErr("cannot search by additional information objects fields: %v", err)
-		}
-
-		// Check for only AII should be used in search
-		if c.QA.OnlyAII() {
-			// If no identifiers by AII were found
-			if len(ids) == 0 {
-				// Than nothing to search, return empty result
-				return rv
-			}
-
-			// Clear search phrases to avoid using them in the next search
-			c.QA.SetSearchPhrases(nil)
-		}
-
-		c.QA.AddIds(ids...)
+	if c.DU != nil {
+		// Aggregate disk usage of found objects instead of printing them
+		return searchDU(dbc, c.QA)
 	}
 
 	qr, err := dbc.Query(c.QA, rqFields)
@@ -64,6 +64,16 @@ func Do(dbc dbms.Client) *types.CmdRV {
 		return rv.AddErr("cannot execute search query: %v", err)
 	}
 
+	// Hosts with stale agents to mark found objects, there are none if they were excluded
+	stale := tools.NewSet[string]()
+	if !c.NoStale && len(qr) != 0 {
+		if stale, err = hosts.StaleHosts(dbc); err != nil {
+			// Found objects are still valid, only marks are missing
+			rv.AddWarn("cannot get hosts with stale agents: %v", err)
+			stale = tools.NewSet[string]()
+		}
+	}
+
 	//
 	// Print results
 	//
@@ -73,14 +83,15 @@ func Do(dbc dbms.Client) *types.CmdRV {
 	// JSON results
 	case c.JSONOut:
 		printJSON(qr)
+		hosts.WarnStale(qr, stale)
 
 	// Results grouped by host
 	case c.HostGroups:
-		printResHG(qr)
+		printResHG(qr, stale)
 
 	// Single-line sorted output
 	default:
-		printResSingle(qr)
+		printResSingle(qr, stale)
 	}
 
 	// OK
@@ -152,7 +163,7 @@ func printJSON(qr dbms.QueryResults) {
 	fmt.Print(`]` + "\n")
 }
 
-func printResHG(qr dbms.QueryResults) {
+func printResHG(qr dbms.QueryResults, stale tools.Set[string]) {
 	// Get configuration
 	c := cfg.Config()
 
@@ -185,7 +196,7 @@ func printResHG(qr dbms.QueryResults) {
 		paths := hg[host]
 		sort.Strings(paths)
 
-		fmt.Printf("%s(%d):\n", host, len(paths))
+		fmt.Printf("%s(%d)%s:\n", host, len(paths), staleMark(stale, host))
 
 		switch {
 		// Print only identifiers
@@ -220,7 +231,7 @@ func printResHGOnlyPaths(paths []string) {
 		fmt.Printf("  %s\n", path)
 	}
 }
-func printResSingle(qr dbms.QueryResults) {
+func printResSingle(qr dbms.QueryResults, stale tools.Set[string]) {
 	// Get configuration
 	c := cfg.Config()
 
@@ -238,13 +249,23 @@ func printResSingle(qr dbms.QueryResults) {
 		for _, k := range objKeys {
 			fmt.Printf("%v\n", qr[k][dbms.FieldID])
 		}
+		hosts.WarnStale(qr, stale)
 	case c.ShowID:
 		for _, k := range objKeys {
-			fmt.Printf("%v %s:%s\n", qr[k][dbms.FieldID], k.Host, k.Path)
+			fmt.Printf("%v %s:%s%s\n", qr[k][dbms.FieldID], k.Host, k.Path, staleMark(stale, k.Host))
 		}
 	default:
 		for _, k := range objKeys {
-			fmt.Printf("%s:%s\n", k.Host, k.Path)
+			fmt.Printf("%s:%s%s\n", k.Host, k.Path, staleMark(stale, k.Host))
 		}
 	}
 }
+
+// staleMark returns the mark of objects of the host if its agent is stale
+func staleMark(stale tools.Set[string], host string) string {
+	if stale.Includes(host) {
+		return " " + staleHostMark
+	}
+
+	return ""
+}
@@ -0,0 +1,102 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/r-che/dfi/cmd/dfi/internal/cfg"
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+)
+
+// Key printed for the group of objects without tags
+const duUntagged = "(untagged)"
+
+func searchDU(dbc dbms.Client, qa *dbms.QueryArgs) *types.CmdRV {
+	// Get configuration
+	c := cfg.Config()
+
+	rv := types.NewCmdRV()
+
+	dur, err := dbc.DiskUsage(qa, c.DU)
+	if err != nil {
+		return rv.AddErr("cannot aggregate disk usage: %v", err)
+	}
+
+	// Results are sorted by size by the backend, keep only the largest groups if required
+	if c.DUTop != 0 && len(dur) > c.DUTop {
+		dur = dur[:c.DUTop]
+	}
+
+	if c.JSONOut {
+		if err = printJSONDU(dur); err != nil {
+			return rv.AddErr("cannot print disk usage: %v", err)
+		}
+	} else {
+		printDU(dur)
+	}
+
+	// OK
+	return rv.AddFound(int64(len(dur)))
+}
+
+func printDU(dur dbms.DUResults) {
+	// Widths of columns of sizes and numbers of objects
+	sw, cw := 0, 0
+	for _, item := range dur {
+		sw = maxInt(sw, len(fmt.Sprint(item.Size)))
+		cw = maxInt(cw, len(fmt.Sprint(item.Count)))
+	}
+
+	for _, item := range dur {
+		fmt.Printf("%*d %*d  %s\n", sw, item.Size, cw, item.Count, duKey(item.Key))
+	}
+}
+
+func printJSONDU(dur dbms.DUResults) error {
+	// Get configuration
+	c := cfg.Config()
+
+	type duJSON struct {
+		Key		string	`json:"key"`
+		Size	int64	`json:"size"`
+		Count	int64	`json:"count"`
+	}
+
+	items := make([]duJSON, 0, len(dur))
+	for _, item := range dur {
+		items = append(items, duJSON{Key: duKey(item.Key), Size: item.Size, Count: item.Count})
+	}
+
+	var data []byte
+	var err error
+	if c.OneLine {
+		data, err = json.Marshal(items)
+	} else {
+		data, err = json.MarshalIndent(items, "", "    ")
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(data))
+
+	// OK
+	return nil
+}
+
+func duKey(key string) string {
+	if key == "" {
+		// Only objects without tags have the empty key
+		return duUntagged
+	}
+
+	return key
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -9,11 +9,14 @@ import (
 	"github.com/r-che/dfi/types"
 	"github.com/r-che/dfi/types/dbms"
 	"github.com/r-che/dfi/cmd/dfi/internal/cfg"
+	"github.com/r-che/dfi/cmd/dfi/hosts"
+	"github.com/r-che/dfi/common/tools"
 
 )
 
 const (
-	invalidMtimeValueFmt	= `<INVALID-MTIME-VALUE(%#v) - %v>`
+	invalidTimeValueFmt	= `<INVALID-%s-VALUE(%#v) - %v>`
+	invalidModeValueFmt	= `<INVALID-MODE-VALUE(%#v) - %v>`
 )
 
 func Do(dbc dbms.Client) *types.CmdRV {
@@ -56,14 +59,22 @@ func Do(dbc dbms.Client) *types.CmdRV {
 		rv.AddErr("cannot get additional information about some objects: %v", err)
 	}
 
+	// Hosts with stale agents to mark objects
+	stale, err := hosts.StaleHosts(dbc)
+	if err != nil {
+		// Objects are still valid, only marks are missing
+		rv.AddWarn("cannot get hosts with stale agents: %v", err)
+		stale = tools.NewSet[string]()
+	}
+
 	// Print all found objects
-	showObjs(ids, objs, aiis)
+	showObjs(ids, objs, aiis, stale)
 
 	// Return results
 	return rv
 }
 
-func showObjs(ids []string, objs dbms.QueryResults, aiis dbms.QueryResultsAII) {
+func showObjs(ids []string, objs dbms.QueryResults, aiis dbms.QueryResultsAII, stale tools.Set[string]) {
 	// Get configuration
 	c := cfg.Config()
 
@@ -77,15 +88,17 @@ func showObjs(ids []string, objs dbms.QueryResults, aiis dbms.QueryResultsAII) {
 	// JSON output
 	case c.JSONOut:
 		showJSONOutput(ids, ikm, objs, aiis)
+		hosts.WarnStale(objs, stale)
 	// One-line output
 	case c.OneLine:
 		for _, id := range ids {
 			showObjOL(ikm[id], objs[ikm[id]], aiis[id])
 		}
+		hosts.WarnStale(objs, stale)
 	// Default output
 	default:
 		for _, id := range ids {
-			showObj(ikm[id], objs[ikm[id]], aiis[id])
+			showObj(ikm[id], objs[ikm[id]], aiis[id], stale.Includes(ikm[id].Host))
 		}
 	}
 }
@@ -212,30 +225,45 @@ func showObjOL(objKey types.ObjKey, fields dbms.QRItem, aii *dbms.AIIArgs) {
 	fmt.Println(strings.Join(res, " "))
 }
 
-func showObj(objKey types.ObjKey, fields dbms.QRItem, aii *dbms.AIIArgs) {
+func showObj(objKey types.ObjKey, fields dbms.QRItem, aii *dbms.AIIArgs, stale bool) {
 	// Object header
 	fmt.Printf(">>> [ID: %s]\n", fields[dbms.FieldID])
 
 	// Common object's information
-	fmt.Printf("Host:      %s\n", objKey.Host)
+	if stale {
+		fmt.Printf("Host:      %s (the agent is stale, the object may be outdated)\n", objKey.Host)
+	} else {
+		fmt.Printf("Host:      %s\n", objKey.Host)
+	}
 	fmt.Printf("Path:      %s\n", objKey.Path)
 
 	// Is real path was set
 	if rp := fields[dbms.FieldRPath]; rp != "" {
-		fmt.Printf("Real path: %s\n", rp)
+		// Show the state of symbolic link target if it cannot be resolved
+		if ls, ok := fields[dbms.FieldLinkState].(string); ok && ls != "" && ls != types.LinkOK {
+			fmt.Printf("Real path: %s (%s)\n", rp, ls)
+		} else {
+			fmt.Printf("Real path: %s\n", rp)
+		}
 	}
 
 	fmt.Printf("Type:      %s\n", fields[dbms.FieldType])
 	fmt.Printf("Size:      %v\n", fields[dbms.FieldSize])
 
 	// Print object modification time
-	showObjMtime(fields)
+	showObjTime(fields, dbms.FieldMTime, "MTime")
 
 	// Is checksum was set
 	if csum := fields[dbms.FieldChecksum]; csum != "" {
 		fmt.Printf("Checksum:  %s\n", csum)
 	}
 
+	// Print owner, permissions, etc...
+	showObjStat(fields)
+
+	// Print metadata of photos and videos
+	showObjMedia(fields)
+
 	// Print additional information if exists
 	if aii != nil {
 		if tags := strings.Join(aii.Tags, ","); tags != "" {
@@ -250,38 +278,148 @@ func showObj(objKey types.ObjKey, fields dbms.QRItem, aii *dbms.AIIArgs) {
 	fmt.Println()
 }
 
-func showObjMtime(fields dbms.QRItem) {
-	// Check for mtime field is not found
-	mtime, ok := fields[dbms.FieldMTime]
+func showObjTime(fields dbms.QRItem, field, label string) {
+	// Check for time field is not found
+	tsVal, ok := fields[field]
 	if !ok {
 		// Nothing to print
 		return
 	}
 
-	var mtimeTS int64
-	var err error
+	var tsStr string	// human-readable representation
+	ts, err := toInt64(tsVal)
+	if err == nil {
+		tsStr = time.Unix(ts, 0).Format("2006-01-02 15:04:05 MST")
+	} else {
+		tsStr = fmt.Sprintf(invalidTimeValueFmt, strings.ToUpper(field), tsVal, err)
+		ts = -1
+	}
 
-	// Type of mtime field is DBMS dependent
-	switch mtime := mtime.(type) {
-	case int64:
-		// Assign value to mtimeTS as is - it is already Unix timestamp
-		mtimeTS = mtime
-	case string:
-		// Convert string Unix timestamp to integer value
-		mtimeTS, err = strconv.ParseInt(mtime, 10, 64)
-	default:
-		err = fmt.Errorf("unexpected mtime type %T", mtime)
+	// Produce output
+	fmt.Printf("%-11s%s (Unix: %d)\n", label + ":", tsStr, ts)
+}
+
+func showObjStat(fields dbms.QRItem) {
+	// Owner and group, names are empty if they were not resolved by the agent
+	if uid, ok := fields[dbms.FieldUID]; ok {
+		fmt.Printf("Owner:     %s\n", nameWithID(fields[dbms.FieldUser], uid))
+	}
+	if gid, ok := fields[dbms.FieldGID]; ok {
+		fmt.Printf("Group:     %s\n", nameWithID(fields[dbms.FieldGroup], gid))
 	}
 
+	// Permissions
+	if modeVal, ok := fields[dbms.FieldMode]; ok {
+		if mode, err := toInt64(modeVal); err == nil {
+			fmt.Printf("Mode:      %04o (%s)\n", mode, permString(mode))
+		} else {
+			fmt.Printf("Mode:      " + invalidModeValueFmt + "\n", modeVal, err)
+		}
+	}
 
-	var mtimeStr string	// human-readable representation
-	if err == nil {
-		mtimeStr = time.Unix(mtimeTS, 0).Format("2006-01-02 15:04:05 MST")
-	} else {
-		mtimeStr = fmt.Sprintf(invalidMtimeValueFmt, mtime, err)
-		mtimeTS = -1
+	// Print inode change time
+	showObjTime(fields, dbms.FieldCTime, "CTime")
+
+	// Hard links related information
+	if inode, ok := fields[dbms.FieldInode]; ok {
+		fmt.Printf("Inode:     %v (device: %v)\n", inode, fields[dbms.FieldDevice])
+	}
+	if nlink, ok := fields[dbms.FieldNLink]; ok {
+		fmt.Printf("Links:     %v\n", nlink)
 	}
 
-	// Produce output
-	fmt.Printf("MTime:     %s (Unix: %d)\n", mtimeStr, mtimeTS)
+	// Content type is detected only for regular files
+	if mime, ok := fields[dbms.FieldMIME].(string); ok && mime != "" {
+		fmt.Printf("MIME:      %s\n", mime)
+	}
+
+	// Archive is set only for archive members
+	if arc, ok := fields[dbms.FieldArchive].(string); ok && arc != "" {
+		fmt.Printf("Archive:   %s\n", arc)
+	}
+}
+
+func showObjMedia(fields dbms.QRItem) {
+	// Zero values mean that metadata were not extracted
+	if taken, err := toInt64(fields[dbms.FieldTaken]); err == nil && taken != 0 {
+		showObjTime(fields, dbms.FieldTaken, "Taken")
+	}
+	if camera, ok := fields[dbms.FieldCamera].(string); ok && camera != "" {
+		fmt.Printf("Camera:    %s\n", camera)
+	}
+
+	// Coordinates are empty/null if location is unknown
+	if lat, lon := fields[dbms.FieldLat], fields[dbms.FieldLon]; lat != nil && lat != "" && lon != nil && lon != "" {
+		fmt.Printf("Location:  %v, %v\n", lat, lon)
+	}
+
+	width, errW := toInt64(fields[dbms.FieldWidth])
+	height, errH := toInt64(fields[dbms.FieldHeight])
+	if errW == nil && errH == nil && width != 0 {
+		fmt.Printf("Size (px): %dx%d\n", width, height)
+	}
+	if duration, err := toInt64(fields[dbms.FieldDuration]); err == nil && duration != 0 {
+		fmt.Printf("Duration:  %v\n", time.Duration(duration) * time.Millisecond)
+	}
+}
+
+func nameWithID(name, id any) string {
+	if n, ok := name.(string); ok && n != "" {
+		return fmt.Sprintf("%s (%v)", n, id)
+	}
+
+	// Name was not resolved, use only identifier
+	return fmt.Sprintf("%v", id)
+}
+
+// permString returns symbolic representation of permission bits like ls(1) does
+func permString(mode int64) string {
+	const rwx = "rwxrwxrwx"
+
+	perm := []byte("---------")
+	for i := range perm {
+		if mode & (1 << (len(perm) - 1 - i)) != 0 {
+			perm[i] = rwx[i]
+		}
+	}
+
+	// Special bits are shown in place of execute bits
+	for _, sb := range []struct {
+		pos		int
+		bit		int64
+		set		byte	// execute bit is set
+		unset	byte	// execute bit is not set
+	}{
+		{2, 0o4000, 's', 'S'},	// setuid
+		{5, 0o2000, 's', 'S'},	// setgid
+		{8, 0o1000, 't', 'T'},	// sticky
+	} {
+		if mode & sb.bit == 0 {
+			continue
+		}
+
+		if perm[sb.pos] == 'x' {
+			perm[sb.pos] = sb.set
+		} else {
+			perm[sb.pos] = sb.unset
+		}
+	}
+
+	return string(perm)
+}
+
+func toInt64(val any) (int64, error) {
+	// Type of numeric fields is DBMS dependent
+	switch v := val.(type) {
+	case int64:
+		// Return value as is
+		return v, nil
+	case int32:
+		return int64(v), nil
+	case string:
+		// Convert string value to integer
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("unexpected type %T", val)
+	}
 }
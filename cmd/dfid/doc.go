@@ -0,0 +1,59 @@
+/*
+
+dfid is the REST/JSON API server of the Distributed File Indexer.
+
+Usage:
+
+  dfid [Options]
+
+# Endpoints
+
+All endpoints are placed under the /api/v1 prefix:
+
+ * GET /version - version of the server and the used DBMS backend
+ * POST /search - search for objects by conditions
+ * GET, POST /objects - objects with all fields, tags and descriptions by identifiers
+ * POST /dupes - duplicates of objects by identifiers
+ * GET /tags - used tags with numbers of objects
+ * POST /aii - set, append or delete tags or description of objects
+ * GET /schema - JSON schema of requests and responses
+
+Search conditions have the same format as values of the corresponding options of the dfi utility.
+Results of search, duplicates and tags are paginated by the offset and limit fields. Errors are
+returned as JSON documents with the corresponding HTTP status.
+
+# Web interface
+
+The single-page web interface is served on /ui/, it works over the API and provides
+search with all conditions, details of objects, editing of tags and descriptions,
+the tag cloud and search for duplicates. The --no-webui option disables it.
+
+# gRPC gateway
+
+The --grpc-listen option enables the gRPC gateway that provides operations of the database
+client to the dfi utility built with the remote backend, so only dfid needs access to the database.
+The Ingest section of the configuration file enables the ingest service that applies batches of
+changes sent by agents built with the remote backend, agents are authenticated by tokens.
+
+# Users
+
+If users are configured by the Users section of the configuration file, requests require
+the HTTP basic authentication. Users have one of the roles: reader, tagger (modification
+of tags and descriptions) or admin (all operations). Visibility of objects can be restricted
+by hosts and prefixes of paths, modifications of tags and descriptions are logged with usernames.
+
+# Configuration
+
+dfid reads database connection settings from the configuration file /etc/dfi/dfid.json
+(can be changed by the --cfg option) that has the same format as the configuration
+of the dfi utility. The file must be owned by the user running dfid and not be
+accessible by others.
+
+# Additional help
+
+For additional reference information, see:
+
+  dfid --help
+
+*/
+package main
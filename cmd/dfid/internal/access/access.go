@@ -0,0 +1,211 @@
+/*
+Package access implements users, roles and visibility restrictions of dfid.
+
+Each user has one of the roles:
+
+  - reader - search and reading of visible objects
+  - tagger - the same as reader, and setting and appending tags and descriptions of visible objects
+  - admin  - all operations, including deletion of tags and descriptions, on all objects
+
+Visibility of objects to readers and taggers can be restricted by lists of hosts and
+paths of directories, objects outside of them are not returned and cannot be modified.
+*/
+package access
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/r-che/dfi/types/dbms"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrForbidden is returned if the operation is not permitted to the user, it is the error of the database
+// client interface, so it is passed to remote clients as the permission denied status
+var ErrForbidden = dbms.ErrForbidden
+
+// ErrUnauthenticated is returned if the user cannot be authenticated
+var ErrUnauthenticated = errors.New("invalid username or password")
+
+// Role of the user, roles with greater values include permissions of lesser roles
+type Role int
+const (
+	Reader = Role(iota)
+	Tagger
+	Admin
+)
+
+func ParseRole(s string) (Role, error) {
+	switch strings.ToLower(s) {
+	case "reader":	return Reader, nil
+	case "tagger":	return Tagger, nil
+	case "admin":	return Admin, nil
+	}
+
+	return 0, fmt.Errorf("unknown role %q, supported roles: reader, tagger, admin", s)
+}
+
+func (r Role) String() string {
+	switch r {
+	case Reader:	return "reader"
+	case Tagger:	return "tagger"
+	case Admin:		return "admin"
+	default:
+		panic(fmt.Sprintf("Unsupported role %d", r))
+	}
+}
+
+// UserConfig is the configuration of the user loaded from the configuration file
+type UserConfig struct {
+	Password	string		// bcrypt hash of the password, e.g. produced by "htpasswd -nB"
+	Role		string
+	Hosts		[]string	// hosts of visible objects, all hosts if empty
+	Paths		[]string	// absolute paths of directories with visible objects, all paths if empty
+}
+
+type User struct {
+	Name	string
+	Role	Role
+	Hosts	[]string
+	Paths	[]string
+}
+
+// Restricted reports whether visibility of objects is restricted for the user
+func (u *User) Restricted() bool {
+	return len(u.Hosts) != 0 || len(u.Paths) != 0
+}
+
+// Visible reports whether the object placed on the host by the path is visible to the user
+func (u *User) Visible(host, path string) bool {
+	if !u.hostVisible(host) {
+		return false
+	}
+	if len(u.Paths) == 0 {
+		return true
+	}
+
+	for _, dir := range u.Paths {
+		if inDir(path, dir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hostVisible reports whether objects of the host can be visible to the user
+func (u *User) hostVisible(host string) bool {
+	return len(u.Hosts) == 0 || inList(strings.ToLower(host), u.Hosts)
+}
+
+// inDir reports whether the path is the directory dir itself or is placed inside of it,
+// dir must be cleaned, so "/home/alice" does not contain "/home/alice2"
+func inDir(path, dir string) bool {
+	return dir == "/" || path == dir || strings.HasPrefix(path, dir + "/")
+}
+
+func inList(v string, list []string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Control authenticates users configured for the server
+type Control struct {
+	users	map[string]*account
+
+	// Hashes of passwords are expensive to verify, digests of verified passwords are cached
+	mu		sync.Mutex
+	checked	map[string][sha256.Size]byte
+}
+
+type account struct {
+	user	*User
+	hash	[]byte
+}
+
+func NewControl(users map[string]*UserConfig) (*Control, error) {
+	c := &Control{
+		users:		make(map[string]*account, len(users)),
+		checked:	map[string][sha256.Size]byte{},
+	}
+
+	for name, uc := range users {
+		if uc == nil {
+			return nil, fmt.Errorf("user %q has no configuration", name)
+		}
+
+		role, err := ParseRole(uc.Role)
+		if err != nil {
+			return nil, fmt.Errorf("user %q: %w", name, err)
+		}
+		if role == Admin && (len(uc.Hosts) != 0 || len(uc.Paths) != 0) {
+			return nil, fmt.Errorf("user %q: visibility of objects cannot be restricted for the %v role", name, role)
+		}
+
+		if _, err := bcrypt.Cost([]byte(uc.Password)); err != nil {
+			return nil, fmt.Errorf("user %q: invalid bcrypt hash of the password: %w", name, err)
+		}
+
+		hosts := make([]string, 0, len(uc.Hosts))
+		for _, host := range uc.Hosts {
+			// Hostnames are stored in lower case
+			hosts = append(hosts, strings.ToLower(host))
+		}
+
+		paths := make([]string, 0, len(uc.Paths))
+		for _, dir := range uc.Paths {
+			// Paths of objects are absolute and cleaned by the agent
+			if !path.IsAbs(dir) {
+				return nil, fmt.Errorf("user %q: visible path %q must be absolute", name, dir)
+			}
+			paths = append(paths, path.Clean(dir))
+		}
+
+		c.users[name] = &account{
+			user:	&User{Name: name, Role: role, Hosts: hosts, Paths: paths},
+			hash:	[]byte(uc.Password),
+		}
+	}
+
+	return c, nil
+}
+
+// Authenticate returns the user with the name if the password is correct
+func (c *Control) Authenticate(name, password string) (*User, error) {
+	acc, ok := c.users[name]
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	digest := sha256.Sum256([]byte(password))
+
+	c.mu.Lock()
+	checked, ok := c.checked[name]
+	c.mu.Unlock()
+	if ok && subtle.ConstantTimeCompare(checked[:], digest[:]) == 1 {
+		// OK, the password was already verified
+		return acc.user, nil
+	}
+
+	if err := bcrypt.CompareHashAndPassword(acc.hash, []byte(password)); err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	c.mu.Lock()
+	c.checked[name] = digest
+	c.mu.Unlock()
+
+	// OK
+	return acc.user, nil
+}
@@ -0,0 +1,108 @@
+package access
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestVisible(t *testing.T) {
+	tests := []struct {
+		hosts, paths	[]string
+		host, path		string
+		want			bool
+	} {
+		// Not restricted
+		{ host: "nas1", path: "/data/x", want: true },
+		// Hosts are compared case-insensitively
+		{ hosts: []string{"NAS1"}, host: "nas1", path: "/data/x", want: true },
+		{ hosts: []string{"nas1"}, host: "NAS1", path: "/data/x", want: true },
+		{ hosts: []string{"nas1"}, host: "nas2", path: "/data/x", want: false },
+		// The directory itself and objects inside of it
+		{ paths: []string{"/home/alice"}, host: "nas1", path: "/home/alice", want: true },
+		{ paths: []string{"/home/alice"}, host: "nas1", path: "/home/alice/docs/a.txt", want: true },
+		// Siblings with the same prefix are not visible
+		{ paths: []string{"/home/alice"}, host: "nas1", path: "/home/alice2/a.txt", want: false },
+		{ paths: []string{"/home/alice"}, host: "nas1", path: "/home/alicebackup", want: false },
+		{ paths: []string{"/home/alice"}, host: "nas1", path: "/home", want: false },
+		// Paths are cleaned when the configuration is loaded
+		{ paths: []string{"/home/alice/"}, host: "nas1", path: "/home/alice/a.txt", want: true },
+		{ paths: []string{"/home//alice/"}, host: "nas1", path: "/home/alice2", want: false },
+		// The root contains all paths
+		{ paths: []string{"/"}, host: "nas1", path: "/data/x", want: true },
+		// Any of paths
+		{ paths: []string{"/srv", "/home/alice"}, host: "nas1", path: "/srv/www", want: true },
+		// Both hosts and paths must match
+		{ hosts: []string{"nas1"}, paths: []string{"/srv"}, host: "nas2", path: "/srv/www", want: false },
+	}
+
+	for i, test := range tests {
+		c, err := NewControl(map[string]*UserConfig{"user": testUserConfig(t, "pw", "reader", test.hosts, test.paths)})
+		if err != nil {
+			t.Fatalf("[%d] NewControl returned unexpected error: %v", i, err)
+		}
+
+		if got := c.users["user"].user.Visible(test.host, test.path); got != test.want {
+			t.Errorf("[%d] Visible(%q, %q) with hosts %v and paths %v - want %t, got %t",
+				i, test.host, test.path, test.hosts, test.paths, test.want, got)
+		}
+	}
+}
+
+func TestNewControlErrors(t *testing.T) {
+	for i, uc := range []*UserConfig{
+		nil,
+		testUserConfig(t, "pw", "superuser", nil, nil),
+		testUserConfig(t, "pw", "admin", []string{"nas1"}, nil),
+		testUserConfig(t, "pw", "reader", nil, []string{"home/alice"}),
+		{ Password: "plain text", Role: "reader" },
+	} {
+		if _, err := NewControl(map[string]*UserConfig{"user": uc}); err == nil {
+			t.Errorf("[%d] NewControl accepted invalid configuration %#v", i, uc)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	c, err := NewControl(map[string]*UserConfig{"alice": testUserConfig(t, "secret", "tagger", nil, nil)})
+	if err != nil {
+		t.Fatalf("NewControl returned unexpected error: %v", err)
+	}
+
+	for _, cred := range [][2]string{{"alice", "wrong"}, {"bob", "secret"}, {"alice", ""}} {
+		if _, err := c.Authenticate(cred[0], cred[1]); !errors.Is(err, ErrUnauthenticated) {
+			t.Errorf("Authenticate(%q, %q) - want %v, got %v", cred[0], cred[1], ErrUnauthenticated, err)
+		}
+	}
+
+	u, err := c.Authenticate("alice", "secret")
+	if err != nil || u.Name != "alice" || u.Role != Tagger {
+		t.Fatalf("Authenticate with the correct password returned %+v, error: %v", u, err)
+	}
+
+	// Break the hash - the verified password must be accepted by the cached digest
+	c.users["alice"].hash = []byte("invalid")
+	if _, err := c.Authenticate("alice", "secret"); err != nil {
+		t.Errorf("cached password was not accepted: %v", err)
+	}
+
+	// Wrong password after the cached success must be rejected, the cache must stay valid
+	if _, err := c.Authenticate("alice", "secret2"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("wrong password after the cached success - want %v, got %v", ErrUnauthenticated, err)
+	}
+	if _, err := c.Authenticate("alice", "secret"); err != nil {
+		t.Errorf("cached password was not accepted after the wrong one: %v", err)
+	}
+}
+
+func testUserConfig(t *testing.T, password, role string, hosts, paths []string) *UserConfig {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("cannot make hash of the password: %v", err)
+	}
+
+	return &UserConfig{Password: string(hash), Role: role, Hosts: hosts, Paths: paths}
+}
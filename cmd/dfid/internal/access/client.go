@@ -0,0 +1,337 @@
+package access
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+)
+
+// Client is the database client that performs operations on behalf of the user, it checks
+// permissions of the user, hides objects invisible to the user and audits modifications
+type Client struct {
+	dbc		dbms.Client
+	user	*User
+}
+
+// Client returns the database client for the user over the dbc client
+func (u *User) Client(dbc dbms.Client) *Client {
+	return &Client{dbc: dbc, user: u}
+}
+
+func (c *Client) Query(qa *dbms.QueryArgs, retFields []string) (dbms.QueryResults, error) {
+	qr, err := c.dbc.Query(qa, retFields)
+
+	return c.filterQR(qr), err
+}
+
+func (c *Client) QueryAIIIds(qa *dbms.QueryArgs) ([]string, error) {
+	ids, err := c.dbc.QueryAIIIds(qa)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.visibleIds(ids)
+}
+
+func (c *Client) QueryContentIds(qa *dbms.QueryArgs) ([]string, error) {
+	ids, err := c.dbc.QueryContentIds(qa)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.visibleIds(ids)
+}
+
+func (c *Client) GetObjects(ids, retFields []string) (dbms.QueryResults, error) {
+	qr, err := c.dbc.GetObjects(ids, retFields)
+
+	return c.filterQR(qr), err
+}
+
+func (c *Client) GetAIIs(ids, retFields []string) (dbms.QueryResultsAII, error) {
+	ids, err := c.visibleIds(ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return dbms.QueryResultsAII{}, nil
+	}
+
+	return c.dbc.GetAIIs(ids, retFields)
+}
+
+func (c *Client) GetAIIIds(withFields []string) ([]string, error) {
+	ids, err := c.dbc.GetAIIIds(withFields)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.visibleIds(ids)
+}
+
+func (c *Client) GetHosts() ([]*dbms.HostInfo, error) {
+	hosts, err := c.dbc.GetHosts()
+	if !c.user.Restricted() {
+		return hosts, err
+	}
+
+	visible := make([]*dbms.HostInfo, 0, len(hosts))
+	for _, hi := range hosts {
+		if !c.user.hostVisible(hi.Host) {
+			continue
+		}
+
+		// Indexing paths are shown only if they can contain visible objects
+		paths := make([]string, 0, len(hi.IdxPaths))
+		for _, dir := range hi.IdxPaths {
+			if c.pathOverlaps(dir) {
+				paths = append(paths, dir)
+			}
+		}
+		if len(paths) == 0 && len(c.user.Paths) != 0 {
+			continue
+		}
+
+		hic := *hi
+		hic.IdxPaths = paths
+		visible = append(visible, &hic)
+	}
+
+	return visible, err
+}
+
+func (c *Client) GetHistory(ha *dbms.HistArgs) (dbms.HistResults, error) {
+	hr, err := c.dbc.GetHistory(ha)
+	if !c.user.Restricted() {
+		return hr, err
+	}
+
+	visible := make(dbms.HistResults, 0, len(hr))
+	for _, rec := range hr {
+		if c.user.Visible(rec.Host, rec.FPath) {
+			visible = append(visible, rec)
+		}
+	}
+
+	return visible, err
+}
+
+func (c *Client) DiskUsage(qa *dbms.QueryArgs, dua *dbms.DUArgs) (dbms.DUResults, error) {
+	if !c.user.Restricted() {
+		return c.dbc.DiskUsage(qa, dua)
+	}
+
+	// Usage is aggregated by the database, so it is restricted to visible objects found by the same query
+	qr, err := c.Query(qa.Clone(), []string{dbms.FieldID})
+	if err != nil {
+		return nil, err
+	}
+	if len(qr) == 0 {
+		return dbms.DUResults{}, nil
+	}
+
+	ids := make([]string, 0, len(qr))
+	for _, fields := range qr {
+		ids = append(ids, fmt.Sprint(fields[dbms.FieldID]))
+	}
+
+	return c.dbc.DiskUsage(qa.Clone().SetOnlyIds(ids...), dua)
+}
+
+func (c *Client) ModifyAII(op dbms.DBOperator, args *dbms.AIIArgs, ids []string, add bool) (int64, int64, error) {
+	// Deletion of tags and descriptions is permitted only to administrators
+	required, action := Tagger, "modification"
+	if op == dbms.Delete {
+		required, action = Admin, "deletion"
+	}
+	if c.user.Role < required {
+		log.W("(Audit) User %q (%v) is not permitted to modify AII: %v %s for %d objects",
+			c.user.Name, c.user.Role, op, aiiStr(args), len(ids))
+		return 0, 0, fmt.Errorf("%w: %s of tags and descriptions requires the %v role", ErrForbidden, action, required)
+	}
+
+	// All objects must be visible to the user
+	if c.user.Restricted() {
+		visible, err := c.visibleIds(ids)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		// Objects that are not found are also not accessible
+		if n := len(ids) - countIn(ids, visible); n != 0 {
+			log.W("(Audit) User %q (%v) is not permitted to modify AII of %d of %d objects: %v %s",
+				c.user.Name, c.user.Role, n, len(ids), op, aiiStr(args))
+			return 0, 0, fmt.Errorf("%w: %d of %d objects are not accessible", ErrForbidden, n, len(ids))
+		}
+	}
+
+	tu, du, err := c.dbc.ModifyAII(op, args, ids, add)
+
+	log.I("(Audit) User %q (%v) modified AII: %v (add: %t) %s for objects %v - %d tags, %d descriptions updated%s",
+		c.user.Name, c.user.Role, op, add, aiiStr(args), ids, tu, du, errStr(err))
+
+	return tu, du, err
+}
+
+func (c *Client) Subscribe(ctx context.Context, handler dbms.ChangesHandler) error {
+	if !c.user.Restricted() {
+		return c.dbc.Subscribe(ctx, handler)
+	}
+
+	// Identifiers of objects which changes were passed to the handler, their deletion is also visible
+	passed := map[string]bool{}
+
+	return c.dbc.Subscribe(ctx, func(changes []*dbms.ObjChange) error {
+		visible := c.visibleChanges(changes, passed)
+		if len(visible) == 0 {
+			return nil
+		}
+
+		return handler(visible)
+	})
+}
+
+// visibleChanges returns changes of objects visible to the user, host and path of changed objects
+// are requested from the database if the backend does not provide them. Deleted objects cannot be
+// requested, so their deletion is visible only if changes of them were passed to the user before
+func (c *Client) visibleChanges(changes []*dbms.ObjChange, passed map[string]bool) []*dbms.ObjChange {
+	// Identifiers of updated objects with unknown hosts and paths
+	unknown := make([]string, 0, len(changes))
+	for _, oc := range changes {
+		if oc.Op == dbms.Update && oc.Host == "" && oc.FPath == "" {
+			unknown = append(unknown, oc.ID)
+		}
+	}
+
+	// Keys of visible objects from the unknown list, indexed by identifiers
+	resolved := make(map[string]types.ObjKey, len(unknown))
+	if len(unknown) != 0 {
+		qr, err := c.dbc.GetObjects(unknown, []string{dbms.FieldID})
+		if err != nil {
+			log.E("(Access) Cannot get changed objects to check their visibility to user %q, %d changes are hidden: %v",
+				c.user.Name, len(unknown), err)
+		}
+		for key, fields := range qr {
+			if c.user.Visible(key.Host, key.Path) {
+				resolved[fmt.Sprint(fields[dbms.FieldID])] = key
+			}
+		}
+	}
+
+	visible := make([]*dbms.ObjChange, 0, len(changes))
+	for _, oc := range changes {
+		switch {
+		case oc.Host != "" || oc.FPath != "":
+			if !c.user.Visible(oc.Host, oc.FPath) {
+				continue
+			}
+		case oc.Op == dbms.Update:
+			key, ok := resolved[oc.ID]
+			if !ok {
+				// The object is not visible or was deleted already
+				continue
+			}
+			oc = &dbms.ObjChange{Op: oc.Op, ID: oc.ID, Host: key.Host, FPath: key.Path}
+		case !passed[oc.ID]:
+			// Deletion of the unknown object
+			continue
+		}
+
+		if oc.Op == dbms.Delete {
+			delete(passed, oc.ID)
+		} else {
+			passed[oc.ID] = true
+		}
+		visible = append(visible, oc)
+	}
+
+	return visible
+}
+
+func (c *Client) filterQR(qr dbms.QueryResults) dbms.QueryResults {
+	if !c.user.Restricted() {
+		return qr
+	}
+
+	for objKey := range qr {
+		if !c.user.Visible(objKey.Host, objKey.Path) {
+			delete(qr, objKey)
+		}
+	}
+
+	return qr
+}
+
+// visibleIds returns identifiers of objects visible to the user
+func (c *Client) visibleIds(ids []string) ([]string, error) {
+	if !c.user.Restricted() || len(ids) == 0 {
+		return ids, nil
+	}
+
+	qr, err := c.GetObjects(ids, []string{dbms.FieldID})
+	if err != nil {
+		return nil, fmt.Errorf("cannot check visibility of objects: %w", err)
+	}
+
+	visible := make([]string, 0, len(qr))
+	for _, fields := range qr {
+		visible = append(visible, fmt.Sprint(fields[dbms.FieldID]))
+	}
+
+	return visible, nil
+}
+
+// countIn returns the number of items of the list that are in the set
+func countIn(list, set []string) int {
+	inSet := make(map[string]bool, len(set))
+	for _, v := range set {
+		inSet[v] = true
+	}
+
+	n := 0
+	for _, v := range list {
+		if inSet[v] {
+			n++
+		}
+	}
+
+	return n
+}
+
+// pathOverlaps reports whether the directory can contain objects visible to the user
+func (c *Client) pathOverlaps(dir string) bool {
+	if len(c.user.Paths) == 0 {
+		return true
+	}
+
+	// The directory is inside of the visible path or contains it
+	dir = path.Clean(dir)
+	for _, visible := range c.user.Paths {
+		if inDir(dir, visible) || inDir(visible, dir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func aiiStr(args *dbms.AIIArgs) string {
+	if args == nil {
+		return "{}"
+	}
+
+	return fmt.Sprintf("{tags: %v, descr: %q}", args.Tags, args.Descr)
+}
+
+func errStr(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return ", error: " + err.Error()
+}
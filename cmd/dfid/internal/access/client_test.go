@@ -0,0 +1,282 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+)
+
+func TestMain(m *testing.M) {
+	// Modifications are audited by the log
+	if err := log.Open(log.DefaultLog, "access-test", log.NoFlags); err != nil {
+		panic("cannot open log: " + err.Error())
+	}
+
+	os.Exit(m.Run())
+}
+
+// testDB is the database client that keeps objects in memory, methods
+// that are not used by tests are not implemented by the embedded nil client
+type testDB struct {
+	dbms.Client
+
+	objects		map[string]types.ObjKey	// identifier => key of the object
+	hosts		[]*dbms.HostInfo
+	modified	[]string
+	changes		[][]*dbms.ObjChange	// portions of changes passed to subscribers
+}
+
+func newTestDB() *testDB {
+	return &testDB{
+		objects:	map[string]types.ObjKey{
+			"a1":	{Host: "nas1", Path: "/home/alice/a.txt"},
+			"a2":	{Host: "nas1", Path: "/home/alice/docs/b.txt"},
+			"s1":	{Host: "nas1", Path: "/home/alice2/c.txt"},
+			"b1":	{Host: "nas1", Path: "/home/bob/d.txt"},
+			"n2":	{Host: "nas2", Path: "/home/alice/e.txt"},
+		},
+		hosts:		[]*dbms.HostInfo{
+			{Host: "nas1", IdxPaths: []string{"/home", "/home/alice2", "/srv"}},
+			{Host: "nas2", IdxPaths: []string{"/home"}},
+			{Host: "nas3", IdxPaths: []string{"/data"}},
+		},
+	}
+}
+
+func (db *testDB) Query(qa *dbms.QueryArgs, retFields []string) (dbms.QueryResults, error) {
+	ids := make([]string, 0, len(db.objects))
+	for id := range db.objects {
+		ids = append(ids, id)
+	}
+
+	return db.GetObjects(ids, retFields)
+}
+
+func (db *testDB) GetObjects(ids, retFields []string) (dbms.QueryResults, error) {
+	qr := dbms.QueryResults{}
+	for _, id := range ids {
+		if key, ok := db.objects[id]; ok {
+			qr[key] = dbms.QRItem{dbms.FieldID: id}
+		}
+	}
+
+	return qr, nil
+}
+
+func (db *testDB) GetHosts() ([]*dbms.HostInfo, error) {
+	return db.hosts, nil
+}
+
+func (db *testDB) ModifyAII(op dbms.DBOperator, args *dbms.AIIArgs, ids []string, add bool) (int64, int64, error) {
+	db.modified = append(db.modified, ids...)
+
+	return int64(len(ids)), 0, nil
+}
+
+func (db *testDB) Subscribe(ctx context.Context, handler dbms.ChangesHandler) error {
+	for _, changes := range db.changes {
+		if err := handler(changes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestModifyAII(t *testing.T) {
+	tests := []struct {
+		role		Role
+		paths		[]string
+		op			dbms.DBOperator
+		ids			[]string
+		forbidden	bool
+	} {
+		{ role: Reader, op: dbms.Update, ids: []string{"a1"}, forbidden: true },
+		{ role: Tagger, op: dbms.Update, ids: []string{"a1", "b1"} },
+		{ role: Tagger, op: dbms.Delete, ids: []string{"a1"}, forbidden: true },
+		{ role: Admin, op: dbms.Delete, ids: []string{"a1", "n2"} },
+		// All objects must be visible
+		{ role: Tagger, paths: []string{"/home/alice"}, op: dbms.Update, ids: []string{"a1", "a2"} },
+		{ role: Tagger, paths: []string{"/home/alice"}, op: dbms.Update, ids: []string{"a1", "s1"}, forbidden: true },
+		{ role: Tagger, paths: []string{"/home/alice"}, op: dbms.Update, ids: []string{"a1", "b1"}, forbidden: true },
+		// Objects that are not found are not accessible
+		{ role: Tagger, paths: []string{"/home/alice"}, op: dbms.Update, ids: []string{"a1", "unknown"}, forbidden: true },
+	}
+
+	for i, test := range tests {
+		db := newTestDB()
+		u := &User{Name: "user", Role: test.role, Paths: test.paths}
+
+		_, _, err := u.Client(db).ModifyAII(test.op, &dbms.AIIArgs{Tags: []string{"tag"}}, test.ids, false)
+		if test.forbidden {
+			if !errors.Is(err, ErrForbidden) {
+				t.Errorf("[%d] %v by %v of %v - want %v, got %v", i, test.op, test.role, test.ids, ErrForbidden, err)
+			}
+			if len(db.modified) != 0 {
+				t.Errorf("[%d] %v by %v was refused but objects %v were modified", i, test.op, test.role, db.modified)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[%d] %v by %v of %v returned unexpected error: %v", i, test.op, test.role, test.ids, err)
+		}
+		if !reflect.DeepEqual(db.modified, test.ids) {
+			t.Errorf("[%d] %v by %v - want modified %v, got %v", i, test.op, test.role, test.ids, db.modified)
+		}
+	}
+}
+
+func TestFilterQR(t *testing.T) {
+	tests := []struct {
+		hosts, paths	[]string
+		want			[]string
+	} {
+		{ want: []string{"a1", "a2", "b1", "n2", "s1"} },
+		{ hosts: []string{"nas2"}, want: []string{"n2"} },
+		{ paths: []string{"/home/alice"}, want: []string{"a1", "a2", "n2"} },
+		{ hosts: []string{"nas1"}, paths: []string{"/home/alice", "/home/bob/d.txt"}, want: []string{"a1", "a2", "b1"} },
+		{ paths: []string{"/srv"}, want: []string{} },
+	}
+
+	for i, test := range tests {
+		u := &User{Name: "user", Role: Reader, Hosts: test.hosts, Paths: test.paths}
+
+		qr, err := u.Client(newTestDB()).Query(dbms.NewQueryArgs(), []string{dbms.FieldID})
+		if err != nil {
+			t.Fatalf("[%d] Query returned unexpected error: %v", i, err)
+		}
+
+		got := make([]string, 0, len(qr))
+		for _, fields := range qr {
+			got = append(got, fields[dbms.FieldID].(string))
+		}
+		sort.Strings(got)
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d] hosts %v, paths %v - want objects %v, got %v", i, test.hosts, test.paths, test.want, got)
+		}
+	}
+}
+
+func TestGetHosts(t *testing.T) {
+	tests := []struct {
+		hosts, paths	[]string
+		want			map[string][]string	// host => indexing paths
+	} {
+		{
+			want: map[string][]string{
+				"nas1": {"/home", "/home/alice2", "/srv"}, "nas2": {"/home"}, "nas3": {"/data"},
+			},
+		},
+		{
+			hosts: []string{"nas2", "nas3"},
+			want: map[string][]string{"nas2": {"/home"}, "nas3": {"/data"}},
+		},
+		// Paths that contain visible directories or are inside of them, siblings are hidden
+		{
+			paths: []string{"/home/alice"},
+			want: map[string][]string{"nas1": {"/home"}, "nas2": {"/home"}},
+		},
+		{
+			hosts: []string{"nas1"}, paths: []string{"/srv/www"},
+			want: map[string][]string{"nas1": {"/srv"}},
+		},
+	}
+
+	for i, test := range tests {
+		u := &User{Name: "user", Role: Reader, Hosts: test.hosts, Paths: test.paths}
+
+		his, err := u.Client(newTestDB()).GetHosts()
+		if err != nil {
+			t.Fatalf("[%d] GetHosts returned unexpected error: %v", i, err)
+		}
+
+		got := make(map[string][]string, len(his))
+		for _, hi := range his {
+			got[hi.Host] = hi.IdxPaths
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d] hosts %v, paths %v - want %v, got %v", i, test.hosts, test.paths, test.want, got)
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	upd := func(id, host, fpath string) *dbms.ObjChange {
+		return &dbms.ObjChange{Op: dbms.Update, ID: id, Host: host, FPath: fpath}
+	}
+	del := func(id, host, fpath string) *dbms.ObjChange {
+		return &dbms.ObjChange{Op: dbms.Delete, ID: id, Host: host, FPath: fpath}
+	}
+
+	tests := []struct {
+		paths	[]string
+		changes	[][]*dbms.ObjChange
+		want	[]string	// "OP ID HOST:PATH" of changes passed to the handler
+	} {
+		// Not restricted users get all changes as is
+		{
+			changes:	[][]*dbms.ObjChange{{upd("b1", "", ""), del("x1", "", "")}},
+			want:		[]string{"Update b1 :", "Delete x1 :"},
+		},
+		// Changes with hosts and paths
+		{
+			paths:		[]string{"/home/alice"},
+			changes:	[][]*dbms.ObjChange{{
+				upd("a1", "nas1", "/home/alice/a.txt"), upd("b1", "nas1", "/home/bob/d.txt"),
+				del("a2", "nas1", "/home/alice/docs/b.txt"), del("s1", "nas1", "/home/alice2/c.txt"),
+			}},
+			want:		[]string{"Update a1 nas1:/home/alice/a.txt", "Delete a2 nas1:/home/alice/docs/b.txt"},
+		},
+		// Changes with only identifiers, hosts and paths of updated objects are requested from the database
+		{
+			paths:		[]string{"/home/alice"},
+			changes:	[][]*dbms.ObjChange{
+				{upd("a1", "", ""), upd("b1", "", ""), upd("unknown", "", ""), del("a2", "", "")},
+				// Deletion is passed only for objects which updates were passed
+				{del("a1", "", ""), del("b1", "", ""), upd("n2", "", "")},
+				// The deleted object is forgotten
+				{del("a1", "", "")},
+			},
+			want:		[]string{
+				"Update a1 nas1:/home/alice/a.txt",
+				"Delete a1 :", "Update n2 nas2:/home/alice/e.txt",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		db := newTestDB()
+		db.changes = test.changes
+		u := &User{Name: "user", Role: Reader, Paths: test.paths}
+
+		got := []string{}
+		err := u.Client(db).Subscribe(context.Background(), func(changes []*dbms.ObjChange) error {
+			if len(changes) == 0 {
+				t.Errorf("[%d] handler was called without changes", i)
+			}
+			for _, oc := range changes {
+				got = append(got, fmt.Sprintf("%v %s %s:%s", oc.Op, oc.ID, oc.Host, oc.FPath))
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("[%d] Subscribe returned unexpected error: %v", i, err)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d] paths %v - want changes %q, got %q", i, test.paths, test.want, got)
+		}
+	}
+}
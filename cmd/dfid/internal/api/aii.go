@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+)
+
+// aii modifies tags or description of objects, exactly one of them should be set in the request
+func (s *Server) aii(r *http.Request) (any, error) {
+	if err := checkMethod(r, http.MethodPost); err != nil {
+		return nil, err
+	}
+
+	var req AIIRequest
+	if err := s.decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+
+	if len(req.Ids) == 0 {
+		return nil, newError(http.StatusBadRequest, "no object identifiers provided")
+	}
+	switch {
+	case len(req.Tags) == 0 && req.Descr == nil:
+		return nil, newError(http.StatusBadRequest, "tags or description should be set")
+	case len(req.Tags) != 0 && req.Descr != nil:
+		return nil, newError(http.StatusBadRequest, "tags and description cannot be set at the same time")
+	}
+
+	op, args, add, err := aiiOp(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	log.D("(API) Modify AII (%s) %#v for: %v", req.Op, args, req.Ids)
+
+	tagsUpdated, descrsUpdated, err := s.client(r).ModifyAII(op, args, req.Ids, add)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AIIResponse{
+		Response:		newResponse(),
+		TagsUpdated:	tagsUpdated,
+		DescrsUpdated:	descrsUpdated,
+	}, nil
+}
+
+// aiiOp converts the request to arguments of the AII modification
+func aiiOp(req *AIIRequest) (dbms.DBOperator, *dbms.AIIArgs, bool, error) {
+	args := &dbms.AIIArgs{NoNL: req.NoNewline}
+
+	// Trim spaces from each tag
+	for _, tag := range req.Tags {
+		if tag = strings.TrimSpace(tag); tag == "" {
+			return 0, nil, false, newError(http.StatusBadRequest, "empty tags are not allowed")
+		}
+		args.Tags = append(args.Tags, tag)
+	}
+
+	switch req.Op {
+	case aiiOpSet, aiiOpAppend:
+		// Check tags for a special value forbidden to set
+		for _, tag := range args.Tags {
+			if tag == dbms.AIIAllTags {
+				return 0, nil, false, newError(http.StatusBadRequest,
+					"tag value %q is a special value that cannot be used as a tag", dbms.AIIAllTags)
+			}
+		}
+
+		if req.Descr != nil {
+			args.SetDescr(*req.Descr)
+		}
+
+		return dbms.Update, args, req.Op == aiiOpAppend, nil
+
+	case aiiOpDelete:
+		if req.Descr != nil {
+			args.Descr = dbms.AIIDelDescr
+		}
+
+		return dbms.Delete, args, false, nil
+	}
+
+	return 0, nil, false, newError(http.StatusBadRequest, "unknown operation %q, valid operations: %s, %s, %s",
+		req.Op, aiiOpSet, aiiOpAppend, aiiOpDelete)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/r-che/dfi/cmd/internal/query"
+	"github.com/r-che/dfi/types"
+)
+
+func (s *Server) dupes(r *http.Request) (any, error) {
+	if err := checkMethod(r, http.MethodPost); err != nil {
+		return nil, err
+	}
+
+	var req DupesRequest
+	if err := s.decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if err := s.page(&req.Page); err != nil {
+		return nil, err
+	}
+
+	if len(req.Ids) == 0 {
+		return nil, newError(http.StatusBadRequest, "no identifiers of objects to search duplicates for provided")
+	}
+	// The same as for the dfi utility, where phrases are used as identifiers
+	if len(req.Phrases) != 0 {
+		return nil, newError(http.StatusBadRequest, "phrases cannot be used to search duplicates")
+	}
+
+	qa, err := req.Conditions.queryArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	rv := types.NewCmdRV()
+	refObjs, objDupes, nd, err := query.FindDupes(s.client(r), qa, req.Ids, rv)
+	if err != nil {
+		return nil, err
+	}
+	if err := rv.ErrsJoin("; "); err != nil {
+		return nil, err
+	}
+
+	resp := &DupesResponse{
+		Response:		newResponse(),
+		PageResponse:	PageResponse{Page: req.Page},
+		Found:			nd,
+		Refs:			[]*DupesRef{},
+	}
+	resp.Warnings = rv.Warns()
+
+	// Keep order of requested identifiers, skip unsuitable objects
+	ids := make([]string, 0, len(refObjs))
+	for _, id := range req.Ids {
+		if _, ok := refObjs[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+
+	resp.Total = len(ids)
+	start, end := pageBounds(&req.Page, resp.Total)
+	for _, id := range ids[start:end] {
+		resp.Refs = append(resp.Refs, newDupesRef(id, refObjs[id], objDupes[id]))
+	}
+
+	return resp, nil
+}
+
+func newDupesRef(id string, ref *query.DupeRef, dupes []query.DupeInfo) *DupesRef {
+	dr := &DupesRef{
+		Object:			Object{ID: id, Host: ref.ObjKey.Host, Path: ref.ObjKey.Path},
+		Checksum:		ref.CSum,
+		Size:			ref.Size,
+		Reclaimable:	query.Reclaimable(ref, dupes),
+		Dupes:			make([]*Dupe, 0, len(dupes)),
+	}
+
+	for _, di := range dupes {
+		dr.Dupes = append(dr.Dupes, &Dupe{
+			Object:	Object{ID: di.ID, Host: di.ObjKey.Host, Path: di.ObjKey.Path},
+			LinkOf:	di.LinkOf,
+		})
+	}
+
+	return dr
+}
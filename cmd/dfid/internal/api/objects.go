@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+)
+
+// objects returns objects with all user valuable fields and additional information by identifiers
+// passed by the id parameters of the GET request or by the body of the POST request
+func (s *Server) objects(r *http.Request) (any, error) {
+	if err := checkMethod(r, http.MethodGet, http.MethodPost); err != nil {
+		return nil, err
+	}
+
+	var req ObjectsRequest
+	if r.Method == http.MethodGet {
+		req.Ids = r.URL.Query()["id"]
+	} else if err := s.decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+
+	if len(req.Ids) == 0 {
+		return nil, newError(http.StatusBadRequest, "no object identifiers provided")
+	}
+	if len(req.Ids) > s.cfg.MaxPageSize {
+		return nil, newError(http.StatusBadRequest, "too many identifiers %d, maximum is %d",
+			len(req.Ids), s.cfg.MaxPageSize)
+	}
+
+	dbc := s.client(r)
+
+	objs, err := dbc.GetObjects(req.Ids, dbms.UVObjFields())
+	if err != nil {
+		return nil, err
+	}
+
+	// Map identifiers to object keys to return objects in the same order as identifiers
+	ikm := make(map[string]types.ObjKey, len(objs))
+	for objKey, fields := range objs {
+		ikm[fieldStr(fields, dbms.FieldID)] = objKey
+	}
+
+	resp := &ObjectsResponse{
+		Response:	newResponse(),
+		Objects:	make([]*Object, 0, len(objs)),
+	}
+
+	found := make([]string, 0, len(objs))
+	for _, id := range req.Ids {
+		objKey, ok := ikm[id]
+		if !ok {
+			resp.NotFound = append(resp.NotFound, id)
+			continue
+		}
+
+		found = append(found, id)
+		resp.Objects = append(resp.Objects, newObject(objKey, objs[objKey], dbms.UVObjFields()))
+	}
+
+	if len(found) == 0 {
+		// OK, nothing to complete by additional information
+		return resp, nil
+	}
+
+	aiis, err := dbc.GetAIIs(found, dbms.UVAIIFields())
+	if err != nil {
+		// Objects are still valid, only additional information is missing
+		resp.Warnings = append(resp.Warnings, "cannot get additional information about objects: " + err.Error())
+	}
+	for _, obj := range resp.Objects {
+		obj.setAII(aiis[obj.ID])
+	}
+
+	return resp, nil
+}
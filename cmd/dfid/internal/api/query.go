@@ -0,0 +1,194 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+)
+
+// queryArgs converts conditions of the request to the query arguments. Conditions
+// are checked the same way as the corresponding options of the dfi utility
+//
+//nolint:cyclop	// Simplifying the code here makes it harder to support
+func (c *Conditions) queryArgs() (*dbms.QueryArgs, error) {
+	qa := dbms.NewQueryArgs()
+
+	// Check for incompatible flags
+	io := []string{}
+	for _, f := range []struct {
+		name	string
+		val		bool
+	}{
+		{ "onlyName",	c.OnlyName },
+		{ "onlyTags",	c.OnlyTags },
+		{ "onlyDescr",	c.OnlyDescr },
+		{ "deep",		c.Deep },
+	} {
+		if f.val {
+			io = append(io, f.name)
+		}
+	}
+	if len(io) > 1 {
+		return nil, newError(http.StatusBadRequest, "conditions are incompatible: %s", strings.Join(io, " "))
+	}
+
+	// Check for required phrases
+	if len(io) != 0 || c.UseTags || c.UseDescr || c.Literal || c.RawQuery {
+		if len(c.Phrases) == 0 {
+			return nil, newError(http.StatusBadRequest, "conditions onlyName, onlyTags, onlyDescr, deep, " +
+				"tags, descr, literal and rawQuery require at least one phrase")
+		}
+	}
+
+	// Raw queries are passed to the DBMS as is, they cannot be matched to other fields
+	if c.RawQuery && (len(io) != 0 || c.UseTags || c.UseDescr || c.Literal) {
+		return nil, newError(http.StatusBadRequest, "condition rawQuery cannot be used with onlyName, onlyTags, " +
+			"onlyDescr, deep, tags, descr and literal")
+	}
+
+	// Conditions with values in the format of the dfi utility options with related parsers
+	for _, cond := range []struct {
+		val		string
+		parser	func(string) error
+	}{
+		{ c.Mtime,		qa.ParseMtimes },
+		{ c.Size,		qa.ParseSizes },
+		{ c.Types,		func(v string) error { return qa.ParseTypes(v, types.ObjTypes()) } },
+		{ c.Checksums,	qa.ParseSums },
+		// Convert hostname to lower case to avoid the need for a case-insensitive search in DB
+		{ strings.ToLower(c.Hosts),	qa.ParseHosts },
+		{ c.Ctime,		qa.ParseCtimes },
+		{ c.Users,		qa.ParseUsers },
+		{ c.UIDs,		qa.ParseUIDs },
+		{ c.Groups,		qa.ParseGroups },
+		{ c.GIDs,		qa.ParseGIDs },
+		{ c.Perm,		qa.ParsePerm },
+		{ c.Inodes,		qa.ParseInodes },
+		{ c.NLinks,		qa.ParseNLinks },
+		{ c.MIMEs,		qa.ParseMIMEs },
+		{ c.Taken,		qa.ParseTakens },
+		{ c.Cameras,	qa.ParseCameras },
+		{ c.PointsTo,	qa.ParsePointsTo },
+		{ c.PathGlob,	qa.ParsePathGlob },
+		{ c.NameRegex,	qa.ParseNameRegex },
+		{ c.Query,		qa.ParseExpr },
+		{ c.AIIFilled,	func(v string) error { return qa.ParseAIIFields(v, dbms.UVAIIFields()) } },
+	} {
+		if cond.val == "" {
+			// Condition was not set
+			continue
+		}
+
+		if err := cond.parser(cond.val); err != nil {
+			return nil, newError(http.StatusBadRequest, "invalid conditions: %v", err)
+		}
+	}
+
+	qa.SetSearchPhrases(c.Phrases)
+	qa.Dangling = c.Dangling
+	qa.Content = strings.TrimSpace(c.Content)
+
+	qa.OnlyName = c.OnlyName
+	qa.OnlyTags = c.OnlyTags
+	qa.OnlyDescr = c.OnlyDescr
+	qa.DeepSearch = c.Deep
+	qa.LiteralSP = c.Literal
+	qa.RawQuery = c.RawQuery
+	qa.OrExpr = c.Or
+	qa.NegExpr = c.Not
+
+	// Update values of flags that depend on other flags
+	qa.UseTags = c.UseTags || c.OnlyTags
+	qa.UseDescr = c.UseDescr || c.OnlyDescr
+
+	// Restrictions
+	hosts := make([]string, 0, len(c.ExcludeHosts))
+	for _, host := range c.ExcludeHosts {
+		hosts = append(hosts, strings.ToLower(host))
+	}
+	qa.SetExclHosts(hosts...)
+	qa.SetOnlyIds(c.OnlyIds...)
+
+	// OK
+	return qa, nil
+}
+
+// Fields with integer values
+var intFields = map[string]bool{
+	dbms.FieldSize:		true,
+	dbms.FieldMTime:	true,
+	dbms.FieldUID:		true,
+	dbms.FieldGID:		true,
+	dbms.FieldMode:		true,
+	dbms.FieldCTime:	true,
+	dbms.FieldInode:	true,
+	dbms.FieldDevice:	true,
+	dbms.FieldNLink:	true,
+	dbms.FieldTaken:	true,
+	dbms.FieldDuration:	true,
+	dbms.FieldWidth:	true,
+	dbms.FieldHeight:	true,
+}
+
+// Fields with floating point values
+var floatFields = map[string]bool{
+	dbms.FieldLat:	true,
+	dbms.FieldLon:	true,
+}
+
+// newObject makes the object of the response from the query result. Some backends return
+// values of all fields as strings, so numeric values are converted to make them independent
+// of the backend, values that cannot be converted are returned as is
+func newObject(objKey types.ObjKey, fields dbms.QRItem, retFields []string) *Object {
+	obj := &Object{
+		ID:		fieldStr(fields, dbms.FieldID),
+		Host:	objKey.Host,
+		Path:	objKey.Path,
+	}
+
+	for _, field := range retFields {
+		val, ok := fields[field]
+		if !ok || field == dbms.FieldID {
+			continue
+		}
+
+		if obj.Fields == nil {
+			obj.Fields = make(map[string]any, len(retFields))
+		}
+
+		if s, ok := val.(string); ok {
+			switch {
+			case intFields[field]:
+				if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+					val = v
+				}
+			case floatFields[field]:
+				if v, err := strconv.ParseFloat(s, 64); err == nil {
+					val = v
+				}
+			}
+		}
+
+		obj.Fields[field] = val
+	}
+
+	return obj
+}
+
+// setAII sets additional information of the object
+func (obj *Object) setAII(aii *dbms.AIIArgs) {
+	if aii == nil {
+		return
+	}
+
+	obj.Tags = aii.Tags
+	obj.Descr = aii.Descr
+}
+
+func fieldStr(fields dbms.QRItem, field string) string {
+	s, _ := fields[field].(string)
+	return s
+}
@@ -0,0 +1,186 @@
+package api
+
+// Version of the API, it is a part of paths of all endpoints. Incompatible changes of requests
+// or responses are made only in a new version, new optional fields can be added to the current one
+const APIVersion = "v1"
+
+// Prefix of paths of all endpoints
+const pathPrefix = "/api/" + APIVersion
+
+// Names of operations on additional information items
+const (
+	aiiOpSet	=	"set"
+	aiiOpAppend	=	"append"
+	aiiOpDelete	=	"delete"
+)
+
+// Page selects a part of the sorted results
+type Page struct {
+	Offset	int	`json:"offset"`
+	Limit	int	`json:"limit"`		// 0 - use the default page size of the server
+}
+
+// Conditions of the search, values of strings have the same format
+// as values of the corresponding options of the dfi utility
+type Conditions struct {
+	Phrases		[]string	`json:"phrases"`
+
+	Mtime		string		`json:"mtime"`
+	Size		string		`json:"size"`
+	Types		string		`json:"types"`
+	Checksums	string		`json:"checksums"`
+	Hosts		string		`json:"hosts"`
+	Ctime		string		`json:"ctime"`
+	Users		string		`json:"users"`
+	UIDs		string		`json:"uids"`
+	Groups		string		`json:"groups"`
+	GIDs		string		`json:"gids"`
+	Perm		string		`json:"perm"`
+	Inodes		string		`json:"inodes"`
+	NLinks		string		`json:"nlinks"`
+	MIMEs		string		`json:"mimes"`
+	Taken		string		`json:"taken"`
+	Cameras		string		`json:"cameras"`
+	Dangling	bool		`json:"dangling"`
+	PointsTo	string		`json:"pointsTo"`
+	PathGlob	string		`json:"pathGlob"`
+	NameRegex	string		`json:"nameRegex"`
+	Query		string		`json:"query"`
+	Content		string		`json:"content"`
+	AIIFilled	string		`json:"aiiFilled"`
+
+	OnlyName	bool		`json:"onlyName"`
+	OnlyTags	bool		`json:"onlyTags"`
+	OnlyDescr	bool		`json:"onlyDescr"`
+	Deep		bool		`json:"deep"`
+	Literal		bool		`json:"literal"`
+	RawQuery	bool		`json:"rawQuery"`
+	UseTags		bool		`json:"tags"`
+	UseDescr	bool		`json:"descr"`
+	Or			bool		`json:"or"`
+	Not			bool		`json:"not"`
+
+	// Restrictions applied regardless of or and not
+	ExcludeHosts	[]string	`json:"excludeHosts"`
+	OnlyIds			[]string	`json:"onlyIds"`
+}
+
+// Request of the search endpoint
+type SearchRequest struct {
+	Conditions
+	Fields	[]string	`json:"fields"`	// fields of objects to return in addition to identifiers
+	Page
+}
+
+// Request of the objects endpoint
+type ObjectsRequest struct {
+	Ids		[]string	`json:"ids"`
+}
+
+// Request of the dupes endpoint, conditions restrict objects among which duplicates are searched
+type DupesRequest struct {
+	Ids		[]string	`json:"ids"`
+	Conditions
+	Page
+}
+
+// Request of the AII endpoint
+type AIIRequest struct {
+	Op			string		`json:"op"`		// set, append or delete
+	Tags		[]string	`json:"tags"`	// tags to operate, the special tag ALL deletes all tags
+	Descr		*string		`json:"descr"`	// description to set or append, any value to delete
+	NoNewline	bool		`json:"noNewline"`	// join the appended description by "; " instead of new line
+	Ids			[]string	`json:"ids"`
+}
+
+// Indexed object
+type Object struct {
+	ID		string			`json:"id"`
+	Host	string			`json:"host"`
+	Path	string			`json:"path"`
+	Fields	map[string]any	`json:"fields,omitempty"`
+	Tags	[]string		`json:"tags,omitempty"`
+	Descr	string			`json:"descr,omitempty"`
+}
+
+// Common part of responses
+type Response struct {
+	APIVersion	string		`json:"apiVersion"`
+	Warnings	[]string	`json:"warnings,omitempty"`
+}
+
+// Common part of paginated responses
+type PageResponse struct {
+	Total	int	`json:"total"`	// total number of items on all pages
+	Page
+}
+
+type SearchResponse struct {
+	Response
+	PageResponse
+	Objects	[]*Object	`json:"objects"`
+}
+
+type ObjectsResponse struct {
+	Response
+	Objects		[]*Object	`json:"objects"`
+	NotFound	[]string	`json:"notFound,omitempty"`
+}
+
+type DupesResponse struct {
+	Response
+	PageResponse
+	Found	int64		`json:"found"`	// number of duplicates of all references excluding hard links
+	Refs	[]*DupesRef	`json:"refs"`
+}
+
+// Object with its duplicates
+type DupesRef struct {
+	Object
+	Checksum	string		`json:"checksum"`
+	Size		int64		`json:"size"`
+	Reclaimable	int64		`json:"reclaimable"`	// space in bytes that can be freed by removing duplicates
+	Dupes		[]*Dupe		`json:"dupes"`
+}
+
+type Dupe struct {
+	Object
+	LinkOf	string	`json:"linkOf,omitempty"`	// identifier of the object to which this one is a hard link
+}
+
+type TagsResponse struct {
+	Response
+	PageResponse
+	Tags	[]*TagUsage	`json:"tags"`
+}
+
+type TagUsage struct {
+	Tag		string	`json:"tag"`
+	Count	int		`json:"count"`	// number of objects with the tag
+}
+
+type AIIResponse struct {
+	Response
+	TagsUpdated		int64	`json:"tagsUpdated"`
+	DescrsUpdated	int64	`json:"descrsUpdated"`
+}
+
+type VersionResponse struct {
+	Response
+	Version	string	`json:"version"`
+	Backend	string	`json:"backend"`
+	User	string	`json:"user,omitempty"`	// authenticated user, empty if authentication is disabled
+	Role	string	`json:"role,omitempty"`
+}
+
+type ErrorResponse struct {
+	Response
+	Error	*Error	`json:"error"`
+}
+
+type Error struct {
+	Status	int		`json:"status"`
+	Message	string	`json:"message"`
+
+	allow	[]string	// methods allowed by the endpoint, only for the method not allowed status
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/r-che/dfi/cmd/internal/query"
+	"github.com/r-che/dfi/common/tools"
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+)
+
+func (s *Server) search(r *http.Request) (any, error) {
+	if err := checkMethod(r, http.MethodPost); err != nil {
+		return nil, err
+	}
+
+	var req SearchRequest
+	if err := s.decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if err := s.page(&req.Page); err != nil {
+		return nil, err
+	}
+
+	// Check requested fields
+	uvFields := tools.NewSet(dbms.UVObjFields()...)
+	for _, field := range req.Fields {
+		if !uvFields.Includes(field) {
+			return nil, newError(http.StatusBadRequest, "unknown field %q, valid fields: %v",
+				field, dbms.UVObjFields())
+		}
+	}
+
+	qa, err := req.Conditions.queryArgs()
+	if err != nil {
+		return nil, err
+	}
+	if !qa.CanSearch(req.Phrases) {
+		return nil, newError(http.StatusBadRequest, "insufficient conditions to make search")
+	}
+
+	resp := &SearchResponse{
+		Response:		newResponse(),
+		PageResponse:	PageResponse{Page: req.Page},
+		Objects:		[]*Object{},
+	}
+
+	dbc := s.client(r)
+
+	// Resolve conditions that are searched separately from objects
+	if ok, err := query.ResolveIds(dbc, qa); err != nil {
+		return nil, err
+	} else if !ok {
+		// Nothing can be found, return empty result
+		return resp, nil
+	}
+
+	qr, err := dbc.Query(qa, append([]string{dbms.FieldID}, req.Fields...))
+	if err != nil {
+		return nil, err
+	}
+
+	// Results are sorted by object keys to make pages stable
+	objKeys := make([]types.ObjKey, 0, len(qr))
+	for k := range qr {
+		objKeys = append(objKeys, k)
+	}
+	sort.Slice(objKeys, func(i, j int) bool {
+		return objKeys[i].Less(objKeys[j])
+	})
+
+	resp.Total = len(objKeys)
+	start, end := pageBounds(&req.Page, resp.Total)
+	for _, k := range objKeys[start:end] {
+		resp.Objects = append(resp.Objects, newObject(k, qr[k], req.Fields))
+	}
+
+	return resp, nil
+}
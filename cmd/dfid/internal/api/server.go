@@ -0,0 +1,356 @@
+// Package api implements the REST/JSON HTTP interface to the index
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	_ "embed"	// to embed the JSON schema of the API
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/r-che/dfi/cmd/dfid/internal/access"
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+)
+
+// Limits of reading of requests
+const (
+	readHeaderTimeout	=	10 * time.Second
+	readTimeout			=	time.Minute
+)
+
+//go:embed schema_v1.json
+var schemaV1 []byte
+
+// Config of the server
+type Config struct {
+	Listen		string		// address to listen
+	TLS			*tls.Config	// requests are served with TLS if set
+	Version		string		// version of the server reported by the version endpoint
+	PageSize	int			// default number of items on the page
+	MaxPageSize	int			// maximum number of items on the page
+	MaxBodySize	int64		// maximum size of the request body
+
+	// Optional web interface working over the API
+	UIPath		string			// path where the interface is served, should end with slash
+	UI			http.Handler	// handler of the interface, nil if disabled
+
+	// Users authenticated by the HTTP basic authentication, nil if authentication is disabled
+	Access		*access.Control
+}
+
+type Server struct {
+	cfg		*Config
+	dbc		dbms.Client
+	srv		*http.Server
+}
+
+func NewServer(cfg *Config, dbc dbms.Client) *Server {
+	s := &Server{
+		cfg:	cfg,
+		dbc:	dbc,
+	}
+
+	mux := http.NewServeMux()
+	for path, h := range map[string]handler{
+		"/version":	s.version,
+		"/search":	s.search,
+		"/objects":	s.objects,
+		"/dupes":	s.dupes,
+		"/tags":	s.tags,
+		"/aii":		s.aii,
+	} {
+		mux.Handle(pathPrefix + path, h)
+	}
+	mux.HandleFunc(pathPrefix + "/schema", s.schema)
+	if cfg.UI != nil {
+		mux.Handle(cfg.UIPath, cfg.UI)
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// Open the web interface by the root path
+		if r.URL.Path == "/" && cfg.UI != nil {
+			http.Redirect(w, r, cfg.UIPath, http.StatusFound)
+			return
+		}
+
+		writeError(w, r, errNotFound)
+	})
+
+	s.srv = &http.Server{
+		Addr:				cfg.Listen,
+		Handler:			s.logRequests(s.authenticate(mux)),
+		ReadHeaderTimeout:	readHeaderTimeout,
+		ReadTimeout:		readTimeout,
+		TLSConfig:			cfg.TLS,
+	}
+
+	return s
+}
+
+// Run starts serving requests, it blocks until the server is stopped
+func (s *Server) Run() error {
+	var err error
+	if s.cfg.TLS != nil {
+		log.I("(API) Listening on %s with TLS", s.cfg.Listen)
+		// Certificates are already loaded to the TLS configuration
+		err = s.srv.ListenAndServeTLS("", "")
+	} else {
+		log.I("(API) Listening on %s", s.cfg.Listen)
+		err = s.srv.ListenAndServe()
+	}
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("(API) server failed: %w", err)
+	}
+
+	// OK
+	return nil
+}
+
+// Stop stops the server waiting for active requests until ctx is done
+func (s *Server) Stop(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
+
+// Handler returns the HTTP handler of the API, it can be used to serve requests by other servers
+func (s *Server) Handler() http.Handler {
+	return s.srv.Handler
+}
+
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(sw, r)
+
+		log.D("(API) %s %s %s - %d (%v)", r.RemoteAddr, r.Method, r.URL, sw.status, time.Since(start))
+	})
+}
+
+// Key of the authenticated user in the context of the request
+type userKey struct{}
+
+// authenticate passes only requests of authenticated users if authentication is enabled
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if s.cfg.Access == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, password, ok := r.BasicAuth()
+		if !ok {
+			w.Header().Set("WWW-Authenticate", authChallenge)
+			writeError(w, r, newError(http.StatusUnauthorized, "authentication required"))
+			return
+		}
+
+		user, err := s.cfg.Access.Authenticate(name, password)
+		if err != nil {
+			log.W("(API) %s %s %s: authentication of user %q failed", r.RemoteAddr, r.Method, r.URL.Path, name)
+			w.Header().Set("WWW-Authenticate", authChallenge)
+			writeError(w, r, newError(http.StatusUnauthorized, "%v", err))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, user)))
+	})
+}
+
+const authChallenge = `Basic realm="dfid", charset="UTF-8"`
+
+// client returns the database client to serve the request on behalf of the authenticated user
+func (s *Server) client(r *http.Request) dbms.Client {
+	if user, ok := r.Context().Value(userKey{}).(*access.User); ok {
+		return user.Client(s.dbc)
+	}
+
+	return s.dbc
+}
+
+// statusWriter keeps the status of the response to log it
+type statusWriter struct {
+	http.ResponseWriter
+	status	int
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	sw.status = status
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+// handler is an endpoint function, it returns the value to encode as the response body or an error
+type handler func(r *http.Request) (any, error)
+
+func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp, err := h(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) schema(w http.ResponseWriter, r *http.Request) {
+	if err := checkMethod(r, http.MethodGet); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/schema+json")
+	if _, err := w.Write(schemaV1); err != nil {
+		log.D("(API) Cannot write schema: %v", err)
+	}
+}
+
+func (s *Server) version(r *http.Request) (any, error) {
+	if err := checkMethod(r, http.MethodGet); err != nil {
+		return nil, err
+	}
+
+	resp := &VersionResponse{
+		Response:	newResponse(),
+		Version:	s.cfg.Version,
+		Backend:	dbms.Backend,
+	}
+	if user, ok := r.Context().Value(userKey{}).(*access.User); ok {
+		resp.User = user.Name
+		resp.Role = user.Role.String()
+	}
+
+	return resp, nil
+}
+
+func newResponse() Response {
+	return Response{APIVersion: APIVersion}
+}
+
+// decodeBody decodes JSON body of the request to v, unknown fields are errors
+// to detect misspelled conditions that would be silently ignored otherwise
+func (s *Server) decodeBody(r *http.Request, v any) error {
+	// Browsers send forms of other sites without preflight requests only with the text/plain or
+	// form content types, so requiring JSON prevents modifications by cross-site request forgery
+	ct := r.Header.Get("Content-Type")
+	if mt, _, err := mime.ParseMediaType(ct); err != nil || mt != "application/json" {
+		return newError(http.StatusUnsupportedMediaType, "unsupported content type %q of request body, application/json is required", ct)
+	}
+
+	// Read one byte more than allowed to detect too large bodies
+	data, err := io.ReadAll(io.LimitReader(r.Body, s.cfg.MaxBodySize + 1))
+	if err != nil {
+		return newError(http.StatusBadRequest, "cannot read request body: %v", err)
+	}
+	if int64(len(data)) > s.cfg.MaxBodySize {
+		return newError(http.StatusRequestEntityTooLarge, "request body is larger than %d bytes", s.cfg.MaxBodySize)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return newError(http.StatusBadRequest, "invalid request body: %v", err)
+	}
+
+	// Only spaces are allowed after the value
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return newError(http.StatusBadRequest, "invalid request body: unexpected data after JSON value")
+	}
+
+	// OK
+	return nil
+}
+
+// page checks the requested page and sets the default limit
+func (s *Server) page(p *Page) error {
+	switch {
+	case p.Offset < 0:
+		return newError(http.StatusBadRequest, "invalid offset %d, must be non-negative", p.Offset)
+	case p.Limit < 0:
+		return newError(http.StatusBadRequest, "invalid limit %d, must be non-negative", p.Limit)
+	case p.Limit > s.cfg.MaxPageSize:
+		return newError(http.StatusBadRequest, "invalid limit %d, maximum is %d", p.Limit, s.cfg.MaxPageSize)
+	case p.Limit == 0:
+		p.Limit = s.cfg.PageSize
+	}
+
+	// OK
+	return nil
+}
+
+// pageBounds returns bounds of the page in the list of total items
+func pageBounds(p *Page, total int) (int, int) {
+	if p.Offset >= total {
+		return total, total
+	}
+
+	end := p.Offset + p.Limit
+	if end > total {
+		end = total
+	}
+
+	return p.Offset, end
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		// Probably, the client has gone
+		log.D("(API) Cannot write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var ae *Error
+	switch {
+	case errors.As(err, &ae):
+		// Error of the client
+	case errors.Is(err, access.ErrForbidden):
+		ae = &Error{Status: http.StatusForbidden, Message: err.Error()}
+	default:
+		// Not a client error, probably, a problem with the database
+		log.E("(API) %s %s: %v", r.Method, r.URL.Path, err)
+		ae = &Error{Status: http.StatusInternalServerError, Message: err.Error()}
+	}
+
+	if len(ae.allow) != 0 {
+		w.Header().Set("Allow", strings.Join(ae.allow, ", "))
+	}
+
+	writeJSON(w, ae.Status, &ErrorResponse{Response: newResponse(), Error: ae})
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func newError(status int, format string, args ...any) *Error {
+	return &Error{Status: status, Message: fmt.Sprintf(format, args...)}
+}
+
+var errNotFound = newError(http.StatusNotFound, "unknown endpoint")
+
+// checkMethod returns an error if the method of the request is not one of allowed
+func checkMethod(r *http.Request, allowed ...string) error {
+	for _, m := range allowed {
+		if r.Method == m {
+			// OK
+			return nil
+		}
+	}
+
+	e := newError(http.StatusMethodNotAllowed, "method %s is not allowed", r.Method)
+	e.allow = allowed
+
+	return e
+}
@@ -0,0 +1,420 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/r-che/dfi/cmd/dfid/internal/access"
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestMain(m *testing.M) {
+	// Server writes messages to the log
+	if err := log.Open(log.DefaultLog, "api-test", log.NoFlags); err != nil {
+		panic("cannot open log: " + err.Error())
+	}
+
+	os.Exit(m.Run())
+}
+
+// testDB is the database client that returns the same objects for any query, methods
+// that are not used by tests are not implemented by the embedded nil client
+type testDB struct {
+	dbms.Client
+
+	objects	map[string]types.ObjKey	// identifier => key of the object
+	err		error					// error returned by all methods
+}
+
+func newTestDB() *testDB {
+	return &testDB{objects: map[string]types.ObjKey{
+		"id1":	{Host: "nas1", Path: "/data/a"},
+		"id2":	{Host: "nas1", Path: "/data/b"},
+		"id3":	{Host: "nas2", Path: "/data/c"},
+	}}
+}
+
+func (db *testDB) Query(qa *dbms.QueryArgs, retFields []string) (dbms.QueryResults, error) {
+	if db.err != nil {
+		return nil, db.err
+	}
+
+	qr := dbms.QueryResults{}
+	for id, key := range db.objects {
+		qr[key] = dbms.QRItem{dbms.FieldID: id}
+	}
+
+	return qr, nil
+}
+
+func (db *testDB) GetObjects(ids, retFields []string) (dbms.QueryResults, error) {
+	qr := dbms.QueryResults{}
+	for _, id := range ids {
+		if key, ok := db.objects[id]; ok {
+			qr[key] = dbms.QRItem{dbms.FieldID: id}
+		}
+	}
+
+	return qr, db.err
+}
+
+func (db *testDB) ModifyAII(op dbms.DBOperator, args *dbms.AIIArgs, ids []string, add bool) (int64, int64, error) {
+	if db.err != nil {
+		return 0, 0, db.err
+	}
+
+	return int64(len(ids)), 0, nil
+}
+
+func newTestServer(t *testing.T, db dbms.Client, users map[string]*access.UserConfig) *Server {
+	t.Helper()
+
+	cfg := &Config{
+		Version:		"test",
+		PageSize:		2,
+		MaxPageSize:	10,
+		MaxBodySize:	64,
+	}
+	if users != nil {
+		ac, err := access.NewControl(users)
+		if err != nil {
+			t.Fatalf("cannot create access control: %v", err)
+		}
+		cfg.Access = ac
+	}
+
+	return NewServer(cfg, db)
+}
+
+func TestDecodeBody(t *testing.T) {
+	s := newTestServer(t, newTestDB(), nil)
+
+	tests := []struct {
+		ct		string
+		body	string
+		want	int	// status of the error, 0 if the body is valid
+	} {
+		{ ct: "application/json", body: `{"ids": ["a"]}` },
+		{ ct: "application/json; charset=utf-8", body: `{"ids": ["a"]}` },
+		{ ct: "Application/JSON", body: `{"ids": ["a"]}` },
+		// Content types that can be sent by forms of other sites
+		{ ct: "", body: `{"ids": ["a"]}`, want: http.StatusUnsupportedMediaType },
+		{ ct: "text/plain", body: `{"ids": ["a"]}`, want: http.StatusUnsupportedMediaType },
+		{ ct: "application/x-www-form-urlencoded", body: `ids=a`, want: http.StatusUnsupportedMediaType },
+		{ ct: "multipart/form-data; boundary=x", body: `{"ids": ["a"]}`, want: http.StatusUnsupportedMediaType },
+		{ ct: "application/json;;", body: `{"ids": ["a"]}`, want: http.StatusUnsupportedMediaType },
+		// The maximum size is 64 bytes
+		{ ct: "application/json", body: `{"ids": ["` + strings.Repeat("a", 51) + `"]}` },
+		{ ct: "application/json", body: `{"ids": ["` + strings.Repeat("a", 52) + `"]}`, want: http.StatusRequestEntityTooLarge },
+		// Invalid bodies
+		{ ct: "application/json", body: ``, want: http.StatusBadRequest },
+		{ ct: "application/json", body: `{"ids": [`, want: http.StatusBadRequest },
+		{ ct: "application/json", body: `{"ids": "a"}`, want: http.StatusBadRequest },
+		{ ct: "application/json", body: `{"idz": ["a"]}`, want: http.StatusBadRequest },
+		{ ct: "application/json", body: `{"ids": ["a"]} {}`, want: http.StatusBadRequest },
+		{ ct: "application/json", body: `{"ids": ["a"]}]`, want: http.StatusBadRequest },
+		// Trailing spaces are allowed
+		{ ct: "application/json", body: "{\"ids\": [\"a\"]}\n\n" },
+	}
+
+	for i, test := range tests {
+		r := httptest.NewRequest(http.MethodPost, pathPrefix + "/objects", strings.NewReader(test.body))
+		if test.ct != "" {
+			r.Header.Set("Content-Type", test.ct)
+		}
+
+		var req ObjectsRequest
+		err := s.decodeBody(r, &req)
+		if got := errStatus(err); got != test.want {
+			t.Errorf("[%d] decodeBody(%q, %q) - want status %d, got %d (%v)", i, test.ct, test.body, test.want, got, err)
+		}
+	}
+}
+
+func TestPage(t *testing.T) {
+	s := newTestServer(t, newTestDB(), nil)
+
+	tests := []struct {
+		page		Page
+		wantLimit	int
+		want		int	// status of the error, 0 if the page is valid
+	} {
+		// The default page size is used
+		{ page: Page{}, wantLimit: 2 },
+		{ page: Page{Offset: 5}, wantLimit: 2 },
+		{ page: Page{Limit: 1}, wantLimit: 1 },
+		// The maximum page size is 10
+		{ page: Page{Limit: 10}, wantLimit: 10 },
+		{ page: Page{Limit: 11}, want: http.StatusBadRequest },
+		{ page: Page{Limit: -1}, want: http.StatusBadRequest },
+		{ page: Page{Offset: -1}, want: http.StatusBadRequest },
+	}
+
+	for i, test := range tests {
+		p := test.page
+		err := s.page(&p)
+		if got := errStatus(err); got != test.want {
+			t.Errorf("[%d] page(%+v) - want status %d, got %d (%v)", i, test.page, test.want, got, err)
+			continue
+		}
+		if err == nil && p.Limit != test.wantLimit {
+			t.Errorf("[%d] page(%+v) - want limit %d, got %d", i, test.page, test.wantLimit, p.Limit)
+		}
+	}
+}
+
+func TestPageBounds(t *testing.T) {
+	tests := []struct {
+		page		Page
+		total		int
+		start, end	int
+	} {
+		{ page: Page{Offset: 0, Limit: 2}, total: 5, start: 0, end: 2 },
+		{ page: Page{Offset: 2, Limit: 2}, total: 5, start: 2, end: 4 },
+		{ page: Page{Offset: 4, Limit: 2}, total: 5, start: 4, end: 5 },
+		// Offset is past the end
+		{ page: Page{Offset: 5, Limit: 2}, total: 5, start: 5, end: 5 },
+		{ page: Page{Offset: 100, Limit: 2}, total: 5, start: 5, end: 5 },
+		{ page: Page{Offset: 0, Limit: 2}, total: 0, start: 0, end: 0 },
+	}
+
+	for i, test := range tests {
+		if start, end := pageBounds(&test.page, test.total); start != test.start || end != test.end {
+			t.Errorf("[%d] pageBounds(%+v, %d) - want [%d:%d], got [%d:%d]",
+				i, test.page, test.total, test.start, test.end, start, end)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	s := newTestServer(t, newTestDB(), nil)
+
+	tests := []struct {
+		body	string
+		want	int
+		total	int
+		ids		[]string
+	} {
+		// Results are sorted by object keys
+		{ body: `{"phrases": ["a"]}`, want: http.StatusOK, total: 3, ids: []string{"id1", "id2"} },
+		{ body: `{"phrases": ["a"], "offset": 1, "limit": 5}`, want: http.StatusOK, total: 3, ids: []string{"id2", "id3"} },
+		{ body: `{"phrases": ["a"], "offset": 3}`, want: http.StatusOK, total: 3, ids: []string{} },
+		{ body: `{"phrases": ["a"], "offset": 30}`, want: http.StatusOK, total: 3, ids: []string{} },
+		{ body: `{"phrases": ["a"], "limit": 11}`, want: http.StatusBadRequest },
+		{ body: `{"phrases": ["a"], "fields": ["unknown"]}`, want: http.StatusBadRequest },
+		{ body: `{}`, want: http.StatusBadRequest },
+	}
+
+	for i, test := range tests {
+		w := serve(s, http.MethodPost, "/search", "application/json", test.body, "", "")
+		if w.Code != test.want {
+			t.Errorf("[%d] search %s - want status %d, got %d: %s", i, test.body, test.want, w.Code, w.Body)
+			continue
+		}
+		if test.want != http.StatusOK {
+			checkError(t, i, w, test.want)
+			continue
+		}
+
+		var resp SearchResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("[%d] cannot decode response: %v", i, err)
+		}
+
+		ids := []string{}
+		for _, obj := range resp.Objects {
+			ids = append(ids, obj.ID)
+		}
+		if resp.Total != test.total || !reflect.DeepEqual(ids, test.ids) {
+			t.Errorf("[%d] search %s - want objects %v (total %d), got %v (total %d)",
+				i, test.body, test.ids, test.total, ids, resp.Total)
+		}
+	}
+}
+
+func TestServeErrors(t *testing.T) {
+	db := newTestDB()
+	s := newTestServer(t, db, nil)
+
+	tests := []struct {
+		method, path	string
+		ct, body		string
+		dbErr			error
+		want			int
+		allow			string
+	} {
+		// Methods not allowed by endpoints
+		{ method: http.MethodGet, path: "/search", want: http.StatusMethodNotAllowed, allow: "POST" },
+		{ method: http.MethodPut, path: "/aii", want: http.StatusMethodNotAllowed, allow: "POST" },
+		{ method: http.MethodPost, path: "/version", want: http.StatusMethodNotAllowed, allow: "GET" },
+		{ method: http.MethodPost, path: "/schema", want: http.StatusMethodNotAllowed, allow: "GET" },
+		// Unknown endpoint
+		{ method: http.MethodGet, path: "/unknown", want: http.StatusNotFound },
+		// Bodies of modifications must be JSON
+		{
+			method: http.MethodPost, path: "/aii", ct: "text/plain",
+			body: `{"op": "set", "tags": ["t"], "ids": ["id1"]}`, want: http.StatusUnsupportedMediaType,
+		},
+		{
+			method: http.MethodPost, path: "/aii", ct: "application/json",
+			body: `{"op": "set", "tags": ["t"], "ids": ["id1"], "descr": "` + strings.Repeat("d", 64) + `"}`,
+			want: http.StatusRequestEntityTooLarge,
+		},
+		// Errors of the database
+		{
+			method: http.MethodPost, path: "/search", ct: "application/json",
+			body: `{"phrases": ["a"]}`, dbErr: errors.New("connection refused"), want: http.StatusInternalServerError,
+		},
+		{
+			method: http.MethodPost, path: "/aii", ct: "application/json",
+			body: `{"op": "set", "tags": ["t"], "ids": ["id1"]}`,
+			dbErr: fmt.Errorf("wrapped: %w", access.ErrForbidden), want: http.StatusForbidden,
+		},
+	}
+
+	for i, test := range tests {
+		db.err = test.dbErr
+
+		w := serve(s, test.method, test.path, test.ct, test.body, "", "")
+		if w.Code != test.want {
+			t.Errorf("[%d] %s %s - want status %d, got %d: %s", i, test.method, test.path, test.want, w.Code, w.Body)
+			continue
+		}
+		checkError(t, i, w, test.want)
+
+		if got := w.Header().Get("Allow"); got != test.allow {
+			t.Errorf("[%d] %s %s - want Allow header %q, got %q", i, test.method, test.path, test.allow, got)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	users := map[string]*access.UserConfig{
+		"admin":	testUserConfig(t, "secret", "admin", nil),
+		"reader":	testUserConfig(t, "secret", "reader", nil),
+		"alice":	testUserConfig(t, "secret", "tagger", []string{"/data/a"}),
+	}
+	s := newTestServer(t, newTestDB(), users)
+
+	const aiiBody = `{"op": "set", "tags": ["t"], "ids": ["id1", "id2"]}`
+
+	tests := []struct {
+		method, path	string
+		body			string
+		user, password	string
+		want			int
+	} {
+		// Authentication is required
+		{ method: http.MethodGet, path: "/version", want: http.StatusUnauthorized },
+		{ method: http.MethodGet, path: "/version", user: "admin", password: "wrong", want: http.StatusUnauthorized },
+		{ method: http.MethodGet, path: "/version", user: "unknown", password: "secret", want: http.StatusUnauthorized },
+		{ method: http.MethodGet, path: "/unknown", want: http.StatusUnauthorized },
+		{ method: http.MethodGet, path: "/version", user: "reader", password: "secret", want: http.StatusOK },
+		// Permissions of roles and visibility of objects
+		{ method: http.MethodPost, path: "/aii", body: aiiBody, user: "reader", password: "secret", want: http.StatusForbidden },
+		{ method: http.MethodPost, path: "/aii", body: aiiBody, user: "alice", password: "secret", want: http.StatusForbidden },
+		{ method: http.MethodPost, path: "/aii", body: aiiBody, user: "admin", password: "secret", want: http.StatusOK },
+	}
+
+	for i, test := range tests {
+		w := serve(s, test.method, test.path, "application/json", test.body, test.user, test.password)
+		if w.Code != test.want {
+			t.Errorf("[%d] %s %s by %q - want status %d, got %d: %s",
+				i, test.method, test.path, test.user, test.want, w.Code, w.Body)
+			continue
+		}
+
+		challenge := w.Header().Get("WWW-Authenticate")
+		if test.want == http.StatusUnauthorized {
+			checkError(t, i, w, test.want)
+			if challenge != authChallenge {
+				t.Errorf("[%d] %s %s by %q - want WWW-Authenticate header %q, got %q",
+					i, test.method, test.path, test.user, authChallenge, challenge)
+			}
+			continue
+		}
+		if challenge != "" {
+			t.Errorf("[%d] %s %s by %q - unexpected WWW-Authenticate header %q", i, test.method, test.path, test.user, challenge)
+		}
+	}
+
+	// The authenticated user is reported by the version endpoint
+	w := serve(s, http.MethodGet, "/version", "", "", "alice", "secret")
+	var resp VersionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("cannot decode response: %v", err)
+	}
+	if resp.User != "alice" || resp.Role != "tagger" || resp.APIVersion != APIVersion {
+		t.Errorf("want user alice with role tagger, API %s, got %+v", APIVersion, resp)
+	}
+}
+
+// serve serves the request to the endpoint of the server and returns the recorded response
+func serve(s *Server, method, endpoint, ct, body, user, password string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, pathPrefix + endpoint, strings.NewReader(body))
+	if ct != "" {
+		r.Header.Set("Content-Type", ct)
+	}
+	if user != "" {
+		r.SetBasicAuth(user, password)
+	}
+
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, r)
+
+	return w
+}
+
+// checkError checks that the response is the error response with the status
+func checkError(t *testing.T, i int, w *httptest.ResponseRecorder, status int) {
+	t.Helper()
+
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Errorf("[%d] cannot decode error response %q: %v", i, w.Body, err)
+		return
+	}
+	if resp.Error == nil || resp.Error.Status != status || resp.Error.Message == "" || resp.APIVersion != APIVersion {
+		t.Errorf("[%d] want error response with status %d, got %s", i, status, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("[%d] want error response of application/json type, got %q", i, ct)
+	}
+}
+
+// errStatus returns the status of the client error, 0 if err is nil
+func errStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var ae *Error
+	if errors.As(err, &ae) {
+		return ae.Status
+	}
+
+	return -1
+}
+
+func testUserConfig(t *testing.T, password, role string, paths []string) *access.UserConfig {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("cannot make hash of the password: %v", err)
+	}
+
+	return &access.UserConfig{Password: string(hash), Role: role, Paths: paths}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/r-che/dfi/common/tools"
+	"github.com/r-che/dfi/types/dbms"
+)
+
+// tags returns used tags with numbers of objects sorted by number of objects in reverse order,
+// the tag parameters of the request restrict the list by the specified tags
+func (s *Server) tags(r *http.Request) (any, error) {
+	if err := checkMethod(r, http.MethodGet); err != nil {
+		return nil, err
+	}
+
+	params := r.URL.Query()
+
+	var page Page
+	for _, p := range []struct {
+		name	string
+		val		*int
+	}{
+		{ "offset",	&page.Offset },
+		{ "limit",	&page.Limit },
+	} {
+		if v := params.Get(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, newError(http.StatusBadRequest, "invalid value of %s parameter: %q", p.name, v)
+			}
+			*p.val = n
+		}
+	}
+	if err := s.page(&page); err != nil {
+		return nil, err
+	}
+
+	dbc := s.client(r)
+
+	// Get tags of all objects with tags
+	ids, err := dbc.GetAIIIds([]string{dbms.AIIFieldTags})
+	if err != nil {
+		return nil, err
+	}
+	qr, err := dbc.GetAIIs(ids, []string{dbms.AIIFieldTags})
+	if err != nil {
+		return nil, err
+	}
+
+	// Count usage of tags, requested but unused tags have zero counters
+	reqTags := tools.NewSet(params["tag"]...)
+	tt := map[string]int{}
+	for _, tag := range reqTags.List() {
+		tt[tag] = 0
+	}
+	for _, aii := range qr {
+		for _, tag := range aii.Tags {
+			if reqTags.Empty() || reqTags.Includes(tag) {
+				tt[tag]++
+			}
+		}
+	}
+
+	tags := make([]*TagUsage, 0, len(tt))
+	for tag, n := range tt {
+		tags = append(tags, &TagUsage{Tag: tag, Count: n})
+	}
+	// The most used tags are the first, tags with the same usage are sorted alphabetically
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+
+	start, end := pageBounds(&page, len(tags))
+
+	return &TagsResponse{
+		Response:		newResponse(),
+		PageResponse:	PageResponse{Total: len(tags), Page: page},
+		Tags:			tags[start:end],
+	}, nil
+}
@@ -0,0 +1,109 @@
+package cfg
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/optsparser"
+)
+
+const authors = "Roman Chebotarev"
+
+// Defaults
+const (
+	defaultConfig			=	"/etc/dfi/dfid.json"
+	defaultListen			=	"127.0.0.1:8091"
+	defaultPageSize			=	100
+	defaultMaxPageSize		=	10000
+	defaultMaxBodySize		=	1024 * 1024
+	defaultShutdownTimeout	=	10 * time.Second
+	defaultIngestRate		=	1000
+	defaultIngestBurst		=	10000
+	defaultIngestMaxWait	=	30 * time.Second
+)
+
+var config progConfig
+
+func Init(name, nameLong, vers string) {
+	// Create new parser
+	p := optsparser.NewParser(name).
+		SetUsageOnFail(false)	// Disable calling Usage on Parse error to handle returned error by itself
+
+	p.AddSeparator(`# Server options`)
+	p.AddString(`listen|l`, `address and port in HOST:PORT format to listen for HTTP requests`,
+		&config.Listen, defaultListen)
+	p.AddString(`grpc-listen|g`, `address and port in HOST:PORT format to serve the gRPC gateway to the database,`+
+		` the gateway is disabled if not set`, &config.GRPCListen, "")
+	p.AddString(`tls-cert`, `path to the PEM certificate of the server, the HTTP API and the gRPC gateway are`+
+		` served with TLS if set, requires --tls-key`, &config.TLSCert, "")
+	p.AddString(`tls-key`, `path to the PEM private key of the certificate of the server`, &config.TLSKey, "")
+	p.AddString(`cfg|c`, `path to configuration file with database connection settings`,
+		&config.confPath, defaultConfig)
+	p.AddInt(`page-size`, `number of items returned by requests without the limit`,
+		&config.PageSize, defaultPageSize)
+	p.AddInt(`max-page-size`, `maximum number of items returned by a single request`,
+		&config.MaxPageSize, defaultMaxPageSize)
+	p.AddInt64(`max-body-size`, `maximum size of the request body in bytes`,
+		&config.MaxBodySize, defaultMaxBodySize)
+	p.AddDuration(`shutdown-timeout`, `maximum time to wait for completion of active requests on stop`,
+		&config.ShutdownTimeout, defaultShutdownTimeout)
+	p.AddFloat64(`ingest-rate`, `maximum number of operations per second applied for a single agent`+
+		` by the ingest service, 0 - unlimited`, &config.IngestRate, defaultIngestRate)
+	p.AddInt(`ingest-burst`, `number of operations of an agent applied above the rate without delay`,
+		&config.IngestBurst, defaultIngestBurst)
+	p.AddDuration(`ingest-max-wait`, `maximum delay of a batch of an agent by the rate limit, batches that need longer delays are rejected`,
+		&config.IngestMaxWait, defaultIngestMaxWait)
+	p.AddBool(`no-webui`, `disable the web interface, only the API is served`, &config.NoWebUI, false)
+	p.AddString(`log-file|L`, `path to the log file`, &config.LogFile, "")
+
+	// Auxiliary options
+	p.AddSeparator(``,
+		`# Auxiliary options`,
+	)
+	p.AddBool(`debug|d`, `enable debug logging`, &config.Debug, false)
+	p.AddBool(`nologts|N`, `disable log timestamps`, &config.NoLogTS, false)
+	showVer := false
+	p.AddBool(`version|V`, `output version and authors information and exit`, &showVer, false)
+
+	// Signals handling information
+	p.AddSeparator(``,
+		`# Supported signals:`,
+		`* TERM, INT - stop application`,
+		`* HUP       - reopen log`,
+	)
+
+	//
+	// Parse options
+	//
+	err := p.Parse()
+
+	// Before checking for a parsing error, check if the --version parameter has been passed
+	if showVer {
+		fmt.Printf("%s (%s) %s\n", nameLong, name, vers)
+		fmt.Printf("DBMS backend: %s\n", dbms.Backend)
+		fmt.Printf("Written by %s\n", authors)
+
+		// Ok, no need to test error
+		os.Exit(0)
+	}
+
+	// Now, need to check the parsing error
+	if err != nil {
+		// Real problem, call Usage with error description
+		p.Usage(err)
+	}
+
+	// Check and prepare configuration
+	if err := config.prepare(); err != nil {
+		p.Usage(err)
+	}
+}
+
+// Config returns a new configuration structure as a copy
+// of existing to avoid accidentally modifications
+func Config() *progConfig {	//nolint:revive	// Currently, I prefer to keep it unexported
+	return config.clone()
+}
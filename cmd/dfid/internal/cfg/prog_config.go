@@ -0,0 +1,207 @@
+package cfg
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/r-che/dfi/cmd/dfid/internal/access"
+	"github.com/r-che/dfi/common/fschecks"
+	"github.com/r-che/dfi/dbi/remote/rpc"
+	"github.com/r-che/dfi/types/dbms"
+)
+
+type progConfig struct {
+	// Server options
+	Listen			string			// Address to listen for HTTP requests
+	GRPCListen		string			// Address to serve the gRPC gateway, empty - disabled
+	TLSCert			string			// Certificate of the server, TLS is disabled if empty
+	TLSKey			string			// Private key of the certificate of the server
+	IngestRate		float64			// Maximum number of operations per second of a single agent
+	IngestBurst		int				// Number of operations of an agent allowed above the rate
+	IngestMaxWait	time.Duration	// Maximum delay of the batch by the rate limit
+	PageSize		int				// Number of items returned by requests without the limit
+	MaxPageSize		int				// Maximum number of items returned by a single request
+	MaxBodySize		int64			// Maximum size of the request body
+	ShutdownTimeout	time.Duration	// Maximum time to wait for completion of active requests on stop
+	NoWebUI			bool			// Do not serve the web interface
+	LogFile			string			// Set location of log file
+	confPath		string			// Path to configuration file
+
+	// Auxiliary options
+	Debug		bool
+	NoLogTS		bool
+
+	// Configuration loaded from file
+	fConf		fileCfg
+	access		*access.Control
+	tlsCfg		*tls.Config
+}
+
+// Configuration file, has the same format as the configuration of the dfi utility
+type fileCfg struct {
+	DB		*dbms.DBConfig
+	Ingest	*ingestCfg						// the ingest service of agents is enabled if set
+	Users	map[string]*access.UserConfig	// users of the API and the gateway, authentication is enabled if set
+}
+
+type ingestCfg struct {
+	Tokens	map[string]string	// authentication tokens of agents by their hostnames
+}
+
+func (pc *progConfig) DBConfig() *dbms.DBConfig {
+	return pc.fConf.DB
+}
+
+// Access returns the access control of users, nil if authentication is disabled
+func (pc *progConfig) Access() *access.Control {
+	return pc.access
+}
+
+// TLSConfig returns the TLS configuration of the server, nil if TLS is disabled
+func (pc *progConfig) TLSConfig() *tls.Config {
+	return pc.tlsCfg
+}
+
+// IngestConfig returns the configuration of the ingest service, nil if it is not enabled
+func (pc *progConfig) IngestConfig() *rpc.IngestConfig {
+	if pc.fConf.Ingest == nil {
+		return nil
+	}
+
+	return &rpc.IngestConfig{
+		Tokens:		pc.fConf.Ingest.Tokens,
+		Rate:		pc.IngestRate,
+		Burst:		pc.IngestBurst,
+		MaxWait:	pc.IngestMaxWait,
+	}
+}
+
+func (pc *progConfig) clone() *progConfig {
+	rv := *pc
+
+	return &rv
+}
+
+func (pc *progConfig) prepare() error {
+	if pc.PageSize <= 0 {
+		return fmt.Errorf("invalid page size %d, must be positive", pc.PageSize)
+	}
+	if pc.MaxPageSize < pc.PageSize {
+		return fmt.Errorf("invalid maximum page size %d, must be at least the page size %d",
+			pc.MaxPageSize, pc.PageSize)
+	}
+	if pc.MaxBodySize <= 0 {
+		return fmt.Errorf("invalid maximum size of the request body %d, must be positive", pc.MaxBodySize)
+	}
+	if pc.ShutdownTimeout < 0 {
+		return fmt.Errorf("invalid shutdown timeout %v, must be non-negative", pc.ShutdownTimeout)
+	}
+	if pc.IngestRate < 0 {
+		return fmt.Errorf("invalid ingest rate %v, must be non-negative", pc.IngestRate)
+	}
+	if pc.IngestBurst < 0 {
+		return fmt.Errorf("invalid ingest burst %d, must be non-negative", pc.IngestBurst)
+	}
+	if pc.IngestMaxWait < 0 {
+		return fmt.Errorf("invalid maximum ingest delay %v, must be non-negative", pc.IngestMaxWait)
+	}
+
+	if err := pc.loadTLS(); err != nil {
+		return err
+	}
+
+	// Load configuration from file
+	if err := pc.loadConf(); err != nil {
+		return err
+	}
+
+	if len(pc.fConf.Users) != 0 {
+		ac, err := access.NewControl(pc.fConf.Users)
+		if err != nil {
+			return fmt.Errorf("invalid users configuration in %q: %w", pc.confPath, err)
+		}
+		pc.access = ac
+	}
+
+	return pc.prepareIngest()
+}
+
+func (pc *progConfig) loadTLS() error {
+	switch {
+	case pc.TLSCert == "" && pc.TLSKey == "":
+		// OK, TLS is disabled
+		return nil
+	case pc.TLSCert == "" || pc.TLSKey == "":
+		return fmt.Errorf("both certificate and key of the server must be set to enable TLS")
+	}
+
+	cert, err := tls.LoadX509KeyPair(pc.TLSCert, pc.TLSKey)
+	if err != nil {
+		return fmt.Errorf("cannot load certificate of the server: %w", err)
+	}
+
+	pc.tlsCfg = &tls.Config{
+		MinVersion:		tls.VersionTLS12,
+		Certificates:	[]tls.Certificate{cert},
+	}
+
+	// OK
+	return nil
+}
+
+func (pc *progConfig) prepareIngest() error {
+	ic := pc.fConf.Ingest
+	if ic == nil {
+		// Ingest service is not enabled
+		return nil
+	}
+
+	if pc.GRPCListen == "" {
+		return fmt.Errorf("the ingest service is configured by %q, but the gRPC gateway is not enabled", pc.confPath)
+	}
+	if len(ic.Tokens) == 0 {
+		return fmt.Errorf("no tokens of agents are configured for the ingest service by %q", pc.confPath)
+	}
+
+	// Hostnames of agents are always in lower case
+	tokens := make(map[string]string, len(ic.Tokens))
+	for host, token := range ic.Tokens {
+		if token == "" {
+			return fmt.Errorf("empty token of agent %q for the ingest service in %q", host, pc.confPath)
+		}
+		tokens[strings.ToLower(host)] = token
+	}
+	ic.Tokens = tokens
+
+	// OK
+	return nil
+}
+
+func (pc *progConfig) loadConf() error {
+	// Configuration contains credentials of the database - check correctness of ownership/permissions
+	if err := fschecks.PrivOwnership(pc.confPath); err != nil {
+		return fmt.Errorf("failed to check ownership/mode of program configuration: %w", err)
+	}
+
+	// Read configuration file
+	data, err := os.ReadFile(pc.confPath)
+	if err != nil {
+		return fmt.Errorf("cannot read program configuration: %w", err)
+	}
+
+	// Parse JSON, load it to configuration
+	if err = json.Unmarshal(data, &pc.fConf); err != nil {
+		return fmt.Errorf("cannot decode configuration %q: %w", pc.confPath, err)
+	}
+
+	if pc.fConf.DB == nil {
+		return fmt.Errorf("configuration %q has no database connection settings", pc.confPath)
+	}
+
+	// OK
+	return nil
+}
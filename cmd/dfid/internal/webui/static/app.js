@@ -0,0 +1,450 @@
+'use strict';
+
+// Prefix of the API endpoints
+const apiPrefix = '/api/v1';
+// Number of objects on the page of search results
+const pageSize = 100;
+// Maximum number of tags in the tag cloud
+const maxTags = 500;
+// Fields of objects shown in search results
+const resultFields = ['type', 'size', 'mtime'];
+
+// Current search request, used to switch pages
+let searchReq = null;
+
+//
+// Helpers
+//
+
+// el creates the element with attributes and children, strings are added as text nodes
+function el(tag, attrs, ...children) {
+	const e = document.createElement(tag);
+	for (const [k, v] of Object.entries(attrs || {})) {
+		if (k.startsWith('on')) {
+			e.addEventListener(k.substring(2), v);
+		} else {
+			e.setAttribute(k, v);
+		}
+	}
+	for (const c of children) {
+		if (c === null || c === undefined) {
+			continue;
+		}
+		e.append(typeof c === 'string' || typeof c === 'number' ? String(c) : c);
+	}
+	return e;
+}
+
+// api calls the endpoint and returns the decoded response, errors of the API are thrown
+async function api(method, path, body) {
+	const opts = {method: method, headers: {}};
+	if (body !== undefined) {
+		opts.headers['Content-Type'] = 'application/json';
+		opts.body = JSON.stringify(body);
+	}
+
+	const resp = await fetch(apiPrefix + path, opts);
+	const data = await resp.json().catch(() => null);
+	if (!resp.ok) {
+		throw new Error(data && data.error ? data.error.message : `${resp.status} ${resp.statusText}`);
+	}
+
+	for (const w of data.warnings || []) {
+		message('warning', w);
+	}
+
+	return data;
+}
+
+function message(kind, text) {
+	const box = document.getElementById('messages');
+	const m = el('div', {class: kind}, text);
+	box.append(m);
+	setTimeout(() => m.remove(), kind === 'error' ? 15000 : 5000);
+}
+
+function clearMessages() {
+	document.getElementById('messages').replaceChildren();
+}
+
+// run calls the async function and shows the thrown error
+async function run(fn) {
+	try {
+		await fn();
+	} catch (err) {
+		message('error', err.message);
+	}
+}
+
+// splitPhrases splits the line by spaces, quoted parts are kept as single phrases
+function splitPhrases(line) {
+	const phrases = [];
+	const re = /"([^"]*)"|'([^']*)'|(\S+)/g;
+	let m;
+	while ((m = re.exec(line)) !== null) {
+		const p = m[1] ?? m[2] ?? m[3];
+		if (p.trim() !== '') {
+			phrases.push(p);
+		}
+	}
+	return phrases;
+}
+
+function splitList(line) {
+	return line.split(/[\s,]+/).filter(s => s !== '');
+}
+
+function humanSize(size) {
+	if (typeof size !== 'number') {
+		return size ?? '';
+	}
+	const units = ['B', 'K', 'M', 'G', 'T', 'P', 'E'];
+	let i = 0;
+	let v = size;
+	while (v >= 1024 && i < units.length - 1) {
+		v /= 1024;
+		i++;
+	}
+	return i === 0 ? `${v} B` : `${v.toFixed(1)} ${units[i]}`;
+}
+
+function humanTime(ts) {
+	if (typeof ts !== 'number' || ts === 0) {
+		return ts ?? '';
+	}
+	return new Date(ts * 1000).toLocaleString();
+}
+
+//
+// Views
+//
+
+function showView(name) {
+	for (const b of document.querySelectorAll('header nav button')) {
+		b.classList.toggle('active', b.dataset.view === name);
+	}
+	for (const v of document.querySelectorAll('.view')) {
+		v.classList.toggle('hidden', v.id !== 'view-' + name);
+	}
+
+	if (name === 'tags') {
+		run(loadTags);
+	}
+}
+
+//
+// Search
+//
+
+function searchConditions(form) {
+	const f = new FormData(form);
+	const cond = {phrases: splitPhrases(f.get('phrases'))};
+
+	for (const name of ['mtime', 'size', 'types', 'checksums', 'hosts', 'ctime', 'users', 'uids', 'groups',
+		'gids', 'perm', 'inodes', 'nlinks', 'mimes', 'taken', 'cameras', 'pointsTo', 'pathGlob', 'nameRegex',
+		'query', 'content', 'aiiFilled']) {
+		const v = f.get(name).trim();
+		if (v !== '') {
+			cond[name] = v;
+		}
+	}
+
+	const excl = splitList(f.get('excludeHosts'));
+	if (excl.length !== 0) {
+		cond.excludeHosts = excl;
+	}
+
+	if (f.get('mode')) {
+		cond[f.get('mode')] = true;
+	}
+	for (const name of ['tags', 'descr', 'literal', 'or', 'not', 'dangling']) {
+		if (f.get(name)) {
+			cond[name] = true;
+		}
+	}
+
+	return cond;
+}
+
+async function search(offset) {
+	searchReq.offset = offset;
+	const resp = await api('POST', '/search', searchReq);
+	showSearchResults(resp);
+}
+
+// showSearchResults shows found objects grouped by hosts, the same as the dfi utility does with --hosts-groups
+function showSearchResults(resp) {
+	const box = document.getElementById('search-results');
+	box.replaceChildren();
+
+	box.append(el('div', {class: 'summary'}, resp.total === 0 ? 'No objects found' :
+		`Found ${resp.total} objects, shown ${resp.offset + 1}-${resp.offset + resp.objects.length}`));
+
+	let table = null;
+	let host = null;
+	for (const obj of resp.objects) {
+		if (obj.host !== host) {
+			host = obj.host;
+			table = el('table');
+			box.append(el('h3', {class: 'host'}, host + ':'), table);
+		}
+
+		const f = obj.fields || {};
+		table.append(el('tr', {class: 'obj', onclick: () => run(() => showDetails(obj.id))},
+			el('td', {}, f.type),
+			el('td', {class: 'num'}, humanSize(f.size)),
+			el('td', {class: 'num'}, humanTime(f.mtime)),
+			el('td', {class: 'path'}, obj.path),
+		));
+	}
+
+	box.append(pager(resp, search));
+}
+
+function pager(resp, load) {
+	const p = el('div', {class: 'pager'});
+	if (resp.total <= resp.limit) {
+		return p;
+	}
+
+	const prev = el('button', {type: 'button', onclick: () => run(() => load(Math.max(resp.offset - resp.limit, 0)))}, '< Previous');
+	const next = el('button', {type: 'button', onclick: () => run(() => load(resp.offset + resp.limit))}, 'Next >');
+	prev.disabled = resp.offset === 0;
+	next.disabled = resp.offset + resp.limit >= resp.total;
+
+	const pages = Math.ceil(resp.total / resp.limit);
+	p.append(prev, `Page ${Math.floor(resp.offset / resp.limit) + 1} of ${pages}`, next);
+	return p;
+}
+
+// searchTag searches objects with the tag
+function searchTag(tag) {
+	const form = document.getElementById('search-form');
+	form.reset();
+	form.elements.phrases.value = tag.includes(' ') ? `"${tag}"` : tag;
+	form.elements.mode.value = 'onlyTags';
+	showView('search');
+	form.requestSubmit();
+}
+
+//
+// Details of object, the same information as shown by the dfi utility in the show mode
+//
+
+// Names of fields shown in details
+const fieldNames = {
+	rpath: 'Real path', type: 'Type', size: 'Size', mtime: 'Modified', csum: 'Checksum',
+	uid: 'UID', gid: 'GID', user: 'User', group: 'Group', mode: 'Mode', ctime: 'Inode changed',
+	inode: 'Inode', dev: 'Device', nlink: 'Hard links', mime: 'Content type', taken: 'Taken',
+	camera: 'Camera', lat: 'Latitude', lon: 'Longitude', duration: 'Duration, ms', width: 'Width',
+	height: 'Height', archive: 'Archive', lstate: 'Link state',
+};
+
+function fieldValue(name, v) {
+	switch (name) {
+	case 'size':
+		return typeof v === 'number' ? `${humanSize(v)} (${v})` : v;
+	case 'mtime':
+	case 'ctime':
+	case 'taken':
+		return humanTime(v);
+	case 'mode':
+		return typeof v === 'number' ? '0' + v.toString(8) : v;
+	}
+	return String(v);
+}
+
+async function showDetails(id) {
+	const resp = await api('GET', '/objects?id=' + encodeURIComponent(id));
+	if (resp.objects.length === 0) {
+		throw new Error(`Object ${id} was not found`);
+	}
+
+	const obj = resp.objects[0];
+	const fields = obj.fields || {};
+
+	const table = el('table', {},
+		el('tr', {}, el('th', {}, 'Identifier'), el('td', {}, obj.id)),
+		el('tr', {}, el('th', {}, 'Host'), el('td', {}, obj.host)),
+	);
+	for (const [name, title] of Object.entries(fieldNames)) {
+		if (name in fields && fields[name] !== '' && fields[name] !== null) {
+			table.append(el('tr', {}, el('th', {}, title), el('td', {}, fieldValue(name, fields[name]))));
+		}
+	}
+
+	// Tags editing
+	const tags = el('div', {});
+	for (const tag of obj.tags || []) {
+		tags.append(el('span', {class: 'tag'},
+			el('a', {onclick: () => searchTag(tag)}, tag),
+			el('button', {type: 'button', title: 'Delete tag', onclick: () => run(() => modifyAII(id, 'delete', {tags: [tag]}))}, '×'),
+		));
+	}
+	const tagInput = el('input', {type: 'text', placeholder: 'tag1,tag2'});
+	// Tags may contain spaces, so they are separated only by commas
+	const tagsValue = () => tagInput.value.split(',').map(t => t.trim()).filter(t => t !== '');
+
+	// Description editing
+	const descr = el('textarea', {placeholder: 'Description'});
+	descr.value = obj.descr || '';
+
+	const body = document.getElementById('details-body');
+	body.replaceChildren(
+		el('h2', {}, obj.path),
+		table,
+		el('h3', {}, 'Tags'),
+		tags,
+		el('div', {class: 'row'},
+			tagInput,
+			el('button', {type: 'button', onclick: () => run(() => modifyAII(id, 'append', {tags: tagsValue()}))}, 'Add'),
+			el('button', {type: 'button', onclick: () => run(() => modifyAII(id, 'set', {tags: tagsValue()}))}, 'Replace'),
+		),
+		el('h3', {}, 'Description'),
+		descr,
+		el('div', {class: 'row'},
+			el('button', {type: 'button', onclick: () => run(() => modifyAII(id, 'set', {descr: descr.value}))}, 'Save'),
+			el('button', {type: 'button', onclick: () => run(() => modifyAII(id, 'delete', {descr: ''}))}, 'Delete'),
+		),
+		fields.type === 'reg' || fields.type === 'arc-member' ?
+			el('div', {class: 'row'},
+				el('button', {type: 'button', onclick: () => run(() => findDupes([id]))}, 'Find duplicates')) :
+			null,
+	);
+
+	document.getElementById('details').classList.remove('hidden');
+}
+
+async function modifyAII(id, op, args) {
+	if (args.tags && args.tags.length === 0) {
+		throw new Error('No tags entered');
+	}
+
+	await api('POST', '/aii', {op: op, ids: [id], ...args});
+	message('info', 'Saved');
+
+	// Reload the details to show the actual state
+	await showDetails(id);
+}
+
+//
+// Tags cloud, tags are sized by number of objects
+//
+
+async function loadTags() {
+	const resp = await api('GET', '/tags?limit=' + maxTags);
+	const cloud = document.getElementById('tag-cloud');
+	cloud.replaceChildren();
+
+	if (resp.tags.length === 0) {
+		cloud.append('No tags are used');
+		return;
+	}
+
+	const max = resp.tags[0].count;
+	const min = resp.tags[resp.tags.length - 1].count;
+
+	// Show tags alphabetically, the size depends on usage
+	const tags = resp.tags.slice().sort((a, b) => a.tag.localeCompare(b.tag));
+	for (const t of tags) {
+		const scale = max === min ? 1 : (t.count - min) / (max - min);
+		cloud.append(el('a', {
+			style: `font-size: ${(0.9 + scale * 1.6).toFixed(2)}em`,
+			title: `${t.count} objects`,
+			onclick: () => searchTag(t.tag),
+		}, t.tag), ' ');
+	}
+
+	if (resp.total > resp.tags.length) {
+		cloud.append(el('div', {class: 'hint'}, `Shown ${resp.tags.length} the most used tags of ${resp.total}`));
+	}
+}
+
+//
+// Duplicates
+//
+
+// Current duplicates request, used to switch pages
+let dupesReq = null;
+
+async function findDupes(ids) {
+	const form = document.getElementById('dupes-form');
+	if (ids) {
+		form.reset();
+		form.elements.ids.value = ids.join(' ');
+		showView('dupes');
+	}
+
+	const f = new FormData(form);
+	dupesReq = {ids: splitList(f.get('ids')), limit: pageSize};
+	if (f.get('hosts').trim() !== '') {
+		dupesReq.hosts = f.get('hosts').trim();
+	}
+	const excl = splitList(f.get('excludeHosts'));
+	if (excl.length !== 0) {
+		dupesReq.excludeHosts = excl;
+	}
+
+	await loadDupes(0);
+}
+
+async function loadDupes(offset) {
+	dupesReq.offset = offset;
+	const resp = await api('POST', '/dupes', dupesReq);
+
+	const box = document.getElementById('dupes-results');
+	box.replaceChildren(el('div', {class: 'summary'}, `Found ${resp.found} duplicates`));
+
+	for (const ref of resp.refs) {
+		const list = el('table');
+		for (const d of ref.dupes) {
+			list.append(el('tr', {class: 'obj', onclick: () => run(() => showDetails(d.id))},
+				el('td', {}, d.host),
+				el('td', {class: 'path'}, d.path,
+					d.linkOf ? el('span', {class: 'link-of'}, ` (hard link of ${d.linkOf})`) : null),
+			));
+		}
+
+		box.append(el('div', {class: 'dupes-ref'},
+			el('div', {class: 'obj', onclick: () => run(() => showDetails(ref.id))},
+				el('b', {}, `${ref.host}:${ref.path}`)),
+			el('div', {class: 'hint'},
+				`${ref.id}, size ${humanSize(ref.size)}, reclaimable ${humanSize(ref.reclaimable)}`),
+			ref.dupes.length === 0 ? 'No duplicates were found' : list,
+		));
+	}
+
+	box.append(pager(resp, loadDupes));
+}
+
+//
+// Initialization
+//
+
+document.addEventListener('DOMContentLoaded', () => {
+	for (const b of document.querySelectorAll('header nav button')) {
+		b.addEventListener('click', () => showView(b.dataset.view));
+	}
+
+	document.getElementById('search-form').addEventListener('submit', e => {
+		e.preventDefault();
+		clearMessages();
+		searchReq = {...searchConditions(e.target), fields: resultFields, limit: pageSize};
+		run(() => search(0));
+	});
+
+	document.getElementById('dupes-form').addEventListener('submit', e => {
+		e.preventDefault();
+		clearMessages();
+		run(() => findDupes());
+	});
+
+	document.getElementById('details-close').addEventListener('click', () => {
+		document.getElementById('details').classList.add('hidden');
+	});
+
+	run(async () => {
+		const v = await api('GET', '/version');
+		document.getElementById('version').textContent = `dfid ${v.version}, ${v.backend}`;
+	});
+});
@@ -0,0 +1,25 @@
+// Package webui contains the single-page web interface to the index that works over the API of dfid
+package webui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// Path where the interface is served
+const Path = "/ui/"
+
+//go:embed static
+var static embed.FS
+
+// Handler returns the handler of static files of the interface, it should be served on Path
+func Handler() http.Handler {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		// Impossible, the directory is embedded at build time
+		panic("(WebUI) cannot open embedded files: " + err.Error())
+	}
+
+	return http.StripPrefix(Path, http.FileServer(http.FS(files)))
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	stdLog "log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/r-che/dfi/cmd/dfid/internal/access"
+	"github.com/r-che/dfi/cmd/dfid/internal/api"
+	"github.com/r-che/dfi/cmd/dfid/internal/cfg"
+	"github.com/r-che/dfi/cmd/dfid/internal/webui"
+	"github.com/r-che/dfi/common/tools"
+	"github.com/r-che/dfi/dbi"
+	"github.com/r-che/dfi/dbi/remote/rpc"
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	ProgName		=	`dfid`
+	ProgNameLong	=	`Distributed File Indexer API server`
+	versMilestone	=	`-alpha.3`
+	ProgVers		=	`0.1.0` + versMilestone
+)
+
+func main() {
+	// Initiate configuration
+	cfg.Init(ProgName, ProgNameLong, ProgVers)
+	c := cfg.Config()
+
+	// Configure logger
+	var logFlags int
+	if !c.NoLogTS {
+		logFlags = stdLog.Ldate | stdLog.Ltime
+	}
+
+	// Open log
+	if err := log.Open(c.LogFile, ProgName, logFlags); err != nil {
+		panic(`Cannot open log file "` + c.LogFile + `": ` + err.Error())
+	}
+	log.SetDebug(c.Debug)
+
+	log.I("==== %s %s started ====", ProgNameLong, ProgVers)
+
+	// Init new database client
+	dbc, err := dbi.NewClient(c.DBConfig())
+	if err != nil {
+		log.F("Cannot initialize database client: %v", err)
+	}
+
+	srvCfg := &api.Config{
+		Listen:			c.Listen,
+		TLS:			c.TLSConfig(),
+		Version:		ProgVers,
+		PageSize:		c.PageSize,
+		MaxPageSize:	c.MaxPageSize,
+		MaxBodySize:	c.MaxBodySize,
+		Access:			c.Access(),
+	}
+	if srvCfg.Access == nil {
+		log.W("No users are configured, authentication is disabled")
+	} else if srvCfg.TLS == nil {
+		log.W("TLS is not configured, credentials of users are passed WITHOUT encryption")
+	}
+	if !c.NoWebUI {
+		srvCfg.UIPath = webui.Path
+		srvCfg.UI = webui.Handler()
+	}
+
+	srv := api.NewServer(srvCfg, dbc)
+
+	go func() {
+		if err := srv.Run(); err != nil {
+			log.F("%v", err)
+		}
+	}()
+
+	// Start the gRPC gateway if required
+	var gs *grpc.Server
+	if c.GRPCListen != "" {
+		services := []registrar{newIndexServer(dbc, c.Access())}
+
+		// Ingest service applies operations of agents by its own client controller
+		if ic := c.IngestConfig(); ic != nil {
+			ctlCfg := *c.DBConfig()
+			ctl, err := dbi.NewClientController(&ctlCfg)
+			if err != nil {
+				log.F("Cannot initialize database client controller for the ingest service: %v", err)
+			}
+			defer ctl.Stop()
+
+			services = append(services, rpc.NewIngestServer(ic, ctl))
+			log.I("(RPC) Ingest service enabled for %d agents", len(ic.Tokens))
+		}
+
+		if gs, err = runGateway(c.GRPCListen, c.TLSConfig(), services...); err != nil {
+			log.F("%v", err)
+		}
+	}
+
+	// Wait for external events (signals)
+	waitSignals(srv, gs, c.ShutdownTimeout)
+
+	log.I("%s %s finished normally", ProgNameLong, ProgVers)
+	log.Close()
+}
+
+// newIndexServer returns the server of the index service, calls of users are served
+// on their behalf if authentication is enabled
+func newIndexServer(dbc dbms.Client, ac *access.Control) *rpc.Server {
+	if ac == nil {
+		return rpc.NewServer(dbc)
+	}
+
+	return rpc.NewServerFunc(func(ctx context.Context) (dbms.Client, error) {
+		name, password, ok := rpc.BasicAuth(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "authentication required")
+		}
+
+		user, err := ac.Authenticate(name, password)
+		if err != nil {
+			log.W("(RPC) Authentication of user %q failed", name)
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		return user.Client(dbc), nil
+	})
+}
+
+// registrar is a service of the gateway
+type registrar interface {
+	Register(gs grpc.ServiceRegistrar)
+}
+
+// runGateway serves the gRPC gateway, calls are served without TLS if tlsCfg is nil
+func runGateway(listen string, tlsCfg *tls.Config, services ...registrar) (*grpc.Server, error) {
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, fmt.Errorf("(RPC) cannot listen for gateway clients: %w", err)
+	}
+
+	opts := []grpc.ServerOption{grpc.MaxRecvMsgSize(rpc.MaxMsgSize)}
+	if tlsCfg != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+
+	gs := grpc.NewServer(opts...)
+	for _, srv := range services {
+		srv.Register(gs)
+	}
+
+	go func() {
+		log.I("(RPC) Serving gRPC gateway on %s%s", listen, tools.Tern(tlsCfg != nil, " with TLS", ""))
+		if err := gs.Serve(ln); err != nil {
+			log.F("(RPC) gateway failed: %v", err)
+		}
+	}()
+
+	return gs, nil
+}
+
+func waitSignals(srv *api.Server, gs *grpc.Server, timeout time.Duration) {
+	chStop := make(chan os.Signal, 1)
+	signal.Notify(chStop, syscall.SIGTERM, syscall.SIGINT)
+
+	chReLogs := make(chan os.Signal, 1)
+	signal.Notify(chReLogs, syscall.SIGHUP)
+
+	for {
+		select {
+		case s := <-chStop:
+			log.W("Received %q - stopping server, waiting up to %v for active requests...", s, timeout)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := srv.Stop(ctx); err != nil {
+				log.E("Cannot stop server gracefully: %v", err)
+			}
+
+			if gs != nil {
+				stopGateway(ctx, gs)
+			}
+
+			return
+
+		case s := <-chReLogs:
+			log.I("Received %q - reopening log file...", s)
+			if err := log.Reopen(); err != nil {
+				log.E("Cannot reopen logs: %v", err)
+			} else {
+				log.I("Log file reopened")
+			}
+		}
+	}
+}
+
+// stopGateway waits for completion of active calls until the context is done, then stops the gateway
+func stopGateway(ctx context.Context, gs *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		gs.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.E("(RPC) Cannot stop gateway gracefully: %v", ctx.Err())
+		gs.Stop()
+	}
+}
@@ -0,0 +1,329 @@
+package query
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+)
+
+// Data corresponding to checksum of some object
+type csData struct {
+	id		string
+	size	int64
+}
+
+// Physical identity of the file, all hard links to the same file have the same identity
+type PhysID struct {
+	Host	string
+	Dev		int64
+	Inode	int64
+}
+// Valid reports whether the identity is known, e.g. archive members do not have inodes
+func (p PhysID) Valid() bool {
+	return p.Inode != 0
+}
+
+// Reference object to search duplicates for
+type DupeRef struct {
+	ObjKey	types.ObjKey
+	CSum	string
+	Size	int64
+	Phys	PhysID
+}
+
+// Duplicate of the reference object
+type DupeInfo struct {
+	ID		string
+	ObjKey	types.ObjKey
+	Phys	PhysID
+	LinkOf	string	// identifier of the object to which this one is a hard link, empty for separate files
+}
+func (di DupeInfo) String() string {
+	if di.LinkOf != "" {
+		return di.ID + ` ` + di.ObjKey.String() + ` (hard link of ` + di.LinkOf + `)`
+	}
+	return di.ID + ` ` + di.ObjKey.String()
+}
+
+// FindDupes searches for duplicates of objects with identifiers ids among objects matched by qa.
+// It returns the reference objects suitable to search duplicates for, indexed by identifiers, their
+// duplicates and the number of found duplicates excluding hard links. Problems with separate objects
+// are reported as warnings of rv
+func FindDupes(dbc dbms.Client, qa *dbms.QueryArgs, ids []string,
+		rv *types.CmdRV) (map[string]*DupeRef, map[string][]DupeInfo, int64, error) {
+	// Need to load information about reference objects which will use to found duplicates
+	refObjs, err := loadDupesRefs(dbc, ids, rv)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	// Check for we have any data to check
+	if len(refObjs) == 0 {
+		// No data, return now
+		return refObjs, map[string][]DupeInfo{}, 0, nil
+	}
+
+	// Map contains the correspondence between checksum<=>reference object
+	refsCSums := make(map[string]csData, len(refObjs))
+
+	for id, ref := range refObjs {
+		// Append checksums to query arguments
+		qa.AddChecksums(ref.CSum)
+		// Make checksum<=>csData pair
+		refsCSums[ref.CSum] = csData{id: id, size: ref.Size}
+	}
+
+	// Clear search phrases due to them contain identifiers that should not be used in search
+	qa.SetSearchPhrases(nil)
+
+	// Run query to get duplicates. Append checksum field to return fields set,
+	// to have ability to match found duplicates with provided references.
+	// Inode and device fields are required to detect hard links
+	qr, err := dbc.Query(qa, []string{dbms.FieldID, dbms.FieldChecksum, dbms.FieldSize, dbms.FieldInode, dbms.FieldDevice})
+	if err != nil {
+		rv.AddErr("cannot execute search query to find duplicates: %v", err)
+	}
+
+	// Create checksum-based object map - checksum is key, DupeInfo is value
+	cdm := dupesMapByCSum(refsCSums, qr, rv)
+
+	// Create resulted map with referred object<=>duplicates list pairs
+	objDupes, nd := dupesMapByID(refObjs, cdm)
+
+	return refObjs, objDupes, nd, nil
+}
+
+func loadDupesRefs(dbc dbms.Client, ids []string, rv *types.CmdRV) (map[string]*DupeRef, error) {
+	// Create query arguments with identifiers
+	qa := dbms.NewQueryArgs().AddIds(ids...)
+
+	// Run query to get information about the objects
+	qr, err := dbc.Query(qa, []string{dbms.FieldID, dbms.FieldType, dbms.FieldChecksum, dbms.FieldSize,
+		dbms.FieldInode, dbms.FieldDevice})
+	if err != nil {
+		return nil, err
+	}
+
+	// Make map of requested IDs mapped to corresponding checksum
+	objRefs := make(map[string]*DupeRef, len(qa.Ids))
+	for _, id := range qa.Ids {
+		objRefs[id] = nil
+	}
+
+	// Assign checksums
+	for objKey, fields := range qr {
+		// Extract identifier
+		id, ok := extrFieldStr(objKey, fields, dbms.FieldID, rv)
+		if !ok {
+			continue
+		}
+		// Check that this object really was requested
+		if _, ok := objRefs[id]; !ok {
+			// Skip strange object
+			rv.AddWarn("Skip object %s (%s) - this ID was not requested! Skip it", id, objKey)
+			continue
+		}
+
+		// Extract type field
+		oType, ok := extrFieldStr(objKey, fields, dbms.FieldType, rv)
+		if !ok {
+			continue
+		}
+		if oType != types.ObjRegular && oType != types.ObjArcMember {
+			// Skip incorrect object
+			rv.AddWarn("Object %s (%s) is not a regular file or an archive member (%s) - cannot search duplicates for it",
+				id, objKey, oType)
+			// Remove it from requested identifiers map
+			delete(objRefs, id)
+
+			continue
+		}
+
+		// Extract checksum
+		csum := extrCSum(objKey, fields, rv)
+		if csum == "" {
+			continue
+		}
+
+		// Extract size
+		size, ok := extrFieldInt64(objKey, fields, dbms.FieldSize, rv)
+		if !ok {
+			continue
+		}
+
+		// Assign collected data to map
+		objRefs[id] = &DupeRef{
+			ObjKey:	objKey,
+			CSum:	csum,
+			Size:	size,
+			Phys:	extrPhysID(objKey, fields),
+		}
+	}
+
+	// Check for idenfifiers without checksum value
+	removeInvalids(objRefs, rv)
+
+	return objRefs, nil
+}
+
+func removeInvalids(objRefs map[string]*DupeRef, rv *types.CmdRV) {
+	// Check for idenfifiers without checksum value
+	nxIds := make([]string, 0, len(objRefs))
+	for id, v := range objRefs {
+		if v == nil {
+			nxIds = append(nxIds, id)
+			// Remove invalid identifier
+			delete(objRefs, id)
+		}
+	}
+	if len(nxIds) != 0 {
+		rv.AddWarn("Requested object(s) do not exist or invalid: %s", strings.Join(nxIds, ", "))
+	}
+}
+
+func extrCSum(objKey types.ObjKey, fields dbms.QRItem, rv *types.CmdRV) string {
+	// Extract checksum
+	csum, ok := extrFieldStr(objKey, fields, dbms.FieldChecksum, rv)
+	if !ok {
+		return ""
+	}
+
+	// Check the value of checksum
+	switch csum {
+	case "":
+		rv.AddWarn("Skip object %s with empty checksum field", objKey)
+		return ""
+	case types.CsTooLarge:
+		rv.AddWarn("Skip object %s - checksum is not set because the file is too large", objKey)
+		return ""
+	case types.CsErrorStub:
+		rv.AddWarn("Skip object %s - checksum is not set because an error occurred during calculation", objKey)
+		return ""
+	}
+
+	return csum
+}
+
+func dupesMapByCSum(refsCSums map[string]csData, qr dbms.QueryResults, rv *types.CmdRV) map[string][]DupeInfo {
+	dm := make(map[string][]DupeInfo, len(qr))
+
+	for objKey, fields := range qr {
+		// Extract identifier
+		id, ok := extrFieldStr(objKey, fields, dbms.FieldID, rv)
+		if !ok {
+			continue
+		}
+
+		// Extract checksum
+		csum, ok := extrFieldStr(objKey, fields, dbms.FieldChecksum, rv)
+		if !ok {
+			continue
+		}
+
+		// Extract size
+		size, ok := extrFieldInt64(objKey, fields, dbms.FieldSize, rv)
+		if !ok {
+			continue
+		}
+
+		// Check for extracted size differ than size of the referenced object
+		if refsCSums[csum].size != size {
+			// Such strange situation, it looks like
+			// we found checksum collision, add warning and skip it
+			rv.AddWarn("An object %s (%s) was found that has the same checksum as reference object %s, " +
+						"but size of this object (%d) is different that the referenced object (%d) - " +
+						"looks like a hash function collision! So, we skip this object",
+						id, objKey, refsCSums[csum].id, size, refsCSums[csum].size)
+			// Skip it
+			continue
+		}
+
+		// Push identifier to duplicates map
+		dm[csum] = append(dm[csum], DupeInfo{ID: id, ObjKey: objKey, Phys: extrPhysID(objKey, fields)})
+	}
+
+	return dm
+}
+
+// extrPhysID extracts physical identity of the object. The identity is unknown (zero) if
+// the object has no inode, e.g. archive members or objects indexed by old versions of the agent
+func extrPhysID(objKey types.ObjKey, fields dbms.QRItem) PhysID {
+	// Missing fields are not an error, so warnings are dropped
+	rv := types.NewCmdRV()
+
+	inode, okI := extrFieldInt64(objKey, fields, dbms.FieldInode, rv)
+	dev, okD := extrFieldInt64(objKey, fields, dbms.FieldDevice, rv)
+	if !okI || !okD {
+		return PhysID{}
+	}
+
+	return PhysID{Host: objKey.Host, Dev: dev, Inode: inode}
+}
+
+// dupesMapByID creates resulted map with referred object<=>duplicates list pairs. Duplicates are sorted
+// by object keys, hard links are collapsed: only the first object of each physical file (starting from
+// the referred object) is counted as a duplicate, the rest are labeled as hard links to it
+func dupesMapByID(refObjs map[string]*DupeRef, dm map[string][]DupeInfo) (map[string][]DupeInfo, int64) {
+	objDupes := make(map[string][]DupeInfo)
+	// Dupes counter
+	var nd int64
+
+	for id, ref := range refObjs {
+		// Copy duplicates with checksum of the ref, because labels of hard links depend on the referred object
+		dupes := make([]DupeInfo, 0, len(dm[ref.CSum]))
+		for _, di := range dm[ref.CSum] {
+			// Skip self
+			if id != di.ID {
+				dupes = append(dupes, di)
+			}
+		}
+		if len(dupes) == 0 {
+			continue
+		}
+
+		// Sort duplicates by object keys to make labels of hard links stable
+		sort.Slice(dupes, func(i, j int) bool {
+			return dupes[i].ObjKey.Less(dupes[j].ObjKey)
+		})
+
+		// Identifiers of the first objects of physical files
+		firsts := map[PhysID]string{}
+		if ref.Phys.Valid() {
+			firsts[ref.Phys] = id
+		}
+
+		for i := range dupes {
+			di := &dupes[i]
+			if di.Phys.Valid() {
+				if first, ok := firsts[di.Phys]; ok {
+					// Hard link to already counted file
+					di.LinkOf = first
+					continue
+				}
+				firsts[di.Phys] = di.ID
+			}
+
+			// Increment dupes counter
+			nd++
+		}
+
+		objDupes[id] = dupes
+	}
+
+	return objDupes, nd
+}
+
+// Reclaimable returns the space that can be freed by removing duplicates of the referred object,
+// hard links and objects without physical identity (e.g. archive members) are not counted
+func Reclaimable(ref *DupeRef, dupes []DupeInfo) int64 {
+	var n int64
+	for _, di := range dupes {
+		if di.LinkOf == "" && di.Phys.Valid() {
+			n++
+		}
+	}
+
+	return n * ref.Size
+}
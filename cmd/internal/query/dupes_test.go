@@ -0,0 +1,132 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/r-che/dfi/types"
+)
+
+// testDupe returns the duplicate with the identifier id of the file with the inode on the device of the host,
+// zero inode means that the physical identity is unknown
+func testDupe(id, host, path string, dev, inode int64) DupeInfo {
+	di := DupeInfo{ID: id, ObjKey: types.ObjKey{Host: host, Path: path}}
+	if inode != 0 {
+		di.Phys = PhysID{Host: host, Dev: dev, Inode: inode}
+	}
+
+	return di
+}
+
+// testRef returns the reference object made from the duplicate info
+func testRef(di DupeInfo, csum string, size int64) *DupeRef {
+	return &DupeRef{ObjKey: di.ObjKey, CSum: csum, Size: size, Phys: di.Phys}
+}
+
+func TestDupesMapByID(t *testing.T) {
+	ref := testDupe("r", "nas1", "/data/ref", 1, 10)
+	ref2 := testDupe("r2", "nas1", "/data/ref2", 1, 20)
+	arc := testDupe("a", "nas1", "/data/arc.zip/file", 0, 0)
+
+	tests := []struct {
+		refs		[]DupeInfo
+		dupes		[]DupeInfo	// objects found by checksum of references, including references
+		want		map[string][]string	// reference identifier => duplicates with labels of hard links
+		wantND		int64
+		wantSpace	map[string]int64	// reference identifier => reclaimable space
+	} {
+		// No duplicates except the reference itself
+		{
+			refs:		[]DupeInfo{ref},
+			dupes:		[]DupeInfo{ref},
+			want:		map[string][]string{},
+		},
+		// Hard links are collapsed by host, device and inode, duplicates are sorted by object keys
+		{
+			refs:		[]DupeInfo{ref},
+			dupes:		[]DupeInfo{
+				testDupe("d4", "nas2", "/data/d4", 1, 11),	// the same device and inode on other host
+				testDupe("d2", "nas1", "/data/d2", 1, 11),
+				ref,
+				testDupe("d1", "nas1", "/data/d1", 1, 11),
+				testDupe("d3", "nas1", "/data/d3", 1, 10),	// hard link of the reference
+				testDupe("d5", "nas1", "/data/d5", 2, 11),	// the same inode on other device
+				testDupe("m1", "nas1", "/data/z.zip/m1", 0, 0),	// archive member
+			},
+			want:		map[string][]string{"r": {
+				"d1 nas1:/data/d1",
+				"d2 nas1:/data/d2 (hard link of d1)",
+				"d3 nas1:/data/d3 (hard link of r)",
+				"d5 nas1:/data/d5",
+				"m1 nas1:/data/z.zip/m1",
+				"d4 nas2:/data/d4",
+			}},
+			wantND:		4,
+			// Archive members are counted as duplicates but cannot be removed to free space
+			wantSpace:	map[string]int64{"r": 3 * 100},
+		},
+		// References are duplicates of each other, labels depend on the reference
+		{
+			refs:		[]DupeInfo{ref, ref2},
+			dupes:		[]DupeInfo{
+				ref, ref2,
+				testDupe("d1", "nas1", "/data/d1", 1, 10),
+				testDupe("d2", "nas1", "/data/d2", 1, 20),
+			},
+			want:		map[string][]string{
+				"r": {
+					"d1 nas1:/data/d1 (hard link of r)",
+					"d2 nas1:/data/d2",
+					"r2 nas1:/data/ref2 (hard link of d2)",
+				},
+				"r2": {
+					"d1 nas1:/data/d1",
+					"d2 nas1:/data/d2 (hard link of r2)",
+					"r nas1:/data/ref (hard link of d1)",
+				},
+			},
+			wantND:		2,
+			wantSpace:	map[string]int64{"r": 100, "r2": 100},
+		},
+		// The reference without physical identity is not a target of hard links
+		{
+			refs:		[]DupeInfo{arc},
+			dupes:		[]DupeInfo{
+				arc,
+				testDupe("d2", "nas1", "/data/d2", 1, 11),
+				testDupe("d1", "nas1", "/data/d1", 1, 11),
+			},
+			want:		map[string][]string{"a": {
+				"d1 nas1:/data/d1",
+				"d2 nas1:/data/d2 (hard link of d1)",
+			}},
+			wantND:		1,
+			wantSpace:	map[string]int64{"a": 100},
+		},
+	}
+
+	for i, test := range tests {
+		refObjs := make(map[string]*DupeRef, len(test.refs))
+		for _, di := range test.refs {
+			refObjs[di.ID] = testRef(di, "c1", 100)
+		}
+
+		objDupes, nd := dupesMapByID(refObjs, map[string][]DupeInfo{"c1": test.dupes})
+
+		got := make(map[string][]string, len(objDupes))
+		for id, dupes := range objDupes {
+			for _, di := range dupes {
+				got[id] = append(got[id], di.String())
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) || nd != test.wantND {
+			t.Errorf("[%d] dupesMapByID - want %q (%d dupes), got %q (%d dupes)", i, test.want, test.wantND, got, nd)
+		}
+
+		for id, want := range test.wantSpace {
+			if got := Reclaimable(refObjs[id], objDupes[id]); got != want {
+				t.Errorf("[%d] reclaimable space of %s - want %d, got %d", i, id, want, got)
+			}
+		}
+	}
+}
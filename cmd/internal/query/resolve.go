@@ -0,0 +1,112 @@
+// Package query contains search operations shared by the command line and network interfaces of the index
+package query
+
+import (
+	"fmt"
+
+	"github.com/r-che/dfi/types/dbms"
+)
+
+// ResolveIds searches for identifiers of objects matched by additional information items
+// and by content of documents and updates qa by them. It returns false if no objects can be found
+func ResolveIds(dbc dbms.Client, qa *dbms.QueryArgs) (bool, error) {
+	if qa.IsAIIFields() {
+		// Search for identifiers of objects that have filled requested AII fields
+		ids, err := dbc.GetAIIIds(qa.AIIFields)
+		if err != nil {
+			return false, fmt.Errorf("cannot search for objects with filled fields %v: %w", qa.AIIFields, err)
+		}
+
+		qa.AddIds(ids...)
+	}
+
+	if qa.UseAII() {
+		// Search by AII fields
+		ids, err := dbc.QueryAIIIds(qa)
+		if err != nil {
+			return false, fmt.Errorf("cannot search by additional information objects fields: %w", err)
+		}
+
+		// Check for only AII should be used in search
+		if qa.OnlyAII() {
+			// If no identifiers by AII were found
+			if len(ids) == 0 {
+				// Than nothing to search
+				return false, nil
+			}
+
+			// Clear search phrases to avoid using them in the next search
+			qa.SetSearchPhrases(nil)
+		}
+
+		qa.AddIds(ids...)
+	}
+
+	if qa.IsContent() {
+		// Search by content of documents
+		ids, err := dbc.QueryContentIds(qa)
+		if err != nil {
+			return false, fmt.Errorf("cannot search by content of documents: %w", err)
+		}
+
+		// If no documents with matched content were found
+		if len(ids) == 0 {
+			// Than nothing to search
+			return false, nil
+		}
+
+		// Restrict the search results by found documents
+		qa.SetContentIds(ids...)
+	}
+
+	if qa.IsExpr() {
+		// Replace conditions of the query expression that cannot be checked on objects by identifiers
+		if err := qa.Expr.Walk(func(c *dbms.Cond) (*dbms.Expr, error) {
+			return resolveCond(dbc, c)
+		}); err != nil {
+			return false, err
+		}
+	}
+
+	// OK
+	return true, nil
+}
+
+// resolveCond returns the condition on identifiers of objects matched by the condition c
+// on additional information items or on content of documents, or nil for other conditions
+func resolveCond(dbc dbms.Client, c *dbms.Cond) (*dbms.Expr, error) {
+	var ids []string
+
+	switch c.Field {
+	case dbms.AIIFieldTags, dbms.AIIFieldDescr:
+		qa := &dbms.QueryArgs{SP: c.Strs}
+		qa.UseTags, qa.UseDescr = c.Field == dbms.AIIFieldTags, c.Field == dbms.AIIFieldDescr
+		aiiIds, err := dbc.QueryAIIIds(qa)
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve condition %s by additional information objects fields: %w", c, err)
+		}
+		ids = aiiIds
+
+	case dbms.ExprFieldContent:
+		// Any of phrases should be found
+		for _, phrase := range c.Strs {
+			contentIds, err := dbc.QueryContentIds(&dbms.QueryArgs{Content: phrase})
+			if err != nil {
+				return nil, fmt.Errorf("cannot resolve condition %s by content of documents: %w", c, err)
+			}
+			ids = append(ids, contentIds...)
+		}
+
+	default:
+		// Nothing to resolve
+		return nil, nil
+	}
+
+	if len(ids) == 0 {
+		// No objects match the condition
+		return dbms.NewConstExpr(false), nil
+	}
+
+	// OK
+	return dbms.NewCondExpr(dbms.FieldID, dbms.CmpEq, nil, ids), nil
+}
@@ -0,0 +1,181 @@
+//go:build linux
+/*
+Package secrets loads private configurations - credentials, tokens and so on - from different sources.
+
+The source is set by the string in one of the forms:
+
+  - env:NAME        - JSON from the environment variable NAME, the variable is removed from the
+                      environment after loading to not pass it to child processes
+  - cmd:COMMAND     - JSON printed to the standard output by the credential helper COMMAND,
+                      the command is run by /bin/sh, so it can contain arguments
+  - systemd:NAME    - JSON from the credential NAME passed to the service by the LoadCredential=
+                      (or SetCredential=) directive of the systemd unit
+  - file:PATH, PATH - JSON from the file PATH, the file must belong to the user running
+                      the application and must not be accessible by other users
+
+The JSON must be an object.
+*/
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/r-che/dfi/common/fschecks"
+)
+
+// Prefixes of sources
+const (
+	SrcEnv		=	"env:"
+	SrcCmd		=	"cmd:"
+	SrcSystemd	=	"systemd:"
+	SrcFile		=	"file:"
+)
+
+// Environment variable with the directory of credentials set by systemd
+const credsDirEnv = "CREDENTIALS_DIRECTORY"
+
+// Maximum run time of the credential helper, it may ask the user, e.g. for the passphrase of the key
+var helperTimeout = time.Minute
+
+// Shell to run credential helpers
+var helperShell = "/bin/sh"
+
+// Load loads the private configuration from the source
+func Load(src string) (map[string]any, error) {
+	var data []byte
+	var err error
+
+	switch {
+	case strings.HasPrefix(src, SrcEnv):
+		data, err = loadEnv(strings.TrimPrefix(src, SrcEnv))
+	case strings.HasPrefix(src, SrcCmd):
+		data, err = loadCmd(strings.TrimPrefix(src, SrcCmd))
+	case strings.HasPrefix(src, SrcSystemd):
+		data, err = loadSystemd(strings.TrimPrefix(src, SrcSystemd))
+	default:
+		data, err = loadFile(strings.TrimPrefix(src, SrcFile))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return decode(src, data)
+}
+
+func loadEnv(name string) ([]byte, error) {
+	if name == "" {
+		return nil, fmt.Errorf("empty name of the environment variable")
+	}
+
+	v, ok := os.LookupEnv(name)
+	if !ok || v == "" {
+		return nil, fmt.Errorf("environment variable %q is not set", name)
+	}
+
+	// Child processes - content extractors, hooks, etc... do not need the secret
+	if err := os.Unsetenv(name); err != nil {
+		return nil, fmt.Errorf("cannot remove environment variable %q: %w", name, err)
+	}
+
+	// OK
+	return []byte(v), nil
+}
+
+func loadCmd(command string) ([]byte, error) {
+	if strings.TrimSpace(command) == "" {
+		return nil, fmt.Errorf("empty command of the credential helper")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), helperTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, helperShell, "-c", command)	//nolint:gosec	// the command is set by the user
+	// The helper may interact with the user by the terminal
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("cannot run credential helper %q: %w", command, err)
+	}
+
+	// Children of the killed helper can keep its output open, so waiting is not continued after the timeout
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				err = fmt.Errorf("%w: %s", err, msg)
+			}
+			return nil, fmt.Errorf("credential helper %q failed: %w", command, err)
+		}
+	case <-ctx.Done():
+		return nil, fmt.Errorf("credential helper %q timed out after %v", command, helperTimeout)
+	}
+
+	// OK
+	return stdout.Bytes(), nil
+}
+
+func loadSystemd(name string) ([]byte, error) {
+	if name == "" || strings.ContainsRune(name, filepath.Separator) {
+		return nil, fmt.Errorf("invalid name of the systemd credential %q", name)
+	}
+
+	dir := os.Getenv(credsDirEnv)
+	if dir == "" {
+		return nil, fmt.Errorf("cannot load systemd credential %q: %s is not set, is LoadCredential=%s:... set by the unit?",
+			name, credsDirEnv, name)
+	}
+
+	// Ownership is not checked - systemd makes the directory of credentials accessible only to the service
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read systemd credential: %w", err)
+	}
+
+	// OK
+	return data, nil
+}
+
+func loadFile(path string) ([]byte, error) {
+	// Check correctness of ownership/permissions of the private file
+	if err := fschecks.PrivOwnership(path); err != nil {
+		return nil, fmt.Errorf("failed to check ownership/mode of the private file: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read private file: %w", err)
+	}
+
+	// OK
+	return data, nil
+}
+
+func decode(src string, data []byte) (map[string]any, error) {
+	var pcf map[string]any
+	if err := json.Unmarshal(data, &pcf); err != nil {
+		// The data is not printed, because it may contain secrets
+		return nil, fmt.Errorf("cannot decode private configuration from %q: %w", src, err)
+	}
+	if pcf == nil {
+		return nil, fmt.Errorf("private configuration from %q is empty", src)
+	}
+
+	// OK
+	return pcf, nil
+}
@@ -0,0 +1,133 @@
+//go:build linux
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testJSON = `{"user": "dfi", "password": "secret"}`
+
+var testPcf = map[string]any{"user": "dfi", "password": "secret"}
+
+func TestLoadEnv(t *testing.T) {
+	const name = "DFI_TEST_PRIV_CFG"
+	t.Setenv(name, testJSON)
+
+	pcf, err := Load(SrcEnv + name)
+	if err != nil {
+		t.Fatalf("Load returned unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(pcf, testPcf) {
+		t.Errorf("Load returned %v, want %v", pcf, testPcf)
+	}
+
+	// The variable must be removed after loading
+	if v, ok := os.LookupEnv(name); ok {
+		t.Errorf("environment variable %q was not removed, value: %q", name, v)
+	}
+
+	// Second loading must fail
+	if _, err := Load(SrcEnv + name); err == nil {
+		t.Errorf("Load of the removed variable returned no error")
+	}
+}
+
+func TestLoadCmd(t *testing.T) {
+	pcf, err := Load(SrcCmd + "echo '" + testJSON + "'")
+	if err != nil {
+		t.Fatalf("Load returned unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(pcf, testPcf) {
+		t.Errorf("Load returned %v, want %v", pcf, testPcf)
+	}
+}
+
+func TestLoadCmdFail(t *testing.T) {
+	const msg = "no such credential"
+
+	_, err := Load(SrcCmd + "echo " + msg + " >&2; exit 1")
+	switch {
+	case err == nil:
+		t.Errorf("Load of failed helper returned no error")
+	case !strings.Contains(err.Error(), msg):
+		t.Errorf("error %q does not contain the output of the helper %q", err, msg)
+	}
+}
+
+func TestLoadCmdTimeout(t *testing.T) {
+	defer func(v time.Duration) { helperTimeout = v }(helperTimeout)
+	helperTimeout = 100 * time.Millisecond
+
+	_, err := Load(SrcCmd + "sleep 10")
+	switch {
+	case err == nil:
+		t.Errorf("Load of hung helper returned no error")
+	case !strings.Contains(err.Error(), "timed out"):
+		t.Errorf("want timeout error, got - %v", err)
+	}
+}
+
+func TestLoadSystemd(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "db"), []byte(testJSON), 0o400); err != nil {
+		t.Fatalf("cannot create test credential: %v", err)
+	}
+	t.Setenv(credsDirEnv, dir)
+
+	pcf, err := Load(SrcSystemd + "db")
+	if err != nil {
+		t.Fatalf("Load returned unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(pcf, testPcf) {
+		t.Errorf("Load returned %v, want %v", pcf, testPcf)
+	}
+
+	// Names must not contain paths
+	if _, err := Load(SrcSystemd + "../db"); err == nil {
+		t.Errorf("Load of credential with path in the name returned no error")
+	}
+
+	// Credentials directory must be set
+	t.Setenv(credsDirEnv, "")
+	if _, err := Load(SrcSystemd + "db"); err == nil {
+		t.Errorf("Load without credentials directory returned no error")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "priv.json")
+	if err := os.WriteFile(file, []byte(testJSON), 0o600); err != nil {
+		t.Fatalf("cannot create test file: %v", err)
+	}
+
+	for _, src := range []string{file, SrcFile + file} {
+		pcf, err := Load(src)
+		if err != nil {
+			t.Fatalf("Load(%q) returned unexpected error: %v", src, err)
+		}
+		if !reflect.DeepEqual(pcf, testPcf) {
+			t.Errorf("Load(%q) returned %v, want %v", src, pcf, testPcf)
+		}
+	}
+
+	// Public readable file must be refused
+	if err := os.Chmod(file, 0o644); err != nil {
+		t.Fatalf("cannot change mode of test file: %v", err)
+	}
+	if _, err := Load(file); err == nil {
+		t.Errorf("Load of public readable file returned no error")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for _, data := range []string{``, `null`, `["user"]`, `{"user":`} {
+		if pcf, err := Load(SrcCmd + "printf '%s' '" + data + "'"); err == nil {
+			t.Errorf("Load of %q returned no error, result: %v", data, pcf)
+		}
+	}
+}
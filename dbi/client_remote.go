@@ -0,0 +1,17 @@
+//go:build dbi_remote
+package dbi
+
+import (
+	"github.com/r-che/dfi/dbi/remote"
+	"github.com/r-che/dfi/types/dbms"
+)
+
+func NewClientController(dbCfg *dbms.DBConfig) (dbms.ClientController, error) {
+	// Initiate client of the ingest service of the gateway
+	return remote.NewController(dbCfg)
+}
+
+func NewClient(dbCfg *dbms.DBConfig) (dbms.Client, error) {
+	// Initiate client of the gateway
+	return remote.NewClient(dbCfg)
+}
@@ -12,3 +12,12 @@ func MakeID(host string, fso *types.FSObject) string {
 	// Use found path as value to generate the identifier
 	return fmt.Sprintf("%x", sha1.Sum([]byte(host + ":" + fso.FPath)))
 }
+
+// ChunkEnd returns the end index of the chunk that starts at start, limited by the total length
+func ChunkEnd(start, chunkSize, total int) int {
+	if end := start + chunkSize; end < total {
+		return end
+	}
+
+	return total
+}
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/r-che/dfi/types/dbms"
+)
+
+// DiskUsage aggregates disk usage of objects found by query arguments qa on the client side,
+// used when the database cannot aggregate the found objects by itself
+func DiskUsage(cli dbms.Client, qa *dbms.QueryArgs, dua *dbms.DUArgs) (dbms.DUResults, error) {
+	qr, err := cli.Query(qa, []string{
+		dbms.FieldID, dbms.FieldType, dbms.FieldSize, dbms.FieldDevice, dbms.FieldInode,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot query objects: %w", err)
+	}
+
+	// Tags of found objects, required only to group by tags
+	aiis := dbms.QueryResultsAII{}
+	if dua.GroupBy == dbms.DUByTag && len(qr) != 0 {
+		ids := make([]string, 0, len(qr))
+		for _, fields := range qr {
+			if id, ok := fields[dbms.FieldID].(string); ok {
+				ids = append(ids, id)
+			}
+		}
+
+		if aiis, err = cli.GetAIIs(ids, []string{dbms.AIIFieldTags}); err != nil {
+			return nil, fmt.Errorf("cannot get tags of found objects: %w", err)
+		}
+	}
+
+	da := dbms.NewDUAggregator(dua)
+
+	for objKey, fields := range qr {
+		obj := &dbms.DUObject{
+			Host:	objKey.Host,
+			FPath:	objKey.Path,
+		}
+		obj.ID, _ = fields[dbms.FieldID].(string)
+		obj.Type, _ = fields[dbms.FieldType].(string)
+
+		// Objects without size (e.g. indexed by old agents) are counted with zero size
+		obj.Size, _ = qrInt64(fields[dbms.FieldSize])
+		obj.Device, _ = qrInt64(fields[dbms.FieldDevice])
+		obj.Inode, _ = qrInt64(fields[dbms.FieldInode])
+
+		if aii, ok := aiis[obj.ID]; ok {
+			obj.Tags = aii.Tags
+		}
+
+		da.Add(obj)
+	}
+
+	return da.Results(), nil
+}
+
+// qrInt64 converts the value of the numeric field returned by query, the type of the value is DBMS dependent
+func qrInt64(val any) (int64, error) {
+	switch v := val.(type) {
+	case int64:
+		return v, nil
+	case int32:
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("unexpected type %T", val)
+	}
+}
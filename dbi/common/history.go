@@ -0,0 +1,49 @@
+package common
+
+import (
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+)
+
+// ObjState is the state of the object recorded to the history of changes
+type ObjState struct {
+	Size		int64
+	Checksum	string
+}
+
+// HistRecord makes the history record of the change of the object from the state before to the state after
+// committed at ts, nil before means that the object was not indexed, nil after - that the object was deleted.
+// It returns nil if both states are nil, e.g. the object was deleted before it was stored to the database,
+// or if the states are equal, e.g. the object was reindexed without changes
+func HistRecord(ts int64, host, fpath string, before, after *ObjState) *dbms.HistRecord {
+	hr := &dbms.HistRecord{
+		Time:	ts,
+		ID:		MakeID(host, &types.FSObject{FPath: fpath}),
+		Host:	host,
+		FPath:	fpath,
+	}
+
+	switch {
+	case before == nil && after == nil:
+		// Nothing was changed
+		return nil
+	case before != nil && after != nil && *before == *after:
+		// The object was updated without changes of the size and the checksum
+		return nil
+	case before == nil:
+		hr.Change = dbms.HistCreated
+	case after == nil:
+		hr.Change = dbms.HistDeleted
+	default:
+		hr.Change = dbms.HistModified
+	}
+
+	if before != nil {
+		hr.OldSize, hr.OldChecksum = before.Size, before.Checksum
+	}
+	if after != nil {
+		hr.NewSize, hr.NewChecksum = after.Size, after.Checksum
+	}
+
+	return hr
+}
@@ -0,0 +1,38 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/r-che/dfi/types/dbms"
+)
+
+func TestHistRecord(t *testing.T) {
+	tests := []struct {
+		before, after	*ObjState
+		want			string
+		none			bool
+	} {
+		{ before: nil, after: nil, none: true },
+		{ before: nil, after: &ObjState{Size: 1, Checksum: "c1"}, want: dbms.HistCreated },
+		{ before: &ObjState{Size: 1, Checksum: "c1"}, after: nil, want: dbms.HistDeleted },
+		{ before: &ObjState{Size: 1, Checksum: "c1"}, after: &ObjState{Size: 2, Checksum: "c1"}, want: dbms.HistModified },
+		{ before: &ObjState{Size: 1, Checksum: "c1"}, after: &ObjState{Size: 1, Checksum: "c2"}, want: dbms.HistModified },
+		// Reindexed objects without changes are not recorded
+		{ before: &ObjState{Size: 1, Checksum: "c1"}, after: &ObjState{Size: 1, Checksum: "c1"}, none: true },
+		{ before: &ObjState{Size: 1}, after: &ObjState{Size: 1}, none: true },
+	}
+
+	for i, test := range tests {
+		hr := HistRecord(1, "host", "/a", test.before, test.after)
+		if test.none {
+			if hr != nil {
+				t.Errorf("[%d] %v => %v - want no record, got %v", i, test.before, test.after, hr.Change)
+			}
+			continue
+		}
+
+		if hr == nil || hr.Change != test.want {
+			t.Errorf("[%d] %v => %v - want %v, got %v", i, test.before, test.after, test.want, hr)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package common
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/r-che/log"
+)
+
+// Field of the private configuration that contains TLS settings of the connection to the DBMS or the gateway
+const PrivFieldTLS = "TLS"
+
+// TLS settings of the connection, for example:
+//
+//	"TLS": {
+//	    "CAFile":     "/etc/dfi/ca.pem",
+//	    "CertFile":   "/etc/dfi/client.pem",
+//	    "KeyFile":    "/etc/dfi/client.key",
+//	    "ServerName": "db.example.com"
+//	}
+type TLSSettings struct {
+	CAFile				string	// PEM bundle of certificates to verify the server, system roots are used if empty
+	CertFile			string	// PEM client certificate to authenticate by the server, requires KeyFile
+	KeyFile				string	// PEM private key of the client certificate
+	ServerName			string	// name to verify the certificate of the server, the host of the address if empty
+	InsecureSkipVerify	bool	// do not verify the certificate of the server, only for testing
+}
+
+// TLSConfig returns the TLS configuration loaded from the private configuration
+// of the database or nil if TLS is not configured
+func TLSConfig(pcf map[string]any) (*tls.Config, error) {
+	v, ok := pcf[PrivFieldTLS]
+	if !ok {
+		// OK, TLS is not used
+		return nil, nil
+	}
+
+	// Re-encode loaded value to decode it to the structure with checking of fields
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode %q field of private configuration: %w", PrivFieldTLS, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var ts TLSSettings
+	if err := dec.Decode(&ts); err != nil {
+		return nil, fmt.Errorf("invalid %q field of private configuration: %w", PrivFieldTLS, err)
+	}
+
+	return ts.Config()
+}
+
+// Config returns the TLS configuration made by the settings
+func (ts *TLSSettings) Config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:			tls.VersionTLS12,
+		ServerName:			ts.ServerName,
+		InsecureSkipVerify:	ts.InsecureSkipVerify,	//nolint:gosec	// explicitly enabled by the configuration
+	}
+
+	if ts.InsecureSkipVerify {
+		log.W("Verification of the certificate of the server is DISABLED, use it only for testing")
+	}
+
+	if ts.CAFile != "" {
+		pem, err := os.ReadFile(ts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read CA bundle: %w", err)
+		}
+
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in CA bundle %q", ts.CAFile)
+		}
+	}
+
+	switch {
+	case ts.CertFile != "" && ts.KeyFile != "":
+		cert, err := tls.LoadX509KeyPair(ts.CertFile, ts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	case ts.CertFile != "" || ts.KeyFile != "":
+		return nil, fmt.Errorf("both client certificate and key files must be set")
+	}
+
+	// OK
+	return cfg, nil
+}
@@ -0,0 +1,162 @@
+package common
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/r-che/log"
+)
+
+func TestMain(m *testing.M) {
+	// Disabled verification is reported to the log
+	if err := log.Open(log.DefaultLog, "common-test", log.NoFlags); err != nil {
+		panic("cannot open log: " + err.Error())
+	}
+
+	os.Exit(m.Run())
+}
+
+// writeTestCert writes the self-signed certificate and its key to the directory,
+// the certificate can be used as the CA bundle too, paths to the files are returned
+func writeTestCert(t *testing.T, dir string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate key: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:			big.NewInt(1),
+		Subject:				pkix.Name{CommonName: "dfi-test"},
+		DNSNames:				[]string{"db.test"},
+		NotBefore:				time.Now().Add(-time.Hour),
+		NotAfter:				time.Now().Add(time.Hour),
+		KeyUsage:				x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:			[]x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:					true,
+		BasicConstraintsValid:	true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("cannot create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("cannot encode key: %v", err)
+	}
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	for file, block := range map[string]*pem.Block{
+		certFile:	{Type: "CERTIFICATE", Bytes: der},
+		keyFile:	{Type: "PRIVATE KEY", Bytes: keyDER},
+	} {
+		if err := os.WriteFile(file, pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatalf("cannot write %s: %v", file, err)
+		}
+	}
+
+	return certFile, keyFile
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+
+	invalidFile := filepath.Join(dir, "invalid.pem")
+	if err := os.WriteFile(invalidFile, []byte("not a certificate\n"), 0o600); err != nil {
+		t.Fatalf("cannot write %s: %v", invalidFile, err)
+	}
+	missingFile := filepath.Join(dir, "missing.pem")
+
+	tests := []struct {
+		tls			any		// value of the TLS field
+		wantErr		bool
+		serverName	string
+		rootCAs		bool	// the CA bundle is loaded
+		certs		int		// number of client certificates
+		insecure	bool
+	} {
+		{ tls: map[string]any{} },
+		{ tls: map[string]any{"ServerName": "db.example.com"}, serverName: "db.example.com" },
+		{ tls: map[string]any{"CAFile": certFile, "ServerName": "db.test"}, serverName: "db.test", rootCAs: true },
+		{ tls: map[string]any{"CertFile": certFile, "KeyFile": keyFile}, certs: 1 },
+		{ tls: map[string]any{"CAFile": certFile, "CertFile": certFile, "KeyFile": keyFile}, rootCAs: true, certs: 1 },
+		{ tls: map[string]any{"InsecureSkipVerify": true}, insecure: true },
+		// Client certificate without key and vice versa
+		{ tls: map[string]any{"CertFile": certFile}, wantErr: true },
+		{ tls: map[string]any{"KeyFile": keyFile}, wantErr: true },
+		// Invalid or mismatched client certificate and key
+		{ tls: map[string]any{"CertFile": invalidFile, "KeyFile": keyFile}, wantErr: true },
+		{ tls: map[string]any{"CertFile": certFile, "KeyFile": certFile}, wantErr: true },
+		{ tls: map[string]any{"CertFile": missingFile, "KeyFile": keyFile}, wantErr: true },
+		// Unreadable or invalid CA bundle
+		{ tls: map[string]any{"CAFile": missingFile}, wantErr: true },
+		{ tls: map[string]any{"CAFile": dir}, wantErr: true },
+		{ tls: map[string]any{"CAFile": invalidFile}, wantErr: true },
+		{ tls: map[string]any{"CAFile": keyFile}, wantErr: true },
+		// Unknown fields and invalid values
+		{ tls: map[string]any{"CAPath": certFile}, wantErr: true },
+		{ tls: map[string]any{"ServerName": 1}, wantErr: true },
+		{ tls: "db.example.com", wantErr: true },
+		{ tls: []any{certFile}, wantErr: true },
+	}
+
+	for i, test := range tests {
+		cfg, err := TLSConfig(map[string]any{"user": "u", PrivFieldTLS: test.tls})
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("[%d] TLS settings %v - want error, got nil", i, test.tls)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[%d] TLS settings %v - returned unexpected error: %v", i, test.tls, err)
+			continue
+		}
+		if cfg.MinVersion != tls.VersionTLS12 {
+			t.Errorf("[%d] want minimal version %x, got %x", i, tls.VersionTLS12, cfg.MinVersion)
+		}
+		if cfg.ServerName != test.serverName {
+			t.Errorf("[%d] want server name %q, got %q", i, test.serverName, cfg.ServerName)
+		}
+		if (cfg.RootCAs != nil) != test.rootCAs {
+			t.Errorf("[%d] want loaded CA bundle %t, got %t", i, test.rootCAs, cfg.RootCAs != nil)
+		}
+		if len(cfg.Certificates) != test.certs {
+			t.Errorf("[%d] want %d client certificates, got %d", i, test.certs, len(cfg.Certificates))
+		}
+		if cfg.InsecureSkipVerify != test.insecure {
+			t.Errorf("[%d] want disabled verification %t, got %t", i, test.insecure, cfg.InsecureSkipVerify)
+		}
+
+		// The loaded bundle verifies certificates issued by the CA
+		if cfg.RootCAs != nil {
+			pemData, _ := os.ReadFile(certFile)
+			block, _ := pem.Decode(pemData)
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				t.Fatalf("cannot parse certificate: %v", err)
+			}
+			if _, err := cert.Verify(x509.VerifyOptions{Roots: cfg.RootCAs, DNSName: "db.test"}); err != nil {
+				t.Errorf("[%d] certificate is not verified by the loaded CA bundle: %v", i, err)
+			}
+		}
+	}
+
+	// TLS is not configured
+	if cfg, err := TLSConfig(map[string]any{"user": "u"}); cfg != nil || err != nil {
+		t.Errorf("configuration without TLS - want nil, nil, got %v, %v", cfg, err)
+	}
+}
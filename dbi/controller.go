@@ -3,8 +3,14 @@ package dbi
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/r-che/log"
 	"github.com/r-che/dfi/types"
@@ -22,6 +28,12 @@ type DBController struct {
 	wg			*sync.WaitGroup
 	cancel		context.CancelFunc
 	termLongVal int		// should be incremented when need to terminate long-term operation
+
+	seq			int64	// sequence number of the last committed batch
+	stateFile	string	// file to record the sequence number of the batch being committed
+
+	hostInfo	*dbms.HostInfo	// information about the client host, nil if it is not registered
+	hbPeriod	time.Duration	// period between heartbeats
 }
 
 func NewController(dbCfg *dbms.DBConfig) (*DBController, error) {
@@ -31,6 +43,12 @@ func NewController(dbCfg *dbms.DBConfig) (*DBController, error) {
 		return nil, err
 	}
 
+	// Load the sequence number of the last committed batch to continue numbering
+	seq, err := dbCli.LastBatchSeq()
+	if err != nil {
+		return nil, err
+	}
+
 	// Context to stop database controller
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -40,9 +58,48 @@ func NewController(dbCfg *dbms.DBConfig) (*DBController, error) {
 		dbCli:		dbCli,
 		wg:			&sync.WaitGroup{},
 		cancel:		cancel,
+		seq:		seq,
 	}, nil
 }
 
+// CheckLastBatch sets the state file used to record the sequence number of each batch before
+// committing it. It returns false if the batch recorded by the previous run did not land to the database
+func (dbc *DBController) CheckLastBatch(stateFile string) (bool, error) {
+	dbc.stateFile = stateFile
+
+	data, err := os.ReadFile(stateFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// First run with this state file, nothing to check
+			return true, nil
+		}
+
+		return false, fmt.Errorf("(DBC:CheckLastBatch) cannot read state file: %w", err)
+	}
+
+	pending, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("(DBC:CheckLastBatch) invalid content of state file %q: %w", stateFile, err)
+	}
+
+	log.D("(DBC:CheckLastBatch) Last batch sequence number - recorded: %d, in database: %d", pending, dbc.seq)
+
+	// Continue numbering from the greatest value to keep the sequence monotonic
+	landed := pending <= dbc.seq
+	if !landed {
+		dbc.seq = pending
+	}
+
+	return landed, nil
+}
+
+// SetHostInfo sets information about the client host that is registered in the
+// database when the controller starts and updated by heartbeats with the period
+func (dbc *DBController) SetHostInfo(hi *dbms.HostInfo, period time.Duration) {
+	dbc.hostInfo = hi
+	dbc.hbPeriod = period
+}
+
 // TermLong terminates long-term operations on database
 func (dbc *DBController) TermLong() {
 	dbc.termLongVal++
@@ -62,6 +119,20 @@ func (dbc *DBController) Stop() {
 func (dbc *DBController) Run() {
 	log.I("(DBC) Database controller started ")
 
+	// Channel of heartbeats, never ready if the host is not registered
+	var hbChan <-chan time.Time
+	var hbTicker *time.Ticker
+	if dbc.hostInfo != nil {
+		dbc.hostInfo.Started = time.Now().Unix()
+		dbc.hostInfo.HBPeriod = int64(dbc.hbPeriod / time.Second)
+
+		// Register the host
+		dbc.heartbeat()
+
+		hbTicker = time.NewTicker(dbc.hbPeriod)
+		hbChan = hbTicker.C
+	}
+
 	// Increment WaitGroup BEFORE start separate goroutine
 	dbc.wg.Add(1)
 
@@ -85,8 +156,16 @@ func (dbc *DBController) Run() {
 					}
 
 					log.I("(DBC) Completed %d operations", changed)
+				// Time to send heartbeat
+				case <-hbChan:
+					dbc.heartbeat()
 				// Wait for finish signal from context
 				case <-dbc.ctx.Done():
+					// Stop heartbeats
+					if hbTicker != nil {
+						hbTicker.Stop()
+					}
+
 					// Stop DB client
 					dbc.dbCli.Stop()
 
@@ -163,10 +242,29 @@ func (dbc *DBController) update(dbOps []*dbms.DBOperation) (*types.CmdRV, int64)
 }
 
 func (dbc *DBController) commit(delExpected int64) (int64, error) {
+	// Sequence number of this batch
+	dbc.seq++
+
+	// Record the batch as pending before committing it
+	if err := dbc.writeState(dbc.seq); err != nil {
+		log.E("(DBC:commit) %v", err)
+	}
+
     // Commit operations
-    updated, deleted, err := dbc.dbCli.Commit()
+    updated, deleted, err := dbc.dbCli.Commit(dbc.seq)
 	if err != nil {
-		return updated + deleted, err
+		// Check for failures of the separate operations
+		var opErrs dbms.OpErrors
+		if !errors.As(err, &opErrs) {
+			// The whole batch failed
+			return updated + deleted, err
+		}
+
+		// The rest of the batch was applied, only report failed operations
+		for _, oe := range opErrs {
+			log.E("(DBC:commit) Operation failed: %v", oe)
+		}
+		log.W("(DBC:commit) %d operations of the batch failed", len(opErrs))
 	}
 
 	// Check for not frequent, but probably situation
@@ -179,6 +277,41 @@ func (dbc *DBController) commit(delExpected int64) (int64, error) {
 
 	log.I("(DBC:commit) %d records updated, %d records deleted", updated, deleted)
 
+	if dbc.hostInfo != nil {
+		// Reported by the next heartbeat
+		dbc.hostInfo.LastFlush = time.Now().Unix()
+	}
+
 	// Return number of changed objects and no error
 	return updated + deleted, nil
 }
+
+func (dbc *DBController) heartbeat() {
+	dbc.hostInfo.Heartbeat = time.Now().Unix()
+
+	if err := dbc.dbCli.UpdateHost(dbc.hostInfo); err != nil {
+		log.E("(DBC:heartbeat) Cannot update information about the host: %v", err)
+		return
+	}
+
+	log.D("(DBC:heartbeat) Information about the host updated, %d objects indexed", dbc.hostInfo.Objects)
+}
+
+func (dbc *DBController) writeState(seq int64) error {
+	if dbc.stateFile == "" {
+		// State file is not used
+		return nil
+	}
+
+	// Write to a temporary file and rename it to keep the state file consistent on crash
+	tmp := filepath.Join(filepath.Dir(dbc.stateFile), "." + filepath.Base(dbc.stateFile) + ".tmp")
+	if err := os.WriteFile(tmp, []byte(strconv.FormatInt(seq, 10) + "\n"), 0o644); err != nil {
+		return fmt.Errorf("cannot write state file: %w", err)
+	}
+	if err := os.Rename(tmp, dbc.stateFile); err != nil {
+		return fmt.Errorf("cannot update state file: %w", err)
+	}
+
+	// OK
+	return nil
+}
@@ -0,0 +1,190 @@
+package dbi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+)
+
+func TestMain(m *testing.M) {
+	// Controller writes messages to the log
+	if err := log.Open(log.DefaultLog, "dbi-test", log.NoFlags); err != nil {
+		panic("cannot open log: " + err.Error())
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestCheckLastBatch(t *testing.T) {
+	tests := []struct {
+		content		*string	// content of the state file, nil if the file does not exist
+		seq			int64	// sequence number of the last batch in the database
+		wantLanded	bool
+		wantSeq		int64
+		wantErr		bool
+	} {
+		// First run
+		{ content: nil, seq: 5, wantLanded: true, wantSeq: 5 },
+		// The recorded batch landed to the database
+		{ content: strPtr("5\n"), seq: 5, wantLanded: true, wantSeq: 5 },
+		{ content: strPtr("3"), seq: 5, wantLanded: true, wantSeq: 5 },
+		{ content: strPtr(" 0 \n"), seq: 0, wantLanded: true, wantSeq: 0 },
+		// The recorded batch was lost, numbering is continued from it
+		{ content: strPtr("7\n"), seq: 5, wantLanded: false, wantSeq: 7 },
+		{ content: strPtr("1"), seq: 0, wantLanded: false, wantSeq: 1 },
+		// Invalid contents
+		{ content: strPtr(""), seq: 5, wantErr: true },
+		{ content: strPtr("seven\n"), seq: 5, wantErr: true },
+		{ content: strPtr("7 8\n"), seq: 5, wantErr: true },
+		{ content: strPtr("99999999999999999999"), seq: 5, wantErr: true },
+	}
+
+	for i, test := range tests {
+		stateFile := filepath.Join(t.TempDir(), "state")
+		if test.content != nil {
+			if err := os.WriteFile(stateFile, []byte(*test.content), 0o644); err != nil {
+				t.Fatalf("[%d] cannot write state file: %v", i, err)
+			}
+		}
+
+		dbc := &DBController{seq: test.seq}
+		landed, err := dbc.CheckLastBatch(stateFile)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("[%d] state file %q - want error, got landed %t", i, *test.content, landed)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[%d] CheckLastBatch returned unexpected error: %v", i, err)
+			continue
+		}
+		if landed != test.wantLanded || dbc.seq != test.wantSeq {
+			t.Errorf("[%d] state file %v, seq %d - want landed %t (seq %d), got %t (seq %d)",
+				i, test.content, test.seq, test.wantLanded, test.wantSeq, landed, dbc.seq)
+		}
+		if dbc.stateFile != stateFile {
+			t.Errorf("[%d] want state file %q, got %q", i, stateFile, dbc.stateFile)
+		}
+	}
+
+	// The state file cannot be read
+	if _, err := (&DBController{}).CheckLastBatch(t.TempDir()); err == nil {
+		t.Errorf("want error of reading the directory as the state file, got nil")
+	}
+}
+
+func TestWriteState(t *testing.T) {
+	dir := t.TempDir()
+	stateFile := filepath.Join(dir, "state")
+
+	// State file is not used
+	if err := (&DBController{}).writeState(1); err != nil {
+		t.Errorf("writeState without state file returned unexpected error: %v", err)
+	}
+
+	dbc := &DBController{stateFile: stateFile}
+	for _, seq := range []int64{1, 2, 10} {
+		if err := dbc.writeState(seq); err != nil {
+			t.Fatalf("writeState(%d) returned unexpected error: %v", seq, err)
+		}
+
+		data, err := os.ReadFile(stateFile)
+		if err != nil {
+			t.Fatalf("cannot read state file: %v", err)
+		}
+		if want := []byte(fmt.Sprintf("%d\n", seq)); string(data) != string(want) {
+			t.Errorf("writeState(%d) - want content %q, got %q", seq, want, data)
+		}
+
+		// Recorded value is read back by the next run
+		next := &DBController{seq: seq - 1}
+		if landed, err := next.CheckLastBatch(stateFile); err != nil || landed || next.seq != seq {
+			t.Errorf("CheckLastBatch after writeState(%d) - want not landed (seq %d), got %t (seq %d), error: %v",
+				seq, seq, landed, next.seq, err)
+		}
+	}
+
+	// The temporary file is renamed to the state file
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("cannot read directory of the state file: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "state" {
+		names := []string{}
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("want only the state file in the directory, got %v", names)
+	}
+
+	// The state file cannot be replaced by the temporary file
+	if err := os.Remove(stateFile); err != nil {
+		t.Fatalf("cannot remove state file: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(stateFile, "busy"), 0o755); err != nil {
+		t.Fatalf("cannot create directory: %v", err)
+	}
+	if err := dbc.writeState(11); err == nil {
+		t.Errorf("writeState over the non-empty directory - want error, got nil")
+	}
+
+	// The directory of the state file does not exist
+	dbc = &DBController{stateFile: filepath.Join(dir, "missing", "state")}
+	if err := dbc.writeState(1); err == nil {
+		t.Errorf("writeState to the missing directory - want error, got nil")
+	}
+}
+
+// testCli records the content of the state file on commits, methods
+// that are not used by tests are not implemented by the embedded nil controller
+type testCli struct {
+	dbms.ClientController
+
+	stateFile	string
+	committed	[]string	// content of the state file at the moment of each commit
+	seqs		[]int64
+}
+
+func (tc *testCli) Commit(seq int64) (int64, int64, error) {
+	data, _ := os.ReadFile(tc.stateFile)
+	tc.committed = append(tc.committed, string(data))
+	tc.seqs = append(tc.seqs, seq)
+
+	return 0, 0, nil
+}
+
+func TestCommitState(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state")
+	cli := &testCli{stateFile: stateFile}
+
+	dbc := &DBController{dbCli: cli, seq: 4}
+	if _, err := dbc.CheckLastBatch(stateFile); err != nil {
+		t.Fatalf("CheckLastBatch returned unexpected error: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := dbc.commit(0); err != nil {
+			t.Fatalf("commit returned unexpected error: %v", err)
+		}
+	}
+
+	// The batch is recorded as pending before it is committed
+	if want := []string{"5\n", "6\n"}; !reflect.DeepEqual(cli.committed, want) {
+		t.Errorf("want state file contents at commits %q, got %q", want, cli.committed)
+	}
+	if want := []int64{5, 6}; !reflect.DeepEqual(cli.seqs, want) {
+		t.Errorf("want committed sequence numbers %v, got %v", want, cli.seqs)
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
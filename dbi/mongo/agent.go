@@ -1,6 +1,8 @@
 package mongo
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"regexp"
@@ -13,6 +15,7 @@ import (
 	"github.com/r-che/log"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -29,21 +32,6 @@ const (
 
 func (mc *Client) UpdateObj(fso *types.FSObject) error {
 	// Push object to update queue
-
-	if mc.ReadOnly {
-		log.W("(MongoCli:UpdateObj) R/O mode IS SET, Insert/Update of %q collection" +
-				" will NOT be performed => %s\n", MongoObjsColl, mc.Cfg.CliHost + ":" + fso.FPath)
-		// Increase the update counter and return no errors
-		mc.updated++
-		// OK
-		return nil
-	}
-	log.D("(MongoCli:UpdateObj) Insert/Update of collection %q => %s\n", MongoObjsColl, mc.Cfg.CliHost + ":" + fso.FPath)
-
-	// Get collection handler
-	coll := mc.c.Database(mc.Cfg.ID).Collection(MongoObjsColl)
-
-	// Update/Insert object
 	id := common.MakeID(mc.Cfg.CliHost, fso)
 	fields := bson.D{
 		// Using mongo-specific identifier field name instead of standard dbms.FieldID
@@ -57,6 +45,30 @@ func (mc *Client) UpdateObj(fso *types.FSObject) error {
 		{dbms.FieldSize,		fso.Size},
 		{dbms.FieldMTime,		fso.MTime},
 		{dbms.FieldChecksum,	fso.Checksum},
+		{dbms.FieldUID,			fso.UID},
+		{dbms.FieldGID,			fso.GID},
+		{dbms.FieldUser,		fso.User},
+		{dbms.FieldGroup,		fso.Group},
+		{dbms.FieldMode,		fso.Mode},
+		{dbms.FieldCTime,		fso.CTime},
+		{dbms.FieldInode,		fso.Inode},
+		{dbms.FieldDevice,		fso.Device},
+		{dbms.FieldNLink,		fso.NLink},
+		{dbms.FieldMIME,		fso.MIME},
+		{dbms.FieldTaken,		fso.Taken},
+		{dbms.FieldCamera,		fso.Camera},
+		{dbms.FieldDuration,	fso.Duration},
+		{dbms.FieldWidth,		fso.Width},
+		{dbms.FieldHeight,		fso.Height},
+		{dbms.FieldArchive,		fso.Archive},
+		{dbms.FieldLinkState,	fso.LinkState},
+	}
+
+	// Coordinates are null if location is unknown
+	if fso.GPS != nil {
+		fields = append(fields, bson.E{dbms.FieldLat, fso.GPS.Lat}, bson.E{dbms.FieldLon, fso.GPS.Lon})
+	} else {
+		fields = append(fields, bson.E{dbms.FieldLat, nil}, bson.E{dbms.FieldLon, nil})
 	}
 
 	// Validate fields
@@ -79,31 +91,49 @@ func (mc *Client) UpdateObj(fso *types.FSObject) error {
 		fields = append(fields, bson.E{MongoFieldTName, strings.ReplaceAll(fso.Name, "_", " ")})
 	}
 
-	//
-	// Update item
-	//
-	res, err := coll.UpdateOne(mc.Ctx,
-		bson.D{{MongoFieldID, id}},			// Update exactly this ID
-		bson.D{{`$set`, fields}},
-		options.Update().SetUpsert(true),	// do insert if no object with this ID was found
-	)
-	if err != nil {
-		return fmt.Errorf("(MongoCli:UpdateObj) updateOne (id: %s, found path: %q) on %s.%s failed: %w",
-				id, fso.FPath, coll.Database().Name(), coll.Name(), err)
-	}
-
-	if res.MatchedCount == 0 && res.UpsertedCount == 0 {
-		return fmt.Errorf("(MongoCli:UpdateObj) updateOne (id: %s) on %s.%s returned success," +
-			" but no documents were changed", id, coll.Database().Name(), coll.Name())
+	if mc.ReadOnly {
+		log.W("(MongoCli:UpdateObj) R/O mode IS SET, Insert/Update of %q collection" +
+				" will NOT be performed => %s\n", MongoObjsColl, mc.Cfg.CliHost + ":" + fso.FPath)
+	} else {
+		log.D("(MongoCli:UpdateObj) Insert/Update (pending) of collection %q => %s\n",
+			MongoObjsColl, mc.Cfg.CliHost + ":" + fso.FPath)
 	}
 
-	// Increase the update counter and return no errors
-	mc.updated++
+	// XXX Append update model regardless of R/O mode because it will be skipped in the Commit() operation
+	mc.toUpdate = append(mc.toUpdate, mongo.NewUpdateOneModel().
+		SetFilter(bson.D{{MongoFieldID, id}}).	// Update exactly this ID
+		SetUpdate(bson.D{{`$set`, fields}}).
+		SetUpsert(true))						// do insert if no object with this ID was found
+	mc.toUpdateIds = append(mc.toUpdateIds, id)
+	mc.toContent = append(mc.toContent, contentModel(id, fso.Content))
+	mc.toUpdateHist = append(mc.toUpdateHist, &histItem{
+		fpath:	fso.FPath,
+		state:	&common.ObjState{Size: fso.Size, Checksum: fso.Checksum},
+	})
 
 	// OK
 	return nil
 }
 
+// contentModel returns the operation to update the content of the object with identifier id
+func contentModel(id string, content *types.ObjContent) mongo.WriteModel {
+	if content == nil {
+		// Remove content that may remain from the previous version of the object
+		return mongo.NewDeleteOneModel().SetFilter(bson.D{{MongoFieldID, id}})
+	}
+
+	return mongo.NewReplaceOneModel().
+		SetFilter(bson.D{{MongoFieldID, id}}).
+		SetReplacement(bson.D{
+			{MongoFieldID,				id},
+			{dbms.ContentFieldText,		content.Text},
+			{dbms.ContentFieldTitle,	content.Title},
+			{dbms.ContentFieldAuthor,	content.Author},
+			{dbms.ContentFieldPages,	content.Pages},
+		}).
+		SetUpsert(true)
+}
+
 func (mc *Client) DeleteObj(fso *types.FSObject) error {
 	mongoID := common.MakeID(mc.Cfg.CliHost, fso)
 
@@ -132,6 +162,13 @@ func (mc *Client) DeleteFPathPref(fso *types.FSObject) (int64, error) {
 	// Collect identifiers that need to be deleted
 	delIds := []string{}
 
+	// Objects queued for update in the same batch must not be deleted,
+	// it allows to replace a set of objects by prefix, e.g. members of an archive
+	queued := make(map[string]bool, len(mc.toUpdateIds))
+	for _, id := range mc.toUpdateIds {
+		queued[id] = true
+	}
+
 	err := mc.loadFieldByFilter(MongoFieldID, filter,
 	// Append found value of identifiers to the list of identifiers that need to be deleted
 	func(value any) error {
@@ -142,6 +179,11 @@ func (mc *Client) DeleteFPathPref(fso *types.FSObject) (int64, error) {
 				" value: %#v", MongoFieldID, value, value)
 		}
 
+		if queued[id] {
+			// Skip object updated in this batch
+			return nil
+		}
+
 		delIds = append(delIds, id)
 
 		// OK
@@ -162,65 +204,333 @@ func (mc *Client) DeleteFPathPref(fso *types.FSObject) (int64, error) {
 	return int64(len(delIds)), nil
 }
 
-func (mc *Client) Commit() (int64, int64, error) {
+func (mc *Client) Commit(seq int64) (int64, int64, error) {
 	// Reset state on return
 	defer func() {
 		// Reset counters
 		mc.updated = 0
 		mc.deleted = 0
 		// Reset lists of queued data
+		mc.toUpdate = nil
+		mc.toUpdateIds = nil
+		mc.toContent = nil
+		mc.toUpdateHist = nil
 		mc.toDelete = nil
 	}()
 
-	// Create filter by identifiers
-	filter := bson.D{{
-		MongoFieldID, bson.D{{ `$in`, mc.toDelete }},
-	}}
+	if mc.ReadOnly {
+		return mc.commitDryRun(seq)
+	}
 
-	// Check for keys to delete
-	if nDel := len(mc.toDelete); nDel != 0 {
-		log.D("(MongoCli:Commit) Need to delete %d keys", nDel)
+	// Check for something to commit, the batch sequence number is stored even if the batch is empty
+	if len(mc.toUpdate) == 0 && len(mc.toDelete) == 0 && seq == 0 {
+		return 0, 0, nil
+	}
 
-		deleted, err := mc.performDelete(filter)
-		if err != nil {
-			return 0, deleted, fmt.Errorf("(MongoCli:Commit) delete failed: %w", err)
+	log.D("(MongoCli:Commit) Need to update %d objects, delete %d objects, batch sequence number: %d",
+		len(mc.toUpdate), len(mc.toDelete), seq)
+
+	// States of objects before the changes are required to record them to the history
+	var before map[string]*histItem
+	if mc.Cfg.History {
+		var err error
+		if before, err = mc.loadObjStates(); err != nil {
+			log.E("(MongoCli:Commit) Changes will not be recorded to the history: %v", err)
+		}
+	}
+
+	// Errors of the separate operations that do not prevent to apply the rest of the batch
+	var opErrs dbms.OpErrors
+
+	for {
+		updated, deleted, err := mc.commitTx(seq)
+		if err == nil {
+			mc.updated, mc.deleted = updated, deleted
+			break
+		}
+
+		// Check for failures of the separate update operations
+		var ue *updateErrors
+		if opErrs != nil || !errors.As(err, &ue) {
+			// Unexpected error or the batch failed again after excluding failed operations
+			return 0, 0, fmt.Errorf("(MongoCli:Commit) commit failed: %w", err)
 		}
 
-		mc.deleted += deleted
+		// A failed write aborts the whole transaction, so need to exclude
+		// the failed operations and commit the rest of the batch again
+		opErrs = ue.opErrs
+		mc.excludeUpdates(ue.failed)
+
+		log.W("(MongoCli:Commit) %d update operations failed, trying to commit the rest of the batch", len(opErrs))
+	}
 
-		log.D("(MongoCli:Commit) Done deletion operation")
+	log.D("(MongoCli:Commit) Commit done, %d objects updated, %d objects deleted, %d operations failed",
+		mc.updated, mc.deleted, len(opErrs))
+
+	// XXX The history is stored after the commit, so the failure to store it does not discard the batch
+	if before != nil {
+		if err := mc.storeHistory(before); err != nil {
+			log.E("(MongoCli:Commit) Cannot record changes to the history: %v", err)
+		}
 	}
 
 	// XXX Use intermediate variables to avoid resetting return values by deferred function
 	ru, rd := mc.updated, mc.deleted
 
+	// Check for failed operations
+	if len(opErrs) != 0 {
+		return ru, rd, opErrs
+	}
+
 	return ru, rd, nil
 }
 
-func (mc *Client) performDelete(filter bson.D) (int64, error) {
-	if mc.ReadOnly {
-		// Simulate deletion by filter
-		return mc.deleteDryRun(filter)
+func (mc *Client) LastBatchSeq() (int64, error) {
+	// Get collection handler
+	coll := mc.c.Database(mc.Cfg.ID).Collection(MongoMetaColl)
+
+	var meta bson.M
+	err := coll.FindOne(mc.Ctx, bson.D{{MongoFieldID, mc.Cfg.CliHost}}).Decode(&meta)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// No batches were committed by this host
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("(MongoCli:LastBatchSeq) cannot load metadata of host %q from %s.%s: %w",
+			mc.Cfg.CliHost, coll.Database().Name(), coll.Name(), err)
+	}
+
+	switch seq := meta[dbms.MetaFieldBatchSeq].(type) {
+		case int64:
+			return seq, nil
+		case int32:
+			return int64(seq), nil
+		case nil:
+			// Field is not set
+			return 0, nil
+		default:
+			return 0, fmt.Errorf("(MongoCli:LastBatchSeq) type of the %q field is %T, want - int64, value: %#v",
+				dbms.MetaFieldBatchSeq, seq, seq)
+	}
+}
+
+func (mc *Client) commitDryRun(seq int64) (int64, int64, error) {
+	// Read-only database mode, all queued updates were already reported by UpdateObj()
+	updated := int64(len(mc.toUpdate))
+
+	if seq != 0 {
+		log.W("(MongoCli:Commit) R/O mode IS SET, batch sequence number %d will not be stored", seq)
+	}
+
+	// Check for objects to delete
+	if len(mc.toDelete) == 0 {
+		return updated, 0, nil
+	}
+
+	// Simulate deletion by filter
+	deleted, err := mc.deleteDryRun(bson.D{{
+		MongoFieldID, bson.D{{ `$in`, mc.toDelete }},
+	}})
+	if err != nil {
+		return updated, deleted, fmt.Errorf("(MongoCli:Commit) delete failed: %w", err)
+	}
+
+	// OK
+	return updated, deleted, nil
+}
+
+func (mc *Client) commitTx(seq int64) (int64, int64, error) {
+	if mc.noTxn {
+		// Transactions are not supported by the server
+		return mc.commitBatch(mc.Ctx, seq)
+	}
+
+	sess, err := mc.c.StartSession()
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot start session: %w", err)
+	}
+	defer sess.EndSession(mc.Ctx)
+
+	// XXX The callback can be called several times on transient errors, so results are assigned, not accumulated
+	var updated, deleted int64
+	_, err = sess.WithTransaction(mc.Ctx, func(sc mongo.SessionContext) (any, error) {
+		var err error
+		updated, deleted, err = mc.commitBatch(sc, seq)
+		return nil, err
+	})
+	if err == nil {
+		// OK
+		return updated, deleted, nil
+	}
+
+	if !isTxnNotSupported(err) {
+		return 0, 0, err
+	}
+
+	// Standalone server, transactions are available only on replica sets and sharded clusters
+	log.W("(MongoCli:Commit) Transactions are not supported by the server (%v), batches" +
+		" will be committed without atomicity", err)
+	mc.noTxn = true
+
+	return mc.commitBatch(mc.Ctx, seq)
+}
+
+func (mc *Client) commitBatch(ctx context.Context, seq int64) (int64, int64, error) {
+	updated, err := mc.performUpdate(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	deleted, err := mc.performDelete(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Check for batch sequence number to store
+	if seq == 0 {
+		return updated, deleted, nil
+	}
+
+	// Get collection handler
+	coll := mc.c.Database(mc.Cfg.ID).Collection(MongoMetaColl)
+
+	_, err = coll.UpdateOne(ctx,
+		bson.D{{MongoFieldID, mc.Cfg.CliHost}},
+		bson.D{{`$set`, bson.D{{dbms.MetaFieldBatchSeq, seq}}}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot store batch sequence number %d to %s.%s: %w",
+			seq, coll.Database().Name(), coll.Name(), err)
+	}
+
+	// OK
+	return updated, deleted, nil
+}
+
+func (mc *Client) performUpdate(ctx context.Context) (int64, error) {
+	ue := &updateErrors{failed: map[int]bool{}}
+
+	updated, err := mc.bulkWrite(ctx, MongoObjsColl, mc.toUpdate, ue)
+	if err != nil {
+		return updated, err
 	}
 
+	// Update content of objects, the failed operations are excluded together with objects updates
+	if _, err = mc.bulkWrite(ctx, MongoContentColl, mc.toContent, ue); err != nil {
+		return updated, err
+	}
+
+	if len(ue.opErrs) != 0 {
+		return updated, ue
+	}
+
+	return updated, nil
+}
+
+// bulkWrite sends operations from the update queue to the collection collName,
+// failed operations are collected to ue
+func (mc *Client) bulkWrite(ctx context.Context, collName string, ops []mongo.WriteModel,
+							ue *updateErrors) (int64, error) {
+	// Get collection handler
+	coll := mc.c.Database(mc.Cfg.ID).Collection(collName)
+
+	var updated int64
+
+	// Unordered bulk write continues to process the rest of the operations if some of them failed
+	opts := options.BulkWrite().SetOrdered(false)
+
+	// Send updates using bulk writes with limited number of operations
+	chunkSize := mc.ChunkSize(MongoDefaultChunkSize)
+	for start := 0; start < len(ops); start += chunkSize {
+		end := common.ChunkEnd(start, chunkSize, len(ops))
+
+		res, err := coll.BulkWrite(ctx, ops[start:end], opts)
+		if res != nil {
+			updated += res.MatchedCount + res.UpsertedCount
+		}
+		if err == nil {
+			log.D("(MongoCli:bulkWrite) Bulk write of %d operations on %q executed", end - start, collName)
+			// Go to the next chunk
+			continue
+		}
+
+		// Check for errors of the separate operations
+		var bwe mongo.BulkWriteException
+		if !errors.As(err, &bwe) || bwe.WriteConcernError != nil || len(bwe.WriteErrors) == 0 {
+			// Whole chunk failed
+			return updated, fmt.Errorf("bulk write of %d operations on %s.%s failed: %w",
+				end - start, coll.Database().Name(), coll.Name(), err)
+		}
+
+		for _, we := range bwe.WriteErrors {
+			// Index of the write error is relative to the passed chunk
+			idx := start + we.Index
+			if ue.failed[idx] {
+				// Already reported
+				continue
+			}
+			ue.failed[idx] = true
+			ue.opErrs = append(ue.opErrs, &dbms.OpError{Op: dbms.Update, Key: mc.toUpdateIds[idx], Err: we})
+		}
+	}
+
+	// OK
+	return updated, nil
+}
+
+func (mc *Client) performDelete(ctx context.Context) (int64, error) {
 	// Get collection handler
 	coll := mc.c.Database(mc.Cfg.ID).Collection(MongoObjsColl)
 
-	// Perform deletion
-	res, err := coll.DeleteMany(mc.Ctx, filter)
+	var deleted int64
 
-	// Is operation successful?
-	if err == nil {
-		return res.DeletedCount, nil
+	// Delete objects by chunks of identifiers
+	chunkSize := mc.ChunkSize(MongoDefaultChunkSize)
+	for start := 0; start < len(mc.toDelete); start += chunkSize {
+		end := common.ChunkEnd(start, chunkSize, len(mc.toDelete))
+
+		// Create filter by identifiers
+		filter := bson.D{{
+			MongoFieldID, bson.D{{ `$in`, mc.toDelete[start:end] }},
+		}}
+
+		// Perform deletion
+		res, err := coll.DeleteMany(ctx, filter)
+		if err != nil {
+			return deleted, fmt.Errorf("delete from %s.%s failed: %w", coll.Database().Name(), coll.Name(), err)
+		}
+
+		deleted += res.DeletedCount
+
+		// Delete content of objects, it does not exist for most of them
+		cColl := mc.c.Database(mc.Cfg.ID).Collection(MongoContentColl)
+		if _, err = cColl.DeleteMany(ctx, filter); err != nil {
+			return deleted, fmt.Errorf("delete from %s.%s failed: %w", cColl.Database().Name(), cColl.Name(), err)
+		}
 	}
 
-	// Operation is not successful, try to extract number of deleted documents
-	deleted := int64(0)
-	if res != nil {
-		deleted = res.DeletedCount
+	// OK
+	return deleted, nil
+}
+
+func (mc *Client) excludeUpdates(failed map[int]bool) {
+	toUpdate := make([]mongo.WriteModel, 0, len(mc.toUpdate) - len(failed))
+	toUpdateIds := make([]string, 0, cap(toUpdate))
+	toContent := make([]mongo.WriteModel, 0, cap(toUpdate))
+	toUpdateHist := make([]*histItem, 0, cap(toUpdate))
+
+	for i := range mc.toUpdate {
+		if !failed[i] {
+			toUpdate = append(toUpdate, mc.toUpdate[i])
+			toUpdateIds = append(toUpdateIds, mc.toUpdateIds[i])
+			toContent = append(toContent, mc.toContent[i])
+			toUpdateHist = append(toUpdateHist, mc.toUpdateHist[i])
+		}
 	}
 
-	return deleted, fmt.Errorf("delete from %s.%s failed: %w", coll.Database().Name(), coll.Name(), err)
+	mc.toUpdate, mc.toUpdateIds, mc.toContent, mc.toUpdateHist = toUpdate, toUpdateIds, toContent, toUpdateHist
 }
 
 func (mc *Client) deleteDryRun(filter bson.D) (int64, error) {
@@ -0,0 +1,80 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/r-che/dfi/common/tools"
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Event of the change stream of the objects collection
+type changeEvent struct {
+	OperationType	string	`bson:"operationType"`
+	DocumentKey		struct {
+		ID	string	`bson:"_id"`
+	}	`bson:"documentKey"`
+}
+
+func (mc *Client) Subscribe(ctx context.Context, handler dbms.ChangesHandler) error {
+	// Get collection handler
+	coll := mc.c.Database(mc.Cfg.ID).Collection(MongoObjsColl)
+
+	// Only changes of documents are required, host and path of objects are not
+	// known for deleted documents, so they are not requested for others too
+	pipeline := bson.A{bson.D{{`$match`, bson.D{{`operationType`, bson.D{{`$in`, bson.A{
+		`insert`, `update`, `replace`, `delete`,
+	}}}}}}}}
+
+	// XXX Change streams are available only on replica sets and sharded clusters
+	cs, err := coll.Watch(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("(MongoCli:Subscribe) cannot watch changes of %s.%s: %w",
+			coll.Database().Name(), coll.Name(), err)
+	}
+	defer func() {
+		// Use the client context, ctx may be already done
+		if err := cs.Close(mc.Ctx); err != nil {
+			log.E("(MongoCli:Subscribe) cannot close change stream: %v", err)
+		}
+	}()
+
+	log.D("(MongoCli:Subscribe) Watching changes of %s.%s", coll.Database().Name(), coll.Name())
+
+	chunkSize := mc.ChunkSize(MongoDefaultChunkSize)
+	for cs.Next(ctx) {
+		// Collect all available events to pass them together, as the agent commits them by batches
+		changes := []*dbms.ObjChange{}
+		for {
+			var ev changeEvent
+			if err := cs.Decode(&ev); err != nil {
+				return fmt.Errorf("(MongoCli:Subscribe) cannot decode change event: %w", err)
+			}
+
+			changes = append(changes, &dbms.ObjChange{
+				Op:	tools.Tern(ev.OperationType == `delete`, dbms.Delete, dbms.Update),
+				ID:	ev.DocumentKey.ID,
+			})
+
+			if len(changes) >= chunkSize || !cs.TryNext(ctx) {
+				break
+			}
+		}
+
+		if err := handler(changes); err != nil {
+			return err
+		}
+	}
+
+	// Check for the reason of the stream end, it is not an error if ctx is done
+	if err := cs.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("(MongoCli:Subscribe) change stream failed: %w", err)
+	}
+
+	// OK
+	return nil
+}
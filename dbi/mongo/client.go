@@ -2,6 +2,7 @@ package mongo
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/r-che/dfi/types"
 	"github.com/r-che/dfi/types/dbms"
@@ -11,6 +12,7 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 //
@@ -21,7 +23,12 @@ func (mc *Client) Query(qa *dbms.QueryArgs, retFields []string) (dbms.QueryResul
 	searchType := tools.Tern(len(qa.SP) == 0, "only arguments-based", "full-text")
 
 	log.D("(MongoCli:Query) Running %s search ...", searchType)
-	qr, err := mc.runSearch(MongoObjsColl, qa, filterMakeFullTextSearch(qa), retFields)
+	ftFilter, err := filterMakeFullTextSearch(qa)
+	if err != nil {
+		return nil, fmt.Errorf("(MongoCli:Query) %w", err)
+	}
+
+	qr, err := mc.runSearch(MongoObjsColl, qa, ftFilter, retFields)
 	if err != nil {
 		return qr, fmt.Errorf("(MongoCli:Query) %s search failed: %w", searchType, err)
 	}
@@ -71,8 +78,69 @@ func (mc *Client) GetObjects(ids, retFields []string) (dbms.QueryResults, error)
 	return qr, nil
 }
 
+func (mc *Client) QueryContentIds(qa *dbms.QueryArgs) ([]string, error) {
+	// Get collection handler
+	coll := mc.c.Database(mc.Cfg.ID).Collection(MongoContentColl)
+
+	// Each word is enclosed by double quotes to require all words of the phrase to be found[1]
+	// [1] https://www.mongodb.com/docs/manual/reference/operator/query/text/#-search-field
+	words := strings.Fields(strings.ReplaceAll(qa.Content, `"`, ` `))
+	for i, word := range words {
+		words[i] = `"` + word + `"`
+	}
+	filter := bson.D{{`$text`, bson.D{{`$search`, strings.Join(words, ` `)}}}}
+
+	log.D("(MongoCli:QueryContentIds) Run full-text search by %s collection using filter %v", MongoContentColl, filter)
+
+	cursor, err := coll.Find(mc.Ctx, filter, options.Find().SetProjection(bson.D{{MongoFieldID, 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("(MongoCli:QueryContentIds) find on %s.%s with filter %v failed: %w",
+			coll.Database().Name(), coll.Name(), filter, err)
+	}
+	defer func() {
+		if err := cursor.Close(mc.Ctx); err != nil {
+			log.E("(MongoCli:QueryContentIds) cannot close cursor: %v", err)
+		}
+	}()
+
+	ids := []string{}
+	for cursor.Next(mc.Ctx) {
+		var item map[string]string
+		if err := cursor.Decode(&item); err != nil {
+			return nil, fmt.Errorf("(MongoCli:QueryContentIds) cannot decode cursor item: %w", err)
+		}
+		ids = append(ids, item[MongoFieldID])
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("(MongoCli:QueryContentIds) cursor failed: %w", err)
+	}
+
+	log.D("(MongoCli:QueryContentIds) Content search found %d identifiers", len(ids))
+
+	// OK
+	return ids, nil
+}
+
 func (mc *Client) runSearch(collName string, qa *dbms.QueryArgs,
 							spFilter *Filter, retFields []string) (dbms.QueryResults, error) {
+	filter, err := makeSearchFilter(qa, spFilter)
+	if err != nil {
+		return nil, fmt.Errorf("(MongoCli:runSearch) %w", err)
+	}
+
+	// XXX Raw query may be too long
+	// log.D("(MongoCli:runSearch) Prepared Mongo filter for search in %q: %v", collName, filter)
+
+	qr, err := mc.aggregateSearch(collName, filter, retFields, qa)
+	if err != nil {
+		return nil, fmt.Errorf("(MongoCli:runSearch) %w", err)
+	}
+
+	return qr, nil
+}
+
+// makeSearchFilter makes the complete search filter from the filter with search phrases and query arguments
+func makeSearchFilter(qa *dbms.QueryArgs, spFilter *Filter) (*Filter, error) {
 	// Create a new filter as a clone of the filter with search phrases
 	filter := spFilter.Clone()
 
@@ -84,17 +152,30 @@ func (mc *Client) runSearch(collName string, qa *dbms.QueryArgs,
 
 	// Join filter with search phrases and probably identifiers with the
 	// query aruments (such mtime, type and so on) using logical AND
-	filter = filter.JoinWithOthers(useAnd, filterMakeByArgs(qa))
+	argsFilter, err := filterMakeByArgs(qa)
+	if err != nil {
+		return nil, err
+	}
+	filter = filter.JoinWithOthers(useAnd, argsFilter)
 
-	// XXX Raw query may be too long
-	// log.D("(MongoCli:runSearch) Prepared Mongo filter for search in %q: %v", collName, filter)
+	// Restrict results by objects with matched content, regardless of negation and OR-ing of arguments
+	if qa.IsContentIds() {
+		filter = filter.JoinWithOthers(useAnd, filterMakeIDs(qa.ContentIds))
+	}
+	// Restrict results by the set of allowed identifiers
+	if qa.IsOnlyIds() {
+		filter = filter.JoinWithOthers(useAnd, filterMakeIDs(qa.OnlyIds))
+	}
 
-	qr, err := mc.aggregateSearch(collName, filter, retFields, qa)
-	if err != nil {
-		return nil, fmt.Errorf("(MongoCli:runSearch) %w", err)
+	// Exclude objects of excluded hosts, also regardless of negation and OR-ing of arguments
+	if qa.IsExclHosts() {
+		filter = filter.JoinWithOthers(useAnd, NewFilter().SetExpr(bson.D{
+			{dbms.FieldHost, bson.D{{`$nin`, qa.ExclHosts}}},
+		}))
 	}
 
-	return qr, nil
+	// OK
+	return filter, nil
 }
 
 func (mc *Client) aggregateSearch(collName string, filter *Filter, retFields []string,
@@ -0,0 +1,187 @@
+package mongo
+
+import (
+	"fmt"
+
+	"github.com/r-che/dfi/dbi/common"
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Pseudo-fields used by the disk usage aggregation pipeline
+const (
+	duFieldKey		= "duKey"
+	duFieldPhys		= "duPhys"
+	duFieldCount	= "count"
+)
+
+func (mc *Client) DiskUsage(qa *dbms.QueryArgs, dua *dbms.DUArgs) (dbms.DUResults, error) {
+	// Deep search merges results of two different searches, it cannot be done by a single pipeline
+	if qa.DeepSearch && len(qa.SP) != 0 {
+		log.D("(MongoCli:DiskUsage) Aggregating disk usage by %s with deep search on the client side...", dua.GroupBy)
+
+		dur, err := common.DiskUsage(mc, qa, dua)
+		if err != nil {
+			return nil, fmt.Errorf("(MongoCli:DiskUsage) %w", err)
+		}
+
+		return dur, nil
+	}
+
+	ftFilter, err := filterMakeFullTextSearch(qa)
+	if err != nil {
+		return nil, fmt.Errorf("(MongoCli:DiskUsage) %w", err)
+	}
+
+	filter, err := makeSearchFilter(qa, ftFilter)
+	if err != nil {
+		return nil, fmt.Errorf("(MongoCli:DiskUsage) %w", err)
+	}
+
+	// Apply filter and configure pipeline by query arguments as the usual search does
+	pipeline := pipelineConfVariadic(filter, mongo.Pipeline{
+		bson.D{{ `$match`, filter.Expr() }},
+	}, []any{qa})
+	pipeline = append(pipeline, duPipeline(dua)...)
+
+	// Get collection handler
+	coll := mc.c.Database(mc.Cfg.ID).Collection(MongoObjsColl)
+
+	cursor, err := coll.Aggregate(mc.Ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("(MongoCli:DiskUsage) aggregate on %s.%s with filter %v failed: %w",
+			coll.Database().Name(), coll.Name(), filter, err)
+	}
+	defer func() {
+		if err := cursor.Close(mc.Ctx); err != nil {
+			log.E("(MongoCli:DiskUsage) cannot close cursor: %v", err)
+		}
+	}()
+
+	dur := dbms.DUResults{}
+	for cursor.Next(mc.Ctx) {
+		var item struct {
+			Key		string	`bson:"_id"`
+			Size	int64	`bson:"size"`
+			Count	int64	`bson:"count"`
+		}
+		if err := cursor.Decode(&item); err != nil {
+			return nil, fmt.Errorf("(MongoCli:DiskUsage) cannot decode cursor item: %w", err)
+		}
+
+		dur = append(dur, &dbms.DUItem{Key: item.Key, Size: item.Size, Count: item.Count})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("(MongoCli:DiskUsage) cursor failed: %w", err)
+	}
+
+	log.D("(MongoCli:DiskUsage) Found %d groups", len(dur))
+
+	// Return results in the same order as the client side aggregation does
+	dur.Sort()
+
+	return dur, nil
+}
+
+// duPipeline returns stages of the pipeline that group matched objects by keys defined
+// by dua. The results are the same as produced by dbms.DUAggregator
+func duPipeline(dua *dbms.DUArgs) mongo.Pipeline {
+	pipeline := mongo.Pipeline{
+		// Sizes of members of archives are already taken into account by the sizes of archives
+		bson.D{{ `$match`, bson.D{{ dbms.FieldType, bson.D{{ `$ne`, types.ObjArcMember }} }} }},
+	}
+
+	var key any
+	switch dua.GroupBy {
+	case dbms.DUByPath:
+		key = bson.D{{ `$concat`, bson.A{`$` + dbms.FieldHost, `:`, duPathPrefixExpr(dua.Depth)} }}
+	case dbms.DUByHost:
+		key = `$` + dbms.FieldHost
+	case dbms.DUByType:
+		key = `$` + dbms.FieldType
+	case dbms.DUByTag:
+		// Join tags from the AII collection, each tag makes a separate document
+		pipeline = append(pipeline,
+			bson.D{{ `$lookup`, bson.D{
+				{ `from`,			MongoAIIColl },
+				{ `localField`,		MongoFieldID },
+				{ `foreignField`,	MongoFieldID },
+				{ `as`,				MongoAIIColl },
+			}}},
+			bson.D{{ `$unwind`, bson.D{
+				{ `path`, `$` + MongoAIIColl },
+				{ `preserveNullAndEmptyArrays`, true },
+			}}},
+			bson.D{{ `$unwind`, bson.D{
+				{ `path`, `$` + MongoAIIColl + `.` + dbms.AIIFieldTags },
+				{ `preserveNullAndEmptyArrays`, true },
+			}}},
+		)
+		// Untagged objects are grouped by the empty key
+		key = bson.D{{ `$ifNull`, bson.A{`$` + MongoAIIColl + `.` + dbms.AIIFieldTags, ``} }}
+	default:
+		panic(fmt.Sprintf("unsupported disk usage grouping %q", dua.GroupBy))
+	}
+
+	return append(pipeline,
+		bson.D{{ `$project`, bson.D{
+			{ duFieldKey, key },
+			{ dbms.FieldSize, 1 },
+			// Hard links have the same device and inode, objects with unknown inode are unique
+			{ duFieldPhys, bson.D{{ `$cond`, bson.A{
+				bson.D{{ `$gt`, bson.A{`$` + dbms.FieldInode, 0} }},
+				bson.D{
+					{ dbms.FieldHost,	`$` + dbms.FieldHost },
+					{ dbms.FieldDevice,	`$` + dbms.FieldDevice },
+					{ dbms.FieldInode,	`$` + dbms.FieldInode },
+				},
+				`$` + MongoFieldID,
+			}}}},
+		}}},
+		// Count each physical file once per group
+		bson.D{{ `$group`, bson.D{
+			{ MongoFieldID, bson.D{
+				{ duFieldKey,	`$` + duFieldKey },
+				{ duFieldPhys,	`$` + duFieldPhys },
+			}},
+			{ dbms.FieldSize, bson.D{{ `$first`, `$` + dbms.FieldSize }} },
+		}}},
+		bson.D{{ `$group`, bson.D{
+			{ MongoFieldID,		`$` + MongoFieldID + `.` + duFieldKey },
+			{ dbms.FieldSize,	bson.D{{ `$sum`, `$` + dbms.FieldSize }} },
+			{ duFieldCount,		bson.D{{ `$sum`, 1 }} },
+		}}},
+	)
+}
+
+// duPathPrefixExpr returns the expression that does the same as dbms.DUPathPrefix() with the found path
+func duPathPrefixExpr(depth int) bson.D {
+	// Number of components of the directory containing the object, the first element of split is empty
+	var n any = bson.D{{ `$subtract`, bson.A{bson.D{{ `$size`, `$$parts` }}, 2} }}
+	if depth != 0 {
+		n = bson.D{{ `$min`, bson.A{n, depth} }}
+	}
+
+	return bson.D{{ `$let`, bson.D{
+		{ `vars`, bson.D{{ `parts`, bson.D{{ `$split`, bson.A{`$` + dbms.FieldFPath, `/`} }} }} },
+		{ `in`, bson.D{{ `$let`, bson.D{
+			{ `vars`, bson.D{{ `n`, n }} },
+			{ `in`, bson.D{{ `$cond`, bson.A{
+				bson.D{{ `$gt`, bson.A{`$$n`, 0} }},
+				// Join the first n components of the directory by "/"
+				bson.D{{ `$reduce`, bson.D{
+					{ `input`,			bson.D{{ `$slice`, bson.A{`$$parts`, 1, `$$n`} }} },
+					{ `initialValue`,	`` },
+					{ `in`,				bson.D{{ `$concat`, bson.A{`$$value`, `/`, `$$this`} }} },
+				}}},
+				// Object is placed in the root directory
+				`/`,
+			}}}},
+		}}}},
+	}}}
+}
@@ -0,0 +1,182 @@
+package mongo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/r-che/dfi/common/tools"
+	"github.com/r-che/dfi/types/dbms"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Fields of objects matched by sets of exact values
+var exprExactFields = map[string]bool{
+	dbms.FieldSize:			true,
+	dbms.FieldMTime:		true,
+	dbms.FieldCTime:		true,
+	dbms.FieldNLink:		true,
+	dbms.FieldUID:			true,
+	dbms.FieldGID:			true,
+	dbms.FieldInode:		true,
+	dbms.FieldTaken:		true,
+	dbms.FieldType:			true,
+	dbms.FieldHost:			true,
+	dbms.FieldUser:			true,
+	dbms.FieldGroup:		true,
+	dbms.FieldChecksum:		true,
+	dbms.FieldLinkState:	true,
+}
+
+// Mongo operators of comparisons
+var exprCmpOps = map[dbms.CmpOp]string{
+	dbms.CmpLt:	`$lt`,
+	dbms.CmpLe:	`$lte`,
+	dbms.CmpGt:	`$gt`,
+	dbms.CmpGe:	`$gte`,
+}
+
+// filterMakeExpr compiles the query expression to the filter document
+func filterMakeExpr(e *dbms.Expr) (bson.D, error) {
+	switch e.Op {
+	case dbms.ExprCond:
+		cond, err := filterMakeCond(e.Cond)
+		if err != nil {
+			return nil, err
+		}
+		return bson.D{cond}, nil
+
+	case dbms.ExprAnd, dbms.ExprOr, dbms.ExprNot:
+		args := make(bson.A, 0, len(e.Args))
+		for _, arg := range e.Args {
+			doc, err := filterMakeExpr(arg)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, doc)
+		}
+
+		// Mongo does not support $not as the first-level operator, $nor of the single operand is used instead
+		op := map[dbms.ExprOp]string{dbms.ExprAnd: `$and`, dbms.ExprOr: `$or`, dbms.ExprNot: `$nor`}[e.Op]
+
+		return bson.D{{op, args}}, nil
+
+	case dbms.ExprConst:
+		// Each document has the identifier
+		return bson.D{{MongoFieldID, bson.D{{`$exists`, e.Val}}}}, nil
+	}
+
+	return nil, fmt.Errorf("(MongoCli:filterMakeExpr) unsupported type of expression %d", e.Op)
+}
+
+// filterMakeCond compiles the condition to the filter expression
+//
+//nolint:cyclop	// Each field is simple, splitting does not make it clearer
+func filterMakeCond(c *dbms.Cond) (bson.E, error) {
+	switch {
+	case c.IsPattern():
+		return filterMakePatternExpr(c)
+
+	case c.Field == dbms.FieldMode:
+		return filterMakePermExpr(c.Ints[0], tools.Tern(c.Cmp == dbms.CmpAllBits, dbms.PermAll, dbms.PermExact)), nil
+
+	case exprExactFields[c.Field] && c.Cmp == dbms.CmpEq:
+		if c.Strs != nil {
+			return bson.E{c.Field, bson.D{{`$in`, c.Strs}}}, nil
+		}
+		return bson.E{c.Field, bson.D{{`$in`, c.Ints}}}, nil
+
+	case exprExactFields[c.Field] && exprCmpOps[c.Cmp] != "" && len(c.Ints) != 0:
+		return bson.E{c.Field, bson.D{{exprCmpOps[c.Cmp], c.Ints[0]}}}, nil
+
+	case c.Field == dbms.FieldID:
+		return bson.E{MongoFieldID, bson.D{{`$in`, c.Strs}}}, nil
+
+	case c.Field == dbms.FieldMIME:
+		return filterMakeMIMEExpr(c.Strs), nil
+
+	case c.Field == dbms.FieldCamera, c.Field == dbms.FieldName:
+		return filterMakeWordsExpr([]string{c.Field}, c.Strs)
+
+	case c.Field == dbms.ExprFieldPath:
+		// Any of phrases should be found in the found path or in the real path
+		return filterMakeWordsExpr([]string{dbms.FieldFPath, dbms.FieldRPath}, c.Strs)
+
+	case c.Field == dbms.ExprFieldTarget:
+		return filterMakePointsToExpr(c.Strs[0]), nil
+
+	case c.Field == dbms.AIIFieldTags || c.Field == dbms.AIIFieldDescr || c.Field == dbms.ExprFieldContent:
+		return bson.E{}, fmt.Errorf("(MongoCli:filterMakeCond) condition %s must be resolved to identifiers of objects", c)
+	}
+
+	return bson.E{}, fmt.Errorf("(MongoCli:filterMakeCond) unsupported condition %s", c)
+}
+
+// filterMakeWordsExpr makes expression to match any of fields by any of phrases by whole words, the same
+// way as the full-text search of RediSearch does: the field must contain all words of the phrase in any order
+func filterMakeWordsExpr(fields, phrases []string) (bson.E, error) {
+	alts := make(bson.A, 0, len(fields) * len(phrases))
+	for _, phrase := range phrases {
+		words := dbms.TextWords(phrase)
+		if len(words) == 0 {
+			return bson.E{}, fmt.Errorf("(MongoCli:filterMakeWordsExpr) phrase %q does not contain words to search", phrase)
+		}
+
+		regexps := make(bson.A, 0, len(words))
+		for _, word := range words {
+			pcre, err := pcreRegexp(wordRegexp(word))
+			if err != nil {
+				return bson.E{}, err
+			}
+			regexps = append(regexps, primitive.Regex{Pattern: pcre})
+		}
+
+		for _, field := range fields {
+			alts = append(alts, bson.D{{field, bson.D{{`$all`, regexps}}}})
+		}
+	}
+
+	// OK
+	return bson.E{`$or`, alts}, nil
+}
+
+// wordRegexp returns the case-insensitive regular expression in the Go syntax that matches the word
+// bounded by separators of words of text fields or by ends of the value
+func wordRegexp(word string) string {
+	var sep strings.Builder
+	// Spaces and control characters are separators, the same as unicode.IsSpace and unicode.IsControl
+	sep.WriteString(`[\p{Z}\p{Cc}`)
+	for _, r := range dbms.TextSeparators {
+		fmt.Fprintf(&sep, `\x{%x}`, r)
+	}
+	sep.WriteString(`]`)
+
+	return `(?i)(?:\A|` + sep.String() + `)` + regexp.QuoteMeta(word) + `(?:` + sep.String() + `|\z)`
+}
+
+// filterMakePatternExpr makes expression to match names or found paths by shell patterns or regular
+// expressions, both are translated to PCRE patterns with the same semantics as they have on the client side
+func filterMakePatternExpr(c *dbms.Cond) (bson.E, error) {
+	field := dbms.FieldFPath
+	if c.Field == dbms.FieldName {
+		field = dbms.FieldName
+	}
+
+	regexps := make(bson.A, 0, len(c.Strs))
+	for _, pattern := range c.Strs {
+		if c.Cmp == dbms.CmpGlob {
+			pattern = dbms.GlobRegexp(pattern)
+		}
+
+		pcre, err := pcreRegexp(pattern)
+		if err != nil {
+			return bson.E{}, err
+		}
+		regexps = append(regexps, primitive.Regex{Pattern: pcre})
+	}
+
+	// OK
+	return bson.E{field, bson.D{{`$in`, regexps}}}, nil
+}
@@ -0,0 +1,97 @@
+package mongo
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/r-che/dfi/types/dbms"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Text fields are matched by whole words of phrases in any order, case-insensitively,
+// the same cases are checked for the Redis backend
+var textMatchTests = []struct {
+	value	string
+	phrases	[]string
+	want	bool
+} {
+	{ "Canon EOS 5D", []string{"canon"}, true },
+	{ "Canon EOS 5D", []string{"EOS canon"}, true },
+	{ "Canon EOS 5D", []string{"cano"}, false },
+	{ "Canonet QL17", []string{"canon"}, false },
+	{ "Canon EOS 5D", []string{"canon d850"}, false },
+	{ "Canon EOS 5D", []string{"nikon", "canon eos"}, true },
+	{ "NIKON D850", []string{"nikon d850"}, true },
+	{ "Sony ILCE-7M3", []string{"ilce 7m3"}, true },
+	{ "holiday-2020.jpg", []string{"2020 holiday"}, true },
+	{ "holiday-2020.jpg", []string{"holi"}, false },
+	{ "IMG_0001.JPG", []string{"img"}, false },
+	{ "IMG_0001.JPG", []string{"img_0001 jpg"}, true },
+}
+
+func TestFilterMakeWordsExpr(t *testing.T) {
+	for i, test := range textMatchTests {
+		e, err := filterMakeCond(&dbms.Cond{Field: dbms.FieldCamera, Cmp: dbms.CmpEq, Strs: test.phrases})
+		if err != nil {
+			t.Fatalf("[%d] filterMakeCond returned unexpected error: %v", i, err)
+		}
+
+		if got := matchWordsExpr(t, e, test.value); got != test.want {
+			t.Errorf("[%d] %q matched by %q - want %t, got %t", i, test.value, test.phrases, test.want, got)
+		}
+	}
+
+	if _, err := filterMakeCond(&dbms.Cond{Field: dbms.FieldName, Cmp: dbms.CmpEq, Strs: []string{"-*-"}}); err == nil {
+		t.Errorf("filterMakeCond returned no error for the phrase without words")
+	}
+}
+
+// matchWordsExpr evaluates the expression made by filterMakeWordsExpr on the value of the field, PCRE
+// patterns of words do not contain lookarounds, so they are checked by regular expressions of Go
+func matchWordsExpr(t *testing.T, e bson.E, value string) bool {
+	t.Helper()
+
+	for _, alt := range e.Value.(bson.A) {
+		all := true
+		for _, re := range alt.(bson.D)[0].Value.(bson.D)[0].Value.(bson.A) {
+			all = all && regexp.MustCompile(re.(primitive.Regex).Pattern).MatchString(value)
+		}
+		if all {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Expressions with patterns that are supported by all backends, the same cases are checked for the Redis backend
+var patternsJoinTests = []struct {
+	expr	string
+	ok		bool
+} {
+	{ `size>1 and name~"*.iso"`, true },
+	{ `not (name~"*.tmp" or path~"/tmp/*") and type:reg`, true },
+	{ `name~"*.iso" or name=~"^a"`, true },
+	{ `size>1 or name~"*.iso"`, false },
+	{ `not (size>1 and name~"*.iso")`, false },
+	{ `type:dir or (size>1 and path~"/data/*")`, false },
+}
+
+func TestFilterMakeByArgsPatterns(t *testing.T) {
+	for i, test := range patternsJoinTests {
+		e, err := dbms.ParseExpr(test.expr)
+		if err != nil {
+			t.Fatalf("[%d] ParseExpr(%q) failed: %v", i, test.expr, err)
+		}
+
+		// Expressions received from the remote backend are not checked by the parser of query arguments
+		qa := dbms.NewQueryArgs()
+		qa.Expr = e
+
+		if _, err := filterMakeByArgs(qa); (err == nil) != test.ok {
+			t.Errorf("[%d] filterMakeByArgs(%s) - want ok %t, got error %v", i, e, test.ok, err)
+		}
+	}
+}
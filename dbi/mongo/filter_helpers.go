@@ -1,103 +1,39 @@
 package mongo
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
+	"unicode"
 
+	"github.com/r-che/dfi/types"
 	"github.com/r-che/dfi/types/dbms"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// filterMakeByArgs makes filter expression to use search arguments like mtime, type of object and so on
-func filterMakeByArgs(qa *dbms.QueryArgs) *Filter {
-	// Make filter based on object properties (mtime, size, etc...)
-	filter := filterAddArgs(qa)
-
-	//
-	// XXX Processing logical flags --not (NOT) and --or (OR)
-	//
-
-	/*
-	 * Here we have a set of conditions in the filter list, they looks like:
-	 *  $cond1, $cond2, $cond3, ...
-	 *
-	 * Need to consider the following cases:
-	 *
-	 * 1. NOT and OR are set - we have something like:
-	 *    > !($cond1 || $cond2 || $cond3 || ...)
-	 *    Use De Morgan's laws again, we will get:
-	 *    > !$cond1 && !$cond2 && !$cond3 && ...
-	 *    That corresponds to the NOR operator, which supported by Mongo natively:
-	 *    > $nor: [ $cond1, $cond2 ]
-	 *
-	 * 2. OR is set - need to rejoin list of condition by logical OR,
-	 *    that can be done using $or Mongo operator:
-	 *    > $or: [ $cond1, $cond2, $cond3, ... ]
-	 *
-	 * 3. NOT is set - we have something like:
-	 *    > !($cond1 && $cond2 && $cond3)
-	 *    Because of Mongo does not support $not as the first level operation, we need
-	 *    to use De Morgan's laws to convert expression to:
-	 *    > !$cond1 || !$cond2 || !$cond3 || ....
-	 *    In Mongo-like notation:
-	 *    > $or: [ {$not: $cond1}, {$not: $cond2}, {$not: $cond3} ]
-	 *
-	 * 4. Neither of logical flags is set: no additional transformations required, by default
-	 *    all conditions within the filter document are treated as joined by logical AND:
-	 *    > $cond1 && $cond2 && $cond3 && ...
-	*/
-
-	switch {
-	// Case #1
-	case qa.NegExpr && qa.OrExpr:
-		return filter.JoinByNor()
-
-	// Case #2
-	case qa.OrExpr:
-		// Rejoing arguments by OR
-		return filter.JoinByOr()
-
-	// Case #3
-	case qa.NegExpr:
-		// 1. Do negation of each condition in filter
-		// 2. Join condiions by OR instead of AND
-		return filter.JoinByOr().NegFilter()
-
-	// Case #4 is the default configuration - nothing to modify
-	default:
-		return filter
-	}
-}
-
-func filterAddArgs(qa *dbms.QueryArgs) *Filter {
-	//
-	// Build search arguments filter
-	//
-	filter := NewFilter()
-
-	if qa.IsMtime() {
-		filter.Append(filterMakeSetRangeExpr(dbms.FieldMTime, qa.MtimeStart, qa.MtimeEnd, qa.MtimeSet))
-	}
-
-	if qa.IsSize() {
-		filter.Append(filterMakeSetRangeExpr(dbms.FieldSize, qa.SizeStart, qa.SizeEnd, qa.SizeSet))
-	}
-
-	if qa.IsType() {
-		filter.Append(bson.E{dbms.FieldType, bson.D{bson.E{`$in`, qa.Types}}})
+// filterMakeByArgs makes filter expression to use search arguments like mtime, type of object and so on,
+// joined with the query expression
+func filterMakeByArgs(qa *dbms.QueryArgs) (*Filter, error) {
+	expr := qa.FilterExpr()
+	if expr == nil {
+		// No conditions, return empty filter
+		return NewFilter(), nil
 	}
 
-	if qa.IsChecksum() {
-		filter.Append(bson.E{dbms.FieldChecksum, bson.D{bson.E{`$in`, qa.CSums}}})
+	// Patterns are matched by MongoDB itself, but expressions not supported by other backends are rejected
+	if err := expr.CheckPatterns(); err != nil {
+		return nil, fmt.Errorf("(MongoCli:filterMakeByArgs) %w", err)
 	}
 
-	if qa.IsHost() {
-		filter.Append(bson.E{dbms.FieldHost, bson.D{ bson.E{`$in`, qa.Hosts}}})
+	doc, err := filterMakeExpr(expr)
+	if err != nil {
+		return nil, fmt.Errorf("(MongoCli:filterMakeByArgs) cannot make filter from the expression: %w", err)
 	}
 
-	return filter
+	// OK
+	return NewFilter().SetExpr(doc), nil
 }
 
 // filterMakeRegexSP makes filter to search by fpath and rpath fields using regular expression
@@ -133,34 +69,66 @@ func filterMakeIDs(ids []string) *Filter {
 }
 
 // filterMakeFullTextSearch makes filter to use full-text search by search phrases
-func filterMakeFullTextSearch(qa *dbms.QueryArgs) *Filter {
+func filterMakeFullTextSearch(qa *dbms.QueryArgs) (*Filter, error) {
 	// Check for any search phrases set
 	if len(qa.SP) == 0 {
 		// No search phrases, return empty filter
-		return NewFilter()
+		return NewFilter(), nil
+	}
+
+	search, err := textSearchString(qa)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFilter().
+		SetFullText().
+		SetExpr(bson.D{bson.E{`$text`,
+			bson.D{{ `$search`, search},
+		}}}), nil
+}
+
+// textSearchString makes the search string of the $text operator[1] from search phrases. Quotes of phrases are
+// removed and words are not prefixed by the minus sign, so phrases cannot alter the structure of the search
+// string. Phrases with white spaces or all phrases if qa.LiteralSP is set are enclosed in double quotes
+// to match them as is. Raw phrases are passed in the search string without changes
+// [1] https://www.mongodb.com/docs/manual/reference/operator/query/text/#-search-field
+func textSearchString(qa *dbms.QueryArgs) (string, error) {
+	if qa.RawQuery {
+		return strings.Join(qa.SP, " "), nil
 	}
 
-	// Prepare search phrases before constructing query: if the phrase contains whitespaces,
-	// it should be interpreted as is - need to enclose it by escaped double quotes[1]
-	// [1] https://www.mongodb.com/docs/manual/reference/operator/query/text/#-search-field
 	prepared := make([]string, 0, len(qa.SP))
 	for _, sp := range qa.SP {
-		// Escape backslash with double quote
-		sp = strings.ReplaceAll(sp, `\"`, `"`)
-		if strings.ContainsAny(sp, " \t\n") {
-			// sp contains whitespaces, need to wrap
-			sp = `\"` + sp + `\"`
+		// Quotes cannot be escaped inside of the search string, so they split phrases
+		n := len(prepared)
+		for _, part := range strings.Split(sp, `"`) {
+			part = strings.Join(strings.FieldsFunc(part, textSearchSpace), " ")
+			switch {
+			case part == "":
+				continue
+			case qa.LiteralSP || strings.Contains(part, " "):
+				// The phrase is matched as is, the minus sign inside of it does not negate words
+				prepared = append(prepared, `"` + part + `"`)
+			default:
+				// The minus sign at the beginning of the word negates it
+				if word := strings.TrimLeft(part, "-"); word != "" {
+					prepared = append(prepared, word)
+				}
+			}
 		}
 
-		// Append to prepared
-		prepared = append(prepared, sp)
+		if len(prepared) == n {
+			return "", fmt.Errorf("(MongoCli:textSearchString) phrase %q does not contain words to search", sp)
+		}
 	}
 
-	return NewFilter().
-		SetFullText().
-		SetExpr(bson.D{bson.E{`$text`,
-			bson.D{{ `$search`, strings.Join(prepared, " ")},
-		}}})
+	return strings.Join(prepared, " "), nil
+}
+
+// textSearchSpace reports whether r separates words in the search string of the $text operator
+func textSearchSpace(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsControl(r)
 }
 
 // mergeIdsWithSPs merges filters by identifiers to existing filter with search expression with search phrases
@@ -174,36 +142,37 @@ func filterMergeWithIDs(filter *Filter, ids []string) *Filter {
 	return newFilter
 }
 
-func filterMakeSetRangeExpr(field string, min, max int64, set []int64) bson.E {
-	// Is set not provided
-	if len(set) != 0 {
-		// Build query from set of values
-		return bson.E{field, bson.D{bson.E{`$in`, set}}}
+func filterMakePermExpr(perm int64, match dbms.PermMatch) bson.E {
+	if match == dbms.PermExact {
+		return bson.E{dbms.FieldMode, perm}
 	}
 
-	//
-	// The set of search values is not provided, then make range min..max query
-	//
+	// All bits from perm should be set
+	return bson.E{dbms.FieldMode, bson.D{bson.E{`$bitsAllSet`, perm}}}
+}
 
-	// If closed interval
-	if min != 0 && max != 0 {
-		return bson.E{field, bson.D{
-			{`$gte`, min},	// greater or equal then min
-			{`$lte`, max},	// lesser or equal then max
-		}}
+// filterMakeMIMEExpr makes expression to match content types, the patterns like "type/*" match all subtypes
+func filterMakeMIMEExpr(mimes []string) bson.E {
+	// $in operator accepts both exact values and regular expressions
+	vals := make(bson.A, 0, len(mimes))
+	for _, mime := range mimes {
+		if prefix, ok := dbms.MIMEWildcard(mime); ok {
+			vals = append(vals, primitive.Regex{Pattern: `^` + regexp.QuoteMeta(prefix)})
+		} else {
+			vals = append(vals, mime)
+		}
 	}
 
-	// Half-open interval
-
-	if min == 0 {
-		// Only the top value of the range specified
-		return bson.E{field, bson.D{
-			{`$lte`, max},	// lesser or equal then max
-		}}
-	}
+	return bson.E{dbms.FieldMIME, bson.D{bson.E{`$in`, vals}}}
+}
 
-	// Only the bottom value of the range specified
-	return bson.E{field, bson.D{
-		{`$gte`, min},	// greater or equal then min
+// filterMakePointsToExpr makes expression to match symbolic links pointing to the path or into it
+func filterMakePointsToExpr(target string) bson.E {
+	// Both conditions form the single expression to be correctly processed by logical operators
+	return bson.E{`$and`, bson.A{
+		bson.D{bson.E{dbms.FieldType, types.ObjSymlink}},
+		bson.D{bson.E{dbms.FieldRPath, primitive.Regex{
+			Pattern: `^(?:` + regexp.QuoteMeta(target) + `$|` + regexp.QuoteMeta(dbms.PathPrefix(target)) + `)`,
+		}}},
 	}}
 }
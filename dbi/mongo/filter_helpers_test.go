@@ -0,0 +1,108 @@
+package mongo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/r-che/dfi/types/dbms"
+)
+
+// textSearchItems splits the search string of the $text operator to phrases and terms the same way as
+// MongoDB does, it reports negated items and unclosed quotes as errors
+func textSearchItems(t *testing.T, search string) []string {
+	t.Helper()
+
+	items := []string{}
+	inPhrase, prevSpace := false, true
+	var item strings.Builder
+
+	flush := func() {
+		if item.Len() != 0 {
+			items = append(items, item.String())
+			item.Reset()
+		}
+	}
+
+	for _, r := range search {
+		switch {
+		case r == '"':
+			flush()
+			inPhrase = !inPhrase
+		case inPhrase:
+			item.WriteRune(r)
+		case textSearchSpace(r):
+			flush()
+		case r == '-' && prevSpace:
+			t.Fatalf("search string %q contains negated item", search)
+		default:
+			item.WriteRune(r)
+		}
+		prevSpace = textSearchSpace(r)
+	}
+	if inPhrase {
+		t.Fatalf("search string %q contains unclosed quotes", search)
+	}
+	flush()
+
+	return items
+}
+
+func FuzzTextSearchString(f *testing.F) {
+	for _, seed := range []string{
+		``, `x`, `snow ball`, `-x`, `--x`, `x -y`, `"x" -"y"`, `"`, `""`, `x"-y`, `-"x"`, "\t-x\n",
+		`\"x\"`, `report-2020.pdf`, "\x00-", "\xff", `Ünïcödé ßnow`,
+	} {
+		f.Add(seed, false)
+		f.Add(seed, true)
+	}
+
+	f.Fuzz(func(t *testing.T, sp string, literal bool) {
+		qa := &dbms.QueryArgs{SP: []string{sp, "x"}}
+		qa.LiteralSP = literal
+
+		search, err := textSearchString(qa)
+		if err != nil {
+			return
+		}
+
+		// Each item must be a part of the phrase, nothing is added. Invalid
+		// UTF-8 bytes are replaced the same way as by splitting to items
+		words := strings.Join(strings.FieldsFunc(string([]rune(sp)), textSearchSpace), " ")
+		items := textSearchItems(t, search)
+		for _, item := range items[:len(items)-1] {
+			if !strings.Contains(words, item) {
+				t.Errorf("search string %q contains item %q that is not a part of the phrase %q", search, item, sp)
+			}
+		}
+		if items[len(items)-1] != "x" {
+			t.Errorf("phrase %q changed the next phrase, search string: %q", sp, search)
+		}
+	})
+}
+
+func TestTextSearchString(t *testing.T) {
+	tests := []struct {
+		sp		[]string
+		literal	bool
+		raw		bool
+		want	string
+	} {
+		{ sp: []string{`snow`, `snow ball`}, want: `snow "snow ball"` },
+		{ sp: []string{`snow`, `-ball`}, literal: true, want: `"snow" "-ball"` },
+		{ sp: []string{`-snow`, `say "hi there" -x`}, want: `snow say "hi there" x` },
+		{ sp: []string{`snow -ball`, `"x y"`}, raw: true, want: `snow -ball "x y"` },
+	}
+
+	for i, test := range tests {
+		qa := &dbms.QueryArgs{SP: test.sp}
+		qa.LiteralSP, qa.RawQuery = test.literal, test.raw
+
+		if got, err := textSearchString(qa); err != nil || got != test.want {
+			t.Errorf("[%d] want %s, got %s (error: %v)", i, test.want, got, err)
+		}
+	}
+
+	if _, err := textSearchString(&dbms.QueryArgs{SP: []string{`" - "`}}); err == nil {
+		t.Errorf("textSearchString returned no error for the phrase without words")
+	}
+}
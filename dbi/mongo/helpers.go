@@ -1,6 +1,7 @@
 package mongo
 
 import (
+	"errors"
 	"fmt"
 	"unicode/utf8"
 
@@ -15,6 +16,9 @@ import (
 const (
 	minNameTextMatchScore = 200	// empiric value
 	scorePseudoFieldName = `__score__`
+
+	// Error code returned by MongoDB for operations not allowed in the current configuration
+	mongoCodeIllegalOperation = 20
 )
 
 func pipelineConfVariadic(filter *Filter, pipeline mongo.Pipeline, configs []any) mongo.Pipeline {
@@ -252,3 +256,19 @@ func fillAII(retFields []string, fields dbms.QRItem) (*dbms.AIIArgs, error) {
 
 	return &aii, nil
 }
+
+// Failures of the separate update operations of the batch
+type updateErrors struct {
+	failed	map[int]bool	// indexes of failed operations in the update queue
+	opErrs	dbms.OpErrors
+}
+func (ue *updateErrors) Error() string {
+	return ue.opErrs.Error()
+}
+
+// isTxnNotSupported returns true if err means that the server does not support transactions
+func isTxnNotSupported(err error) bool {
+	var ce mongo.CommandError
+	// IllegalOperation code is returned by standalone servers
+	return errors.As(err, &ce) && ce.Code == mongoCodeIllegalOperation
+}
@@ -0,0 +1,238 @@
+package mongo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/r-che/dfi/dbi/common"
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// State of the object recorded to the history
+type histItem struct {
+	fpath	string
+	state	*common.ObjState
+}
+
+// Document of the history collection
+type histDoc struct {
+	Time		int64	`bson:"time"`
+	Change		string	`bson:"change"`
+	ID			string	`bson:"oid"`
+	Host		string	`bson:"host"`
+	FPath		string	`bson:"fpath"`
+	OldSize		int64	`bson:"osize"`
+	NewSize		int64	`bson:"nsize"`
+	OldChecksum	string	`bson:"ocsum"`
+	NewChecksum	string	`bson:"ncsum"`
+}
+
+func (mc *Client) GetHistory(ha *dbms.HistArgs) (dbms.HistResults, error) {
+	// Get collection handler
+	coll := mc.c.Database(mc.Cfg.ID).Collection(MongoHistColl)
+
+	cursor, err := coll.Find(mc.Ctx, histFilter(ha), options.Find().SetSort(bson.D{{dbms.HistFieldTime, 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("(MongoCli:GetHistory) find on %s.%s failed: %w",
+			coll.Database().Name(), coll.Name(), err)
+	}
+	defer func() {
+		if err := cursor.Close(mc.Ctx); err != nil {
+			log.E("(MongoCli:GetHistory) cannot close cursor: %v", err)
+		}
+	}()
+
+	// Keep current termLong value to have ability to compare during long-term operations
+	initTermLong := mc.TermLongVal
+
+	hr := dbms.HistResults{}
+	for cursor.Next(mc.Ctx) {
+		// If value of the termLong was updated - need to terminate long-term operation
+		if mc.TermLongVal != initTermLong {
+			return nil, fmt.Errorf("(MongoCli:GetHistory) terminated")
+		}
+
+		var doc histDoc
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("(MongoCli:GetHistory) cannot decode cursor item: %w", err)
+		}
+
+		hr = append(hr, &dbms.HistRecord{
+			Time:			doc.Time,
+			Change:			doc.Change,
+			ID:				doc.ID,
+			Host:			doc.Host,
+			FPath:			doc.FPath,
+			OldSize:		doc.OldSize,
+			OldChecksum:	doc.OldChecksum,
+			NewSize:		doc.NewSize,
+			NewChecksum:	doc.NewChecksum,
+		})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("(MongoCli:GetHistory) cursor failed: %w", err)
+	}
+
+	hr.Sort()
+
+	// OK
+	return hr, nil
+}
+
+func histFilter(ha *dbms.HistArgs) bson.D {
+	filter := bson.D{}
+
+	if ha.Since > 0 {
+		filter = append(filter, bson.E{dbms.HistFieldTime, bson.D{{`$gte`, ha.Since}}})
+	}
+
+	if ha.IsHosts() {
+		filter = append(filter, bson.E{dbms.HistFieldHost, bson.D{{`$in`, ha.Hosts}}})
+	}
+
+	// Record matches objects if any of identifiers or paths is matched
+	objs := bson.A{}
+	if ha.IsIds() {
+		objs = append(objs, bson.D{{dbms.HistFieldOID, bson.D{{`$in`, ha.Ids}}}})
+	}
+	if ha.IsPaths() {
+		objs = append(objs, bson.D{{dbms.HistFieldFPath, bson.D{{`$in`, ha.Paths}}}})
+	}
+	if len(objs) != 0 {
+		filter = append(filter, bson.E{`$or`, objs})
+	}
+
+	return filter
+}
+
+// loadObjStates returns states of the queued to changes objects stored in the database,
+// indexed by identifiers, identifiers of not existing objects are skipped
+func (mc *Client) loadObjStates() (map[string]*histItem, error) {
+	// Get collection handler
+	coll := mc.c.Database(mc.Cfg.ID).Collection(MongoObjsColl)
+
+	ids := make([]string, 0, len(mc.toUpdateIds) + len(mc.toDelete))
+	ids = append(append(ids, mc.toUpdateIds...), mc.toDelete...)
+
+	states := make(map[string]*histItem, len(ids))
+
+	// Load states by chunks of identifiers
+	chunkSize := mc.ChunkSize(MongoDefaultChunkSize)
+	for start := 0; start < len(ids); start += chunkSize {
+		end := common.ChunkEnd(start, chunkSize, len(ids))
+
+		cursor, err := coll.Find(mc.Ctx,
+			bson.D{{MongoFieldID, bson.D{{`$in`, ids[start:end]}}}},
+			options.Find().SetProjection(bson.D{
+				{dbms.FieldFPath, 1},
+				{dbms.FieldSize, 1},
+				{dbms.FieldChecksum, 1},
+			}),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load states of %d objects from %s.%s: %w",
+				end - start, coll.Database().Name(), coll.Name(), err)
+		}
+
+		var docs []struct {
+			ID			string	`bson:"_id"`
+			FPath		string	`bson:"fpath"`
+			Size		int64	`bson:"size"`
+			Checksum	string	`bson:"csum"`
+		}
+		if err := cursor.All(mc.Ctx, &docs); err != nil {
+			return nil, fmt.Errorf("cannot decode states of objects from %s.%s: %w",
+				coll.Database().Name(), coll.Name(), err)
+		}
+
+		for _, doc := range docs {
+			states[doc.ID] = &histItem{
+				fpath:	doc.FPath,
+				state:	&common.ObjState{Size: doc.Size, Checksum: doc.Checksum},
+			}
+		}
+	}
+
+	// OK
+	return states, nil
+}
+
+// storeHistory stores records about the committed changes to the history, before contains states of
+// objects before the changes. Records older than the retention period are removed
+func (mc *Client) storeHistory(before map[string]*histItem) error {
+	// Get collection handler
+	coll := mc.c.Database(mc.Cfg.ID).Collection(MongoHistColl)
+
+	now := time.Now().Unix()
+
+	docs := make([]any, 0, len(mc.toUpdateIds) + len(mc.toDelete))
+	appendRecord := func(fpath string, before, after *common.ObjState) {
+		hr := common.HistRecord(now, mc.Cfg.CliHost, fpath, before, after)
+		if hr == nil {
+			// Nothing was changed
+			return
+		}
+
+		docs = append(docs, &histDoc{
+			Time:			hr.Time,
+			Change:			hr.Change,
+			ID:				hr.ID,
+			Host:			hr.Host,
+			FPath:			hr.FPath,
+			OldSize:		hr.OldSize,
+			NewSize:		hr.NewSize,
+			OldChecksum:	hr.OldChecksum,
+			NewChecksum:	hr.NewChecksum,
+		})
+	}
+
+	for i, id := range mc.toUpdateIds {
+		var state *common.ObjState
+		if item, ok := before[id]; ok {
+			state = item.state
+		}
+		appendRecord(mc.toUpdateHist[i].fpath, state, mc.toUpdateHist[i].state)
+	}
+	for _, id := range mc.toDelete {
+		// Only objects existed before the commit were deleted
+		if item, ok := before[id]; ok {
+			appendRecord(item.fpath, item.state, nil)
+		}
+	}
+
+	// Insert records by chunks
+	chunkSize := mc.ChunkSize(MongoDefaultChunkSize)
+	for start := 0; start < len(docs); start += chunkSize {
+		end := common.ChunkEnd(start, chunkSize, len(docs))
+
+		if _, err := coll.InsertMany(mc.Ctx, docs[start:end], options.InsertMany().SetOrdered(false)); err != nil {
+			return fmt.Errorf("cannot insert %d records to %s.%s: %w",
+				end - start, coll.Database().Name(), coll.Name(), err)
+		}
+	}
+
+	// Check for the retention period
+	since := mc.HistSince(now)
+	if since == 0 {
+		// OK, records are kept forever
+		return nil
+	}
+
+	res, err := coll.DeleteMany(mc.Ctx, bson.D{
+		{dbms.HistFieldHost, mc.Cfg.CliHost},
+		{dbms.HistFieldTime, bson.D{{`$lt`, since}}},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot remove outdated records from %s.%s: %w",
+			coll.Database().Name(), coll.Name(), err)
+	}
+	log.D("(MongoCli:storeHistory) %d outdated records removed from the history", res.DeletedCount)
+
+	// OK
+	return nil
+}
@@ -0,0 +1,133 @@
+package mongo
+
+import (
+	"fmt"
+
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (mc *Client) UpdateHost(hi *dbms.HostInfo) error {
+	if mc.ReadOnly {
+		log.D("(MongoCli:UpdateHost) R/O mode IS SET, information about host %q will not be stored", hi.Host)
+		return nil
+	}
+
+	// Count indexed objects of the host
+	objs := mc.c.Database(mc.Cfg.ID).Collection(MongoObjsColl)
+	n, err := objs.CountDocuments(mc.Ctx, bson.D{{dbms.FieldHost, hi.Host}})
+	if err != nil {
+		return fmt.Errorf("(MongoCli:UpdateHost) cannot count objects of host %q in %s.%s: %w",
+			hi.Host, objs.Database().Name(), objs.Name(), err)
+	}
+	hi.Objects = n
+
+	// Get collection handler
+	coll := mc.c.Database(mc.Cfg.ID).Collection(MongoMetaColl)
+
+	// Fields of the agent metadata set by other operations are kept as is
+	_, err = coll.UpdateOne(mc.Ctx,
+		bson.D{{MongoFieldID, hi.Host}},
+		bson.D{{`$set`, bson.D{
+			{dbms.MetaFieldVersion,		hi.Version},
+			{dbms.MetaFieldPaths,		hi.IdxPaths},
+			{dbms.MetaFieldCalcSums,	hi.CalcSums},
+			{dbms.MetaFieldMaxSumSize,	hi.MaxSumSize},
+			{dbms.MetaFieldStarted,		hi.Started},
+			{dbms.MetaFieldHeartbeat,	hi.Heartbeat},
+			{dbms.MetaFieldHBPeriod,	hi.HBPeriod},
+			{dbms.MetaFieldLastFlush,	hi.LastFlush},
+			{dbms.MetaFieldObjects,		hi.Objects},
+		}}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("(MongoCli:UpdateHost) cannot store information about host %q to %s.%s: %w",
+			hi.Host, coll.Database().Name(), coll.Name(), err)
+	}
+
+	// OK
+	return nil
+}
+
+func (mc *Client) GetHosts() ([]*dbms.HostInfo, error) {
+	// Get collection handler
+	coll := mc.c.Database(mc.Cfg.ID).Collection(MongoMetaColl)
+
+	cursor, err := coll.Find(mc.Ctx, bson.D{})
+	if err != nil {
+		return nil, fmt.Errorf("(MongoCli:GetHosts) find on %s.%s failed: %w",
+			coll.Database().Name(), coll.Name(), err)
+	}
+	defer func() {
+		if err := cursor.Close(mc.Ctx); err != nil {
+			log.E("(MongoCli:GetHosts) cannot close cursor: %v", err)
+		}
+	}()
+
+	hosts := []*dbms.HostInfo{}
+	for cursor.Next(mc.Ctx) {
+		var meta bson.M
+		if err := cursor.Decode(&meta); err != nil {
+			return nil, fmt.Errorf("(MongoCli:GetHosts) cannot decode cursor item: %w", err)
+		}
+
+		host, ok := meta[MongoFieldID].(string)
+		if !ok {
+			log.W("(MongoCli:GetHosts) Skip metadata with invalid identifier: %#v", meta[MongoFieldID])
+			continue
+		}
+
+		hosts = append(hosts, hostInfoFromMeta(host, meta))
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("(MongoCli:GetHosts) cursor failed: %w", err)
+	}
+
+	// OK
+	return hosts, nil
+}
+
+// hostInfoFromMeta converts the agent metadata of the host to the host information, hosts registered by
+// old agents have only some of fields, so missing and invalid fields are left with zero values
+func hostInfoFromMeta(host string, meta bson.M) *dbms.HostInfo {
+	hi := &dbms.HostInfo{Host: host}
+
+	hi.Version, _ = meta[dbms.MetaFieldVersion].(string)
+	hi.CalcSums, _ = meta[dbms.MetaFieldCalcSums].(bool)
+
+	if paths, ok := meta[dbms.MetaFieldPaths].(primitive.A); ok {
+		for _, path := range paths {
+			if path, ok := path.(string); ok {
+				hi.IdxPaths = append(hi.IdxPaths, path)
+			}
+		}
+	}
+
+	for field, v := range map[string]*int64{
+		dbms.MetaFieldMaxSumSize:	&hi.MaxSumSize,
+		dbms.MetaFieldStarted:		&hi.Started,
+		dbms.MetaFieldHeartbeat:	&hi.Heartbeat,
+		dbms.MetaFieldHBPeriod:		&hi.HBPeriod,
+		dbms.MetaFieldLastFlush:	&hi.LastFlush,
+		dbms.MetaFieldObjects:		&hi.Objects,
+	} {
+		switch val := meta[field].(type) {
+		case int64:
+			*v = val
+		case int32:
+			*v = int64(val)
+		case nil:
+			// Field is not set
+		default:
+			log.W("(MongoCli:hostInfoFromMeta) Invalid type %T of field %q of host %q", val, field, host)
+		}
+	}
+
+	return hi
+}
@@ -19,6 +19,26 @@ For example, to authenticate using SCRAM-SHA-1 you must provide the following ob
 
 See the component's README file, the [official reference], and the [Go-example].
 
+# TLS configuration
+
+Connections to the MongoDB server are encrypted if the same object contains the "TLS" object
+with the CA bundle to verify the server, the client certificate and key to authenticate by
+the server (both are optional, e.g. for the MONGODB-X509 mechanism) and the name of the server:
+
+  {
+      "AuthMechanism": "SCRAM-SHA-1",
+      "Username": "USER-NAME",
+      "Password": "USER-PASSWORD",
+      "TLS": {
+          "CAFile": "/etc/dfi/ca.pem",
+          "CertFile": "/etc/dfi/client.pem",
+          "KeyFile": "/etc/dfi/client.key",
+          "ServerName": "mongo.example.com"
+      }
+  }
+
+The "InsecureSkipVerify" field disables verification of the server certificate, use it only for testing.
+
 [official reference]: https://www.mongodb.com/docs/manual/core/authentication/
 [Go-example]: https://www.mongodb.com/docs/drivers/go/current/fundamentals/auth/
 */
@@ -33,7 +53,7 @@ import (
 
 //	"github.com/r-che/dfi/types"
 	"github.com/r-che/dfi/types/dbms"
-//	"github.com/r-che/dfi/dbi/common"
+	"github.com/r-che/dfi/dbi/common"
 
 	"github.com/r-che/log"
 
@@ -45,6 +65,12 @@ const (
 	MongoFieldID		=	"_" + dbms.FieldID
 	MongoObjsColl		=	"objs"
 	MongoAIIColl		=	"aii"
+	MongoMetaColl		=	"meta"
+	MongoContentColl	=	"content"
+	MongoHistColl		=	"history"
+
+	// Default number of operations sent to MongoDB in a single bulk write
+	MongoDefaultChunkSize	=	1000
 )
 
 
@@ -62,7 +88,12 @@ type Client struct {
 	c	*mongo.Client
 
 	// Dynamic members
+	toUpdate	[]mongo.WriteModel
+	toUpdateIds	[]string
+	toContent	[]mongo.WriteModel	// content operations, the same indexes as operations in toUpdate
+	toUpdateHist	[]*histItem		// states of updated objects, the same indexes as operations in toUpdate
 	toDelete	[]string
+	noTxn		bool	// transactions are not supported by the server
 	updated		int64
 	deleted		int64
 }
@@ -90,7 +121,15 @@ func NewClient(dbCfg *dbms.DBConfig) (*Client, error) {
 		return nil, err
 	}
 
-	var err error
+	// Check for TLS settings
+	tlsCfg, err := common.TLSConfig(dbCfg.PrivCfg)
+	if err != nil {
+		return nil, fmt.Errorf("(MongoCli:NewClient) failed to load TLS settings: %w", err)
+	}
+	if tlsCfg != nil {
+		opts.SetTLSConfig(tlsCfg)
+	}
+
 	if mc.c, err = mongo.Connect(mc.Ctx, opts); err != nil {
 		return nil, fmt.Errorf("(MongoCli:NewClient) cannot create new client to %s: %w", dbCfg.HostPort, err)
 	}
@@ -143,6 +182,9 @@ func parsePrivCfg(pcf map[string]any) (creds *options.Credential, err error) {
 	// https://pkg.go.dev/go.mongodb.org/mongo-driver/mongo/options#Credential
 	creds = &options.Credential{}
 
+	// Number of set fields, the configuration may contain only TLS settings
+	nSet := 0
+
 	s := reflect.ValueOf(creds).Elem()
 	for i := 0; i < s.NumField(); i++ {
 		// Get the field name
@@ -156,6 +198,11 @@ func parsePrivCfg(pcf map[string]any) (creds *options.Credential, err error) {
 
 		// Set the field value
 		s.Field(i).Set(reflect.ValueOf(v))
+		nSet++
+	}
+
+	if nSet == 0 {
+		return nil, errNoPrivCfg
 	}
 
 	return creds, nil
@@ -0,0 +1,162 @@
+package mongo
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+
+	"github.com/r-che/dfi/common/tools"
+)
+
+// Range of surrogates of UTF-16
+const (
+	surrFirst	=	rune(0xd800)
+	surrLast	=	rune(0xdfff)
+)
+
+// Subpatterns of PCRE with the same semantics as operators of Go regular expressions have
+const (
+	pcreNoMatch		=	`[^\x{0}-\x{10ffff}]`
+	pcreAnyChar		=	`[\s\S]`
+	pcreBeginLine	=	`(?:^|(?<=\n))`
+	pcreEndLine		=	`(?=\n|\z)`
+	// Word boundaries of Go regular expressions are ASCII-only regardless of options of PCRE
+	pcreWordChar	=	`[0-9A-Za-z_]`
+	pcreWordBound	=	`(?:(?<=` + pcreWordChar + `)(?!` + pcreWordChar + `)|(?<!` + pcreWordChar + `)(?=` + pcreWordChar + `))`
+	pcreNoWordBound	=	`(?:(?<=` + pcreWordChar + `)(?=` + pcreWordChar + `)|(?<!` + pcreWordChar + `)(?!` + pcreWordChar + `))`
+)
+
+// pcreRegexp translates the regular expression in the Go syntax to the PCRE pattern used by MongoDB, which
+// matches the same strings. Operators that differ between dialects, e.g. "$", case folding and word boundaries,
+// are replaced by explicit equivalents, so results of the search do not depend on the DBMS
+func pcreRegexp(expr string) (string, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return "", fmt.Errorf("(MongoCli:pcreRegexp) invalid regular expression %q: %w", expr, err)
+	}
+
+	var b strings.Builder
+	pcreWrite(&b, re)
+
+	// OK
+	return b.String(), nil
+}
+
+// pcreWrite writes the PCRE equivalent of the parsed regular expression, only the fact of matching
+// is kept - capturing groups are not numbered the same way and repetitions are always greedy
+//
+//nolint:cyclop	// Each operator is simple, splitting does not make it clearer
+func pcreWrite(b *strings.Builder, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		b.WriteString(pcreNoMatch)
+	case syntax.OpEmptyMatch:
+		b.WriteString(`(?:)`)
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 {
+				pcreWriteFold(b, r)
+			} else {
+				pcreWriteRune(b, r)
+			}
+		}
+	case syntax.OpCharClass:
+		pcreWriteClass(b, re.Rune)
+	case syntax.OpAnyCharNotNL:
+		b.WriteString(`[^\n]`)
+	case syntax.OpAnyChar:
+		b.WriteString(pcreAnyChar)
+	case syntax.OpBeginLine:
+		b.WriteString(pcreBeginLine)
+	case syntax.OpEndLine:
+		b.WriteString(pcreEndLine)
+	case syntax.OpBeginText:
+		b.WriteString(`\A`)
+	case syntax.OpEndText:
+		// Unlike "$" of PCRE, it does not match before the trailing new line
+		b.WriteString(`\z`)
+	case syntax.OpWordBoundary:
+		b.WriteString(pcreWordBound)
+	case syntax.OpNoWordBoundary:
+		b.WriteString(pcreNoWordBound)
+	case syntax.OpCapture:
+		pcreWriteGroup(b, re.Sub[0])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest:
+		pcreWriteGroup(b, re.Sub[0])
+		b.WriteString(map[syntax.Op]string{syntax.OpStar: `*`, syntax.OpPlus: `+`, syntax.OpQuest: `?`}[re.Op])
+	case syntax.OpRepeat:
+		pcreWriteGroup(b, re.Sub[0])
+		if re.Max == -1 {
+			fmt.Fprintf(b, `{%d,}`, re.Min)
+		} else {
+			fmt.Fprintf(b, `{%d,%d}`, re.Min, re.Max)
+		}
+	case syntax.OpConcat:
+		b.WriteString(`(?:`)
+		for _, sub := range re.Sub {
+			pcreWrite(b, sub)
+		}
+		b.WriteString(`)`)
+	case syntax.OpAlternate:
+		b.WriteString(`(?:`)
+		for i, sub := range re.Sub {
+			if i != 0 {
+				b.WriteString(`|`)
+			}
+			pcreWrite(b, sub)
+		}
+		b.WriteString(`)`)
+	default:
+		// All operators produced by the parser are handled above
+		panic(fmt.Sprintf("Unsupported operator of regular expression %v", re.Op))
+	}
+}
+
+func pcreWriteGroup(b *strings.Builder, re *syntax.Regexp) {
+	b.WriteString(`(?:`)
+	pcreWrite(b, re)
+	b.WriteString(`)`)
+}
+
+func pcreWriteRune(b *strings.Builder, r rune) {
+	fmt.Fprintf(b, `\x{%x}`, r)
+}
+
+// pcreWriteFold writes the class of all characters equivalent to r under simple case folding, like Go does
+func pcreWriteFold(b *strings.Builder, r rune) {
+	b.WriteString(`[`)
+	pcreWriteRune(b, r)
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		pcreWriteRune(b, f)
+	}
+	b.WriteString(`]`)
+}
+
+// pcreWriteClass writes the class of sorted ranges of characters, surrogates
+// are excluded from ranges because they are not valid characters of UTF-8
+func pcreWriteClass(b *strings.Builder, ranges []rune) {
+	var chunks strings.Builder
+	for i := 0; i+1 < len(ranges); i += 2 {
+		for _, rg := range [][2]rune{
+			{ranges[i], tools.Tern(ranges[i+1] < surrFirst, ranges[i+1], surrFirst - 1)},
+			{tools.Tern(ranges[i] > surrLast, ranges[i], surrLast + 1), ranges[i+1]},
+		} {
+			if rg[0] > rg[1] {
+				continue
+			}
+			pcreWriteRune(&chunks, rg[0])
+			if rg[0] != rg[1] {
+				chunks.WriteString(`-`)
+				pcreWriteRune(&chunks, rg[1])
+			}
+		}
+	}
+
+	if chunks.Len() == 0 {
+		b.WriteString(pcreNoMatch)
+		return
+	}
+
+	b.WriteString(`[` + chunks.String() + `]`)
+}
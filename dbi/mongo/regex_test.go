@@ -0,0 +1,66 @@
+package mongo
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPcreRegexp(t *testing.T) {
+	tests := []struct {
+		expr	string
+		want	string
+	} {
+		// The dollar does not match before the trailing new line
+		{ `a$`, `(?:\x{61}\z)` },
+		// Case folding includes the Kelvin sign like Go does
+		{ `(?i)k`, `[\x{4b}\x{6b}\x{212a}]` },
+		{ `^[a-c]+\.(jpe?g|png)`, `(?:\A(?:[\x{61}-\x{63}])+\x{2e}(?:(?:(?:\x{6a}\x{70}(?:\x{65})?\x{67})|\x{70}\x{6e}\x{67})))` },
+		{ `(?m)^x.`, `(?:` + pcreBeginLine + `\x{78}[^\n])` },
+		{ `\bx{2,}`, `(?:` + pcreWordBound + `(?:\x{78}){2,})` },
+		{ `[^\x00-\x{10FFFF}]`, pcreNoMatch },
+	}
+
+	for i, test := range tests {
+		if got, err := pcreRegexp(test.expr); err != nil || got != test.want {
+			t.Errorf("[%d] pcreRegexp(%q) - want %s, got %s (error: %v)", i, test.expr, test.want, got, err)
+		}
+	}
+
+	if _, err := pcreRegexp(`(unclosed`); err == nil {
+		t.Errorf("pcreRegexp returned no error for the invalid regular expression")
+	}
+}
+
+func FuzzPcreRegexp(f *testing.F) {
+	for _, seed := range []string{
+		`a$`, `(?i)straße`, `^IMG_\d+\.jpe?g$`, `(?s).+`, `[^/]*\.iso`, `\p{Cyrillic}+`, `a{2,5}|b*?`, `(?U)x+`, `\Qa.b\E`,
+	} {
+		f.Add(seed, "IMG_01.jpg\nstraSSe.iso")
+	}
+
+	f.Fuzz(func(t *testing.T, expr, s string) {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return
+		}
+
+		pcre, err := pcreRegexp(expr)
+		if err != nil {
+			t.Fatalf("pcreRegexp(%q) returned unexpected error: %v", expr, err)
+		}
+
+		// Lookarounds are not supported by Go, other constructs of the translated pattern can be checked by it
+		if strings.Contains(pcre, `(?<`) || strings.Contains(pcre, `(?=`) || strings.Contains(pcre, `(?!`) {
+			return
+		}
+		tre, err := regexp.Compile(pcre)
+		if err != nil {
+			t.Fatalf("pattern %s translated from %q is invalid: %v", pcre, expr, err)
+		}
+
+		if re.MatchString(s) != tre.MatchString(s) {
+			t.Errorf("pattern %s translated from %q matches %q differently", pcre, expr, s)
+		}
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/r-che/dfi/types"
 	"github.com/r-che/dfi/common/tools"
+	"github.com/r-che/dfi/dbi/common"
 	"github.com/r-che/dfi/types/dbms"
 
 	"github.com/r-che/log"
@@ -20,15 +21,16 @@ func (rc *Client) UpdateObj(fso *types.FSObject) error {
 		// Read-only database mode, do nothing
 		log.W("(RedisCli:UpdateObj) R/O mode IS SET, will not be performed: HSET => %s\n", key)
 	} else {
-		log.D("(RedisCli:UpdateObj) HSET => %s\n", key)
-		// Do real update
-		res := rc.c.HSet(rc.Ctx, key, prepareHSetValues(rc.Cfg.CliHost, fso))
-		if err := res.Err(); err != nil {
-			return fmt.Errorf("(RedisCli:UpdateObj) HSET of key %q returned error: %w", key, err)
-		}
+		log.D("(RedisCli:UpdateObj) HSET (pending) => %s\n", key)
 	}
 
-	rc.updated++
+	// XXX Append item to update regardless of R/O mode because it will be skipped in the Commit() operation
+	rc.toUpdate = append(rc.toUpdate, &hsetItem{
+		key:		key,
+		values:		prepareHSetValues(rc.Cfg.CliHost, fso),
+		content:	prepareContentValues(fso.Content),
+		state:		&common.ObjState{Size: fso.Size, Checksum: fso.Checksum},
+	})
 
 	// OK
 	return nil
@@ -58,6 +60,13 @@ func (rc *Client) DeleteFPathPref(fso *types.FSObject) (int64, error) {
 	// Keys to delete using prefix
 	toDel := []string{}
 
+	// Objects queued for update in the same batch must not be deleted,
+	// it allows to replace a set of objects by prefix, e.g. members of an archive
+	queued := make(map[string]bool, len(rc.toUpdate))
+	for _, item := range rc.toUpdate {
+		queued[item.key] = true
+	}
+
 	// Load keys
 	err := rc.loadKeysByPrefix(pref,
 	// Append found key to the list of keys to delete
@@ -69,6 +78,11 @@ func (rc *Client) DeleteFPathPref(fso *types.FSObject) (int64, error) {
 			panic(fmt.Sprintf("(RedisCli:DeleteFPathPref:appender) non-string key: %#v", value))
 		}
 
+		if queued[key] {
+			// Skip object updated in this batch
+			return nil
+		}
+
 		toDel = append(toDel, key)
 
 		// OK
@@ -90,55 +104,245 @@ func (rc *Client) DeleteFPathPref(fso *types.FSObject) (int64, error) {
 	return int64(len(toDel)), nil
 }
 
-func (rc *Client) Commit() (int64, int64, error) {
+func (rc *Client) Commit(seq int64) (int64, int64, error) {
 	// Reset state on return
 	defer func() {
 		// Reset counters
 		rc.updated = 0
 		rc.deleted = 0
-		// Reset list to delete
+		// Reset lists of queued data
+		rc.toUpdate = nil
 		rc.toDelete = nil
 	}()
 
-	var err error
+	if rc.ReadOnly {
+		return rc.commitDryRun(seq)
+	}
 
-	// Check for keys to delete
-	if nDel := len(rc.toDelete); nDel != 0 {
-		log.D("(RedisCli:Commit) Need to delete %d keys", nDel)
+	// Make a list of commands to execute in a single transaction
+	cmds := rc.makeTxCmds(seq)
 
-		deleted, err := rc.performDelete()
-		if err != nil {
-			return 0, deleted, fmt.Errorf("(RedisCli:Commit) delete failed: %w", err)
+	// Records of the history are appended in the same transaction
+	if rc.Cfg.History {
+		if hist, err := rc.makeHistCmds(); err != nil {
+			log.E("(RedisCli:Commit) Changes will not be recorded to the history: %v", err)
+		} else {
+			cmds = append(cmds, hist...)
 		}
+	}
 
-		rc.deleted += deleted
+	// Subscribers are notified about changes by the same transaction
+	if pub, err := rc.makePublishCmds(); err != nil {
+		log.E("(RedisCli:Commit) Changes will not be published: %v", err)
+	} else {
+		cmds = append(cmds, pub...)
+	}
+	if len(cmds) == 0 {
+		// Nothing to commit
+		return 0, 0, nil
+	}
 
-		log.D("(RedisCli:Commit) Done deletion operation with results: %v",
-			tools.Tern[any](err == nil, "no errors", err))
+	log.D("(RedisCli:Commit) Need to update %d keys, delete %d keys, batch sequence number: %d",
+		len(rc.toUpdate), len(rc.toDelete), seq)
+
+	opErrs, err := rc.execTx(cmds)
+	if err != nil {
+		return 0, 0, fmt.Errorf("(RedisCli:Commit) transaction failed: %w", err)
 	}
 
+	log.D("(RedisCli:Commit) Transaction done, %d keys updated, %d keys deleted, %d operations failed",
+		rc.updated, rc.deleted, len(opErrs))
+
 	// XXX Use intermediate variables to avoid resetting return values by deferred function
 	ru, rd := rc.updated, rc.deleted
 
-	return ru, rd, err
+	// Check for failed operations
+	if len(opErrs) != 0 {
+		return ru, rd, opErrs
+	}
+
+	return ru, rd, nil
 }
 
-func (rc *Client) performDelete() (int64, error) {
-	if rc.ReadOnly {
-		return rc.deleteDryRun()
+func (rc *Client) LastBatchSeq() (int64, error) {
+	seq, err := rc.c.HGet(rc.Ctx, rc.metaKey(), dbms.MetaFieldBatchSeq).Int64()
+	if err != nil {
+		if errors.Is(err, RedisNotFound) {
+			// No batches were committed by this host
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("(RedisCli:LastBatchSeq) cannot get field %q of key %q: %w",
+			dbms.MetaFieldBatchSeq, rc.metaKey(), err)
+	}
+
+	// OK
+	return seq, nil
+}
+
+func (rc *Client) metaKey() string {
+	return RedisMetaPrefix + rc.Cfg.CliHost
+}
+
+func (rc *Client) commitDryRun(seq int64) (int64, int64, error) {
+	// Read-only database mode, all queued updates were already reported by UpdateObj()
+	updated := int64(len(rc.toUpdate))
+
+	if seq != 0 {
+		log.W("(RedisCli:Commit) R/O mode IS SET, batch sequence number %d will not be stored", seq)
 	}
 
-	// Delete all keys from rc.toDelete slice
-	res := rc.c.Del(rc.Ctx, rc.toDelete...)
+	// Check for keys to delete
+	if len(rc.toDelete) == 0 {
+		return updated, 0, nil
+	}
+
+	deleted, err := rc.deleteDryRun()
+	if err != nil {
+		return updated, deleted, fmt.Errorf("(RedisCli:Commit) delete failed: %w", err)
+	}
+
+	// OK
+	return updated, deleted, nil
+}
+
+func (rc *Client) makeTxCmds(seq int64) []*txCmd {
+	chunkSize := rc.ChunkSize(RedisDefaultChunkSize)
+	cmds := make([]*txCmd, 0, 2 * len(rc.toUpdate) + 2 * (len(rc.toDelete) / chunkSize + 1) + 1)
+
+	// HSET command per each updated key, followed by the command to update its content
+	for _, item := range rc.toUpdate {
+		cmds = append(cmds, &txCmd{op: dbms.Update, keys: []string{item.key}, args: hsetArgs(item.key, item.values)})
 
-	// Check for deletion error
-	if err := res.Err(); err != nil {
-		// Return error description
-		return res.Val(), fmt.Errorf("(RedisCli:Commit) DEL operation failed: %w", err)
+		cKey := contentKey(item.key)
+		if item.content == nil {
+			// Remove content that may remain from the previous version of the object
+			cmds = append(cmds, &txCmd{op: dbms.Delete, keys: []string{cKey}, args: []any{"DEL", cKey}, aux: true})
+		} else {
+			cmds = append(cmds, &txCmd{op: dbms.Update, keys: []string{cKey}, args: hsetArgs(cKey, item.content), aux: true})
+		}
+	}
+
+	// DEL command per each chunk of deleted keys and keys of their content
+	for start := 0; start < len(rc.toDelete); start += chunkSize {
+		chunk := rc.toDelete[start:common.ChunkEnd(start, chunkSize, len(rc.toDelete))]
+
+		args := make([]any, 0, len(chunk) + 1)
+		cArgs := make([]any, 0, len(chunk) + 1)
+		cKeys := make([]string, 0, len(chunk))
+		args = append(args, "DEL")
+		cArgs = append(cArgs, "DEL")
+		for _, key := range chunk {
+			args = append(args, key)
+			cKey := contentKey(key)
+			cArgs = append(cArgs, cKey)
+			cKeys = append(cKeys, cKey)
+		}
+
+		cmds = append(cmds,
+			&txCmd{op: dbms.Delete, keys: chunk, args: args},
+			&txCmd{op: dbms.Delete, keys: cKeys, args: cArgs, aux: true},
+		)
+	}
+
+	// Check for the batch sequence number to store
+	if seq == 0 {
+		return cmds
+	}
+
+	// Store the batch sequence number in the same transaction, even if
+	// the batch is empty to confirm that it was processed
+	return append(cmds, &txCmd{
+		op:		dbms.Update,
+		keys:	[]string{rc.metaKey()},
+		args:	[]any{"HSET", rc.metaKey(), dbms.MetaFieldBatchSeq, seq},
+		aux:	true,
+	})
+}
+
+func hsetArgs(key string, values []string) []any {
+	args := make([]any, 0, len(values) + 2)
+	args = append(args, "HSET", key)
+	for _, v := range values {
+		args = append(args, v)
+	}
+
+	return args
+}
+
+func (rc *Client) execTx(cmds []*txCmd) (dbms.OpErrors, error) {
+	// All commands of the transaction must be sent using the same connection
+	conn := rc.c.Conn(rc.Ctx)
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.E("(RedisCli:execTx) cannot close connection: %v", err)
+		}
+	}()
+
+	if err := connDo(rc.Ctx, conn, "MULTI").Err(); err != nil {
+		return nil, fmt.Errorf("MULTI failed: %w", err)
+	}
+
+	// Queue commands using pipelines with limited number of commands
+	chunkSize := rc.ChunkSize(RedisDefaultChunkSize)
+	for start := 0; start < len(cmds); start += chunkSize {
+		end := common.ChunkEnd(start, chunkSize, len(cmds))
+
+		pipe := conn.Pipeline()
+		for _, cmd := range cmds[start:end] {
+			pipe.Do(rc.Ctx, cmd.args...)
+		}
+
+		// Each command should be replied as QUEUED, any error here means that the transaction cannot be executed
+		if _, err := pipe.Exec(rc.Ctx); err != nil {
+			if dErr := connDo(rc.Ctx, conn, "DISCARD").Err(); dErr != nil {
+				log.E("(RedisCli:execTx) DISCARD failed: %v", dErr)
+			}
+
+			return nil, fmt.Errorf("cannot queue %d commands: %w", end - start, err)
+		}
+
+		log.D("(RedisCli:execTx) Pipeline of %d commands queued", end - start)
+	}
+
+	// Execute the transaction
+	res, err := connDo(rc.Ctx, conn, "EXEC").Slice()
+	if err != nil {
+		return nil, fmt.Errorf("EXEC failed: %w", err)
+	}
+	if len(res) != len(cmds) {
+		return nil, fmt.Errorf("EXEC returned %d results, want - %d", len(res), len(cmds))
+	}
+
+	// Errors of the separate commands do not roll back the rest of the transaction
+	var opErrs dbms.OpErrors
+
+	// Check results of the separate commands
+	for i, r := range res {
+		cmd := cmds[i]
+
+		if err, ok := r.(error); ok {
+			// All keys of the command were not changed
+			for _, key := range cmd.keys {
+				opErrs = append(opErrs, &dbms.OpError{Op: cmd.op, Key: key, Err: err})
+			}
+			continue
+		}
+
+		switch {
+		case cmd.aux:
+			// Not an object, skip it
+		case cmd.op == dbms.Update:
+			rc.updated++
+		case cmd.op == dbms.Delete:
+			// DEL returns the number of really deleted keys
+			if n, ok := r.(int64); ok {
+				rc.deleted += n
+			}
+		}
 	}
 
-	// OK, return number of deleted keys
-	return res.Val(), nil
+	return opErrs, nil
 }
 
 func (rc *Client) deleteDryRun() (int64, error) {
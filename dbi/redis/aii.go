@@ -28,7 +28,11 @@ func (rc *Client) ModifyAII(op dbms.DBOperator, args *dbms.AIIArgs, ids []string
 	// Empty query arguments - no special search parameters are required
 	qa := &dbms.QueryArgs{}
 	// Create RediSearch query to get identifiers
-	q := rsh.NewQuery(rshQueryByIds(ids, qa))
+	rq, err := rshQueryByIds(ids, qa)
+	if err != nil {
+		return 0, 0, fmt.Errorf("(RedisCli:ModifyAII) %w", err)
+	}
+	q := rsh.NewQuery(rq)
 	// Run search to get results by IDs
 	qr, err := rshSearch(rsc, q, []string{dbms.FieldID})
 	if err != nil {
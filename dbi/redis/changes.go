@@ -0,0 +1,135 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/r-che/dfi/dbi/common"
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+)
+
+// Change of the object published to the changes channel
+type changeMsg struct {
+	Op		string	`json:"op"`
+	ID		string	`json:"id"`
+	Host	string	`json:"host"`
+	FPath	string	`json:"fpath"`
+}
+
+func (rc *Client) Subscribe(ctx context.Context, handler dbms.ChangesHandler) error {
+	channel := rc.changesChannel()
+
+	ps := rc.c.Subscribe(ctx, channel)
+	defer func() {
+		if err := ps.Close(); err != nil {
+			log.E("(RedisCli:Subscribe) cannot close subscription: %v", err)
+		}
+	}()
+
+	// Wait for confirmation of the subscription to get errors, if any
+	if _, err := ps.Receive(ctx); err != nil {
+		return fmt.Errorf("(RedisCli:Subscribe) cannot subscribe to %q: %w", channel, err)
+	}
+
+	log.D("(RedisCli:Subscribe) Subscribed to %q", channel)
+
+	msgs := ps.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			// OK
+			return nil
+		case msg, ok := <-msgs:
+			if !ok {
+				return fmt.Errorf("(RedisCli:Subscribe) subscription to %q was closed", channel)
+			}
+
+			changes, err := decodeChanges(msg.Payload)
+			if err != nil {
+				log.W("(RedisCli:Subscribe) Skip invalid message from %q: %v", channel, err)
+				continue
+			}
+
+			if err := handler(changes); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func decodeChanges(payload string) ([]*dbms.ObjChange, error) {
+	var msgs []changeMsg
+	if err := json.Unmarshal([]byte(payload), &msgs); err != nil {
+		return nil, err
+	}
+
+	changes := make([]*dbms.ObjChange, 0, len(msgs))
+	for _, msg := range msgs {
+		var op dbms.DBOperator
+		switch msg.Op {
+		case dbms.Update.String():
+			op = dbms.Update
+		case dbms.Delete.String():
+			op = dbms.Delete
+		default:
+			return nil, fmt.Errorf("unsupported operation %q on object %s", msg.Op, msg.ID)
+		}
+
+		changes = append(changes, &dbms.ObjChange{Op: op, ID: msg.ID, Host: msg.Host, FPath: msg.FPath})
+	}
+
+	return changes, nil
+}
+
+// makePublishCmds returns commands to publish the queued changes to subscribers,
+// changes are published by chunks to keep messages reasonably small
+func (rc *Client) makePublishCmds() ([]*txCmd, error) {
+	msgs := make([]changeMsg, 0, len(rc.toUpdate) + len(rc.toDelete))
+
+	appendMsg := func(op dbms.DBOperator, objKey string) {
+		fpath := rc.objFPath(objKey)
+		msgs = append(msgs, changeMsg{
+			Op:		op.String(),
+			ID:		common.MakeID(rc.Cfg.CliHost, &types.FSObject{FPath: fpath}),
+			Host:	rc.Cfg.CliHost,
+			FPath:	fpath,
+		})
+	}
+
+	for _, item := range rc.toUpdate {
+		appendMsg(dbms.Update, item.key)
+	}
+	for _, objKey := range rc.toDelete {
+		appendMsg(dbms.Delete, objKey)
+	}
+
+	channel := rc.changesChannel()
+	chunkSize := rc.ChunkSize(RedisDefaultChunkSize)
+	cmds := make([]*txCmd, 0, len(msgs) / chunkSize + 1)
+
+	for start := 0; start < len(msgs); start += chunkSize {
+		payload, err := json.Marshal(msgs[start:common.ChunkEnd(start, chunkSize, len(msgs))])
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode changes: %w", err)
+		}
+
+		cmds = append(cmds, &txCmd{
+			op:		dbms.Update,
+			keys:	[]string{channel},
+			args:	[]any{"PUBLISH", channel, payload},
+			aux:	true,
+		})
+	}
+
+	return cmds, nil
+}
+
+// changesChannel returns the channel to publish changes, the channels namespace
+// is shared by all databases of the server, so the channel includes the database identifier
+func (rc *Client) changesChannel() string {
+	return RedisChangesPrefix + rc.Cfg.ID
+}
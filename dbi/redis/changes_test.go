@@ -0,0 +1,143 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/r-che/dfi/dbi/common"
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+)
+
+func testChange(op dbms.DBOperator, host, fpath string) *dbms.ObjChange {
+	return &dbms.ObjChange{Op: op, ID: common.MakeID(host, &types.FSObject{FPath: fpath}), Host: host, FPath: fpath}
+}
+
+// Changes published by the commit transaction are read back by subscribers
+func TestPublishChanges(t *testing.T) {
+	tests := []struct {
+		chunkSize	int
+		updated		[]string	// paths of updated objects
+		deleted		[]string	// paths of deleted objects
+		wantCmds	int
+	} {
+		{ chunkSize: 0, wantCmds: 0 },
+		{ chunkSize: 0, updated: []string{"/a"}, wantCmds: 1 },
+		{ chunkSize: 0, deleted: []string{"/a"}, wantCmds: 1 },
+		{ chunkSize: 0, updated: []string{"/a", "/b"}, deleted: []string{"/c"}, wantCmds: 1 },
+		// Changes are published by chunks
+		{ chunkSize: 2, updated: []string{"/a", "/b"}, deleted: []string{"/c"}, wantCmds: 2 },
+		{ chunkSize: 1, updated: []string{"/a", "/b"}, deleted: []string{"/c"}, wantCmds: 3 },
+		{ chunkSize: 3, updated: []string{"/a", "/b"}, deleted: []string{"/c"}, wantCmds: 1 },
+		// Paths with separators of keys and characters escaped by JSON
+		{ chunkSize: 0, updated: []string{`/x:y/"q" \b`, "/new\nline/Ünïcödé"}, deleted: []string{"/<&>"}, wantCmds: 1 },
+	}
+
+	for i, test := range tests {
+		rc := &Client{CommonClient: dbms.NewCommonClient(&dbms.DBConfig{ID: "3", CliHost: "nas1", ChunkSize: test.chunkSize})}
+
+		want := []*dbms.ObjChange{}
+		for _, fpath := range test.updated {
+			rc.toUpdate = append(rc.toUpdate, &hsetItem{key: RedisObjPrefix + "nas1:" + fpath})
+			want = append(want, testChange(dbms.Update, "nas1", fpath))
+		}
+		for _, fpath := range test.deleted {
+			rc.toDelete = append(rc.toDelete, RedisObjPrefix + "nas1:" + fpath)
+			want = append(want, testChange(dbms.Delete, "nas1", fpath))
+		}
+
+		cmds, err := rc.makePublishCmds()
+		if err != nil {
+			t.Errorf("[%d] makePublishCmds returned unexpected error: %v", i, err)
+			continue
+		}
+		if len(cmds) != test.wantCmds {
+			t.Errorf("[%d] want %d commands, got %d", i, test.wantCmds, len(cmds))
+		}
+
+		got := []*dbms.ObjChange{}
+		for j, cmd := range cmds {
+			if !cmd.aux || !reflect.DeepEqual(cmd.keys, []string{"changes:3"}) || len(cmd.args) != 3 ||
+				cmd.args[0] != "PUBLISH" || cmd.args[1] != "changes:3" {
+				t.Errorf("[%d] command %d - want auxiliary PUBLISH to %q, got %v (keys %v, aux %t)",
+					i, j, "changes:3", cmd.args, cmd.keys, cmd.aux)
+				continue
+			}
+
+			payload, ok := cmd.args[2].([]byte)
+			if !ok {
+				t.Errorf("[%d] command %d - want []byte payload, got %T", i, j, cmd.args[2])
+				continue
+			}
+
+			changes, err := decodeChanges(string(payload))
+			if err != nil {
+				t.Errorf("[%d] command %d - cannot decode payload %q: %v", i, j, payload, err)
+				continue
+			}
+			if len(changes) == 0 || test.chunkSize != 0 && len(changes) > test.chunkSize {
+				t.Errorf("[%d] command %d - want 1..%d changes in the message, got %d", i, j, test.chunkSize, len(changes))
+			}
+			got = append(got, changes...)
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("[%d] want published changes %v, got %v", i, want, got)
+		}
+	}
+}
+
+func TestDecodeChanges(t *testing.T) {
+	tests := []struct {
+		payload	string
+		want	[]*dbms.ObjChange
+		wantErr	bool
+	} {
+		{ payload: `[]`, want: []*dbms.ObjChange{} },
+		{ payload: `null`, want: []*dbms.ObjChange{} },
+		{
+			payload: `[{"op":"Update","id":"i1","host":"h1","fpath":"/a"},{"op":"Delete","id":"i2","host":"h2","fpath":"/b"}]`,
+			want: []*dbms.ObjChange{
+				{Op: dbms.Update, ID: "i1", Host: "h1", FPath: "/a"},
+				{Op: dbms.Delete, ID: "i2", Host: "h2", FPath: "/b"},
+			},
+		},
+		// Missing fields are empty, unknown fields are ignored
+		{ payload: `[{"op":"Delete","id":"i1"}]`, want: []*dbms.ObjChange{{Op: dbms.Delete, ID: "i1"}} },
+		{ payload: `[{"op":"Update","id":"i1","size":1}]`, want: []*dbms.ObjChange{{Op: dbms.Update, ID: "i1"}} },
+		// Unknown operations
+		{ payload: `[{"op":"Rename","id":"i1"}]`, wantErr: true },
+		{ payload: `[{"op":"update","id":"i1"}]`, wantErr: true },
+		{ payload: `[{"op":"DeletePrefix","id":"i1"}]`, wantErr: true },
+		{ payload: `[{"id":"i1"}]`, wantErr: true },
+		{ payload: `[{"op":"Update","id":"i1"},{"op":"Move","id":"i2"}]`, wantErr: true },
+		// Malformed messages
+		{ payload: ``, wantErr: true },
+		{ payload: `[`, wantErr: true },
+		{ payload: `[{"op":"Update","id":"i1"}`, wantErr: true },
+		{ payload: `{"op":"Update","id":"i1"}`, wantErr: true },
+		{ payload: `["Update"]`, wantErr: true },
+		{ payload: `[{"op":1,"id":"i1"}]`, wantErr: true },
+		{ payload: `[{"op":"Update","id":["i1"]}]`, wantErr: true },
+		{ payload: `[] []`, wantErr: true },
+		{ payload: "\xff", wantErr: true },
+	}
+
+	for i, test := range tests {
+		got, err := decodeChanges(test.payload)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("[%d] decodeChanges(%q) - want error, got %v", i, test.payload, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[%d] decodeChanges(%q) returned unexpected error: %v", i, test.payload, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d] decodeChanges(%q) - want %v, got %v", i, test.payload, test.want, got)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/r-che/dfi/dbi/common"
 	"github.com/r-che/dfi/types/dbms"
 
 	"github.com/r-che/log"
@@ -19,7 +20,11 @@ func (rc *Client) Query(qa *dbms.QueryArgs, retFields []string) (dbms.QueryResul
 	}
 
 	// Create simple (non-deep) query
-	q := rsh.NewQuery(rshQuery(qa))
+	rq, patterns, err := rshQuery(qa)
+	if err != nil {
+		return nil, fmt.Errorf("(RedisCli:Query) %w", err)
+	}
+	q := rsh.NewQuery(rq)
 
 	// Do search
 	qr, err := rshSearch(rsc, q, retFields)
@@ -38,6 +43,21 @@ func (rc *Client) Query(qa *dbms.QueryArgs, retFields []string) (dbms.QueryResul
 		log.D("(RedisCli:Query) Total of %d records were found with a deep (SCAN) search", n)
 	}
 
+	// Patterns of names and paths cannot be matched by RediSearch, so check them here
+	if patterns != nil {
+		match, err := patterns.PatternsMatcher()
+		if err != nil {
+			return nil, fmt.Errorf("(RedisCli:Query) %w", err)
+		}
+
+		for objKey := range qr {
+			if !match(objKey.Path) {
+				delete(qr, objKey)
+			}
+		}
+		log.D("(RedisCli:Query) %d records matched patterns of names and paths", len(qr))
+	}
+
 	return qr, nil
 }
 
@@ -48,31 +68,66 @@ func (rc *Client) QueryAIIIds(qa *dbms.QueryArgs) ([]string, error) {
 		return nil, fmt.Errorf("(RedisCli:QueryAIIIds) cannot initialize RediSearch client: %w", err)
 	}
 
+	// Make query to search by AII fields
+	aq, err := rshAIIQuery(qa)
+	if err != nil {
+		return nil, fmt.Errorf("(RedisCli:QueryAIIIds) %w", err)
+	}
+	q := rsh.NewQuery(aq)
+
+	ids, err := rshSearchIds(rsc, q, RedisAIIPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("(RedisCli:QueryAIIIds) cannot execute query %q: %w", q.Raw, err)
+	}
+
+	log.D("(RedisCli:QueryAIIIds) AII search (tags: %t descr: %t) found identifiers: %v", qa.UseTags, qa.UseDescr, ids)
+
+	return ids, nil
+}
+
+// rshAIIQuery makes the query to match search phrases to tags or descriptions of objects
+func rshAIIQuery(qa *dbms.QueryArgs) (string, error) {
 	var chunks []string
 
 	// Check for need to use tags
 	if qa.UseTags {
-		et := make([]string, 0, len(qa.SP))	// Escaped tags
-		for _, tag := range qa.SP {
-			et = append(et, rsh.EscapeTextFileString(tag))
-		}
-		chunks = append(chunks, `(@` + dbms.AIIFieldTags + `:{` +  strings.Join(et, `|`) + `})`)
+		// Each search phrase is a separate tag
+		chunks = append(chunks, makeTagsQuery(dbms.AIIFieldTags, qa.SP))
 	}
 
 	// Check for need to use description
 	if qa.UseDescr {
-		chunks = append(chunks, `(@` + dbms.AIIFieldDescr + `:` +  strings.Join(qa.SP, `|`) + `)` )
+		descrQuery, err := makeTextQuery(dbms.AIIFieldDescr, qa.SP, qa.LiteralSP)
+		if err != nil {
+			return "", err
+		}
+		chunks = append(chunks, descrQuery)
 	}
 
-	// Make query to search by AII fields
-	q := rsh.NewQuery(strings.Join(chunks, ` | `))
+	// OK
+	return strings.Join(chunks, ` | `), nil
+}
 
-	ids, err := rshSearchAII(rsc, q)
+func (rc *Client) QueryContentIds(qa *dbms.QueryArgs) ([]string, error) {
+	// Get RediSearch client to search by content of documents
+	rsc, err := rc.rschInit(contentRschIdx)
 	if err != nil {
-		return nil, fmt.Errorf("(RedisCli:QueryAIIIds) cannot execute query %q: %w", q.Raw, err)
+		return nil, fmt.Errorf("(RedisCli:QueryContentIds) cannot initialize RediSearch client: %w", err)
 	}
 
-	log.D("(RedisCli:QueryAIIIds) AII search (tags: %t descr: %t) found identifiers: %v", qa.UseTags, qa.UseDescr, ids)
+	// All words of the phrase should be found in the text or in the title
+	cq, err := makeTextQuery(dbms.ContentFieldText + `|` + dbms.ContentFieldTitle, []string{qa.Content}, false)
+	if err != nil {
+		return nil, fmt.Errorf("(RedisCli:QueryContentIds) %w", err)
+	}
+	q := rsh.NewQuery(cq)
+
+	ids, err := rshSearchIds(rsc, q, RedisContentPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("(RedisCli:QueryContentIds) cannot execute query %q: %w", q.Raw, err)
+	}
+
+	log.D("(RedisCli:QueryContentIds) Content search found %d identifiers", len(ids))
 
 	return ids, nil
 }
@@ -85,8 +140,25 @@ func (rc *Client) GetObjects(ids, retFields []string) (dbms.QueryResults, error)
 	}
 
 	// Make initial query
-	q := rsh.NewQuery(rshQueryByIds(ids, &dbms.QueryArgs{}))
+	rq, err := rshQueryByIds(ids, &dbms.QueryArgs{})
+	if err != nil {
+		return nil, fmt.Errorf("(RedisCli:GetObjects) %w", err)
+	}
 
 	// Do search and return
-	return rshSearch(rsc, q, retFields)
+	return rshSearch(rsc, rsh.NewQuery(rq), retFields)
+}
+
+func (rc *Client) DiskUsage(qa *dbms.QueryArgs, dua *dbms.DUArgs) (dbms.DUResults, error) {
+	// RediSearch cannot group by prefixes of paths and skip hard links, so aggregate on the client side
+	log.D("(RedisCli:DiskUsage) Aggregating disk usage by %s on the client side...", dua.GroupBy)
+
+	dur, err := common.DiskUsage(rc, qa, dua)
+	if err != nil {
+		return nil, fmt.Errorf("(RedisCli:DiskUsage) %w", err)
+	}
+
+	log.D("(RedisCli:DiskUsage) Found %d groups", len(dur))
+
+	return dur, nil
 }
@@ -0,0 +1,98 @@
+package redis
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/r-che/dfi/types/dbms"
+)
+
+// Pairs of brackets of the RediSearch query syntax
+var rshBrackets = map[rune]rune{')': '(', '}': '{', ']': '['}
+
+// rshWords splits the phrase to words the same way as RediSearch tokenizes values
+// of text fields, so words of the phrase can match words of indexed values
+func rshWords(phrase string) []string {
+	return dbms.TextWords(phrase)
+}
+
+// rshEscape escapes all characters of the value except letters, digits and
+// underscores, so the value cannot change the structure of the query
+func rshEscape(val string) string {
+	var b strings.Builder
+	for _, r := range val {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// rshGlobEscape escapes special characters of the value to use it in the pattern of the SCAN command
+func rshGlobEscape(val string) string {
+	var b strings.Builder
+	for _, r := range val {
+		if strings.ContainsRune(`*?[]^-\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// rshRawQuery checks the query set by the user in the RediSearch syntax and encloses it in parentheses.
+// Brackets and quotes of the query must be balanced and nested, quotes must not contain unescaped brackets
+// and must not be used inside tags and ranges, so the query cannot affect other conditions joined with it
+func rshRawQuery(q string) (string, error) {
+	if strings.TrimSpace(q) == "" {
+		return "", fmt.Errorf("(RedisCli:rshRawQuery) empty raw query")
+	}
+
+	opened := []rune{}
+	inQuotes := false
+
+	for rs := []rune(q); len(rs) != 0; rs = rs[1:] {
+		switch r := rs[0]; r {
+		case '\\':
+			if len(rs) == 1 {
+				return "", fmt.Errorf("(RedisCli:rshRawQuery) raw query %q ends with the escaping backslash", q)
+			}
+			// Skip escaped character
+			rs = rs[1:]
+
+		case '"':
+			if n := len(opened); n != 0 && opened[n-1] != '(' {
+				return "", fmt.Errorf("(RedisCli:rshRawQuery) quotes inside of %q are not allowed in raw query %q",
+					string(opened[n-1]), q)
+			}
+			inQuotes = !inQuotes
+
+		case '(', '{', '[', ')', '}', ']':
+			if inQuotes {
+				return "", fmt.Errorf("(RedisCli:rshRawQuery) unescaped %q inside of quotes in raw query %q",
+					string(r), q)
+			}
+
+			pair, closing := rshBrackets[r]
+			if !closing {
+				opened = append(opened, r)
+				continue
+			}
+			if n := len(opened); n == 0 || opened[n-1] != pair {
+				return "", fmt.Errorf("(RedisCli:rshRawQuery) unbalanced %q in raw query %q", string(r), q)
+			}
+			opened = opened[:len(opened)-1]
+		}
+	}
+
+	if inQuotes || len(opened) != 0 {
+		return "", fmt.Errorf("(RedisCli:rshRawQuery) unclosed quotes or brackets in raw query %q", q)
+	}
+
+	// OK
+	return `(` + q + `)`, nil
+}
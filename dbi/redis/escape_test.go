@@ -0,0 +1,251 @@
+package redis
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/r-che/dfi/types/dbms"
+)
+
+// Seeds of fuzz tests - syntax of RediSearch queries and typical values
+var fuzzSeeds = []string{
+	``, ` `, `x`, `snow ball`, `report-2020.pdf`, `-x`, `x | y`, `x)|(y`, `@host:{nas}`, `x}) | (@id:{y`,
+	`"quoted"`, `"`, `\`, `x\`, `\)`, `*`, `%fuzzy%`, `~opt`, `[1 2]`, `$param`, `=>{$weight: 2}`,
+	"tab\tnew\nline", "\x00", "\xff\xfe", `Ünïcödé ßnow`, `_`, `'`,
+}
+
+// querySkeleton returns the structure of the query: runs of letters, digits, underscores and escaped characters
+// are replaced by "w" and words separated by spaces are collapsed to the single "w", so values of conditions
+// that are properly escaped do not change the skeleton
+func querySkeleton(q string) string {
+	var b strings.Builder
+	for rs := []rune(q); len(rs) != 0; rs = rs[1:] {
+		switch r := rs[0]; {
+		case r == '\\' && len(rs) > 1:
+			rs = rs[1:]
+			b.WriteRune('w')
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			b.WriteRune('w')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return regexp.MustCompile(`w+(\s+w+)*`).ReplaceAllString(b.String(), `w`)
+}
+
+// fuzzQueryArgs returns query arguments with the text value txt and the value val used by all fields
+func fuzzQueryArgs(txt, val string, literal, onlyName bool) *dbms.QueryArgs {
+	qa := dbms.NewQueryArgs()
+	qa.SP = []string{txt}
+	qa.LiteralSP = literal
+	qa.OnlyName = onlyName
+	qa.Ids = []string{val}
+	qa.Hosts = []string{val}
+	qa.Users = []string{val}
+	qa.CSums = []string{val}
+	qa.MIMEs = []string{val + "/*"}
+	qa.ContentIds = []string{val}
+	qa.OnlyIds = []string{val}
+	qa.ExclHosts = []string{val}
+	qa.Expr = dbms.NewOrExpr(
+		dbms.NewCondExpr(dbms.FieldName, dbms.CmpEq, nil, []string{txt}),
+		dbms.NewNotExpr(dbms.NewCondExpr(dbms.ExprFieldPath, dbms.CmpEq, nil, []string{txt})),
+		dbms.NewCondExpr(dbms.FieldCamera, dbms.CmpEq, nil, []string{txt}),
+		dbms.NewCondExpr(dbms.FieldGroup, dbms.CmpEq, nil, []string{val}),
+		dbms.NewCondExpr(dbms.ExprFieldTarget, dbms.CmpEq, nil, []string{"/" + val}),
+	)
+
+	return qa
+}
+
+// Placeholders of values that have the same structure in the query
+func textPlaceholder(txt string) string {
+	if len(rshWords(txt)) == 0 {
+		// The query cannot be made
+		return txt
+	}
+	return "x"
+}
+func tagPlaceholder(val string) string {
+	if val == "" {
+		return ""
+	}
+	return "x"
+}
+
+func FuzzRshQuery(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed, seed, false, false)
+		f.Add(seed, seed, true, true)
+	}
+
+	f.Fuzz(func(t *testing.T, txt, val string, literal, onlyName bool) {
+		q, _, err := rshQuery(fuzzQueryArgs(txt, val, literal, onlyName))
+		if err != nil {
+			// The query was not made, so it cannot be broken
+			return
+		}
+
+		want, _, err := rshQuery(fuzzQueryArgs(textPlaceholder(txt), tagPlaceholder(val), literal, onlyName))
+		if err != nil {
+			t.Fatalf("cannot make query with placeholders: %v", err)
+		}
+
+		if querySkeleton(q) != querySkeleton(want) {
+			t.Errorf("values %q and %q changed structure of the query:\n%s\n%s\nwant structure of:\n%s\n%s",
+				txt, val, q, querySkeleton(q), want, querySkeleton(want))
+		}
+	})
+}
+
+func FuzzRshQueryByIds(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, id string) {
+		q, err := rshQueryByIds([]string{id, "id"}, &dbms.QueryArgs{})
+		if err != nil {
+			t.Fatalf("rshQueryByIds returned unexpected error: %v", err)
+		}
+
+		want, _ := rshQueryByIds([]string{tagPlaceholder(id), "id"}, &dbms.QueryArgs{})
+		if querySkeleton(q) != querySkeleton(want) {
+			t.Errorf("identifier %q changed structure of the query: %s, want structure of: %s", id, q, want)
+		}
+	})
+}
+
+func FuzzRshAIIQuery(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed, false)
+		f.Add(seed, true)
+	}
+
+	f.Fuzz(func(t *testing.T, sp string, literal bool) {
+		qa := &dbms.QueryArgs{SP: []string{sp, "x"}}
+		qa.UseTags, qa.UseDescr, qa.LiteralSP = true, true, literal
+
+		q, err := rshAIIQuery(qa)
+		if err != nil {
+			return
+		}
+
+		qa.SP[0] = textPlaceholder(sp)
+		want, err := rshAIIQuery(qa)
+		if err != nil {
+			t.Fatalf("cannot make query with placeholders: %v", err)
+		}
+
+		// Tags and descriptions use the same phrase, so the placeholder of the tag does not differ
+		if querySkeleton(q) != querySkeleton(want) {
+			t.Errorf("phrase %q changed structure of the query: %s, want structure of: %s", sp, q, want)
+		}
+	})
+}
+
+func FuzzRshRawQuery(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		qa := dbms.NewQueryArgs()
+		qa.SP = []string{raw}
+		qa.RawQuery = true
+		qa.OnlyIds = []string{"id"}
+		qa.ExclHosts = []string{"host"}
+
+		q, _, err := rshQuery(qa)
+		if err != nil {
+			return
+		}
+
+		// Restrictions must be kept outside of the raw query, which is enclosed in parentheses
+		prefix, suffix := `(@id:{id}) (((`, `))) -(@host:{host})`
+		if !strings.HasPrefix(q, prefix) || !strings.HasSuffix(q, suffix) {
+			t.Fatalf("unexpected query %q", q)
+		}
+		if minDepth, depth := rawDepths(strings.TrimSuffix(q, suffix)[len(prefix)-1:]); minDepth != 1 || depth != 1 {
+			t.Errorf("raw query %q closes the enclosing parentheses or leaves them unclosed: %s", raw, q)
+		}
+	})
+}
+
+// rawDepths returns the minimal depth of brackets after the first one and the depth at the end of
+// the query, brackets in quotes and escaped characters are skipped, unclosed quotes make depths invalid
+func rawDepths(q string) (minDepth, depth int) {
+	minDepth = 1
+	inQuotes := false
+	for rs := []rune(q); len(rs) != 0; rs = rs[1:] {
+		switch rs[0] {
+		case '\\':
+			if len(rs) > 1 {
+				rs = rs[1:]
+			}
+		case '"':
+			inQuotes = !inQuotes
+		case '(', '{', '[':
+			if !inQuotes {
+				depth++
+			}
+		case ')', '}', ']':
+			if !inQuotes {
+				depth--
+			}
+		}
+		if depth < minDepth {
+			minDepth = depth
+		}
+	}
+	if inQuotes {
+		return -1, -1
+	}
+
+	return minDepth, depth
+}
+
+func TestRshRawQuery(t *testing.T) {
+	for q, ok := range map[string]bool{
+		`@name:{report} | @size:[1 +inf]`:	true,
+		`"exact phrase" (a | b) -c`:		true,
+		`"(quoted)"`:						false,
+		`"\(quoted\)"`:						true,
+		`x) | (y`:							false,
+		`@id:{x`:							false,
+		`@id:{"x}`:							false,
+		`x\`:								false,
+		`"x`:								false,
+		` `:								false,
+	} {
+		if _, err := rshRawQuery(q); (err == nil) != ok {
+			t.Errorf("rshRawQuery(%q) - want success %t, got error: %v", q, ok, err)
+		}
+	}
+}
+
+func TestMakeTextQuery(t *testing.T) {
+	tests := []struct {
+		phrases	[]string
+		literal	bool
+		want	string
+	} {
+		{ []string{`Report-2020.pdf`}, false, `(@f:((report 2020 pdf)))` },
+		{ []string{`snow ball`, `x|y`}, true, `(@f:("snow ball"|"x y"))` },
+		{ []string{`it's a ?`}, false, `(@f:((it s a \?)))` },
+	}
+
+	for i, test := range tests {
+		got, err := makeTextQuery("f", test.phrases, test.literal)
+		if err != nil || got != test.want {
+			t.Errorf("[%d] want %s, got %s (error: %v)", i, test.want, got, err)
+		}
+	}
+
+	if _, err := makeTextQuery("f", []string{`-+-`}, false); err == nil {
+		t.Errorf("makeTextQuery returned no error for the phrase without words")
+	}
+}
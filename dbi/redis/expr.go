@@ -0,0 +1,242 @@
+package redis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/r-che/dfi/common/tools"
+	"github.com/r-che/dfi/types/dbms"
+)
+
+// Numeric fields of the objects index
+var rshNumFields = map[string]bool{
+	dbms.FieldSize:		true,
+	dbms.FieldMTime:	true,
+	dbms.FieldCTime:	true,
+	dbms.FieldNLink:	true,
+	dbms.FieldUID:		true,
+	dbms.FieldGID:		true,
+	dbms.FieldInode:	true,
+	dbms.FieldTaken:	true,
+}
+
+// Tag fields of the objects index matched by exact values
+var rshTagFields = map[string]bool{
+	dbms.FieldID:			true,
+	dbms.FieldType:			true,
+	dbms.FieldHost:			true,
+	dbms.FieldUser:			true,
+	dbms.FieldGroup:		true,
+	dbms.FieldChecksum:		true,
+	dbms.FieldLinkState:	true,
+}
+
+// Query that matches all objects, each object has the size field
+var rshAllQuery = `(@` + dbms.FieldSize + `:[-inf +inf])`
+
+// rshFilter returns the expression of conditions set by query arguments, which is compiled to the
+// RediSearch query, and the expression of conditions on names and paths by shell patterns and regular
+// expressions, which cannot be matched by RediSearch - found objects are filtered by them on the client side
+func rshFilter(qa *dbms.QueryArgs) (*dbms.Expr, *dbms.Expr, error) {
+	expr := qa.FilterExpr()
+	if expr == nil {
+		// No conditions
+		return nil, nil, nil
+	}
+
+	if err := expr.CheckPatterns(); err != nil {
+		return nil, nil, fmt.Errorf("(RedisCli:rshFilter) %w", err)
+	}
+	rest, patterns, _ := expr.SplitPatterns()
+
+	// Words of shell patterns that must be found in matched names and paths
+	// narrow down the query, so fewer objects are filtered on the client side
+	for _, arg := range patterns.Conjuncts() {
+		if arg.Op == dbms.ExprCond && arg.Cond.Cmp == dbms.CmpGlob {
+			rest = dbms.NewAndExpr(rest, globWordsExpr(arg.Cond))
+		}
+	}
+
+	// OK
+	return rest, patterns, nil
+}
+
+// globWordsExpr returns the condition on words that must be contained in names or paths matched by any of shell
+// patterns of the condition c, or nil if some pattern has no such words. Only words bounded by separators or
+// by ends of the pattern are used, because wildcards next to them can match parts of the same indexed word
+func globWordsExpr(c *dbms.Cond) *dbms.Expr {
+	phrases := make([]string, 0, len(c.Strs))
+	for _, pattern := range c.Strs {
+		words := globWords(pattern)
+		if len(words) == 0 {
+			// Any name or path can be matched by this pattern
+			return nil
+		}
+		phrases = append(phrases, strings.Join(words, " "))
+	}
+
+	// Paths are matched by words of found and real paths, it only widens the condition
+	return dbms.NewCondExpr(c.Field, dbms.CmpEq, nil, phrases)
+}
+
+// globWords returns words of the shell pattern that are bounded by separators or by ends of the pattern
+func globWords(pattern string) []string {
+	words := []string{}
+
+	// Literal characters of the current part of the pattern between wildcards
+	var lit []rune
+	// Whether the current literal part starts at the beginning of the pattern
+	atStart := true
+
+	flush := func(atEnd bool) {
+		// Add separators at bounded ends, words touching wildcards are incomplete
+		runs := rshWords(string(lit))
+		if len(runs) != 0 && !atStart && !dbms.IsTextSeparator(lit[0]) {
+			runs = runs[1:]
+		}
+		if len(runs) != 0 && !atEnd && !dbms.IsTextSeparator(lit[len(lit)-1]) {
+			runs = runs[:len(runs)-1]
+		}
+		words = append(words, runs...)
+		lit, atStart = nil, false
+	}
+
+	for rs := []rune(pattern); len(rs) != 0; rs = rs[1:] {
+		switch rs[0] {
+		case '\\':
+			if len(rs) > 1 {
+				rs = rs[1:]
+			}
+			lit = append(lit, rs[0])
+		case '*', '?':
+			flush(false)
+		case '[':
+			flush(false)
+			// Skip the class, the first character after the optional negation is always its element
+			rs = rs[1:]
+			if len(rs) != 0 && rs[0] == '^' {
+				rs = rs[1:]
+			}
+			for first := true; len(rs) != 0 && (first || rs[0] != ']'); first = false {
+				if rs[0] == '\\' && len(rs) > 1 {
+					rs = rs[1:]
+				}
+				rs = rs[1:]
+			}
+			if len(rs) == 0 {
+				// Invalid pattern, it cannot be matched
+				return words
+			}
+		default:
+			lit = append(lit, rs[0])
+		}
+	}
+	flush(true)
+
+	return words
+}
+
+// rshExpr compiles the expression to the RediSearch query
+func rshExpr(e *dbms.Expr) (string, error) {
+	if e == nil {
+		return "", nil
+	}
+
+	switch e.Op {
+	case dbms.ExprCond:
+		return rshCond(e.Cond)
+
+	case dbms.ExprAnd, dbms.ExprOr:
+		chunks := make([]string, 0, len(e.Args))
+		for _, arg := range e.Args {
+			chunk, err := rshExpr(arg)
+			if err != nil {
+				return "", err
+			}
+			chunks = append(chunks, chunk)
+		}
+
+		if e.Op == dbms.ExprOr {
+			return `(` + strings.Join(chunks, ` | `) + `)`, nil
+		}
+		return `(` + strings.Join(chunks, ` `) + `)`, nil
+
+	case dbms.ExprNot:
+		chunk, err := rshExpr(e.Args[0])
+		if err != nil {
+			return "", err
+		}
+		if !strings.HasPrefix(chunk, `(`) {
+			chunk = `(` + chunk + `)`
+		}
+		return `-` + chunk, nil
+
+	case dbms.ExprConst:
+		if e.Val {
+			return rshAllQuery, nil
+		}
+		return `-` + rshAllQuery, nil
+	}
+
+	return "", fmt.Errorf("(RedisCli:rshExpr) unsupported type of expression %d", e.Op)
+}
+
+// rshCond compiles the condition to the RediSearch query
+//
+//nolint:cyclop	// Each field is simple, splitting does not make it clearer
+func rshCond(c *dbms.Cond) (string, error) {
+	switch {
+	case c.IsPattern():
+		return "", fmt.Errorf("(RedisCli:rshCond) patterns cannot be matched by RediSearch: %s", c)
+
+	case rshNumFields[c.Field]:
+		return makeNumQuery(c.Field, c.Cmp, c.Ints)
+
+	case c.Field == dbms.FieldMode:
+		return makePermQuery(c.Ints[0], tools.Tern(c.Cmp == dbms.CmpAllBits, dbms.PermAll, dbms.PermExact)), nil
+
+	case rshTagFields[c.Field]:
+		return makeTagsQuery(c.Field, c.Strs), nil
+
+	case c.Field == dbms.FieldMIME:
+		return makeMIMEQuery(c.Strs), nil
+
+	case c.Field == dbms.FieldCamera, c.Field == dbms.FieldName:
+		return makeTextQuery(c.Field, c.Strs, false)
+
+	case c.Field == dbms.ExprFieldPath:
+		return makeTextQuery(dbms.FieldFPath + `|` + dbms.FieldRPath, c.Strs, false)
+
+	case c.Field == dbms.ExprFieldTarget:
+		return makePointsToQuery(c.Strs[0]), nil
+
+	case c.Field == dbms.AIIFieldTags || c.Field == dbms.AIIFieldDescr || c.Field == dbms.ExprFieldContent:
+		return "", fmt.Errorf("(RedisCli:rshCond) condition %s must be resolved to identifiers of objects", c)
+	}
+
+	return "", fmt.Errorf("(RedisCli:rshCond) unsupported condition %s", c)
+}
+
+func makeNumQuery(field string, cmp dbms.CmpOp, vals []int64) (string, error) {
+	if cmp == dbms.CmpEq {
+		chunks := make([]string, 0, len(vals))
+		for _, v := range vals {
+			chunks = append(chunks, fmt.Sprintf(`@%s:[%d %d]`, field, v, v))
+		}
+
+		return `(` + strings.Join(chunks, `|`) + `)`, nil
+	}
+
+	// Exclusive bounds are prefixed by the parenthesis
+	var bounds string
+	switch cmp {
+	case dbms.CmpLt:	bounds = fmt.Sprintf(`-inf (%d`, vals[0])
+	case dbms.CmpLe:	bounds = fmt.Sprintf(`-inf %d`, vals[0])
+	case dbms.CmpGt:	bounds = fmt.Sprintf(`(%d +inf`, vals[0])
+	case dbms.CmpGe:	bounds = fmt.Sprintf(`%d +inf`, vals[0])
+	default:
+		return "", fmt.Errorf("(RedisCli:makeNumQuery) unsupported comparison %q of numeric field %q", cmp, field)
+	}
+
+	return `@` + field + `:[` + bounds + `]`, nil
+}
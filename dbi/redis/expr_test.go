@@ -0,0 +1,153 @@
+package redis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/r-che/dfi/types/dbms"
+)
+
+func TestGlobWords(t *testing.T) {
+	tests := []struct {
+		pattern	string
+		want	[]string
+	} {
+		{ `/data/Photos/*.jpg`, []string{`data`, `photos`, `jpg`} },
+		{ `/data/ph*`, []string{`data`} },
+		{ `*.tar.gz`, []string{`tar`, `gz`} },
+		{ `report[0-9]*`, []string{} },
+		{ `/a\*b/c`, []string{`a`, `b`, `c`} },
+		{ `IMG_?.JPG`, []string{`jpg`} },
+		{ `[]a]x y`, []string{`y`} },
+		{ `*`, []string{} },
+	}
+
+	for i, test := range tests {
+		if got := globWords(test.pattern); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d] globWords(%q) - want %q, got %q", i, test.pattern, test.want, got)
+		}
+	}
+}
+
+func TestRshFilterPatterns(t *testing.T) {
+	qa := dbms.NewQueryArgs()
+	qa.PathGlob, qa.NameRegex = `/data/*/photo-[0-9]*.jpg`, `^photo`
+	qa.OrExpr = true
+	if err := qa.ParseSizes("1k.."); err != nil {
+		t.Fatalf("ParseSizes failed: %v", err)
+	}
+
+	rest, patterns, err := rshFilter(qa)
+	if err != nil {
+		t.Fatalf("rshFilter returned unexpected error: %v", err)
+	}
+
+	// Words of the shell pattern narrow down the query, the regular expression is checked only on the client side
+	if want := `((size>=1024) and path:"data photo jpg")`; rest.String() != want {
+		t.Errorf("want query expression %s, got %s", want, rest)
+	}
+	if want := `(path~"/data/*/photo-[0-9]*.jpg" and name=~"^photo")`; patterns.String() != want {
+		t.Errorf("want patterns %s, got %s", want, patterns)
+	}
+
+	// Any path can be matched by the pattern without bounded words
+	qa.PathGlob = `/*`
+	if rest, _, _ := rshFilter(qa); rest.String() != `(size>=1024)` {
+		t.Errorf("pattern without words changed the query expression: %s", rest)
+	}
+}
+
+// Text fields are matched by whole words of phrases in any order, case-insensitively,
+// the same cases are checked for the MongoDB backend
+var textMatchTests = []struct {
+	value	string
+	phrases	[]string
+	want	bool
+} {
+	{ "Canon EOS 5D", []string{"canon"}, true },
+	{ "Canon EOS 5D", []string{"EOS canon"}, true },
+	{ "Canon EOS 5D", []string{"cano"}, false },
+	{ "Canonet QL17", []string{"canon"}, false },
+	{ "Canon EOS 5D", []string{"canon d850"}, false },
+	{ "Canon EOS 5D", []string{"nikon", "canon eos"}, true },
+	{ "NIKON D850", []string{"nikon d850"}, true },
+	{ "Sony ILCE-7M3", []string{"ilce 7m3"}, true },
+	{ "holiday-2020.jpg", []string{"2020 holiday"}, true },
+	{ "holiday-2020.jpg", []string{"holi"}, false },
+	{ "IMG_0001.JPG", []string{"img"}, false },
+	{ "IMG_0001.JPG", []string{"img_0001 jpg"}, true },
+}
+
+func TestRshCondWords(t *testing.T) {
+	for i, test := range textMatchTests {
+		q, err := rshCond(&dbms.Cond{Field: dbms.FieldCamera, Cmp: dbms.CmpEq, Strs: test.phrases})
+		if err != nil {
+			t.Fatalf("[%d] rshCond returned unexpected error: %v", i, err)
+		}
+
+		// Each phrase is the intersection of its words, phrases are joined by the union
+		chunks := make([]string, 0, len(test.phrases))
+		for _, phrase := range test.phrases {
+			chunks = append(chunks, `(` + strings.Join(rshWords(phrase), ` `) + `)`)
+		}
+		if want := `(@camera:(` + strings.Join(chunks, `|`) + `))`; q != want {
+			t.Errorf("[%d] rshCond(%q) - want %s, got %s", i, test.phrases, want, q)
+		}
+
+		// RediSearch matches words of the query with words of the value tokenized by the same separators
+		if got := rshWordsMatch(test.value, test.phrases); got != test.want {
+			t.Errorf("[%d] %q matched by %q - want %t, got %t", i, test.value, test.phrases, test.want, got)
+		}
+	}
+}
+
+// rshWordsMatch reports whether the value contains all words of any of phrases
+func rshWordsMatch(value string, phrases []string) bool {
+	words := map[string]bool{}
+	for _, word := range rshWords(value) {
+		words[word] = true
+	}
+
+	for _, phrase := range phrases {
+		all := true
+		for _, word := range rshWords(phrase) {
+			all = all && words[word]
+		}
+		if all {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Expressions with patterns that are supported by all backends, the same cases are checked for the MongoDB backend
+var patternsJoinTests = []struct {
+	expr	string
+	ok		bool
+} {
+	{ `size>1 and name~"*.iso"`, true },
+	{ `not (name~"*.tmp" or path~"/tmp/*") and type:reg`, true },
+	{ `name~"*.iso" or name=~"^a"`, true },
+	{ `size>1 or name~"*.iso"`, false },
+	{ `not (size>1 and name~"*.iso")`, false },
+	{ `type:dir or (size>1 and path~"/data/*")`, false },
+}
+
+func TestRshFilterPatternsJoin(t *testing.T) {
+	for i, test := range patternsJoinTests {
+		e, err := dbms.ParseExpr(test.expr)
+		if err != nil {
+			t.Fatalf("[%d] ParseExpr(%q) failed: %v", i, test.expr, err)
+		}
+
+		// Expressions received from the remote backend are not checked by the parser of query arguments
+		qa := dbms.NewQueryArgs()
+		qa.Expr = e
+
+		if _, _, err := rshFilter(qa); (err == nil) != test.ok {
+			t.Errorf("[%d] rshFilter(%s) - want ok %t, got error %v", i, e, test.ok, err)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package redis
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -10,8 +11,26 @@ import (
 	"github.com/r-che/dfi/types/dbms"
 
 	"github.com/r-che/log"
+
+	"github.com/go-redis/redis/v8"
 )
 
+// Queued HSET operation
+type hsetItem struct {
+	key		string
+	values	[]string
+	content	[]string	// values of the content key, nil - the object has no content
+	state	*common.ObjState	// state of the object recorded to the history
+}
+
+// Command executed as part of the commit transaction
+type txCmd struct {
+	op		dbms.DBOperator
+	keys	[]string	// keys affected by the command
+	args	[]any
+	aux		bool		// command changes auxiliary data (agent metadata, content), not objects
+}
+
 func (rc *Client) loadKeysByPrefix(prefix string, appendFunc func(any) error) error {
 	// Keep current termLong value to have ability to compare during long-term operations
 	initTermLong := rc.TermLongVal
@@ -56,7 +75,8 @@ func (rc *Client) loadKeysByPrefix(prefix string, appendFunc func(any) error) er
 
 func prepareHSetValues(host string, fso *types.FSObject) []string {
 	// Output slice with values prepared to send to Redis
-	values := make([]string, 0, types.FSObjectFieldsNum + 1 /* id field */ + 1 /* host field */)
+	values := make([]string, 0, (types.FSObjectFieldsNum + 1 /* id field */ + 1 /* host field */ +
+		1 /* mode bits field */ + 1 /* target field */ + 1 /* GPS is stored as two fields */) * 2 /* field name + value */)
 
 	/*
 	 * Prepare FPath value
@@ -81,7 +101,87 @@ func prepareHSetValues(host string, fso *types.FSObject) []string {
 		dbms.FieldSize, strconv.FormatInt(fso.Size, 10),
 		dbms.FieldMTime, strconv.FormatInt(fso.MTime, 10),
 		dbms.FieldChecksum, fso.Checksum,
+		dbms.FieldUID, strconv.FormatInt(fso.UID, 10),
+		dbms.FieldGID, strconv.FormatInt(fso.GID, 10),
+		dbms.FieldUser, fso.User,
+		dbms.FieldGroup, fso.Group,
+		dbms.FieldMode, strconv.FormatInt(fso.Mode, 10),
+		RedisFieldModeBits, strings.Join(modeBits(fso.Mode), ","),
+		dbms.FieldCTime, strconv.FormatInt(fso.CTime, 10),
+		dbms.FieldInode, strconv.FormatInt(fso.Inode, 10),
+		dbms.FieldDevice, strconv.FormatInt(fso.Device, 10),
+		dbms.FieldNLink, strconv.FormatInt(fso.NLink, 10),
+		dbms.FieldMIME, fso.MIME,
+		dbms.FieldTaken, strconv.FormatInt(fso.Taken, 10),
+		dbms.FieldCamera, fso.Camera,
+		dbms.FieldDuration, strconv.FormatInt(fso.Duration, 10),
+		dbms.FieldWidth, strconv.FormatInt(fso.Width, 10),
+		dbms.FieldHeight, strconv.FormatInt(fso.Height, 10),
+		dbms.FieldArchive, fso.Archive,
+		dbms.FieldLinkState, fso.LinkState,
 	)
 
+	// Target is set only for symbolic links to match them by the real path, tags with empty values are not indexed
+	target := ""
+	if fso.Type == types.ObjSymlink {
+		target = fso.RPath
+	}
+	values = append(values, RedisFieldTarget, target)
+
+	// Coordinates are not indexed, empty values mean unknown location
+	lat, lon := "", ""
+	if fso.GPS != nil {
+		lat = strconv.FormatFloat(fso.GPS.Lat, 'f', -1, 64)
+		lon = strconv.FormatFloat(fso.GPS.Lon, 'f', -1, 64)
+	}
+	values = append(values, dbms.FieldLat, lat, dbms.FieldLon, lon)
+
 	return values
 }
+
+func prepareContentValues(content *types.ObjContent) []string {
+	if content == nil {
+		return nil
+	}
+
+	return []string{
+		dbms.ContentFieldText, content.Text,
+		dbms.ContentFieldTitle, content.Title,
+		dbms.ContentFieldAuthor, content.Author,
+		dbms.ContentFieldPages, strconv.FormatInt(content.Pages, 10),
+	}
+}
+
+// contentKey returns the key of the content related to the object key objKey
+func contentKey(objKey string) string {
+	// Object key has the format: prefix + host + ":" + path
+	host, path, _ := strings.Cut(strings.TrimPrefix(objKey, RedisObjPrefix), ":")
+
+	return RedisContentPrefix + common.MakeID(host, &types.FSObject{FPath: path})
+}
+
+// Number of permission bits including setuid, setgid and sticky bits
+const permBitsNum = 12
+
+// modeBits returns the list of permission bits set in mode, each bit is represented
+// by its octal value. It is required to match permission bits by mask using
+// RediSearch, that does not support bitwise operations on numeric fields
+func modeBits(mode int64) []string {
+	bits := []string{}
+	for i := 0; i < permBitsNum; i++ {
+		if bit := int64(1) << i; mode & bit != 0 {
+			bits = append(bits, strconv.FormatInt(bit, 8))
+		}
+	}
+
+	return bits
+}
+
+// connDo executes a single command with arbitrary arguments using the dedicated connection
+func connDo(ctx context.Context, conn *redis.Conn, args ...any) *redis.Cmd {
+	cmd := redis.NewCmd(ctx, args...)
+	// XXX The error is also stored in the command, so it can be ignored here
+	_ = conn.Process(ctx, cmd)
+
+	return cmd
+}
@@ -0,0 +1,229 @@
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/r-che/dfi/dbi/common"
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func (rc *Client) GetHistory(ha *dbms.HistArgs) (dbms.HistResults, error) {
+	// Keys of history streams of hosts
+	var keys []string
+	if ha.IsHosts() {
+		for _, host := range ha.Hosts {
+			keys = append(keys, RedisHistPrefix + host)
+		}
+	} else {
+		var err error
+		keys, err = rc.scanKeyMatch(RedisHistPrefix + "*", func(string) bool { return true })
+		if err != nil {
+			return nil, fmt.Errorf("(RedisCli:GetHistory) cannot scan keys of history streams: %w", err)
+		}
+	}
+
+	hr := dbms.HistResults{}
+	for _, key := range keys {
+		if err := rc.loadHistory(key, ha, &hr); err != nil {
+			return nil, fmt.Errorf("(RedisCli:GetHistory) %w", err)
+		}
+	}
+
+	hr.Sort()
+
+	// OK
+	return hr, nil
+}
+
+// loadHistory appends records from the history stream key matched by ha to hr
+func (rc *Client) loadHistory(key string, ha *dbms.HistArgs, hr *dbms.HistResults) error {
+	host := strings.TrimPrefix(key, RedisHistPrefix)
+
+	// Identifiers of stream entries start with the time of adding in milliseconds
+	start := "-"
+	if ha.Since > 0 {
+		start = strconv.FormatInt(ha.Since * 1000, 10)
+	}
+
+	// Keep current termLong value to have ability to compare during long-term operations
+	initTermLong := rc.TermLongVal
+
+	for {
+		// If value of the termLong was updated - need to terminate long-term operation
+		if rc.TermLongVal != initTermLong {
+			return fmt.Errorf("terminated")
+		}
+
+		msgs, err := rc.c.XRangeN(rc.Ctx, key, start, "+", RedisMaxScanKeys).Result()
+		if err != nil {
+			return fmt.Errorf("XRANGE on %q from %s failed: %w", key, start, err)
+		}
+
+		for _, msg := range msgs {
+			rec, err := histRecordFromValues(host, msg.Values)
+			if err != nil {
+				log.W("(RedisCli:loadHistory) Skip invalid entry %s of %q: %v", msg.ID, key, err)
+				continue
+			}
+
+			if ha.Match(rec) {
+				*hr = append(*hr, rec)
+			}
+		}
+
+		if len(msgs) < RedisMaxScanKeys {
+			// End of the stream reached
+			return nil
+		}
+
+		// Continue after the last loaded entry
+		start = "(" + msgs[len(msgs)-1].ID
+	}
+}
+
+func histRecordFromValues(host string, values map[string]any) (*dbms.HistRecord, error) {
+	hr := &dbms.HistRecord{Host: host}
+
+	for field, v := range map[string]*string{
+		dbms.HistFieldChange:	&hr.Change,
+		dbms.HistFieldOID:		&hr.ID,
+		dbms.HistFieldFPath:	&hr.FPath,
+		dbms.HistFieldOldCsum:	&hr.OldChecksum,
+		dbms.HistFieldNewCsum:	&hr.NewChecksum,
+	} {
+		*v, _ = values[field].(string)
+	}
+
+	for field, v := range map[string]*int64{
+		dbms.HistFieldTime:		&hr.Time,
+		dbms.HistFieldOldSize:	&hr.OldSize,
+		dbms.HistFieldNewSize:	&hr.NewSize,
+	} {
+		val, _ := values[field].(string)
+
+		n, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of field %q: %w", field, err)
+		}
+		*v = n
+	}
+
+	// OK
+	return hr, nil
+}
+
+// makeHistCmds returns commands to append records about the queued changes to the history of the host
+func (rc *Client) makeHistCmds() ([]*txCmd, error) {
+	// Keys of all changed objects
+	keys := make([]string, 0, len(rc.toUpdate) + len(rc.toDelete))
+	for _, item := range rc.toUpdate {
+		keys = append(keys, item.key)
+	}
+	keys = append(keys, rc.toDelete...)
+
+	// States of objects before the changes
+	before, err := rc.loadObjStates(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	histKey := RedisHistPrefix + rc.Cfg.CliHost
+	cmds := make([]*txCmd, 0, len(keys))
+
+	appendRecord := func(objKey string, after *common.ObjState) {
+		hr := common.HistRecord(now, rc.Cfg.CliHost, rc.objFPath(objKey), before[objKey], after)
+		if hr == nil {
+			// Nothing was changed
+			return
+		}
+
+		cmds = append(cmds, &txCmd{
+			op:		dbms.Update,
+			keys:	[]string{histKey},
+			args:	rc.xaddHistArgs(histKey, hr),
+			aux:	true,
+		})
+	}
+
+	for _, item := range rc.toUpdate {
+		appendRecord(item.key, item.state)
+	}
+	for _, objKey := range rc.toDelete {
+		appendRecord(objKey, nil)
+	}
+
+	return cmds, nil
+}
+
+// loadObjStates returns states of existing objects with keys, the keys of not existing objects are skipped
+func (rc *Client) loadObjStates(keys []string) (map[string]*common.ObjState, error) {
+	states := make(map[string]*common.ObjState, len(keys))
+
+	// Load states using pipelines with limited number of commands
+	chunkSize := rc.ChunkSize(RedisDefaultChunkSize)
+	for start := 0; start < len(keys); start += chunkSize {
+		chunk := keys[start:common.ChunkEnd(start, chunkSize, len(keys))]
+
+		pipe := rc.c.Pipeline()
+		cmds := make([]*redis.SliceCmd, 0, len(chunk))
+		for _, key := range chunk {
+			cmds = append(cmds, pipe.HMGet(rc.Ctx, key, dbms.FieldSize, dbms.FieldChecksum))
+		}
+
+		if _, err := pipe.Exec(rc.Ctx); err != nil {
+			return nil, fmt.Errorf("cannot load states of %d objects: %w", len(chunk), err)
+		}
+
+		for i, cmd := range cmds {
+			vals := cmd.Val()
+
+			// HMGET returns nil values for not existing keys
+			size, ok := vals[0].(string)
+			if !ok {
+				continue
+			}
+
+			state := &common.ObjState{}
+			state.Size, _ = strconv.ParseInt(size, 10, 64)
+			state.Checksum, _ = vals[1].(string)
+
+			states[chunk[i]] = state
+		}
+	}
+
+	// OK
+	return states, nil
+}
+
+func (rc *Client) xaddHistArgs(histKey string, hr *dbms.HistRecord) []any {
+	args := []any{"XADD", histKey}
+
+	// Remove records older than the retention period
+	if since := rc.HistSince(hr.Time); since != 0 {
+		args = append(args, "MINID", "~", since * 1000)
+	}
+
+	return append(args, "*",
+		dbms.HistFieldTime,		hr.Time,
+		dbms.HistFieldChange,	hr.Change,
+		dbms.HistFieldOID,		hr.ID,
+		dbms.HistFieldFPath,	hr.FPath,
+		dbms.HistFieldOldSize,	hr.OldSize,
+		dbms.HistFieldNewSize,	hr.NewSize,
+		dbms.HistFieldOldCsum,	hr.OldChecksum,
+		dbms.HistFieldNewCsum,	hr.NewChecksum,
+	)
+}
+
+// objFPath returns the found path of the object of the client host by the key
+func (rc *Client) objFPath(objKey string) string {
+	return strings.TrimPrefix(objKey, RedisObjPrefix + rc.Cfg.CliHost + ":")
+}
@@ -0,0 +1,123 @@
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+
+	rsh "github.com/RediSearch/redisearch-go/redisearch"
+)
+
+func (rc *Client) UpdateHost(hi *dbms.HostInfo) error {
+	if rc.ReadOnly {
+		log.D("(RedisCli:UpdateHost) R/O mode IS SET, information about host %q will not be stored", hi.Host)
+		return nil
+	}
+
+	// Count indexed objects of the host
+	n, err := rc.countHostObjects(hi.Host)
+	if err != nil {
+		return fmt.Errorf("(RedisCli:UpdateHost) %w", err)
+	}
+	hi.Objects = n
+
+	// Fields of the agent metadata set by other operations are kept as is
+	err = rc.c.HSet(rc.Ctx, rc.metaKey(),
+		dbms.MetaFieldVersion,		hi.Version,
+		dbms.MetaFieldPaths,		strings.Join(hi.IdxPaths, ","),
+		dbms.MetaFieldCalcSums,		strconv.FormatBool(hi.CalcSums),
+		dbms.MetaFieldMaxSumSize,	hi.MaxSumSize,
+		dbms.MetaFieldStarted,		hi.Started,
+		dbms.MetaFieldHeartbeat,	hi.Heartbeat,
+		dbms.MetaFieldHBPeriod,		hi.HBPeriod,
+		dbms.MetaFieldLastFlush,	hi.LastFlush,
+		dbms.MetaFieldObjects,		hi.Objects,
+	).Err()
+	if err != nil {
+		return fmt.Errorf("(RedisCli:UpdateHost) cannot update key %q: %w", rc.metaKey(), err)
+	}
+
+	// OK
+	return nil
+}
+
+func (rc *Client) GetHosts() ([]*dbms.HostInfo, error) {
+	keys, err := rc.scanKeyMatch(RedisMetaPrefix + "*", func(string) bool { return true })
+	if err != nil {
+		return nil, fmt.Errorf("(RedisCli:GetHosts) cannot scan keys of hosts metadata: %w", err)
+	}
+
+	hosts := make([]*dbms.HostInfo, 0, len(keys))
+	for _, key := range keys {
+		meta, err := rc.c.HGetAll(rc.Ctx, key).Result()
+		if err != nil {
+			return nil, fmt.Errorf("(RedisCli:GetHosts) cannot get metadata of host from %q: %w", key, err)
+		}
+
+		hosts = append(hosts, hostInfoFromMeta(strings.TrimPrefix(key, RedisMetaPrefix), meta))
+	}
+
+	// OK
+	return hosts, nil
+}
+
+func (rc *Client) countHostObjects(host string) (int64, error) {
+	// Get RediSearch client
+	rsc, err := rc.rschInit(metaRschIdx)
+	if err != nil {
+		return 0, fmt.Errorf("cannot initialize RediSearch client: %w", err)
+	}
+
+	// Only the total number of matched documents is required
+	q := rsh.NewQuery(makeTagsQuery(dbms.FieldHost, []string{host})).
+		SetFlags(rsh.QueryNoContent).
+		Limit(0, 0)
+
+	_, total, err := rsc.Search(q)
+	if err != nil {
+		return 0, fmt.Errorf("cannot count objects of host %q: %w", host, err)
+	}
+
+	return int64(total), nil
+}
+
+// hostInfoFromMeta converts the agent metadata of the host to the host information, hosts registered by
+// old agents have only some of fields, so missing and invalid fields are left with zero values
+func hostInfoFromMeta(host string, meta map[string]string) *dbms.HostInfo {
+	hi := &dbms.HostInfo{
+		Host:		host,
+		Version:	meta[dbms.MetaFieldVersion],
+	}
+
+	if paths := meta[dbms.MetaFieldPaths]; paths != "" {
+		hi.IdxPaths = strings.Split(paths, ",")
+	}
+	hi.CalcSums, _ = strconv.ParseBool(meta[dbms.MetaFieldCalcSums])
+
+	for field, v := range map[string]*int64{
+		dbms.MetaFieldMaxSumSize:	&hi.MaxSumSize,
+		dbms.MetaFieldStarted:		&hi.Started,
+		dbms.MetaFieldHeartbeat:	&hi.Heartbeat,
+		dbms.MetaFieldHBPeriod:		&hi.HBPeriod,
+		dbms.MetaFieldLastFlush:	&hi.LastFlush,
+		dbms.MetaFieldObjects:		&hi.Objects,
+	} {
+		val, ok := meta[field]
+		if !ok {
+			continue
+		}
+
+		n, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			log.W("(RedisCli:hostInfoFromMeta) Invalid value of field %q of host %q: %v", field, host, err)
+			continue
+		}
+		*v = n
+	}
+
+	return hi
+}
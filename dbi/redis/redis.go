@@ -15,15 +15,36 @@ For example:
     "password": "redis-password"
   }
 
+# TLS configuration
+
+Connections to the Redis server are encrypted if the same section contains
+the "TLS" object with the CA bundle to verify the server, the client certificate
+and key to authenticate by the server (both are optional) and the name of the server:
+
+  {
+    "user": "redis-username",
+    "password": "redis-password",
+    "TLS": {
+      "CAFile": "/etc/dfi/ca.pem",
+      "CertFile": "/etc/dfi/client.pem",
+      "KeyFile": "/etc/dfi/client.key",
+      "ServerName": "redis.example.com"
+    }
+  }
+
+The "InsecureSkipVerify" field disables verification of the server certificate, use it only for testing.
+
 See the component's README file for more information.
 
 */
 package redis
 
 import (
+	"crypto/tls"
 	"fmt"
 	"strconv"
 
+	"github.com/r-che/dfi/dbi/common"
 	"github.com/r-che/dfi/types/dbms"
 
 	"github.com/go-redis/redis/v8"
@@ -31,12 +52,23 @@ import (
 
 const (
 	RedisMaxScanKeys	=	1024 * 10
+	// Default number of commands queued to Redis in a single pipeline
+	RedisDefaultChunkSize	=	1000
 
 	// Redis namespace prefixes
 	RedisObjPrefix		=	"obj:"
 	RedisAIIPrefix		=	"aii:"
 	RedisAIIDMetaPefix	=	"aii-meta:"
 	RedisAIIDSetPrefix	=	RedisAIIDMetaPefix + "set-"
+	RedisMetaPrefix		=	"meta:"
+	RedisContentPrefix	=	"content:"
+	RedisHistPrefix		=	"hist:"
+	// Prefix of the channel to publish committed changes
+	RedisChangesPrefix	=	"changes:"
+
+	// Redis-specific object fields
+	RedisFieldModeBits	=	"modebits"	// TAG field with permission bits set in the mode field
+	RedisFieldTarget	=	"target"	// TAG field with real path of symbolic links, used to match links by target
 
 	// Private configuration fields
 	userField	=	"user"
@@ -50,8 +82,10 @@ type Client struct {
 	*dbms.CommonClient
 
 	c	*redis.Client
+	tls	*tls.Config	// TLS configuration of connections, nil if TLS is not used
 
 	// Dynamic members
+	toUpdate	[]*hsetItem
 	toDelete	[]string
 	updated		int64
 	deleted		int64
@@ -70,6 +104,12 @@ func NewClient(dbCfg *dbms.DBConfig) (*Client, error) {
 		return nil, fmt.Errorf("(RedisCli:NewClient) failed to load username/password from private configuration: %w", err)
 	}
 
+	// Load TLS settings from private data if set
+	tlsCfg, err := common.TLSConfig(dbCfg.PrivCfg)
+	if err != nil {
+		return nil, fmt.Errorf("(RedisCli:NewClient) failed to load TLS settings: %w", err)
+	}
+
 	// Initialize Redis client
 	rc := &Client{
 		CommonClient: dbms.NewCommonClient(dbCfg),
@@ -78,20 +118,23 @@ func NewClient(dbCfg *dbms.DBConfig) (*Client, error) {
 			Username:	user,
 			Password:	passw,
 			DB:			int(dbid),
+			TLSConfig:	tlsCfg,
 		}),
+		tls:	tlsCfg,
 	}
 
 	return rc, nil
 }
 
 func userPasswd(pcf map[string]any) (string, string, error) {
-	// Check for empty configuration
-	if pcf == nil {
+	// Check for configuration without credentials, e.g. only with TLS settings
+	_, hasUser := pcf[userField]
+	_, hasPass := pcf[passField]
+	if !hasUser && !hasPass {
 		// OK, just return nothing
 		return "", "", nil
 	}
 
-
 	loadField := func(field string) (string, error) {
 		v, ok := pcf[field]
 		if !ok {
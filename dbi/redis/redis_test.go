@@ -0,0 +1,188 @@
+package redis
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/r-che/dfi/dbi/common"
+	"github.com/r-che/dfi/types/dbms"
+)
+
+// handshake is the result of the TLS handshake accepted by the test server
+type handshake struct {
+	serverName	string	// name requested by the client
+	peerCerts	int		// number of certificates presented by the client
+	err			error
+}
+
+// testTLSServer starts the server that requires client certificates issued by the CA, the server
+// uses the same self-signed certificate as the CA, its address and the configuration of clients are returned
+func testTLSServer(t *testing.T) (string, map[string]any, <-chan handshake) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:			big.NewInt(1),
+		Subject:				pkix.Name{CommonName: "dfi-test"},
+		DNSNames:				[]string{"db.test"},
+		NotBefore:				time.Now().Add(-time.Hour),
+		NotAfter:				time.Now().Add(time.Hour),
+		KeyUsage:				x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:			[]x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:					true,
+		BasicConstraintsValid:	true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("cannot create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("cannot encode key: %v", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatalf("cannot write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatalf("cannot write key: %v", err)
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("cannot load certificate: %v", err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(certPEM)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %v", err)
+	}
+	t.Cleanup(func() { lis.Close() })
+
+	handshakes := make(chan handshake, 8)
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				// Listener is closed
+				return
+			}
+
+			var hs handshake
+			srv := tls.Server(conn, &tls.Config{
+				Certificates:	[]tls.Certificate{cert},
+				ClientCAs:		clientCAs,
+				ClientAuth:		tls.RequireAndVerifyClientCert,
+				GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+					hs.serverName = hello.ServerName
+					return nil, nil
+				},
+			})
+			hs.err = srv.Handshake()
+			hs.peerCerts = len(srv.ConnectionState().PeerCertificates)
+			srv.Close()
+
+			handshakes <- hs
+		}
+	}()
+
+	return lis.Addr().String(), map[string]any{
+		common.PrivFieldTLS: map[string]any{
+			"CAFile":		certFile,
+			"CertFile":		certFile,
+			"KeyFile":		keyFile,
+			"ServerName":	"db.test",
+		},
+	}, handshakes
+}
+
+// Connections of the main client and of RediSearch clients use the same TLS configuration
+func TestClientTLS(t *testing.T) {
+	addr, pcf, handshakes := testTLSServer(t)
+
+	rc, err := NewClient(&dbms.DBConfig{HostPort: addr, ID: "0", PrivCfg: pcf})
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+	if rc.tls == nil || rc.c.Options().TLSConfig != rc.tls {
+		t.Fatalf("want the same TLS configuration of the client and connections, got %p and %p",
+			rc.tls, rc.c.Options().TLSConfig)
+	}
+
+	checkHandshake := func(cli string) {
+		select {
+		case hs := <-handshakes:
+			if hs.err != nil {
+				t.Errorf("%s - TLS handshake failed: %v", cli, hs.err)
+			}
+			if hs.serverName != "db.test" {
+				t.Errorf("%s - want server name %q, got %q", cli, "db.test", hs.serverName)
+			}
+			if hs.peerCerts != 1 {
+				t.Errorf("%s - want 1 client certificate, got %d", cli, hs.peerCerts)
+			}
+		case <-time.After(10 * time.Second):
+			t.Errorf("%s - no TLS handshake with the server", cli)
+		}
+	}
+
+	// Connection of the main client
+	conn, err := rc.c.Options().Dialer(context.Background(), "tcp", addr)
+	if err != nil {
+		t.Errorf("main client cannot connect to the server: %v", err)
+	} else {
+		conn.Close()
+		checkHandshake("main client")
+	}
+
+	// Connection from the pool of RediSearch clients
+	pool, err := rc.rschPool()
+	if err != nil {
+		t.Fatalf("rschPool returned unexpected error: %v", err)
+	}
+	defer pool.Close()
+
+	rconn := pool.Get()
+	if err := rconn.Err(); err != nil {
+		t.Errorf("RediSearch client cannot connect to the server: %v", err)
+	} else {
+		rconn.Close()
+		checkHandshake("RediSearch client")
+	}
+
+	// Without TLS settings connections are not encrypted
+	rc, err = NewClient(&dbms.DBConfig{HostPort: addr, ID: "0", PrivCfg: map[string]any{}})
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+	if rc.tls != nil || rc.c.Options().TLSConfig != nil {
+		t.Errorf("want no TLS configuration, got %v and %v", rc.tls, rc.c.Options().TLSConfig)
+	}
+
+	// Invalid settings are reported
+	pcf = map[string]any{common.PrivFieldTLS: map[string]any{"CertFile": "/nonexistent"}}
+	if _, err := NewClient(&dbms.DBConfig{HostPort: addr, ID: "0", PrivCfg: pcf}); err == nil {
+		t.Errorf("NewClient with invalid TLS settings - want error, got nil")
+	}
+}
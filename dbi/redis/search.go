@@ -18,20 +18,22 @@ import (
 const (
 	metaRschIdx		=	"obj-meta-idx"
 	aiiRschIdx		=	"aii-idx"
+	contentRschIdx	=	"content-idx"
 	objsPerQuery	=	1000
 
 	// Estimated maximum number of search results, empiric value
 	estResultsCount	=	32
 )
 
-func rshSearchAII(cli *rsh.Client, q *rsh.Query) ([]string, error) {
+// rshSearchIds returns identifiers of found documents, prefix is the prefix of keys of the documents
+func rshSearchIds(cli *rsh.Client, q *rsh.Query, prefix string) ([]string, error) {
 	// Offset from which matched documents should be selected
 	offset := 0
 
 	// Content is not needed - only keys should be returned
 	q.SetFlags(rsh.QueryNoContent)
 
-	// log.D("(RedisCli:rshSearchAII) Prepared RediSearch query string: %v", q.Raw)	// XXX Raw query may be too long
+	// log.D("(RedisCli:rshSearchIds) Prepared RediSearch query string: %v", q.Raw)	// XXX Raw query may be too long
 
 	// Output result
 	ids := make([]string, 0, estResultsCount)
@@ -40,7 +42,7 @@ func rshSearchAII(cli *rsh.Client, q *rsh.Query) ([]string, error) {
 	totDocs := 0
 
 	// Key prefix length
-	kpl := len(RedisAIIPrefix)
+	kpl := len(prefix)
 
 	for {
 		// Update query to set offset/limit
@@ -49,7 +51,7 @@ func rshSearchAII(cli *rsh.Client, q *rsh.Query) ([]string, error) {
 		// Do search
 		docs, total, err := cli.Search(q)
 		if err != nil {
-			return ids, fmt.Errorf("(RedisCli:rshSearchAII) RediSearch returned %d records and failed: %w", len(ids), err)
+			return ids, fmt.Errorf("(RedisCli:rshSearchIds) RediSearch returned %d records and failed: %w", len(ids), err)
 		}
 
 		log.D("(RedisCli) Scanned offset: %d .. %d, selected %d (total matched %d)",
@@ -58,7 +60,7 @@ func rshSearchAII(cli *rsh.Client, q *rsh.Query) ([]string, error) {
 		// Convert scanned documents to output result
 		for _, doc := range docs {
 			if len(doc.Id) <= kpl {
-				log.E("RedisCli:rshSearchAII) Found invalid AII with too short key %q, skip it", doc.Id)
+				log.E("(RedisCli:rshSearchIds) Found invalid document with too short key %q, skip it", doc.Id)
 				continue
 			}
 			ids = append(ids, doc.Id[kpl:])
@@ -74,51 +76,63 @@ func rshSearchAII(cli *rsh.Client, q *rsh.Query) ([]string, error) {
 	}
 
 	// Return results
-	log.D("(RedisCli:rshSearchAII) RediSearch returned %d records", len(ids))
+	log.D("(RedisCli:rshSearchIds) RediSearch returned %d records", len(ids))
 
 	// OK
 	return ids, nil
 }
 
 func (rc *Client) rschInit(rschIdx string) (*rsh.Client, error) {
+	pool, err := rc.rschPool()
+	if err != nil {
+		return nil, err
+	}
+
+	// OK, return client from pool
+	return rsh.NewClientFromPool(pool, rschIdx), nil
+}
+
+// rschPool returns the pool of connections used by RediSearch clients, connections are made with the same
+// database identifier, credentials and TLS configuration as connections of the main client
+func (rc *Client) rschPool() (*redis.Pool, error) {
 	// Read username/password from private data if set
 	user, passw, err := userPasswd(rc.Cfg.PrivCfg)
 	if err != nil {
-		return nil, fmt.Errorf("(RedisCli:rschInit) failed to load username/password from private configuration: %w", err)
+		return nil, fmt.Errorf("(RedisCli:rschPool) failed to load username/password from private configuration: %w", err)
 	}
 
 	// Convert string representation of database identifier to numeric database index
 	dbid, err := strconv.ParseUint(rc.Cfg.ID, 10, 64)
 	if err != nil {
-		return nil, fmt.Errorf("(RedisCli:rschInit) cannot convert database identifier value to unsigned integer: %w", err)
+		return nil, fmt.Errorf("(RedisCli:rschPool) cannot convert database identifier value to unsigned integer: %w", err)
 	}
 
 	// Check for DB ID is not 0
 	if dbid != 0 {
 		// It may cause problems
-		log.W("(RedisCli:rschInit) WARNING! Redis DB ID is set to %d - it may cause incorrect results " +
+		log.W("(RedisCli:rschPool) WARNING! Redis DB ID is set to %d - it may cause incorrect results " +
 			"due to RediSearch does not work on DBs with non-zero ID, see: %s",
 			dbid, `https://github.com/RediSearch/RediSearch/issues/367`)
 	}
 
-	// Create pool to have ability to provide authentication and database identifier
+	// Options of connections to provide database identifier, authentication and TLS
+	opts := []redis.DialOption{redis.DialDatabase(int(dbid))}
+	if passw != "" {
+		opts = append(opts, redis.DialUsername(user), redis.DialPassword(passw))
+	}
+	if rc.tls != nil {
+		opts = append(opts, redis.DialUseTLS(true), redis.DialTLSConfig(rc.tls))
+	}
+
+	// Create pool to have ability to provide connection options
 	pool := &redis.Pool{
 		Dial: func() (redis.Conn, error) {
-			if passw == "" {
-				// Simple dial
-				return redis.Dial("tcp", rc.Cfg.HostPort, redis.DialDatabase(int(dbid)))
-			}
-			// Dial with authentication
-			return redis.Dial("tcp", rc.Cfg.HostPort,
-				redis.DialDatabase(int(dbid)),
-				redis.DialUsername(user),
-				redis.DialPassword(passw),
-			)
+			return redis.Dial("tcp", rc.Cfg.HostPort, opts...)
 		},
 	}
 
-	// OK, return client from pool
-	return rsh.NewClientFromPool(pool, rschIdx), nil
+	// OK
+	return pool, nil
 }
 
 func rshSearch(cli *rsh.Client, q *rsh.Query, retFields []string) (dbms.QueryResults, error) {
@@ -188,128 +202,206 @@ func rshSearch(cli *rsh.Client, q *rsh.Query, retFields []string) (dbms.QueryRes
 	return qr, nil
 }
 
-func rshQueryByIds(ids []string, qa *dbms.QueryArgs) string {
-	// Make query to search by IDs
-	idsQuery := fmt.Sprintf(`(@%s:{%s})`, dbms.FieldID, strings.Join(ids, `|`))
+func rshQueryByIds(ids []string, qa *dbms.QueryArgs) (string, error) {
+	// Conditions on names and paths by patterns are ignored here, they
+	// are checked on the client side by the caller if it is necessary
+	expr, _, err := rshFilter(qa)
+	if err != nil {
+		return "", err
+	}
+
+	argsQuery, err := rshArgs(expr)
+	if err != nil {
+		return "", err
+	}
+
+	// Make a summary query to search by IDs with query arguments
+	return rshRestrict(makeTagsQuery(dbms.FieldID, ids) + ` ` + argsQuery, qa), nil
+}
+
+// rshQuery returns the RediSearch query and the expression with conditions
+// on names and paths by patterns to filter found objects
+func rshQuery(qa *dbms.QueryArgs) (string, *dbms.Expr, error) {
+	expr, patterns, err := rshFilter(qa)
+	if err != nil {
+		return "", nil, err
+	}
+
+	argsQuery, err := rshArgs(expr)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sq, err := rshSearchQuery(qa, argsQuery)
+	if err != nil {
+		return "", nil, err
+	}
+
+	q := rshRestrict(sq, qa)
+	if q == "" {
+		// All conditions are checked on the client side
+		q = rshAllQuery
+	}
+
+	// OK
+	return q, patterns, nil
+}
+
+// rshRestrict restricts the query q by identifiers of objects with matched content and by
+// the set of allowed identifiers, also it excludes objects of excluded hosts, if any
+func rshRestrict(q string, qa *dbms.QueryArgs) string {
+	// Restrictions are independent of the negation and OR-ing of other conditions
+	if qa.IsContentIds() {
+		q = makeTagsQuery(dbms.FieldID, qa.ContentIds) + ` ` + q
+	}
+	if qa.IsOnlyIds() {
+		q = makeTagsQuery(dbms.FieldID, qa.OnlyIds) + ` ` + q
+	}
+	if qa.IsExclHosts() {
+		q += ` -` + makeTagsQuery(dbms.FieldHost, qa.ExclHosts)
+	}
 
-	// Make a summary query with search phrases
-	return idsQuery + ` ` + rshArgs(qa)
+	return strings.TrimSpace(q)
 }
 
-func rshQuery(qa *dbms.QueryArgs) string {
+func rshSearchQuery(qa *dbms.QueryArgs, argsQuery string) (string, error) {
 	if len(qa.SP) == 0 && !qa.IsIds() {
 		// Return only arguments part
-		return rshArgs(qa)
+		return argsQuery, nil
 	}
 
 	chunks := make([]string, 0, 1)	// At least we need 1 chunk for search
 
 	if len(qa.SP) != 0 {
-		// XXX Convert of search phrase values to lowercase because RediSearch
-		// XXX does not fully support case insensitivity for non-English locales
-		spLower := make([]string, 0, len(qa.SP))
-		for _, sp := range qa.SP {
-			spLower= append(spLower, strings.ToLower(sp))
-		}
-
-		// Make search phrases query - try to search them in found path and real path
-		if qa.OnlyName {
-			// Use only the "name" field to search
-			chunks = append(chunks, fmt.Sprintf(`(@%s:%s)`, dbms.FieldName, strings.Join(spLower, `|`)))
-		} else {
-			// Use the found path and real path fields to search
-			chunks = append(chunks,
-				fmt.Sprintf(`(@%[1]s|%[2]s:%[3]s)`, dbms.FieldFPath, dbms.FieldRPath, strings.Join(spLower, `|`)))
+		spQuery, err := rshPhrasesQuery(qa)
+		if err != nil {
+			return "", err
 		}
+		chunks = append(chunks, spQuery)
 	}
 
 	if qa.IsIds() {
-		chunks = append(chunks, `(@` + dbms.FieldID + `:{` +  strings.Join(qa.Ids, `|`) + `})`)
+		chunks = append(chunks, makeTagsQuery(dbms.FieldID, qa.Ids))
 	}
 
-	if len(chunks) == 0 {
-		// No search phrases/AII data was provided, use only query arguments
-		return rshArgs(qa)
+	// Make a summary query with search phrases/AII data + query arguments
+	q := `(` + strings.Join(chunks, ` | `) + `)`
+	if argsQuery != "" {
+		q += ` ` + argsQuery
 	}
 
-	// Make a summary query with search phrases/AII data + query arguments
-	return `(` + strings.Join(chunks, ` | `) + `)` + ` ` + rshArgs(qa)
+	return q, nil
 }
 
-func rshArgs(qa *dbms.QueryArgs) string {
-	// Arguments query chunks
-	chunks := []string{}
-
-	if qa.IsMtime() {
-		chunks = append(chunks, makeSetRangeQuery(dbms.FieldMTime, qa.MtimeStart, qa.MtimeEnd, qa.MtimeSet))
-	}
-	if qa.IsSize() {
-		chunks = append(chunks, makeSetRangeQuery(dbms.FieldSize, qa.SizeStart, qa.SizeEnd, qa.SizeSet))
-	}
-	if qa.IsType() {
-		chunks = append(chunks, `(@` + dbms.FieldType + `:{` +  strings.Join(qa.Types, `|`) + `})`)
-	}
-	if qa.IsChecksum() {
-		chunks = append(chunks, `(@` + dbms.FieldChecksum + `:{` +  strings.Join(qa.CSums, `|`) + `})`)
-	}
-	if qa.IsHost() {
-		// At least need to escape dashes ("-") inside of hostname to avoid split hostnames by RediSearch tokenizer
-		escapedHosts := make([]string, 0, len(qa.Hosts))
-		for _, host := range qa.Hosts {
-			escapedHosts = append(escapedHosts, rsh.EscapeTextFileString(host))
+// rshPhrasesQuery makes the query to match search phrases
+func rshPhrasesQuery(qa *dbms.QueryArgs) (string, error) {
+	if qa.RawQuery {
+		// Phrases are queries in the RediSearch syntax, any of them should match
+		chunks := make([]string, 0, len(qa.SP))
+		for _, sp := range qa.SP {
+			raw, err := rshRawQuery(sp)
+			if err != nil {
+				return "", err
+			}
+			chunks = append(chunks, raw)
 		}
 
-		chunks = append(chunks, `(@` + dbms.FieldHost + `:{` + strings.Join(escapedHosts, `|`) + `})`)
+		return `(` + strings.Join(chunks, ` | `) + `)`, nil
 	}
 
-	// Check that chunks is not empty
-	if len(chunks) == 0 {
-		return ""
+	// Make search phrases query - try to search them in found path and real path
+	field := dbms.FieldFPath + `|` + dbms.FieldRPath
+	if qa.OnlyName {
+		// Use only the "name" field to search
+		field = dbms.FieldName
 	}
 
-	// Need to build request from chunks
-	var argsQuery string
-	negMark := ""
-	if qa.NegExpr {
-		negMark = "-"
-	}
+	return makeTextQuery(field, qa.SP, qa.LiteralSP)
+}
 
-	if qa.OrExpr {
-		argsQuery = fmt.Sprintf(`%s(%s)`, negMark, strings.Join(chunks, ` | `))
-	} else {
-		argsQuery = fmt.Sprintf(`%s(%s)`, negMark, strings.Join(chunks, ` `))
+// rshArgs compiles the expression of query arguments, the query expression
+// must not contain conditions on names and paths by shell patterns
+func rshArgs(expr *dbms.Expr) (string, error) {
+	argsQuery, err := rshExpr(expr)
+	if err != nil {
+		return "", fmt.Errorf("(RedisCli:rshArgs) cannot make query from the expression: %w", err)
 	}
 
 	// Done
-	return argsQuery
+	return argsQuery, nil
 }
 
-func makeSetRangeQuery(field string, min, max int64, set []int64) string {
-	// Is set is not provided
-	if len(set) == 0 {
-		// Then min/max query
+func makeTagsQuery(field string, tags []string) string {
+	escaped := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		escaped = append(escaped, rshEscape(tag))
+	}
+
+	return `(@` + field + `:{` + strings.Join(escaped, `|`) + `})`
+}
 
-		// If closed interval
-		if min != 0 && max != 0 {
-			return fmt.Sprintf(`@%s:[%d %d]`, field, min, max)
+// makeTextQuery makes query to match any of phrases in the text field, all words of a phrase
+// should match in any order, or in the same order one after another if literal is set
+func makeTextQuery(field string, phrases []string, literal bool) (string, error) {
+	chunks := make([]string, 0, len(phrases))
+	for _, phrase := range phrases {
+		words := rshWords(phrase)
+		if len(words) == 0 {
+			return "", fmt.Errorf("(RedisCli:makeTextQuery) phrase %q does not contain words to search", phrase)
 		}
 
-		// Half-open interval
-		if min == 0 {
-			return fmt.Sprintf(`@%s:[-inf %d]`, field, max)
+		for i, word := range words {
+			words[i] = rshEscape(word)
 		}
 
-		return fmt.Sprintf(`@%s:[%d +inf]`, field, min)
+		if literal {
+			chunks = append(chunks, `"` + strings.Join(words, ` `) + `"`)
+		} else {
+			chunks = append(chunks, `(` + strings.Join(words, ` `) + `)`)
+		}
 	}
 
-	// Build query from set of values
-	chunks := make([]string, 0, len(set))
+	return `(@` + field + `:(` + strings.Join(chunks, `|`) + `))`, nil
+}
 
-	for _, item := range set {
-		chunks = append(chunks, fmt.Sprintf(`@%s:[%d %d]`, field, item, item))
+func makeMIMEQuery(mimes []string) string {
+	escaped := make([]string, 0, len(mimes))
+	for _, mime := range mimes {
+		if prefix, ok := dbms.MIMEWildcard(mime); ok {
+			// Use tag prefix query to match all subtypes, the asterisk must not be escaped
+			escaped = append(escaped, rshEscape(prefix) + `*`)
+		} else {
+			escaped = append(escaped, rshEscape(mime))
+		}
 	}
 
-	return `(` + strings.Join(chunks, `|`) + `)`
+	return `(@` + dbms.FieldMIME + `:{` + strings.Join(escaped, `|`) + `})`
+}
+
+// makePointsToQuery makes query to match symbolic links pointing to the path or into it
+func makePointsToQuery(target string) string {
+	// Use tag prefix query to match nested objects, the asterisk must not be escaped
+	return `(@` + RedisFieldTarget + `:{` + rshEscape(target) + `|` + rshEscape(dbms.PathPrefix(target)) + `*})`
+}
+
+func makePermQuery(perm int64, match dbms.PermMatch) string {
+	if match == dbms.PermExact {
+		return fmt.Sprintf(`@%s:[%d %d]`, dbms.FieldMode, perm, perm)
+	}
+
+	// All bits from perm should be set - each bit is a separate tag in the mode bits field
+	bits := modeBits(perm)
+	if len(bits) == 0 {
+		// Any mode matches the empty mask
+		return fmt.Sprintf(`@%s:[-inf +inf]`, dbms.FieldMode)
+	}
+
+	chunks := make([]string, 0, len(bits))
+	for _, bit := range bits {
+		chunks = append(chunks, `@` + RedisFieldModeBits + `:{` + bit + `}`)
+	}
+
+	return `(` + strings.Join(chunks, ` `) + `)`
 }
 
 //
@@ -396,7 +488,11 @@ func (rc *Client) scanSearch(rsc *rsh.Client, qa *dbms.QueryArgs,
 	// 3. Run RediSearch with extracted IDs and provided query arguments
 
 	// Run search to get results by IDs
-	qr, err := rshSearch(rsc, rsh.NewQuery(rshQueryByIds(ids, qa)), retFields)
+	q, err := rshQueryByIds(ids, qa)
+	if err != nil {
+		return 0, fmt.Errorf("(RedisCli:scanSearch) %w", err)
+	}
+	qr, err := rshSearch(rsc, rsh.NewQuery(q), retFields)
 
 	// 4. Merge selected results with the previous results
 	for k, v := range qr {
@@ -412,14 +508,8 @@ func scanSearchPatterns(qa *dbms.QueryArgs) []string {
 	patterns := make([]string, 0, len(qa.SP) * len(qa.Hosts))
 
 	for _, sp := range qa.SP {
-		// Prepend by asterisk
-		if !strings.HasPrefix(sp, "*") {
-			sp = "*" + sp
-		}
-		// Append asterisk at the end
-		if !strings.HasSuffix(sp, "*") {
-			sp += "*"
-		}
+		// Search phrase can be found in any part of the key
+		sp = "*" + rshGlobEscape(sp) + "*"
 
 		// Is hosts list is empty?
 		if len(qa.Hosts) == 0 {
@@ -428,7 +518,7 @@ func scanSearchPatterns(qa *dbms.QueryArgs) []string {
 		} else {
 			// Prepare search phrases for each host separately
 			for _, host := range qa.Hosts {
-				patterns = append(patterns, RedisObjPrefix + host + ":" + sp)
+				patterns = append(patterns, RedisObjPrefix + rshGlobEscape(host) + ":" + sp)
 			}
 		}
 	}
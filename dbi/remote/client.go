@@ -0,0 +1,123 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/r-che/dfi/dbi/remote/rpc"
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+)
+
+func (c *Client) Query(qa *dbms.QueryArgs, retFields []string) (dbms.QueryResults, error) {
+	var resp rpc.QueryResponse
+	err := c.invoke("Query", rpc.MethodQuery, &rpc.QueryRequest{QA: qa, RetFields: retFields}, &resp)
+
+	return rpc.DecodeQR(resp.Results), err
+}
+
+func (c *Client) QueryAIIIds(qa *dbms.QueryArgs) ([]string, error) {
+	var resp rpc.IdsResponse
+	err := c.invoke("QueryAIIIds", rpc.MethodQueryAIIIds, &rpc.QueryRequest{QA: qa}, &resp)
+
+	return resp.Ids, err
+}
+
+func (c *Client) QueryContentIds(qa *dbms.QueryArgs) ([]string, error) {
+	var resp rpc.IdsResponse
+	err := c.invoke("QueryContentIds", rpc.MethodQueryContentIds, &rpc.QueryRequest{QA: qa}, &resp)
+
+	return resp.Ids, err
+}
+
+func (c *Client) GetObjects(ids, retFields []string) (dbms.QueryResults, error) {
+	var resp rpc.QueryResponse
+	err := c.invoke("GetObjects", rpc.MethodGetObjects, &rpc.IdsRequest{Ids: ids, RetFields: retFields}, &resp)
+
+	return rpc.DecodeQR(resp.Results), err
+}
+
+func (c *Client) GetAIIs(ids, retFields []string) (dbms.QueryResultsAII, error) {
+	var resp rpc.AIIsResponse
+	err := c.invoke("GetAIIs", rpc.MethodGetAIIs, &rpc.IdsRequest{Ids: ids, RetFields: retFields}, &resp)
+
+	if resp.AIIs == nil {
+		resp.AIIs = dbms.QueryResultsAII{}
+	}
+
+	return resp.AIIs, err
+}
+
+func (c *Client) GetAIIIds(withFields []string) ([]string, error) {
+	var resp rpc.IdsResponse
+	err := c.invoke("GetAIIIds", rpc.MethodGetAIIIds, &rpc.IdsRequest{RetFields: withFields}, &resp)
+
+	return resp.Ids, err
+}
+
+func (c *Client) GetHosts() ([]*dbms.HostInfo, error) {
+	var resp rpc.HostsResponse
+	err := c.invoke("GetHosts", rpc.MethodGetHosts, &rpc.Empty{}, &resp)
+
+	return resp.Hosts, err
+}
+
+func (c *Client) GetHistory(ha *dbms.HistArgs) (dbms.HistResults, error) {
+	var resp rpc.HistoryResponse
+	err := c.invoke("GetHistory", rpc.MethodGetHistory, &rpc.HistoryRequest{HA: ha}, &resp)
+
+	return resp.Records, err
+}
+
+func (c *Client) DiskUsage(qa *dbms.QueryArgs, dua *dbms.DUArgs) (dbms.DUResults, error) {
+	var resp rpc.DiskUsageResponse
+	err := c.invoke("DiskUsage", rpc.MethodDiskUsage, &rpc.DiskUsageRequest{QA: qa, DUA: dua}, &resp)
+
+	return resp.Results, err
+}
+
+func (c *Client) ModifyAII(op dbms.DBOperator, args *dbms.AIIArgs, ids []string, add bool) (int64, int64, error) {
+	var resp rpc.ModifyAIIResponse
+	err := c.invoke("ModifyAII", rpc.MethodModifyAII,
+		&rpc.ModifyAIIRequest{Op: op, Args: args, Ids: ids, Add: add}, &resp)
+
+	return resp.TagsUpdated, resp.DescrsUpdated, err
+}
+
+func (c *Client) Subscribe(ctx context.Context, handler dbms.ChangesHandler) error {
+	stream, err := c.conn.NewStream(ctx, &rpc.ServiceDesc.Streams[0], rpc.FullMethod(rpc.MethodSubscribe))
+	if err != nil {
+		return fmt.Errorf("(RemoteCli:Subscribe) cannot subscribe to changes: %w", err)
+	}
+
+	if err := stream.SendMsg(&rpc.Empty{}); err != nil {
+		return fmt.Errorf("(RemoteCli:Subscribe) cannot send subscription request: %w", err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		return fmt.Errorf("(RemoteCli:Subscribe) cannot close sending of requests: %w", err)
+	}
+
+	log.D("(RemoteCli:Subscribe) Subscribed to changes")
+
+	for {
+		var msg rpc.ChangesMessage
+		if err := stream.RecvMsg(&msg); err != nil {
+			switch {
+			case ctx.Err() != nil:
+				// OK, the subscription was cancelled
+				return nil
+			case errors.Is(err, io.EOF):
+				return fmt.Errorf("(RemoteCli:Subscribe) subscription was closed by the gateway")
+			}
+
+			return fmt.Errorf("(RemoteCli:Subscribe) cannot receive changes: %w", rpc.NewCallError(err, nil))
+		}
+
+		if err := handler(msg.Changes); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,378 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/r-che/dfi/dbi/remote/rpc"
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestMain(m *testing.M) {
+	// Client and service write messages to the log
+	if err := log.Open(log.DefaultLog, "remote-test", log.NoFlags); err != nil {
+		panic("cannot open log: " + err.Error())
+	}
+
+	os.Exit(m.Run())
+}
+
+// Results returned by the database of the gateway
+var (
+	testQR = dbms.QueryResults{
+		{Host: "nas1", Path: "/data/a"}:	{
+			dbms.FieldID:	"id1",
+			dbms.FieldSize:	int64(1) << 40,
+			"ratio":		0.5,
+			"flag":			true,
+			dbms.AIIFieldTags:	[]string{"t1", "t2"},
+		},
+		{Host: "nas2", Path: "/data/b"}:	{dbms.FieldID: "id2"},
+	}
+	testAIIs	= dbms.QueryResultsAII{"id1": {Tags: []string{"t1"}, Descr: "d1"}}
+	testHosts	= []*dbms.HostInfo{{Host: "nas1", Version: "1.0", IdxPaths: []string{"/data"}, Objects: 2}}
+	testHist	= dbms.HistResults{{Time: 1, Change: dbms.HistModified, ID: "id1", Host: "nas1", FPath: "/data/a",
+		OldSize: 1, OldChecksum: "c1", NewSize: 2, NewChecksum: "c2"}}
+	testDU		= dbms.DUResults{{Key: "nas1", Size: 100, Count: 2}}
+	testChanges	= [][]*dbms.ObjChange{
+		{{Op: dbms.Update, ID: "id1", Host: "nas1", FPath: "/data/a"}},
+		{{Op: dbms.Delete, ID: "id2"}, {Op: dbms.Update, ID: "id3"}},
+	}
+)
+
+// testDB is the database of the gateway that returns the same results for all requests and records
+// arguments of calls, methods that are not used by tests are not implemented by the embedded nil client
+type testDB struct {
+	dbms.Client
+
+	calls	[]string	// methods with arguments
+	err		error		// error returned by all methods with results
+}
+
+func (db *testDB) call(method string, args ...any) {
+	db.calls = append(db.calls, fmt.Sprintf("%s%v", method, args))
+}
+
+func (db *testDB) Query(qa *dbms.QueryArgs, retFields []string) (dbms.QueryResults, error) {
+	db.call("Query", qa.SP, qa.Hosts, qa.SizeStart, qa.NameRegex, retFields)
+	return testQR, db.err
+}
+
+func (db *testDB) QueryAIIIds(qa *dbms.QueryArgs) ([]string, error) {
+	db.call("QueryAIIIds", qa.SP)
+	return []string{"id1"}, db.err
+}
+
+func (db *testDB) QueryContentIds(qa *dbms.QueryArgs) ([]string, error) {
+	db.call("QueryContentIds", qa.Content)
+	return []string{"id2"}, db.err
+}
+
+func (db *testDB) GetObjects(ids, retFields []string) (dbms.QueryResults, error) {
+	db.call("GetObjects", ids, retFields)
+	return testQR, db.err
+}
+
+func (db *testDB) GetAIIs(ids, retFields []string) (dbms.QueryResultsAII, error) {
+	db.call("GetAIIs", ids, retFields)
+	return testAIIs, db.err
+}
+
+func (db *testDB) GetAIIIds(withFields []string) ([]string, error) {
+	db.call("GetAIIIds", withFields)
+	return []string{"id1", "id2"}, db.err
+}
+
+func (db *testDB) GetHosts() ([]*dbms.HostInfo, error) {
+	db.call("GetHosts")
+	return testHosts, db.err
+}
+
+func (db *testDB) GetHistory(ha *dbms.HistArgs) (dbms.HistResults, error) {
+	db.call("GetHistory", ha.Ids, ha.Since)
+	return testHist, db.err
+}
+
+func (db *testDB) DiskUsage(qa *dbms.QueryArgs, dua *dbms.DUArgs) (dbms.DUResults, error) {
+	db.call("DiskUsage", qa.Hosts, dua.GroupBy, dua.Depth)
+	return testDU, db.err
+}
+
+func (db *testDB) ModifyAII(op dbms.DBOperator, args *dbms.AIIArgs, ids []string, add bool) (int64, int64, error) {
+	db.call("ModifyAII", op, args.Tags, args.Descr, ids, add)
+	return 2, 1, db.err
+}
+
+func (db *testDB) Subscribe(ctx context.Context, handler dbms.ChangesHandler) error {
+	db.call("Subscribe")
+	for _, changes := range testChanges {
+		if err := handler(changes); err != nil {
+			return err
+		}
+	}
+	if db.err != nil {
+		return db.err
+	}
+
+	// Wait for cancellation by the client
+	<-ctx.Done()
+
+	return nil
+}
+
+// testClient returns the client connected to the gateway that serves calls by the client returned by cf
+func testClient(t *testing.T, cf rpc.ClientFunc) *Client {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	gs := grpc.NewServer()
+	rpc.NewServerFunc(cf).Register(gs)
+	go func() {
+		if err := gs.Serve(lis); err != nil {
+			t.Errorf("gateway failed: %v", err)
+		}
+	}()
+	t.Cleanup(gs.Stop)
+
+	conn, err := dial("bufnet", insecure.NewCredentials(),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}))
+	if err != nil {
+		t.Fatalf("cannot connect to the gateway: %v", err)
+	}
+
+	c := &Client{CommonClient: dbms.NewCommonClient(&dbms.DBConfig{}), conn: conn}
+	t.Cleanup(c.Stop)
+
+	return c
+}
+
+// testCalls calls all methods of the client, results are returned with the method names
+func testCalls(c *Client) []struct{ method string; results []any; err error } {
+	qa := dbms.NewQueryArgs().SetSearchPhrases([]string{"a b"})
+	qa.Hosts = []string{"nas1"}
+	qa.SizeStart = 10
+	qa.NameRegex = `^x\.txt$`
+	qa.Content = "text"
+
+	type call = struct{ method string; results []any; err error }
+	var calls []call
+	add := func(method string, err error, results ...any) {
+		calls = append(calls, call{method: method, results: results, err: err})
+	}
+
+	qr, err := c.Query(qa, []string{dbms.FieldSize})
+	add("Query", err, qr)
+	ids, err := c.QueryAIIIds(qa)
+	add("QueryAIIIds", err, ids)
+	ids, err = c.QueryContentIds(qa)
+	add("QueryContentIds", err, ids)
+	qr, err = c.GetObjects([]string{"id1", "id2"}, []string{dbms.FieldSize})
+	add("GetObjects", err, qr)
+	aiis, err := c.GetAIIs([]string{"id1"}, []string{dbms.AIIFieldTags})
+	add("GetAIIs", err, aiis)
+	ids, err = c.GetAIIIds([]string{dbms.AIIFieldDescr})
+	add("GetAIIIds", err, ids)
+	hosts, err := c.GetHosts()
+	add("GetHosts", err, hosts)
+	hr, err := c.GetHistory(&dbms.HistArgs{Ids: []string{"id1"}, Since: 5})
+	add("GetHistory", err, hr)
+	dur, err := c.DiskUsage(qa, &dbms.DUArgs{GroupBy: dbms.DUByHost, Depth: 2})
+	add("DiskUsage", err, dur)
+	tu, du, err := c.ModifyAII(dbms.Update, &dbms.AIIArgs{Tags: []string{"t1"}, Descr: "d1"}, []string{"id1"}, true)
+	add("ModifyAII", err, tu, du)
+
+	return calls
+}
+
+func TestClientRoundTrip(t *testing.T) {
+	db := &testDB{}
+	c := testClient(t, func(context.Context) (dbms.Client, error) { return db, nil })
+
+	want := map[string][]any{
+		"Query":			{testQR},
+		"QueryAIIIds":		{[]string{"id1"}},
+		"QueryContentIds":	{[]string{"id2"}},
+		"GetObjects":		{testQR},
+		"GetAIIs":			{testAIIs},
+		"GetAIIIds":		{[]string{"id1", "id2"}},
+		"GetHosts":			{testHosts},
+		"GetHistory":		{testHist},
+		"DiskUsage":		{testDU},
+		"ModifyAII":		{int64(2), int64(1)},
+	}
+
+	calls := testCalls(c)
+	if len(calls) != len(want) {
+		t.Fatalf("want %d calls, got %d", len(want), len(calls))
+	}
+	for _, call := range calls {
+		if call.err != nil {
+			t.Errorf("%s returned unexpected error: %v", call.method, call.err)
+			continue
+		}
+		if !reflect.DeepEqual(call.results, want[call.method]) {
+			t.Errorf("%s - want results %#v, got %#v", call.method, want[call.method], call.results)
+		}
+	}
+
+	// Arguments are passed to the database of the gateway
+	wantCalls := []string{
+		"Query[[a b] [nas1] 10 ^x\\.txt$ [size]]",
+		"QueryAIIIds[[a b]]",
+		"QueryContentIds[text]",
+		"GetObjects[[id1 id2] [size]]",
+		"GetAIIs[[id1] [tags]]",
+		"GetAIIIds[[descr]]",
+		"GetHosts[]",
+		"GetHistory[[id1] 5]",
+		fmt.Sprintf("DiskUsage[[nas1] %v 2]", dbms.DUByHost),
+		"ModifyAII[Update [t1] d1 [id1] true]",
+	}
+	if !reflect.DeepEqual(db.calls, wantCalls) {
+		t.Errorf("want calls of the database:\n%q\ngot:\n%q", wantCalls, db.calls)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		err			error
+		code		codes.Code
+		is			error	// error of the database interface matched by the returned error
+	} {
+		{ err: errors.New("connection refused"), code: codes.Internal },
+		{ err: fmt.Errorf("(Cli:Query) %w: bad pattern", dbms.ErrInvalidArgs), code: codes.InvalidArgument, is: dbms.ErrInvalidArgs },
+		{ err: fmt.Errorf("%w: 1 of 2 objects are not accessible", dbms.ErrForbidden), code: codes.PermissionDenied, is: dbms.ErrForbidden },
+		{ err: fmt.Errorf("(Cli:Query) %w", context.DeadlineExceeded), code: codes.DeadlineExceeded, is: context.DeadlineExceeded },
+	}
+
+	for i, test := range tests {
+		db := &testDB{err: test.err}
+		c := testClient(t, func(context.Context) (dbms.Client, error) { return db, nil })
+
+		for _, call := range testCalls(c) {
+			if code := status.Code(call.err); code != test.code {
+				t.Errorf("[%d] %s - want status %v, got %v (%v)", i, call.method, test.code, code, call.err)
+			}
+			if test.is != nil && !errors.Is(call.err, test.is) {
+				t.Errorf("[%d] %s - want error matched to %v, got %v", i, call.method, test.is, call.err)
+			}
+			for _, dbErr := range []error{dbms.ErrInvalidArgs, dbms.ErrForbidden} {
+				if dbErr != test.is && errors.Is(call.err, dbErr) {
+					t.Errorf("[%d] %s - error %v is unexpectedly matched to %v", i, call.method, call.err, dbErr)
+				}
+			}
+		}
+	}
+
+	// Partial results are returned with errors
+	db := &testDB{err: errors.New("some objects failed")}
+	c := testClient(t, func(context.Context) (dbms.Client, error) { return db, nil })
+
+	tu, du, err := c.ModifyAII(dbms.Update, &dbms.AIIArgs{Tags: []string{"t1"}}, []string{"id1"}, false)
+	if err == nil || tu != 2 || du != 1 {
+		t.Errorf("ModifyAII - want partial results (2, 1) with error, got (%d, %d), error: %v", tu, du, err)
+	}
+	qr, err := c.Query(dbms.NewQueryArgs(), nil)
+	if err == nil || !reflect.DeepEqual(qr, testQR) {
+		t.Errorf("Query - want partial results %v with error, got %v, error: %v", testQR, qr, err)
+	}
+
+	// Invalid requests are rejected by the gateway
+	_, _, err = c.ModifyAII(dbms.Update, nil, []string{"id1"}, false)
+	if status.Code(err) != codes.InvalidArgument || !errors.Is(err, dbms.ErrInvalidArgs) {
+		t.Errorf("ModifyAII without arguments - want %v error, got %v", codes.InvalidArgument, err)
+	}
+
+	// Statuses returned by the gateway are kept, e.g. failed authentication
+	c = testClient(t, func(context.Context) (dbms.Client, error) {
+		return nil, status.Error(codes.Unauthenticated, "invalid username or password")
+	})
+	if _, err := c.GetHosts(); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("GetHosts by unauthenticated user - want %v error, got %v", codes.Unauthenticated, err)
+	}
+}
+
+func TestClientSubscribe(t *testing.T) {
+	db := &testDB{}
+	c := testClient(t, func(context.Context) (dbms.Client, error) { return db, nil })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var got [][]*dbms.ObjChange
+	err := c.Subscribe(ctx, func(changes []*dbms.ObjChange) error {
+		got = append(got, changes)
+		if len(got) == len(testChanges) {
+			// All changes are received, stop the subscription
+			cancel()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Subscribe returned unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, testChanges) {
+		t.Errorf("want changes %v, got %v", testChanges, got)
+	}
+
+	// Errors of the database stop the subscription
+	db = &testDB{err: fmt.Errorf("%w: subscription is not permitted", dbms.ErrForbidden)}
+	c = testClient(t, func(context.Context) (dbms.Client, error) { return db, nil })
+
+	got = nil
+	err = c.Subscribe(context.Background(), func(changes []*dbms.ObjChange) error {
+		got = append(got, changes)
+		return nil
+	})
+	if status.Code(err) != codes.PermissionDenied || !errors.Is(err, dbms.ErrForbidden) {
+		t.Errorf("Subscribe - want %v error, got %v", codes.PermissionDenied, err)
+	}
+	if len(got) != len(testChanges) {
+		t.Errorf("want %d portions of changes before the error, got %d", len(testChanges), len(got))
+	}
+}
+
+// Values of all supported types are passed by the codec without changes of types
+func TestQRCodec(t *testing.T) {
+	qr := dbms.QueryResults{
+		types.ObjKey{Host: "h", Path: "/p"}: {
+			"s": "str", "i": int64(-7), "f": 1.25, "b": false, "l": []string{},
+			"i32": int32(5), "int": 6, "any": []any{"x", 1},
+		},
+	}
+	want := dbms.QueryResults{
+		types.ObjKey{Host: "h", Path: "/p"}: {
+			"s": "str", "i": int64(-7), "f": 1.25, "b": false, "l": []string{},
+			"i32": int64(5), "int": int64(6), "any": []string{"x", "1"},
+		},
+	}
+
+	data, err := rpc.Codec().Marshal(&rpc.QueryResponse{Results: rpc.EncodeQR(qr)})
+	if err != nil {
+		t.Fatalf("cannot encode results: %v", err)
+	}
+
+	var resp rpc.QueryResponse
+	if err := rpc.Codec().Unmarshal(data, &resp); err != nil {
+		t.Fatalf("cannot decode results: %v", err)
+	}
+	if got := rpc.DecodeQR(resp.Results); !reflect.DeepEqual(got, want) {
+		t.Errorf("want decoded results %#v, got %#v", want, got)
+	}
+}
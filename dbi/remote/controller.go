@@ -0,0 +1,253 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/r-che/dfi/dbi/remote/rpc"
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Field of the private configuration that contains the authentication token of the agent
+const PrivFieldToken = "Token"
+
+// Resending of failed batches
+const (
+	commitAttempts	=	5
+	commitDelay		=	time.Second	// delay before the first resending, doubled by each attempt
+)
+
+// Controller is the client controller of agents that sends operations to the ingest service of the gateway
+type Controller struct {
+	*dbms.CommonClient
+
+	conn	*grpc.ClientConn
+
+	// Operations queued for the next commit
+	ops		[]*dbms.DBOperation
+}
+
+func NewController(dbCfg *dbms.DBConfig) (*Controller, error) {
+	if dbCfg.HostPort == "" {
+		return nil, fmt.Errorf("(RemoteCtl:NewController) address of the gateway is not set")
+	}
+
+	token, ok := dbCfg.PrivCfg[PrivFieldToken].(string)
+	if !ok || token == "" {
+		return nil, fmt.Errorf("(RemoteCtl:NewController) authentication token is not set by the %q field" +
+			" of the private configuration", PrivFieldToken)
+	}
+
+	tc, allowInsecure, err := transportCreds(dbCfg, true)
+	if err != nil {
+		return nil, fmt.Errorf("(RemoteCtl:NewController) %w", err)
+	}
+
+	conn, err := dial(dbCfg.HostPort, tc,
+		grpc.WithPerRPCCredentials(rpc.TokenCredentials(dbCfg.CliHost, token, allowInsecure)))
+	if err != nil {
+		return nil, fmt.Errorf("(RemoteCtl:NewController) %w", err)
+	}
+
+	return &Controller{
+		CommonClient:	dbms.NewCommonClient(dbCfg),
+		conn:			conn,
+	}, nil
+}
+
+func (c *Controller) LoadHostPaths(match dbms.MatchStrFunc) ([]string, error) {
+	var resp rpc.HostPathsResponse
+	if err := c.invoke(c.Ctx, "LoadHostPaths", rpc.MethodHostPaths, &rpc.Empty{}, &resp); err != nil {
+		return nil, err
+	}
+
+	// The gateway returns all paths of the host
+	paths := make([]string, 0, len(resp.Paths))
+	for _, path := range resp.Paths {
+		if match(path) {
+			paths = append(paths, path)
+		}
+	}
+
+	return paths, nil
+}
+
+func (c *Controller) UpdateObj(fso *types.FSObject) error {
+	c.queue("UpdateObj", dbms.Update, fso)
+
+	// OK
+	return nil
+}
+
+func (c *Controller) DeleteObj(fso *types.FSObject) error {
+	c.queue("DeleteObj", dbms.Delete, fso)
+
+	// OK
+	return nil
+}
+
+func (c *Controller) DeleteFPathPref(fso *types.FSObject) (int64, error) {
+	c.queue("DeleteFPathPref", dbms.DeletePrefix, fso)
+
+	// Matched objects are known only to the ingest service, they are counted by the commit
+	return 0, nil
+}
+
+func (c *Controller) queue(caller string, op dbms.DBOperator, fso *types.FSObject) {
+	if c.ReadOnly {
+		log.W("(RemoteCtl:%s) R/O mode IS SET, will not be performed: %v => %s:%s\n",
+			caller, op, c.Cfg.CliHost, fso.FPath)
+	} else {
+		log.D("(RemoteCtl:%s) %v (pending) => %s:%s\n", caller, op, c.Cfg.CliHost, fso.FPath)
+	}
+
+	// XXX Append operation regardless of R/O mode because it will be skipped in the Commit() operation
+	c.ops = append(c.ops, &dbms.DBOperation{Op: op, ObjectInfo: fso})
+}
+
+func (c *Controller) Commit(seq int64) (int64, int64, error) {
+	// Reset queued operations on return
+	defer func() {
+		c.ops = nil
+	}()
+
+	if c.ReadOnly {
+		return c.commitDryRun(seq)
+	}
+
+	if len(c.ops) == 0 && seq == 0 {
+		// Nothing to commit
+		return 0, 0, nil
+	}
+
+	log.D("(RemoteCtl:Commit) Need to send %d operations, batch sequence number: %d", len(c.ops), seq)
+
+	req := &rpc.IngestRequest{Seq: seq, Ops: c.ops}
+	var resp rpc.IngestResponse
+
+	delay := commitDelay
+	for attempt := 1; ; attempt++ {
+		resp = rpc.IngestResponse{}
+		err := c.invoke(c.Ctx, "Commit", rpc.MethodIngest, req, &resp)
+		if err == nil {
+			break
+		}
+
+		if !retryable(err) || attempt == commitAttempts {
+			// Partial results are returned with errors of the database
+			return resp.Updated, resp.Deleted, err
+		}
+
+		log.W("(RemoteCtl:Commit) Attempt %d of %d to send batch %d failed, next attempt after %v: %v",
+			attempt, commitAttempts, seq, delay, err)
+
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-c.Ctx.Done():
+			return 0, 0, fmt.Errorf("(RemoteCtl:Commit) sending of batch %d interrupted: %w", seq, c.Ctx.Err())
+		}
+	}
+
+	if resp.Duplicate {
+		log.W("(RemoteCtl:Commit) Batch %d was already applied by the gateway", seq)
+	}
+	if resp.Dropped != 0 {
+		log.D("(RemoteCtl:Commit) %d operations of batch %d were superseded by later operations",
+			resp.Dropped, seq)
+	}
+
+	log.D("(RemoteCtl:Commit) Batch %d applied, %d objects updated, %d objects deleted, %d operations failed",
+		seq, resp.Updated, resp.Deleted, len(resp.OpErrors))
+
+	// Check for failed operations
+	if len(resp.OpErrors) != 0 {
+		return resp.Updated, resp.Deleted, rpc.DecodeOpErrors(resp.OpErrors)
+	}
+
+	return resp.Updated, resp.Deleted, nil
+}
+
+func (c *Controller) commitDryRun(seq int64) (int64, int64, error) {
+	// Read-only database mode, all queued operations were already reported on queueing
+	var updated, deleted int64
+	for _, op := range c.ops {
+		if op.Op == dbms.Update {
+			updated++
+		} else {
+			deleted++
+		}
+	}
+
+	if seq != 0 {
+		log.W("(RemoteCtl:Commit) R/O mode IS SET, batch sequence number %d will not be sent", seq)
+	}
+
+	// OK
+	return updated, deleted, nil
+}
+
+func (c *Controller) LastBatchSeq() (int64, error) {
+	var resp rpc.SeqResponse
+	if err := c.invoke(c.Ctx, "LastBatchSeq", rpc.MethodLastBatchSeq, &rpc.Empty{}, &resp); err != nil {
+		return 0, err
+	}
+
+	// OK
+	return resp.Seq, nil
+}
+
+func (c *Controller) UpdateHost(hi *dbms.HostInfo) error {
+	if c.ReadOnly {
+		log.D("(RemoteCtl:UpdateHost) R/O mode IS SET, information about host %q will not be sent", hi.Host)
+		return nil
+	}
+
+	var resp rpc.UpdateHostResponse
+	if err := c.invoke(c.Ctx, "UpdateHost", rpc.MethodUpdateHost, &rpc.UpdateHostRequest{Host: hi}, &resp); err != nil {
+		return err
+	}
+
+	// Objects are counted by the gateway
+	hi.Objects = resp.Objects
+
+	// OK
+	return nil
+}
+
+func (c *Controller) Stop() {
+	c.CommonClient.Stop()
+
+	if err := c.conn.Close(); err != nil {
+		log.E("(RemoteCtl:Stop) cannot close connection to the gateway: %v", err)
+	}
+}
+
+// invoke calls the method of the ingest service, partial results of the failed call are decoded to resp,
+// errors of the call are prefixed by the caller name
+func (c *Controller) invoke(ctx context.Context, caller, method string, req, resp any) error {
+	if err := c.conn.Invoke(ctx, rpc.IngestFullMethod(method), req, resp); err != nil {
+		return fmt.Errorf("(RemoteCtl:%s) call of gateway failed: %w", caller, rpc.NewCallError(err, resp))
+	}
+
+	// OK
+	return nil
+}
+
+// retryable reports whether the failed call can be repeated later
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted:
+		return true
+	}
+
+	return false
+}
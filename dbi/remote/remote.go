@@ -0,0 +1,186 @@
+/*
+Package remote provides a database client that works through the gRPC gateway served by dfid
+instead of a direct connection to the DBMS. Clients do not need database credentials and network
+access to the database, only to the gateway.
+
+# Configuration
+
+The HostPort field of the database configuration contains the address of the gateway
+in the HOST:PORT format, the ID field is not used:
+
+  {
+      "DB": {
+          "HostPort": "gateway.example.com:8092"
+      }
+  }
+
+If users are configured on the gateway, credentials of the user are set in the private configuration:
+
+  {
+      "DB": {
+          "HostPort": "gateway.example.com:8092",
+          "PrivCfg": {
+              "Username": "${USER}",
+              "Password": "${PASSWORD}"
+          }
+      }
+  }
+
+# TLS
+
+The gateway should be served with TLS, settings of the connection are set by the TLS field of the private
+configuration, the same as for connections to the DBMS:
+
+  {
+      "DB": {
+          "HostPort": "gateway.example.com:8092",
+          "PrivCfg": {
+              "Username": "${USER}",
+              "Password": "${PASSWORD}",
+              "TLS": {
+                  "CAFile": "/etc/dfi/ca.pem"
+              }
+          }
+      }
+  }
+
+Credentials of users and tokens of agents are not passed over connections without TLS, it can be
+allowed for testing or on trusted networks by setting the Insecure field of the private configuration
+to true.
+
+# Agents
+
+Agents use the Controller that sends batches of operations to the ingest service of the gateway
+instead of writing them to the database. The agent is authenticated by the token configured on
+the gateway for the hostname of the agent, the token is set in the private configuration:
+
+  {
+      "Token": "${AGENT_TOKEN}"
+  }
+
+Batches rejected by the rate limit of the ingest service or failed because the gateway is
+unavailable are resent several times with increasing delays.
+*/
+package remote
+
+import (
+	"fmt"
+
+	"github.com/r-che/dfi/dbi/common"
+	"github.com/r-che/dfi/dbi/remote/rpc"
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Fields of the private configuration that contain credentials of the user
+const (
+	PrivFieldUsername	=	"Username"
+	PrivFieldPassword	=	"Password"
+)
+
+// Field of the private configuration that allows passing credentials to the gateway without TLS
+const PrivFieldInsecure = "Insecure"
+
+type Client struct {
+	*dbms.CommonClient
+
+	conn	*grpc.ClientConn
+}
+
+func NewClient(dbCfg *dbms.DBConfig) (*Client, error) {
+	if dbCfg.HostPort == "" {
+		return nil, fmt.Errorf("(RemoteCli:NewClient) address of the gateway is not set")
+	}
+
+	// Credentials of the user are required if authentication is enabled on the gateway
+	user, _ := dbCfg.PrivCfg[PrivFieldUsername].(string)
+
+	tc, allowInsecure, err := transportCreds(dbCfg, user != "")
+	if err != nil {
+		return nil, fmt.Errorf("(RemoteCli:NewClient) %w", err)
+	}
+
+	var opts []grpc.DialOption
+	if user != "" {
+		password, _ := dbCfg.PrivCfg[PrivFieldPassword].(string)
+		opts = append(opts, grpc.WithPerRPCCredentials(rpc.BasicCredentials(user, password, allowInsecure)))
+	}
+
+	conn, err := dial(dbCfg.HostPort, tc, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("(RemoteCli:NewClient) %w", err)
+	}
+
+	return &Client{
+		CommonClient:	dbms.NewCommonClient(dbCfg),
+		conn:			conn,
+	}, nil
+}
+
+// transportCreds returns credentials of the connection to the gateway made by TLS settings of the private
+// configuration, the connection is not encrypted if TLS is not configured. Credentials of the caller
+// (withCreds is set) are passed without encryption only if it is explicitly allowed, it is reported
+// by the returned flag
+func transportCreds(dbCfg *dbms.DBConfig, withCreds bool) (credentials.TransportCredentials, bool, error) {
+	tlsCfg, err := common.TLSConfig(dbCfg.PrivCfg)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if tlsCfg != nil {
+		// OK
+		return credentials.NewTLS(tlsCfg), false, nil
+	}
+
+	allowInsecure, _ := dbCfg.PrivCfg[PrivFieldInsecure].(bool)
+	switch {
+	case !withCreds:
+		// OK, nothing to protect
+	case !allowInsecure:
+		return nil, false, fmt.Errorf("credentials cannot be passed to the gateway without TLS, configure it by" +
+			" the %q field of the private configuration or set the %q field to allow it on trusted networks",
+			common.PrivFieldTLS, PrivFieldInsecure)
+	default:
+		log.W("Credentials are passed to the gateway %q WITHOUT encryption, use it only for testing" +
+			" or on trusted networks", dbCfg.HostPort)
+	}
+
+	return insecure.NewCredentials(), allowInsecure, nil
+}
+
+// dial creates the connection to the gateway, the connection is established lazily on the first call
+func dial(hostPort string, tc credentials.TransportCredentials, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	conn, err := grpc.Dial(hostPort, append([]grpc.DialOption{
+		grpc.WithTransportCredentials(tc),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(rpc.Codec()), grpc.MaxCallRecvMsgSize(rpc.MaxMsgSize)),
+	}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create connection to the gateway %q: %w", hostPort, err)
+	}
+
+	return conn, nil
+}
+
+// invoke calls the method of the gateway, partial results of the failed call are decoded to resp,
+// errors of the call are prefixed by the caller name
+func (c *Client) invoke(caller, method string, req, resp any) error {
+	if err := c.conn.Invoke(c.Ctx, rpc.FullMethod(method), req, resp); err != nil {
+		return fmt.Errorf("(RemoteCli:%s) call of gateway failed: %w", caller, rpc.NewCallError(err, resp))
+	}
+
+	// OK
+	return nil
+}
+
+func (c *Client) Stop() {
+	c.CommonClient.Stop()
+
+	if err := c.conn.Close(); err != nil {
+		log.E("(RemoteCli:Stop) cannot close connection to the gateway: %v", err)
+	}
+}
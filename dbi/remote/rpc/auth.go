@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata keys used to authenticate callers
+const (
+	MDHost	=	"dfi-host"		// hostname of the agent
+	MDAuth	=	"authorization"	// credentials of the caller
+
+	bearerScheme	=	"Bearer "	// token of the agent
+	basicScheme		=	"Basic "	// username and password of the user, the same as in HTTP
+)
+
+// TokenCredentials returns credentials of the agent passed in metadata of each call of the ingest service,
+// credentials are not passed over connections without TLS unless insecure is set
+func TokenCredentials(host, token string, insecure bool) credentials.PerRPCCredentials {
+	return &mdCreds{
+		md:			map[string]string{
+			MDHost:	host,
+			MDAuth:	bearerScheme + token,
+		},
+		insecure:	insecure,
+	}
+}
+
+// BasicCredentials returns credentials of the user passed in metadata of each call of the service,
+// credentials are not passed over connections without TLS unless insecure is set
+func BasicCredentials(user, password string, insecure bool) credentials.PerRPCCredentials {
+	return &mdCreds{
+		md:			map[string]string{
+			MDAuth:	basicScheme + base64.StdEncoding.EncodeToString([]byte(user + ":" + password)),
+		},
+		insecure:	insecure,
+	}
+}
+
+// BasicAuth returns the username and password passed in metadata of the call
+func BasicAuth(ctx context.Context) (string, string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", "", false
+	}
+
+	auths := md.Get(MDAuth)
+	if len(auths) != 1 || !strings.HasPrefix(auths[0], basicScheme) {
+		return "", "", false
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(auths[0], basicScheme))
+	if err != nil {
+		return "", "", false
+	}
+
+	user, password, ok := strings.Cut(string(data), ":")
+
+	return user, password, ok
+}
+
+// mdCreds passes its content in metadata of each call
+type mdCreds struct {
+	md			map[string]string
+	insecure	bool	// pass metadata over connections without TLS
+}
+
+func (mc *mdCreds) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return mc.md, nil
+}
+
+func (mc *mdCreds) RequireTransportSecurity() bool {
+	// gRPC refuses to send credentials that require security over insecure connections
+	return !mc.insecure
+}
@@ -0,0 +1,47 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestCredentialsSecurity(t *testing.T) {
+	for i, test := range []struct {
+		insecure	bool
+		want		bool
+	} {
+		{ insecure: false, want: true },
+		{ insecure: true, want: false },
+	} {
+		if got := TokenCredentials("h1", "t1", test.insecure).RequireTransportSecurity(); got != test.want {
+			t.Errorf("[%d] token credentials (insecure: %t) - want security %t, got %t", i, test.insecure, test.want, got)
+		}
+		if got := BasicCredentials("u", "p", test.insecure).RequireTransportSecurity(); got != test.want {
+			t.Errorf("[%d] basic credentials (insecure: %t) - want security %t, got %t", i, test.insecure, test.want, got)
+		}
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	md, err := BasicCredentials("alice", "pass:word", false).GetRequestMetadata(context.Background())
+	if err != nil {
+		t.Fatalf("GetRequestMetadata returned unexpected error: %v", err)
+	}
+
+	user, password, ok := BasicAuth(metadata.NewIncomingContext(context.Background(), metadata.New(md)))
+	if !ok || user != "alice" || password != "pass:word" {
+		t.Errorf("want credentials (alice, pass:word), got (%s, %s), ok: %t", user, password, ok)
+	}
+
+	for i, md := range []metadata.MD{
+		metadata.Pairs(MDAuth, bearerScheme + "token"),
+		metadata.Pairs(MDAuth, basicScheme + "not base64"),
+		metadata.Pairs(MDAuth, basicScheme + "YWxpY2U="),	// no colon
+	} {
+		if _, _, ok := BasicAuth(metadata.NewIncomingContext(context.Background(), md)); ok {
+			t.Errorf("[%d] BasicAuth accepted invalid metadata %v", i, md)
+		}
+	}
+}
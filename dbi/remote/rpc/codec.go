@@ -0,0 +1,38 @@
+package rpc
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// Name of the codec of messages, clients in other languages should use the application/grpc+json content type
+const CodecName = "json"
+
+// Maximum size of messages accepted by the service and its clients, large enough for batches of agents
+const MaxMsgSize = 256 * 1024 * 1024
+
+// Messages are plain Go structures encoded by JSON, so the service does not depend on generated code
+type codec struct{}
+
+func (codec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (codec) Name() string {
+	return CodecName
+}
+
+// Codec returns the codec used by the service
+func Codec() encoding.Codec {
+	return codec{}
+}
+
+func init() {
+	// Register the codec to make it available to the server by the content subtype of requests
+	encoding.RegisterCodec(codec{})
+}
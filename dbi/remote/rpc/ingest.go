@@ -0,0 +1,329 @@
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Full name of the ingest service that applies operations of agents to the database
+const IngestServiceName = "dfi.v1.Ingest"
+
+// Names of methods of the ingest service
+const (
+	MethodIngest		=	"Ingest"
+	MethodLastBatchSeq	=	"LastBatchSeq"
+	MethodUpdateHost	=	"UpdateHost"
+	MethodHostPaths		=	"HostPaths"
+)
+
+// IngestFullMethod returns the full name of the method of the ingest service used to call it
+func IngestFullMethod(method string) string {
+	return fullMethod(IngestServiceName, method)
+}
+
+// IngestConfig is the configuration of the ingest service
+type IngestConfig struct {
+	Tokens	map[string]string	// authentication tokens of agents by their hostnames
+	Rate	float64				// maximum number of operations per second of a single host, 0 - unlimited
+	Burst	int					// number of operations of a host allowed above the rate
+	MaxWait	time.Duration		// maximum delay of the batch by the rate limit, after it the batch is rejected
+}
+
+// IngestServer applies batches of operations of agents to the database by the single client controller
+type IngestServer struct {
+	cfg		*IngestConfig
+	ctl		dbms.ClientController
+
+	// Batches are applied one by one, because the controller is switched between hosts
+	mu		sync.Mutex
+
+	lmu			sync.Mutex
+	limiters	map[string]*limiter
+}
+
+func NewIngestServer(cfg *IngestConfig, ctl dbms.ClientController) *IngestServer {
+	return &IngestServer{
+		cfg:		cfg,
+		ctl:		ctl,
+		limiters:	map[string]*limiter{},
+	}
+}
+
+// Register registers the ingest service on the gRPC server
+func (s *IngestServer) Register(gs grpc.ServiceRegistrar) {
+	gs.RegisterService(&IngestServiceDesc, s)
+}
+
+// IngestServiceDesc describes the ingest service for gRPC
+var IngestServiceDesc = grpc.ServiceDesc{
+	ServiceName:	IngestServiceName,
+	HandlerType:	(*any)(nil),
+	Methods:		[]grpc.MethodDesc{
+		method(IngestServiceName, MethodIngest, (*IngestServer).ingest),
+		method(IngestServiceName, MethodLastBatchSeq, (*IngestServer).lastBatchSeq),
+		method(IngestServiceName, MethodUpdateHost, (*IngestServer).updateHost),
+		method(IngestServiceName, MethodHostPaths, (*IngestServer).hostPaths),
+	},
+}
+
+func (s *IngestServer) ingest(ctx context.Context, req *IngestRequest) (*IngestResponse, error) {
+	host, err := s.auth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkOps(req.Ops); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid batch %d: %v", req.Seq, err)
+	}
+
+	ops, dropped := dedupOps(req.Ops)
+
+	// Wait for the rate limit before taking the controller, so other hosts are not blocked
+	if err := s.wait(ctx, host, len(ops)); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ctl.SetCliHost(host)
+
+	// Batches resent by agents after failures of calls can be already applied
+	if req.Seq != 0 {
+		last, err := s.ctl.LastBatchSeq()
+		if err != nil {
+			return nil, statusError(err, nil)
+		}
+		if req.Seq <= last {
+			log.W("(Ingest) %s: batch %d is already applied (last %d), skipped", host, req.Seq, last)
+			return &IngestResponse{Duplicate: true}, nil
+		}
+	}
+
+	resp := &IngestResponse{Dropped: dropped}
+
+	// Failed operations are reported, the rest of the batch is committed
+	var oes dbms.OpErrors
+	for _, op := range ops {
+		if err := queueOp(s.ctl, op); err != nil {
+			oes = append(oes, &dbms.OpError{Op: op.Op, Key: op.ObjectInfo.FPath, Err: err})
+		}
+	}
+
+	resp.Updated, resp.Deleted, err = s.ctl.Commit(req.Seq)
+	if err != nil {
+		var commitOEs dbms.OpErrors
+		if !errors.As(err, &commitOEs) {
+			// The whole batch failed
+			return nil, statusError(err, resp)
+		}
+
+		oes = append(oes, commitOEs...)
+	}
+	resp.OpErrors = encodeOpErrors(oes)
+
+	log.I("(Ingest) %s: batch %d - %d updated, %d deleted, %d failed, %d superseded",
+		host, req.Seq, resp.Updated, resp.Deleted, len(oes), dropped)
+
+	return resp, nil
+}
+
+func (s *IngestServer) lastBatchSeq(ctx context.Context, _ *Empty) (*SeqResponse, error) {
+	host, err := s.auth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ctl.SetCliHost(host)
+	seq, err := s.ctl.LastBatchSeq()
+	if err != nil {
+		return nil, statusError(err, nil)
+	}
+
+	return &SeqResponse{Seq: seq}, nil
+}
+
+func (s *IngestServer) updateHost(ctx context.Context, req *UpdateHostRequest) (*UpdateHostResponse, error) {
+	host, err := s.auth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Host == nil {
+		return nil, status.Error(codes.InvalidArgument, "no information about the host")
+	}
+
+	// Agents can update information only about themselves
+	req.Host.Host = host
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ctl.SetCliHost(host)
+	if err := s.ctl.UpdateHost(req.Host); err != nil {
+		return nil, statusError(err, nil)
+	}
+
+	return &UpdateHostResponse{Objects: req.Host.Objects}, nil
+}
+
+func (s *IngestServer) hostPaths(ctx context.Context, _ *Empty) (*HostPathsResponse, error) {
+	host, err := s.auth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// All paths are returned, they are filtered by the agent
+	s.ctl.SetCliHost(host)
+	paths, err := s.ctl.LoadHostPaths(func(string) bool { return true })
+	if err != nil {
+		return nil, statusError(err, nil)
+	}
+
+	return &HostPathsResponse{Paths: paths}, nil
+}
+
+// auth returns the host of the agent authenticated by the token passed in metadata of the call
+func (s *IngestServer) auth(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "no credentials")
+	}
+
+	hosts, auths := md.Get(MDHost), md.Get(MDAuth)
+	if len(hosts) != 1 || len(auths) != 1 || !strings.HasPrefix(auths[0], bearerScheme) {
+		return "", status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+
+	host := hosts[0]
+	token, ok := s.cfg.Tokens[host]
+	if !ok || token == "" ||
+		subtle.ConstantTimeCompare([]byte(token), []byte(strings.TrimPrefix(auths[0], bearerScheme))) != 1 {
+		log.W("(Ingest) Authentication of agent %q failed", host)
+		return "", status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+
+	// OK
+	return host, nil
+}
+
+// wait delays the batch of n operations of the host according to the rate limit
+func (s *IngestServer) wait(ctx context.Context, host string, n int) error {
+	if s.cfg.Rate <= 0 || n == 0 {
+		// Rate is not limited
+		return nil
+	}
+
+	s.lmu.Lock()
+	l, ok := s.limiters[host]
+	if !ok {
+		l = newLimiter(s.cfg.Rate, s.cfg.Burst)
+		s.limiters[host] = l
+	}
+	s.lmu.Unlock()
+
+	delay, ok := l.reserve(n, s.cfg.MaxWait)
+	if !ok {
+		log.W("(Ingest) %s: batch of %d operations rejected by the rate limit, required delay %v",
+			host, n, delay)
+		return status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry after %v", delay)
+	}
+	if delay == 0 {
+		return nil
+	}
+
+	log.D("(Ingest) %s: batch of %d operations delayed by the rate limit for %v", host, n, delay)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// checkOps checks operations received from the agent
+func checkOps(ops []*dbms.DBOperation) error {
+	for i, op := range ops {
+		switch {
+		case op == nil || op.ObjectInfo == nil:
+			return fmt.Errorf("operation #%d has no object", i)
+		case op.Op != dbms.Update && op.Op != dbms.Delete && op.Op != dbms.DeletePrefix:
+			return fmt.Errorf("operation #%d has unsupported type %d", i, op.Op)
+		case op.ObjectInfo.FPath == "":
+			// Empty prefix matches all objects of the host
+			return fmt.Errorf("operation #%d has empty path", i)
+		}
+	}
+
+	// OK
+	return nil
+}
+
+// dedupOps removes updates and deletions of objects superseded by later operations
+// on the same objects, it returns the rest of operations and the number of removed
+func dedupOps(ops []*dbms.DBOperation) ([]*dbms.DBOperation, int) {
+	type opKey struct {
+		prefix	bool
+		path	string
+	}
+
+	seen := make(map[opKey]bool, len(ops))
+	keep := make([]bool, len(ops))
+	n := 0
+
+	// Only the last operation on the object is kept
+	for i := len(ops) - 1; i >= 0; i-- {
+		key := opKey{prefix: ops[i].Op == dbms.DeletePrefix, path: ops[i].ObjectInfo.FPath}
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		keep[i] = true
+		n++
+	}
+
+	rv := make([]*dbms.DBOperation, 0, n)
+	for i, op := range ops {
+		if keep[i] {
+			rv = append(rv, op)
+		}
+	}
+
+	return rv, len(ops) - n
+}
+
+func queueOp(ctl dbms.ClientController, op *dbms.DBOperation) error {
+	switch op.Op {
+	case dbms.Update:
+		return ctl.UpdateObj(op.ObjectInfo)
+	case dbms.Delete:
+		return ctl.DeleteObj(op.ObjectInfo)
+	default:
+		_, err := ctl.DeleteFPathPref(op.ObjectInfo)
+		return err
+	}
+}
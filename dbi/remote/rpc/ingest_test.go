@@ -0,0 +1,271 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestMain(m *testing.M) {
+	// Ingest service writes messages to the log
+	if err := log.Open(log.DefaultLog, "rpc-test", log.NoFlags); err != nil {
+		panic("cannot open log: " + err.Error())
+	}
+
+	os.Exit(m.Run())
+}
+
+// testCtl is the client controller that records committed operations, methods
+// that are not used by tests are not implemented by the embedded nil controller
+type testCtl struct {
+	dbms.ClientController
+
+	host		string
+	queued		[]string
+	committed	map[string][]string	// host => committed operations
+	lastSeq		map[string]int64	// host => sequence number of the last batch
+}
+
+func newTestCtl() *testCtl {
+	return &testCtl{committed: map[string][]string{}, lastSeq: map[string]int64{}}
+}
+
+func (tc *testCtl) SetCliHost(host string) {
+	tc.host = host
+}
+
+func (tc *testCtl) UpdateObj(fso *types.FSObject) error {
+	tc.queued = append(tc.queued, "U " + fso.FPath)
+	return nil
+}
+
+func (tc *testCtl) DeleteObj(fso *types.FSObject) error {
+	tc.queued = append(tc.queued, "D " + fso.FPath)
+	return nil
+}
+
+func (tc *testCtl) DeleteFPathPref(fso *types.FSObject) (int64, error) {
+	tc.queued = append(tc.queued, "P " + fso.FPath)
+	return 0, nil
+}
+
+func (tc *testCtl) Commit(seq int64) (int64, int64, error) {
+	tc.committed[tc.host] = append(tc.committed[tc.host], tc.queued...)
+	n := int64(len(tc.queued))
+	tc.queued = nil
+
+	if seq != 0 {
+		tc.lastSeq[tc.host] = seq
+	}
+
+	return n, 0, nil
+}
+
+func (tc *testCtl) LastBatchSeq() (int64, error) {
+	return tc.lastSeq[tc.host], nil
+}
+
+// testOps returns operations described by strings in the "OP PATH" format,
+// where OP is U - update, D - delete, P - deletion by prefix
+func testOps(descrs ...string) []*dbms.DBOperation {
+	ops := make([]*dbms.DBOperation, 0, len(descrs))
+	for _, descr := range descrs {
+		op, path, _ := strings.Cut(descr, " ")
+		ops = append(ops, &dbms.DBOperation{
+			Op:			map[string]dbms.DBOperator{"U": dbms.Update, "D": dbms.Delete, "P": dbms.DeletePrefix}[op],
+			ObjectInfo:	&types.FSObject{FPath: path},
+		})
+	}
+
+	return ops
+}
+
+func opsStr(ops []*dbms.DBOperation) []string {
+	rv := make([]string, 0, len(ops))
+	for _, op := range ops {
+		rv = append(rv, map[dbms.DBOperator]string{dbms.Update: "U", dbms.Delete: "D", dbms.DeletePrefix: "P"}[op.Op] +
+			" " + op.ObjectInfo.FPath)
+	}
+
+	return rv
+}
+
+func TestDedupOps(t *testing.T) {
+	tests := []struct {
+		ops			[]string
+		want		[]string
+		dropped		int
+	} {
+		{ ops: []string{}, want: []string{} },
+		{ ops: []string{"U /a", "U /b"}, want: []string{"U /a", "U /b"} },
+		// Only the last operation on the object is kept in its place
+		{ ops: []string{"U /a", "U /b", "U /a"}, want: []string{"U /b", "U /a"}, dropped: 1 },
+		{ ops: []string{"U /a", "D /a"}, want: []string{"D /a"}, dropped: 1 },
+		{ ops: []string{"D /a", "U /a"}, want: []string{"U /a"}, dropped: 1 },
+		// Deletion by prefix is not the operation on the object with the same path, the order is kept
+		{ ops: []string{"U /a", "P /a"}, want: []string{"U /a", "P /a"} },
+		{ ops: []string{"P /a", "U /a"}, want: []string{"P /a", "U /a"} },
+		{ ops: []string{"P /a", "U /a/x", "P /a"}, want: []string{"U /a/x", "P /a"}, dropped: 1 },
+		{ ops: []string{"U /a", "P /a", "D /a", "U /a"}, want: []string{"P /a", "U /a"}, dropped: 2 },
+		{ ops: []string{"U /a/x", "P /a", "U /a/x", "D /a/y"}, want: []string{"P /a", "U /a/x", "D /a/y"}, dropped: 1 },
+	}
+
+	for i, test := range tests {
+		ops, dropped := dedupOps(testOps(test.ops...))
+		if got := opsStr(ops); !reflect.DeepEqual(got, test.want) || dropped != test.dropped {
+			t.Errorf("[%d] dedupOps(%q) - want %q (%d dropped), got %q (%d dropped)",
+				i, test.ops, test.want, test.dropped, got, dropped)
+		}
+	}
+}
+
+func TestIngestDuplicates(t *testing.T) {
+	ctl := newTestCtl()
+	s := NewIngestServer(&IngestConfig{Tokens: map[string]string{"h1": "t1", "h2": "t2"}}, ctl)
+
+	tests := []struct {
+		host, token	string
+		seq			int64
+		ops			[]string
+		duplicate	bool
+	} {
+		{ host: "h1", token: "t1", seq: 1, ops: []string{"U /a"} },
+		{ host: "h1", token: "t1", seq: 2, ops: []string{"U /b", "U /b"} },
+		// Resent batches are skipped
+		{ host: "h1", token: "t1", seq: 2, ops: []string{"U /b"}, duplicate: true },
+		{ host: "h1", token: "t1", seq: 1, ops: []string{"U /a"}, duplicate: true },
+		// Sequence numbers are separate for each host
+		{ host: "h2", token: "t2", seq: 1, ops: []string{"D /a"} },
+		// Batches without sequence numbers are always applied
+		{ host: "h1", token: "t1", seq: 0, ops: []string{"P /c"} },
+		{ host: "h1", token: "t1", seq: 3, ops: []string{"U /d"} },
+	}
+
+	for i, test := range tests {
+		resp, err := s.ingest(agentCtx(test.host, test.token), &IngestRequest{Seq: test.seq, Ops: testOps(test.ops...)})
+		if err != nil {
+			t.Fatalf("[%d] ingest returned unexpected error: %v", i, err)
+		}
+		if resp.Duplicate != test.duplicate {
+			t.Errorf("[%d] batch %d of %s - want duplicate %t, got %t", i, test.seq, test.host, test.duplicate, resp.Duplicate)
+		}
+	}
+
+	want := map[string][]string{
+		"h1": {"U /a", "U /b", "P /c", "U /d"},
+		"h2": {"D /a"},
+	}
+	if !reflect.DeepEqual(ctl.committed, want) {
+		t.Errorf("want committed operations %q, got %q", want, ctl.committed)
+	}
+}
+
+func TestIngestAuth(t *testing.T) {
+	s := NewIngestServer(&IngestConfig{Tokens: map[string]string{"h1": "t1", "empty": ""}}, newTestCtl())
+
+	tests := []struct {
+		md		metadata.MD
+		want	string	// authenticated host, empty if authentication fails
+	} {
+		{ md: nil },
+		{ md: metadata.Pairs(MDAuth, bearerScheme + "t1") },
+		{ md: metadata.Pairs(MDHost, "h1") },
+		{ md: metadata.Pairs(MDHost, "h1", MDAuth, "t1") },
+		{ md: metadata.Pairs(MDHost, "h1", MDAuth, basicScheme + "t1") },
+		{ md: metadata.Pairs(MDHost, "h1", MDAuth, bearerScheme + "t2") },
+		{ md: metadata.Pairs(MDHost, "h1", MDAuth, bearerScheme) },
+		{ md: metadata.Pairs(MDHost, "h2", MDAuth, bearerScheme + "t1") },
+		{ md: metadata.Pairs(MDHost, "empty", MDAuth, bearerScheme) },
+		// Host and token must be passed once
+		{ md: metadata.Pairs(MDHost, "h1", MDHost, "h2", MDAuth, bearerScheme + "t1") },
+		{ md: metadata.Pairs(MDHost, "h1", MDAuth, bearerScheme + "t1", MDAuth, bearerScheme + "t1") },
+		{ md: metadata.Pairs(MDHost, "h1", MDAuth, bearerScheme + "t1"), want: "h1" },
+	}
+
+	for i, test := range tests {
+		ctx := context.Background()
+		if test.md != nil {
+			ctx = metadata.NewIncomingContext(ctx, test.md)
+		}
+
+		host, err := s.auth(ctx)
+		if test.want == "" {
+			if status.Code(err) != codes.Unauthenticated {
+				t.Errorf("[%d] auth with metadata %v - want %v error, got host %q, error %v",
+					i, test.md, codes.Unauthenticated, host, err)
+			}
+			continue
+		}
+
+		if err != nil || host != test.want {
+			t.Errorf("[%d] auth with metadata %v - want host %q, got %q, error %v", i, test.md, test.want, host, err)
+		}
+	}
+}
+
+func TestIngestWait(t *testing.T) {
+	s := NewIngestServer(&IngestConfig{Rate: 1, Burst: 2, MaxWait: 0}, newTestCtl())
+
+	tests := []struct {
+		host	string
+		n		int
+		want	codes.Code
+	} {
+		{ host: "h1", n: 2, want: codes.OK },
+		{ host: "h1", n: 1, want: codes.OK },
+		// The delay exceeds the maximum wait
+		{ host: "h1", n: 1, want: codes.ResourceExhausted },
+		// Empty batches are not limited
+		{ host: "h1", n: 0, want: codes.OK },
+		// Rates of hosts are limited separately
+		{ host: "h2", n: 3, want: codes.OK },
+		{ host: "h2", n: 1, want: codes.ResourceExhausted },
+	}
+
+	for i, test := range tests {
+		if err := s.wait(context.Background(), test.host, test.n); status.Code(err) != test.want {
+			t.Errorf("[%d] wait for %d operations of %s - want %v, got %v", i, test.n, test.host, test.want, err)
+		}
+	}
+
+	// Delayed batch is waited for
+	s = NewIngestServer(&IngestConfig{Rate: 10, Burst: 1, MaxWait: time.Second}, newTestCtl())
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		if err := s.wait(context.Background(), "h1", 1); err != nil {
+			t.Fatalf("wait returned unexpected error: %v", err)
+		}
+		if elapsed := time.Since(start); i == 2 && elapsed < 50 * time.Millisecond {
+			t.Errorf("batch over the burst was not delayed, waited %v", elapsed)
+		}
+	}
+
+	// Waiting is interrupted by the context
+	s = NewIngestServer(&IngestConfig{Rate: 0.1, Burst: 1, MaxWait: time.Minute}, newTestCtl())
+	ctx, cancel := context.WithTimeout(context.Background(), 50 * time.Millisecond)
+	defer cancel()
+	if err := s.wait(ctx, "h1", 2); err != nil {
+		t.Fatalf("wait returned unexpected error: %v", err)
+	}
+	if err := s.wait(ctx, "h1", 1); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("want %v error of interrupted waiting, got %v", codes.DeadlineExceeded, err)
+	}
+}
+
+func agentCtx(host, token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(MDHost, host, MDAuth, fmt.Sprintf("%s%s", bearerScheme, token)))
+}
@@ -0,0 +1,50 @@
+package rpc
+
+import (
+	"sync"
+	"time"
+)
+
+// limiter limits the rate of operations using the virtual scheduling algorithm: each operation
+// moves the theoretical arrival time (TAT) forward by the interval, a new batch is allowed without
+// delay while the TAT does not exceed the current time by more than the burst
+type limiter struct {
+	mu			sync.Mutex
+	interval	time.Duration	// time of a single operation at the configured rate
+	burst		time.Duration	// time of the burst of operations allowed without delay
+	tat			time.Time
+}
+
+func newLimiter(rate float64, burst int) *limiter {
+	interval := time.Duration(float64(time.Second) / rate)
+
+	return &limiter{
+		interval:	interval,
+		burst:		interval * time.Duration(burst),
+	}
+}
+
+// reserve reserves n operations and returns the delay after which they can be performed,
+// nothing is reserved and false is returned if the delay exceeds maxWait
+func (l *limiter) reserve(n int, maxWait time.Duration) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	tat := l.tat
+	if tat.Before(now) {
+		tat = now
+	}
+
+	delay := tat.Sub(now) - l.burst
+	if delay < 0 {
+		delay = 0
+	}
+	if delay > maxWait {
+		return delay, false
+	}
+
+	l.tat = tat.Add(l.interval * time.Duration(n))
+
+	return delay, true
+}
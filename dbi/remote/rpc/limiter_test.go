@@ -0,0 +1,43 @@
+package rpc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterReserve(t *testing.T) {
+	// One operation per second, two operations are allowed without delay
+	l := newLimiter(1, 2)
+
+	// Tolerance of delays to the time passed between calls
+	const tolerance = 100 * time.Millisecond
+
+	tests := []struct {
+		n			int
+		maxWait		time.Duration
+		wantDelay	time.Duration
+		wantOK		bool
+	} {
+		// The burst
+		{ n: 2, wantDelay: 0, wantOK: true },
+		// Operations reserved by the burst are not the delay yet
+		{ n: 1, wantDelay: 0, wantOK: true },
+		// Over the burst - one interval of delay, greater than allowed
+		{ n: 1, maxWait: 0, wantDelay: time.Second, wantOK: false },
+		{ n: 1, maxWait: time.Second / 2, wantDelay: time.Second, wantOK: false },
+		// Rejected batches do not reserve operations
+		{ n: 1, maxWait: 2 * time.Second, wantDelay: time.Second, wantOK: true },
+		// Reserved operations of the batch are added to the delay of the next one
+		{ n: 5, maxWait: 2 * time.Second, wantDelay: 2 * time.Second, wantOK: true },
+		{ n: 1, maxWait: 6 * time.Second, wantDelay: 7 * time.Second, wantOK: false },
+		{ n: 1, maxWait: 7 * time.Second, wantDelay: 7 * time.Second, wantOK: true },
+	}
+
+	for i, test := range tests {
+		delay, ok := l.reserve(test.n, test.maxWait)
+		if ok != test.wantOK || delay > test.wantDelay || delay < test.wantDelay - tolerance {
+			t.Errorf("[%d] reserve(%d, %v) - want (%v, %t), got (%v, %t)",
+				i, test.n, test.maxWait, test.wantDelay, test.wantOK, delay, ok)
+		}
+	}
+}
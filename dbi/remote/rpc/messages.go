@@ -0,0 +1,232 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+)
+
+type QueryRequest struct {
+	QA			*dbms.QueryArgs	`json:"qa"`
+	RetFields	[]string		`json:"retFields"`
+}
+
+type QueryResponse struct {
+	Results	[]*QRItem	`json:"results"`
+}
+
+type IdsRequest struct {
+	Ids			[]string	`json:"ids"`
+	RetFields	[]string	`json:"retFields"`
+}
+
+type IdsResponse struct {
+	Ids		[]string	`json:"ids"`
+}
+
+type AIIsResponse struct {
+	AIIs	dbms.QueryResultsAII	`json:"aiis"`
+}
+
+type HostsResponse struct {
+	Hosts	[]*dbms.HostInfo	`json:"hosts"`
+}
+
+type HistoryRequest struct {
+	HA	*dbms.HistArgs	`json:"ha"`
+}
+
+type HistoryResponse struct {
+	Records	dbms.HistResults	`json:"records"`
+}
+
+type DiskUsageRequest struct {
+	QA	*dbms.QueryArgs	`json:"qa"`
+	DUA	*dbms.DUArgs	`json:"dua"`
+}
+
+type DiskUsageResponse struct {
+	Results	dbms.DUResults	`json:"results"`
+}
+
+type ModifyAIIRequest struct {
+	Op		dbms.DBOperator	`json:"op"`
+	Args	*dbms.AIIArgs	`json:"args"`
+	Ids		[]string		`json:"ids"`
+	Add		bool			`json:"add"`
+}
+
+type ModifyAIIResponse struct {
+	TagsUpdated		int64	`json:"tagsUpdated"`
+	DescrsUpdated	int64	`json:"descrsUpdated"`
+}
+
+type Empty struct {}
+
+// Portion of changes sent by the subscription stream
+type ChangesMessage struct {
+	Changes	[]*dbms.ObjChange	`json:"changes"`
+}
+
+// QRItem is an item of query results with typed values of fields, because
+// JSON does not distinguish integer and floating point numbers
+type QRItem struct {
+	Host	string				`json:"host"`
+	Path	string				`json:"path"`
+	Fields	map[string]*Value	`json:"fields"`
+}
+
+// Value of the field, only one member is set
+type Value struct {
+	Str		*string		`json:"s,omitempty"`
+	Int		*int64		`json:"i,omitempty"`
+	Float	*float64	`json:"f,omitempty"`
+	Bool	*bool		`json:"b,omitempty"`
+	List	*[]string	`json:"l,omitempty"`
+}
+
+func newValue(v any) *Value {
+	switch v := v.(type) {
+	case string:
+		return &Value{Str: &v}
+	case int64:
+		return &Value{Int: &v}
+	case int32:
+		i := int64(v)
+		return &Value{Int: &i}
+	case int:
+		i := int64(v)
+		return &Value{Int: &i}
+	case float64:
+		return &Value{Float: &v}
+	case bool:
+		return &Value{Bool: &v}
+	case []string:
+		return &Value{List: &v}
+	case []any:
+		l := make([]string, 0, len(v))
+		for _, item := range v {
+			l = append(l, fmt.Sprint(item))
+		}
+		return &Value{List: &l}
+	}
+
+	// Unsupported types are passed as strings
+	s := fmt.Sprint(v)
+	return &Value{Str: &s}
+}
+
+func (v *Value) value() any {
+	switch {
+	case v.Str != nil:
+		return *v.Str
+	case v.Int != nil:
+		return *v.Int
+	case v.Float != nil:
+		return *v.Float
+	case v.Bool != nil:
+		return *v.Bool
+	case v.List != nil:
+		return *v.List
+	}
+
+	return nil
+}
+
+// EncodeQR converts query results to the list of items
+func EncodeQR(qr dbms.QueryResults) []*QRItem {
+	items := make([]*QRItem, 0, len(qr))
+	for objKey, fields := range qr {
+		item := &QRItem{
+			Host:	objKey.Host,
+			Path:	objKey.Path,
+			Fields:	make(map[string]*Value, len(fields)),
+		}
+		for name, v := range fields {
+			item.Fields[name] = newValue(v)
+		}
+
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// DecodeQR converts the list of items to query results
+func DecodeQR(items []*QRItem) dbms.QueryResults {
+	qr := make(dbms.QueryResults, len(items))
+	for _, item := range items {
+		fields := make(dbms.QRItem, len(item.Fields))
+		for name, v := range item.Fields {
+			if v != nil {
+				fields[name] = v.value()
+			}
+		}
+
+		qr[types.ObjKey{Host: item.Host, Path: item.Path}] = fields
+	}
+
+	return qr
+}
+
+//
+// Messages of the ingest service
+//
+
+// Batch of operations of the agent, non-zero sequence number is stored as the sequence number of the batch
+type IngestRequest struct {
+	Seq		int64					`json:"seq"`
+	Ops		[]*dbms.DBOperation		`json:"ops"`
+}
+
+type IngestResponse struct {
+	Updated		int64			`json:"updated"`
+	Deleted		int64			`json:"deleted"`
+	Duplicate	bool			`json:"duplicate,omitempty"`	// the batch was already applied
+	Dropped		int				`json:"dropped,omitempty"`		// number of operations superseded in the batch
+	OpErrors	[]*OpErrorMsg	`json:"opErrors,omitempty"`
+}
+
+// OpErrorMsg describes a failure of a single operation of the batch
+type OpErrorMsg struct {
+	Op		dbms.DBOperator	`json:"op"`
+	Key		string			`json:"key"`
+	Error	string			`json:"error"`
+}
+
+type SeqResponse struct {
+	Seq		int64	`json:"seq"`
+}
+
+type UpdateHostRequest struct {
+	Host	*dbms.HostInfo	`json:"host"`
+}
+
+type UpdateHostResponse struct {
+	Objects	int64	`json:"objects"`
+}
+
+type HostPathsResponse struct {
+	Paths	[]string	`json:"paths"`
+}
+
+func encodeOpErrors(oes dbms.OpErrors) []*OpErrorMsg {
+	msgs := make([]*OpErrorMsg, 0, len(oes))
+	for _, oe := range oes {
+		msgs = append(msgs, &OpErrorMsg{Op: oe.Op, Key: oe.Key, Error: oe.Err.Error()})
+	}
+
+	return msgs
+}
+
+// DecodeOpErrors converts failures of operations of the batch to errors returned by ClientController.Commit()
+func DecodeOpErrors(msgs []*OpErrorMsg) dbms.OpErrors {
+	oes := make(dbms.OpErrors, 0, len(msgs))
+	for _, msg := range msgs {
+		oes = append(oes, &dbms.OpError{Op: msg.Op, Key: msg.Key, Err: errors.New(msg.Error)})
+	}
+
+	return oes
+}
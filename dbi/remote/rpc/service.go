@@ -0,0 +1,252 @@
+// Package rpc implements the gRPC service that provides operations of the database client to remote
+// clients, so they do not need credentials and network access to the database
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+
+	"google.golang.org/grpc"
+)
+
+// Full name of the service
+const ServiceName = "dfi.v1.Index"
+
+// Names of methods of the service
+const (
+	MethodQuery				=	"Query"
+	MethodQueryAIIIds		=	"QueryAIIIds"
+	MethodQueryContentIds	=	"QueryContentIds"
+	MethodGetObjects		=	"GetObjects"
+	MethodGetAIIs			=	"GetAIIs"
+	MethodGetAIIIds			=	"GetAIIIds"
+	MethodGetHosts			=	"GetHosts"
+	MethodGetHistory		=	"GetHistory"
+	MethodDiskUsage			=	"DiskUsage"
+	MethodModifyAII			=	"ModifyAII"
+	MethodSubscribe			=	"Subscribe"
+)
+
+// FullMethod returns the full name of the method used to call it
+func FullMethod(method string) string {
+	return fullMethod(ServiceName, method)
+}
+
+func fullMethod(service, method string) string {
+	return "/" + service + "/" + method
+}
+
+// ClientFunc returns the database client to serve the call with the context ctx, the returned
+// error is returned as the status of the call, e.g. if the caller cannot be authenticated
+type ClientFunc func(ctx context.Context) (dbms.Client, error)
+
+// Server serves calls of the service by the database client
+type Server struct {
+	client	ClientFunc
+}
+
+// NewServer returns the server that serves all calls by the database client dbc
+func NewServer(dbc dbms.Client) *Server {
+	return NewServerFunc(func(context.Context) (dbms.Client, error) {
+		return dbc, nil
+	})
+}
+
+// NewServerFunc returns the server that serves each call by the database client returned by cf
+func NewServerFunc(cf ClientFunc) *Server {
+	return &Server{client: cf}
+}
+
+// Register registers the service on the gRPC server
+func (s *Server) Register(gs grpc.ServiceRegistrar) {
+	gs.RegisterService(&ServiceDesc, s)
+}
+
+// ServiceDesc describes the service for gRPC, the handler type is
+// an empty interface because the service is implemented only by *Server
+var ServiceDesc = grpc.ServiceDesc{
+	ServiceName:	ServiceName,
+	HandlerType:	(*any)(nil),
+	Methods:		[]grpc.MethodDesc{
+		unary(MethodQuery, query),
+		unary(MethodQueryAIIIds, queryAIIIds),
+		unary(MethodQueryContentIds, queryContentIds),
+		unary(MethodGetObjects, getObjects),
+		unary(MethodGetAIIs, getAIIs),
+		unary(MethodGetAIIIds, getAIIIds),
+		unary(MethodGetHosts, getHosts),
+		unary(MethodGetHistory, getHistory),
+		unary(MethodDiskUsage, diskUsage),
+		unary(MethodModifyAII, modifyAII),
+	},
+	Streams:		[]grpc.StreamDesc{
+		{
+			StreamName:		MethodSubscribe,
+			Handler:		subscribe,
+			ServerStreams:	true,
+		},
+	},
+}
+
+// unary makes the description of the unary method served by the call with the database client of the call,
+// the error returned by the call is the status of the call with partial results of the response attached
+func unary[Req, Resp any](name string, call func(dbms.Client, *Req) (*Resp, error)) grpc.MethodDesc {
+	return method(ServiceName, name, func(s *Server, ctx context.Context, req *Req) (*Resp, error) {
+		dbc, err := s.client(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := call(dbc, req)
+		switch {
+		case err == nil:
+			return resp, nil
+		case resp == nil:
+			// No results
+			return nil, statusError(err, nil)
+		}
+
+		return nil, statusError(err, resp)
+	})
+}
+
+// method makes the description of the unary method of the service served by the server of type S,
+// the error returned by the call is the status of the call
+func method[S, Req, Resp any](service, name string, call func(S, context.Context, *Req) (*Resp, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName:	name,
+		Handler:	func(srv any, ctx context.Context, dec func(any) error,
+						interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := new(Req)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+
+			handler := func(ctx context.Context, req any) (any, error) {
+				return call(srv.(S), ctx, req.(*Req))
+			}
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+
+			return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod(service, name)}, handler)
+		},
+	}
+}
+
+func query(dbc dbms.Client, req *QueryRequest) (*QueryResponse, error) {
+	if req.QA == nil {
+		req.QA = dbms.NewQueryArgs()
+	}
+
+	qr, err := dbc.Query(req.QA, req.RetFields)
+
+	return &QueryResponse{Results: EncodeQR(qr)}, err
+}
+
+func queryAIIIds(dbc dbms.Client, req *QueryRequest) (*IdsResponse, error) {
+	if req.QA == nil {
+		req.QA = dbms.NewQueryArgs()
+	}
+
+	ids, err := dbc.QueryAIIIds(req.QA)
+
+	return &IdsResponse{Ids: ids}, err
+}
+
+func queryContentIds(dbc dbms.Client, req *QueryRequest) (*IdsResponse, error) {
+	if req.QA == nil {
+		req.QA = dbms.NewQueryArgs()
+	}
+
+	ids, err := dbc.QueryContentIds(req.QA)
+
+	return &IdsResponse{Ids: ids}, err
+}
+
+func getObjects(dbc dbms.Client, req *IdsRequest) (*QueryResponse, error) {
+	qr, err := dbc.GetObjects(req.Ids, req.RetFields)
+
+	return &QueryResponse{Results: EncodeQR(qr)}, err
+}
+
+func getAIIs(dbc dbms.Client, req *IdsRequest) (*AIIsResponse, error) {
+	aiis, err := dbc.GetAIIs(req.Ids, req.RetFields)
+
+	return &AIIsResponse{AIIs: aiis}, err
+}
+
+func getAIIIds(dbc dbms.Client, req *IdsRequest) (*IdsResponse, error) {
+	// Requested fields are passed as return fields
+	ids, err := dbc.GetAIIIds(req.RetFields)
+
+	return &IdsResponse{Ids: ids}, err
+}
+
+func getHosts(dbc dbms.Client, _ *Empty) (*HostsResponse, error) {
+	hosts, err := dbc.GetHosts()
+
+	return &HostsResponse{Hosts: hosts}, err
+}
+
+func getHistory(dbc dbms.Client, req *HistoryRequest) (*HistoryResponse, error) {
+	if req.HA == nil {
+		req.HA = &dbms.HistArgs{}
+	}
+
+	hr, err := dbc.GetHistory(req.HA)
+
+	return &HistoryResponse{Records: hr}, err
+}
+
+func diskUsage(dbc dbms.Client, req *DiskUsageRequest) (*DiskUsageResponse, error) {
+	if req.QA == nil {
+		req.QA = dbms.NewQueryArgs()
+	}
+	if req.DUA == nil {
+		req.DUA = &dbms.DUArgs{}
+	}
+
+	dur, err := dbc.DiskUsage(req.QA, req.DUA)
+
+	return &DiskUsageResponse{Results: dur}, err
+}
+
+func modifyAII(dbc dbms.Client, req *ModifyAIIRequest) (*ModifyAIIResponse, error) {
+	if req.Args == nil {
+		return nil, fmt.Errorf("%w: no additional information arguments", dbms.ErrInvalidArgs)
+	}
+
+	log.D("(RPC) Modify AII (%v, add: %t) %#v for: %v", req.Op, req.Add, req.Args, req.Ids)
+
+	tu, du, err := dbc.ModifyAII(req.Op, req.Args, req.Ids, req.Add)
+
+	return &ModifyAIIResponse{TagsUpdated: tu, DescrsUpdated: du}, err
+}
+
+// subscribe streams changes committed by agents until the client cancels the call
+func subscribe(srv any, stream grpc.ServerStream) error {
+	var req Empty
+	if err := stream.RecvMsg(&req); err != nil {
+		return err
+	}
+
+	dbc, err := srv.(*Server).client(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	err = dbc.Subscribe(stream.Context(), func(changes []*dbms.ObjChange) error {
+		return stream.SendMsg(&ChangesMessage{Changes: changes})
+	})
+	if err != nil {
+		return statusError(err, nil)
+	}
+
+	// OK
+	return nil
+}
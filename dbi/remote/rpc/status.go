@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Errors of the database are returned as statuses of calls, so gRPC middleware distinguishes failed calls.
+// Some operations return partial results with errors, such results are attached to the status as the
+// detail that contains the response encoded by the codec of the service
+
+// statusError returns the status of the call failed with the error, resp is the response with partial results or nil
+func statusError(err error, resp any) error {
+	st := status.New(errorCode(err), err.Error())
+	if resp == nil {
+		return st.Err()
+	}
+
+	data, err := Codec().Marshal(resp)
+	if err != nil {
+		log.E("(RPC) Cannot encode partial results of the failed call: %v", err)
+		return st.Err()
+	}
+
+	std, err := st.WithDetails(wrapperspb.Bytes(data))
+	if err != nil {
+		log.E("(RPC) Cannot attach partial results to the status of the failed call: %v", err)
+		return st.Err()
+	}
+
+	return std.Err()
+}
+
+// errorCode returns the status code of the call failed with the error
+func errorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, dbms.ErrInvalidArgs):
+		return codes.InvalidArgument
+	case errors.Is(err, dbms.ErrForbidden):
+		return codes.PermissionDenied
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	}
+
+	// Errors that already have statuses, e.g. failed authentication
+	if st, ok := status.FromError(err); ok {
+		return st.Code()
+	}
+
+	// Failure of the database
+	return codes.Internal
+}
+
+// CallError is the error of the failed call returned to clients, it keeps the status of the call and matches
+// errors of the database interface by the status code, so clients can check them as errors of local databases
+type CallError struct {
+	st	*status.Status
+}
+
+// NewCallError returns the error of the call failed with err, partial results attached to the status are decoded to resp
+func NewCallError(err error, resp any) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		// Not a status, e.g. the error of the codec
+		return err
+	}
+
+	for _, detail := range st.Details() {
+		if data, ok := detail.(*wrapperspb.BytesValue); ok && resp != nil {
+			if err := Codec().Unmarshal(data.GetValue(), resp); err != nil {
+				log.W("(RPC) Cannot decode partial results of the failed call: %v", err)
+			}
+		}
+	}
+
+	return &CallError{st: st}
+}
+
+func (ce *CallError) Error() string {
+	return ce.st.Err().Error()
+}
+
+// GRPCStatus returns the status of the call, it is used by functions of the status package
+func (ce *CallError) GRPCStatus() *status.Status {
+	return ce.st
+}
+
+func (ce *CallError) Is(target error) bool {
+	switch target {
+	case dbms.ErrInvalidArgs:
+		return ce.st.Code() == codes.InvalidArgument
+	case dbms.ErrForbidden:
+		return ce.st.Code() == codes.PermissionDenied
+	case context.Canceled:
+		return ce.st.Code() == codes.Canceled
+	case context.DeadlineExceeded:
+		return ce.st.Code() == codes.DeadlineExceeded
+	}
+
+	return false
+}
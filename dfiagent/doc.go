@@ -28,6 +28,16 @@ etc.). The authentication data file is in JSON format. The specific format of
 its contents depends on the used DBMS.  For details, see the database
 driver-specific information in the dbi/{DBMS-name} directory.
 
+Instead of the file, the authentication data can be loaded from other sources
+set by the option value prefix:
+
+  * env:VARIABLE - JSON from the environment variable, the variable is removed
+    from the environment after loading
+  * cmd:CREDENTIAL-HELPER - JSON printed by the credential helper command,
+    e.g. "cmd:pass show dfi/agent"
+  * systemd:CREDENTIAL - JSON from the credential passed by systemd using
+    the LoadCredential= directive of the unit, e.g. "systemd:db"
+
 For for more information about configuration options please run:
 
   dfiagent --help
@@ -60,6 +70,56 @@ time and cause a heavy I/O and CPU load.
 
 To start reindexing by a resident process, use sending a proper signal to it. See Signals handling section.
 
+The --state-file option is recommended for the resident service. With this
+option, dfiagent records the sequence number of each batch of changes before
+committing it to the database. After restart, if the last recorded batch was
+not committed (crash, connection loss, etc.), reindexing is performed automatically.
+
+On startup, dfiagent registers the host in the database: the agent version,
+indexing paths, checksum settings and the number of indexed objects. This
+information is updated periodically by heartbeats, the period is set by the
+--heartbeat-period option. Registered hosts can be listed by "dfi --hosts".
+
+The --history option enables recording of changes of indexed objects to the
+append-only history: creation, modification and deletion with sizes and
+checksums before and after the change. Records older than --history-keep
+are removed. The history is shown by "dfi --history" and "dfi --changes".
+
+The real path of each symbolic link and directory is stored as the fully
+resolved absolute path, dangling links and links in loops are marked. The
+--follow-symlinks option enables following of symbolic links to directories
+outside of the indexing paths, objects of such directories are indexed by
+paths containing the link. Links to directories inside of the indexing paths
+or inside of already followed directories are not followed to avoid cycles.
+
+The --media-meta option enables extraction of metadata of photos and videos:
+capture time, camera make and model and GPS location from EXIF of JPEG and TIFF
+images, duration and resolution of MP4, QuickTime and AVI videos. It makes
+possible to search photos by capture time and camera using the dfi utility.
+
+The --extract-content option enables extraction of the text of documents: plain
+text, Markdown, HTML, PDF and OpenDocument files. The text, title, author and
+number of pages are stored separately from the objects information and make
+possible to search documents by their content using the --content option of
+the dfi utility. Files larger than --max-extract-size are skipped, extraction
+of a single file is limited by --extract-timeout, the extracted text is
+truncated to 1 MiB.
+
+The --archives option enables indexing of members of zip, tar, tar.gz and
+tar.zst archives. Each regular file stored in an archive is indexed as a virtual
+object of the arc-member type with the path in the ARCHIVE!/MEMBER format, e.g.
+/data/backups/etc.tar.gz!/etc/hosts, size, modification time, owner and
+permissions, the checksum is calculated if --checksums is set. Members are
+updated when the archive changes and removed together with it. The number of
+indexed members of a single archive is limited by --max-archive-members.
+
+The --hooks-cfg option sets the JSON file with hooks - actions run on
+filesystem events matched by rules: kinds of events, glob patterns of paths,
+types and sizes of objects. An action is an HTTP POST of the event in JSON
+to a webhook or an execution of a local command with the event in environment
+variables. Actions are run with a limited rate, failed actions are retried.
+See the README of dfiagent for the configuration format.
+
 # Signals handling
 
   * TERM, INT - stop application
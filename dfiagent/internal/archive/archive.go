@@ -0,0 +1,233 @@
+// Package archive enumerates members of zip, tar and compressed tar archives,
+// used by the agent to index the members as virtual filesystem objects
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Member describes a regular file stored in an archive
+type Member struct {
+	Path	string	// Relative path of the member inside of the archive
+	Size	int64
+	MTime	int64
+	Mode	int64	// Permission bits in Unix format
+	UID		int64
+	GID		int64
+	User	string	// Owner names, set only by tar archives
+	Group	string
+}
+
+// WalkFunc is called for each regular file member of the archive, r reads the content of the member
+type WalkFunc func(m *Member, r io.Reader) error
+
+var (
+	// Returned when the compressed file does not contain a tar archive
+	ErrNotArchive = errors.New("not an archive")
+	// Returned when the archive contains more members than allowed
+	ErrTooManyMembers = errors.New("too many archive members")
+)
+
+const (
+	mimeZip		= "application/zip"
+	mimeTar		= "application/x-tar"
+	mimeGzip	= "application/x-gzip"
+	mimeZstd	= "application/zstd"
+
+	// Maximum memory used by zstd decoder, protects against malicious frames
+	zstdMaxMemory = 512 * 1024 * 1024
+)
+
+// Extensions of supported archives, used when the content type cannot be detected (e.g. removed files)
+var archiveExts = []string{
+	".zip",
+	".tar",
+	".tar.gz",
+	".tgz",
+	".tar.zst",
+	".tzst",
+}
+
+// IsArchive reports whether the content type mime may belong to a supported archive.
+// Compressed files are reported too, but they may contain something other than tar
+func IsArchive(mime string) bool {
+	switch mime {
+	case mimeZip, mimeTar, mimeGzip, mimeZstd:
+		return true
+	default:
+		return false
+	}
+}
+
+// HasArchiveExt reports whether the name has an extension of a supported archive
+func HasArchiveExt(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Walk calls fn for each regular file member of the archive placed by path with
+// content type mime. If max is not 0, walking stops with ErrTooManyMembers after
+// max members. Errors returned by fn stop walking and are returned as is
+func Walk(path, mime string, max int, fn WalkFunc) error {
+	if mime == mimeZip {
+		return walkZip(path, max, fn)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader
+	switch mime {
+	case mimeTar:
+		r = f
+	case mimeGzip:
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("cannot read gzip stream: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	case mimeZstd:
+		zr, err := zstd.NewReader(f, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(zstdMaxMemory))
+		if err != nil {
+			return fmt.Errorf("cannot read zstd stream: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return ErrNotArchive
+	}
+
+	return walkTar(r, max, fn)
+}
+
+func walkTar(r io.Reader, max int, fn WalkFunc) error {
+	tr := tar.NewReader(r)
+
+	// Numbers of read headers and found members
+	for headers, n := 0, 0; ; headers++ {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			// OK, end of archive
+			return nil
+		}
+		if err != nil {
+			if headers == 0 {
+				// The first header is invalid, probably, compressed file is not a tar archive
+				return fmt.Errorf("%w: %v", ErrNotArchive, err)	//nolint:errorlint	// only one error can be wrapped
+			}
+			return fmt.Errorf("cannot read tar header: %w", err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {	//nolint:staticcheck	// TypeRegA is used by old archives
+			// Only regular files are indexed
+			continue
+		}
+
+		name, ok := cleanName(hdr.Name)
+		if !ok {
+			continue
+		}
+
+		n++
+		if err = checkMax(n, max); err != nil {
+			return err
+		}
+
+		m := &Member{
+			Path:	name,
+			Size:	hdr.Size,
+			MTime:	hdr.ModTime.Unix(),
+			Mode:	hdr.Mode & 0o7777,
+			UID:	int64(hdr.Uid),
+			GID:	int64(hdr.Gid),
+			User:	hdr.Uname,
+			Group:	hdr.Gname,
+		}
+		if err = fn(m, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func walkZip(path string, max int, fn WalkFunc) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	n := 0
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			// Only regular files are indexed
+			continue
+		}
+
+		name, ok := cleanName(f.Name)
+		if !ok {
+			continue
+		}
+
+		n++
+		if err = checkMax(n, max); err != nil {
+			return err
+		}
+
+		if err = walkZipFile(f, name, fn); err != nil {
+			return err
+		}
+	}
+
+	// OK
+	return nil
+}
+
+func walkZipFile(f *zip.File, name string, fn WalkFunc) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("cannot open zip member %q: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	return fn(&Member{
+		Path:	name,
+		Size:	int64(f.UncompressedSize64),
+		MTime:	f.Modified.Unix(),
+		Mode:	int64(f.Mode().Perm()),
+	}, rc)
+}
+
+func checkMax(n, max int) error {
+	if max != 0 && n > max {
+		return fmt.Errorf("%w, limit is %d", ErrTooManyMembers, max)
+	}
+
+	return nil
+}
+
+// cleanName returns the cleaned relative path of the member or false if the name is empty
+func cleanName(name string) (string, bool) {
+	name = strings.TrimPrefix(path.Clean("/" + name), "/")
+
+	return name, name != ""
+}
@@ -0,0 +1,217 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Test members of archives, directories are added to check that they are skipped
+var testMembers = []struct {
+	name	string
+	data	string
+}{
+	{ "etc/",				"" },
+	{ "etc/hosts",			"127.0.0.1 localhost\n" },
+	{ "./etc/../etc/motd",	"Welcome!\n" },
+	{ "/abs/file.txt",		"absolute" },
+}
+
+var testMTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// Expected results of walking through the test members
+var wantMembers = []walked{
+	{ "etc/hosts",		20,	"127.0.0.1 localhost\n" },
+	{ "etc/motd",		9,	"Welcome!\n" },
+	{ "abs/file.txt",	8,	"absolute" },
+}
+
+type walked struct {
+	path	string
+	size	int64
+	data	string
+}
+
+func testTar(t *testing.T, w io.Writer) {
+	t.Helper()
+
+	tw := tar.NewWriter(w)
+	for _, m := range testMembers {
+		hdr := &tar.Header{
+			Name:		m.name,
+			Typeflag:	tar.TypeReg,
+			Size:		int64(len(m.data)),
+			Mode:		0o644,
+			ModTime:	testMTime,
+			Uname:		"root",
+			Gname:		"root",
+		}
+		if m.name[len(m.name)-1] == '/' {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Mode = 0o755
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("cannot write tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(m.data)); err != nil {
+			t.Fatalf("cannot write tar member: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("cannot close tar writer: %v", err)
+	}
+}
+
+func testZip(t *testing.T, w io.Writer) {
+	t.Helper()
+
+	zw := zip.NewWriter(w)
+	for _, m := range testMembers {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: m.name, Modified: testMTime, Method: zip.Deflate})
+		if err != nil {
+			t.Fatalf("cannot create zip member: %v", err)
+		}
+		if _, err = fw.Write([]byte(m.data)); err != nil {
+			t.Fatalf("cannot write zip member: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("cannot close zip writer: %v", err)
+	}
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("cannot write test file: %v", err)
+	}
+
+	return path
+}
+
+func walkAll(path, mime string, max int) ([]walked, error) {
+	got := []walked{}
+	err := Walk(path, mime, max, func(m *Member, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if m.MTime != testMTime.Unix() {
+			return fmt.Errorf("member %q - want mtime %d, got %d", m.Path, testMTime.Unix(), m.MTime)
+		}
+		got = append(got, walked{m.Path, m.Size, string(data)})
+
+		return nil
+	})
+
+	return got, err
+}
+
+func TestWalk(t *testing.T) {
+	var tarBuf, zipBuf, gzBuf, zstBuf bytes.Buffer
+
+	testTar(t, &tarBuf)
+	testZip(t, &zipBuf)
+
+	gw := gzip.NewWriter(&gzBuf)
+	testTar(t, gw)
+	if err := gw.Close(); err != nil {
+		t.Fatalf("cannot close gzip writer: %v", err)
+	}
+
+	zw, err := zstd.NewWriter(&zstBuf)
+	if err != nil {
+		t.Fatalf("cannot create zstd writer: %v", err)
+	}
+	testTar(t, zw)
+	if err = zw.Close(); err != nil {
+		t.Fatalf("cannot close zstd writer: %v", err)
+	}
+
+	tests := []struct {
+		name	string
+		mime	string
+		data	[]byte
+	}{
+		{ "test.tar",		mimeTar,	tarBuf.Bytes() },
+		{ "test.zip",		mimeZip,	zipBuf.Bytes() },
+		{ "test.tar.gz",	mimeGzip,	gzBuf.Bytes() },
+		{ "test.tar.zst",	mimeZstd,	zstBuf.Bytes() },
+	}
+
+	for _, test := range tests {
+		path := writeFile(t, test.name, test.data)
+
+		got, err := walkAll(path, test.mime, 0)
+		if err != nil {
+			t.Errorf("%s - unexpected error: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, wantMembers) {
+			t.Errorf("%s - want members %#v, got %#v", test.name, wantMembers, got)
+		}
+
+		// Check the limit of members
+		if _, err = walkAll(path, test.mime, 2); !errors.Is(err, ErrTooManyMembers) {
+			t.Errorf("%s - want error %v, got %v", test.name, ErrTooManyMembers, err)
+		}
+		if _, err = walkAll(path, test.mime, len(wantMembers)); err != nil {
+			t.Errorf("%s - want no error with limit equal to number of members, got %v", test.name, err)
+		}
+	}
+}
+
+func TestWalkNotArchive(t *testing.T) {
+	// Compressed file that is not a tar archive
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write([]byte("just a compressed text, not an archive\n")); err != nil {
+		t.Fatalf("cannot write gzip data: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("cannot close gzip writer: %v", err)
+	}
+
+	path := writeFile(t, "text.gz", buf.Bytes())
+	if _, err := walkAll(path, mimeGzip, 0); !errors.Is(err, ErrNotArchive) {
+		t.Errorf("want error %v, got %v", ErrNotArchive, err)
+	}
+
+	// Unsupported content type
+	if _, err := walkAll(path, "text/plain", 0); !errors.Is(err, ErrNotArchive) {
+		t.Errorf("want error %v for unsupported content type, got %v", ErrNotArchive, err)
+	}
+}
+
+func TestHasArchiveExt(t *testing.T) {
+	tests := []struct {
+		name	string
+		want	bool
+	}{
+		{ "/backups/etc.tar.gz",	true },
+		{ "/backups/ETC.TGZ",		true },
+		{ "/backups/data.zip",		true },
+		{ "/backups/data.tar.zst",	true },
+		{ "/backups/data.gz",		false },
+		{ "/docs/report.odt",		false },
+	}
+
+	for i, test := range tests {
+		if got := HasArchiveExt(test.name); got != test.want {
+			t.Errorf("[%d] %q - want %t, got %t", i, test.name, test.want, got)
+		}
+	}
+}
@@ -14,8 +14,15 @@ import (
 const authors = "Roman Chebotarev"
 
 const (
-	fallbackHostname	=	`FALLBACK-HOSTNAME`
-	defaultFlushPeriod	=	5 * time.Second
+	fallbackHostname		=	`FALLBACK-HOSTNAME`
+	defaultFlushPeriod		=	5 * time.Second
+	defaultHeartbeatPeriod	=	time.Minute
+	defaultMaxExtractSize	=	16 * 1024 * 1024
+	defaultExtractTimeout	=	10 * time.Second
+	defaultMaxArcMembers	=	100000
+	defaultHistKeep			=	30 * 24 * time.Hour
+	// Maximum size of the text extracted from a single document
+	maxContentTextSize		=	1024 * 1024
 )
 
 var config progConfig
@@ -51,11 +58,18 @@ func Init(name, nameLong, vers string) {
 		`# Other options`,
 	)
 	p.AddString(`db-priv-cfg|P`,
-		`path to the file with private data specific to the particular DBMS - user/pass, etc...`,
+		`source of private data specific to the particular DBMS - user/pass, etc...: path to the file, ` +
+		`"env:VARIABLE", "cmd:CREDENTIAL-HELPER" or "systemd:CREDENTIAL"`,
 		&config.DBPrivCfg, "")
 	p.AddBool(`db-readonly`,
 		`do not perform any database updates (read-only mode), can be used for debugging`,
 		&config.DBReadOnly, false)
+	p.AddInt(`db-chunk-size`,
+		`maximum number of operations sent to the database at once, 0 - use the DBMS-specific default`,
+		&config.DBCfg.ChunkSize, 0)
+	p.AddString(`state-file|S`,
+		`path to the file to record the last batch sent to the database, used to detect lost batches after restart`,
+		&config.StateFile, "")
 	p.AddString(`hostname`,
 		`override real agent's hostname to the provided value`, &config.DBCfg.CliHost, hostname)
 	p.AddString(`log-file|l`, `path to the log file`, &config.LogFile, "")
@@ -64,9 +78,24 @@ func Init(name, nameLong, vers string) {
 	p.AddBool(`cleanup|c`,
 		`delete DB records with no existing files on the disk and not matching the configured paths`,
 		&config.Cleanup, false)
+	p.AddBool(`follow-symlinks|L`,
+		`follow symbolic links to directories outside of the indexing paths, objects are indexed by paths with links`,
+		&config.FollowLinks, false)
 	p.AddDuration(`flush-period|F`,
 		`period between flushing the collected filesystem events to database`,
 		&config.FlushPeriod, defaultFlushPeriod)
+	p.AddDuration(`heartbeat-period`,
+		`period between updates of the information about this host in database, at least one second`,
+		&config.HeartbeatPeriod, defaultHeartbeatPeriod)
+	p.AddBool(`history`,
+		`record changes of indexed objects to the history, see "dfi --docs history" for details`,
+		&config.DBCfg.History, false)
+	p.AddDuration(`history-keep`,
+		`retention period of the history records, 0 - keep forever`,
+		&config.DBCfg.HistKeep, defaultHistKeep)
+	p.AddString(`hooks-cfg`,
+		`path to the JSON file with hooks - actions run on events matched by rules, see README for details`,
+		&config.HooksCfg, "")
 	p.AddBool(`checksums|C`,
 		`calculate SHA1 sums for regular files, required for duplicates search support.`,
 		&config.CalcSums, false)
@@ -75,6 +104,24 @@ func Init(name, nameLong, vers string) {
 	p.AddInt64(`max-checksum-size|M`,
 		`maximum size of the file in bytes, the checksum of which can be calculated, 0 - no limits`,
 		&config.MaxSumSize, 0)
+	p.AddBool(`media-meta|m`,
+		`extract metadata of photos and videos - capture time, camera, location, duration, resolution`,
+		&config.MediaMeta, false)
+	p.AddBool(`extract-content|E`,
+		`extract text of documents - plain text, Markdown, HTML, PDF, OpenDocument - to make it searchable`,
+		&config.ExtractContent, false)
+	p.AddInt64(`max-extract-size`,
+		`maximum size of the file in bytes, the content of which can be extracted, 0 - no limits`,
+		&config.Extract.MaxFileSize, defaultMaxExtractSize)
+	p.AddDuration(`extract-timeout`,
+		`maximum time of the content extraction of a single file, 0 - no limits`,
+		&config.Extract.Timeout, defaultExtractTimeout)
+	p.AddBool(`archives|a`,
+		`index members of zip, tar, tar.gz and tar.zst archives as virtual objects`,
+		&config.Archives, false)
+	p.AddInt(`max-archive-members`,
+		`maximum number of indexed members of a single archive, 0 - no limits`,
+		&config.MaxArcMembers, defaultMaxArcMembers)
 
 	// Auxiliary options
 	p.AddSeparator(``,
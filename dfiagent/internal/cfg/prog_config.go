@@ -1,13 +1,13 @@
 package cfg
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
-	"github.com/r-che/dfi/common/fschecks"
+	"github.com/r-che/dfi/common/secrets"
+	"github.com/r-che/dfi/dfiagent/internal/extract"
+	"github.com/r-che/dfi/dfiagent/internal/hooks"
 	"github.com/r-che/dfi/types/dbms"
 )
 
@@ -17,7 +17,7 @@ type progConfig struct {
 	// Required options
 	paths		string			// Hidden option to write original value from the command line
 	IdxPaths	[]string
-	DBPrivCfg		string			// Path to file with DBMS-specific private data - username/password, keys and so on
+	DBPrivCfg		string			// Source of DBMS-specific private data - username/password, keys and so on, see secrets.Load
 	DBCfg		dbms.DBConfig
 
 	// Other options
@@ -25,9 +25,19 @@ type progConfig struct {
 	Reindex		bool	// Start reindex on startup
 	Cleanup		bool	// Cleanup database
 	FlushPeriod	time.Duration	// Period between flushing FS events to database
+	HeartbeatPeriod	time.Duration	// Period between updates of the host information in database
+	FollowLinks	bool	// Follow symbolic links to directories outside of indexing paths
 	CalcSums	bool	// Caclculate checksums for regular files
 	DBReadOnly	bool	// Do not update any information in database
 	MaxSumSize	int64	// Maximum size of the file, checksum of which will be calculated
+	MediaMeta	bool	// Extract metadata of photos and videos
+	ExtractContent	bool	// Extract text content of documents
+	Extract		extract.Limits	// Limits of the content extraction
+	Archives	bool	// Index members of archives
+	MaxArcMembers	int	// Maximum number of indexed members of a single archive
+	StateFile	string	// File to record sequence number of the last batch sent to database
+	HooksCfg	string	// File with configuration of hooks
+	Hooks		*hooks.Config	// Loaded configuration of hooks, nil if hooks are not configured
 
 	// Auxiliary options
 	Debug		bool
@@ -52,6 +62,45 @@ func (pc *progConfig) prepare() error {
 		return err
 	}
 
+	// Check chunk size of database operations
+	if pc.DBCfg.ChunkSize < 0 {
+		return fmt.Errorf("invalid chunk size of database operations %d, must be non-negative",
+			pc.DBCfg.ChunkSize)
+	}
+
+	// Check limits of the content extraction
+	if pc.Extract.MaxFileSize < 0 {
+		return fmt.Errorf("invalid maximum size of file for content extraction %d, must be non-negative",
+			pc.Extract.MaxFileSize)
+	}
+	if pc.Extract.Timeout < 0 {
+		return fmt.Errorf("invalid content extraction timeout %v, must be non-negative", pc.Extract.Timeout)
+	}
+	pc.Extract.MaxTextSize = maxContentTextSize
+
+	// Check limit of archive members
+	if pc.MaxArcMembers < 0 {
+		return fmt.Errorf("invalid maximum number of archive members %d, must be non-negative", pc.MaxArcMembers)
+	}
+
+	// Check retention period of the history
+	if pc.DBCfg.HistKeep < 0 {
+		return fmt.Errorf("invalid retention period of the history %v, must be non-negative", pc.DBCfg.HistKeep)
+	}
+
+	// Load configuration of hooks
+	if pc.HooksCfg != "" {
+		var err error
+		if pc.Hooks, err = hooks.LoadConfig(pc.HooksCfg); err != nil {
+			return err
+		}
+	}
+
+	// Heartbeat periods are stored in seconds
+	if pc.HeartbeatPeriod < time.Second {
+		return fmt.Errorf("invalid heartbeat period %v, must be at least one second", pc.HeartbeatPeriod)
+	}
+
 	// Convert hostname to lower case to avoid the need for a case-insensitive search in DB
 	pc.DBCfg.CliHost = strings.ToLower(pc.DBCfg.CliHost)
 
@@ -66,22 +115,12 @@ func (pc *progConfig) loadPriv() error {
 		return nil
 	}
 
-	// Check correctness of ownership/permissions of the private file
-	if err := fschecks.PrivOwnership(pc.DBPrivCfg); err != nil {
-		return fmt.Errorf("failed to check ownership/mode the private configuration of DB: %w", err)
-	}
-
-	// Read configuration file
-	data, err := os.ReadFile(pc.DBPrivCfg)
+	// Load private configuration from the file, environment, credential helper and so on
+	pcf, err := secrets.Load(pc.DBPrivCfg)
 	if err != nil {
-		return fmt.Errorf("cannot read private database configuration: %w", err)
-	}
-
-	// Parse JSON, load it to configuration
-	pc.DBCfg.PrivCfg= map[string]any{}
-	if err = json.Unmarshal(data, &pc.DBCfg.PrivCfg); err != nil {
-		return fmt.Errorf("cannot decode private database configuration %q: %w", pc.DBPrivCfg, err)
+		return fmt.Errorf("cannot load private database configuration: %w", err)
 	}
+	pc.DBCfg.PrivCfg = pcf
 
 	// OK
 	return nil
@@ -44,8 +44,8 @@ func Run() error {
 		}
 	}
 
-	// Commit deletion
-	if _, deleted, err := dbc.Commit(); err != nil {
+	// Commit deletion, cleanup batches are not numbered
+	if _, deleted, err := dbc.Commit(0); err != nil {
 		log.E("(Cleanup) Fail to delete objects from DB: %v", err)
 	} else {
 		log.I("(Cleanup) Cleaned up %d objects from DB", deleted)
@@ -87,6 +87,14 @@ func loadHostPaths(dbc dbms.ClientController, configuredPaths []string) ([]strin
 
 		// Check a type of the errror
 		if errors.Is(err, fs.ErrNotExist) {
+			// Members of archives do not exist on the filesystem, they exist while the archive exists
+			if arcPath, _, ok := types.SplitArcMemberFPath(path); ok {
+				if ai, err := os.Lstat(arcPath); err == nil && ai.Mode().IsRegular() {
+					// OK, archive exists, its members are updated by the watcher => should NOT be deleted
+					return false
+				}
+			}
+
 			log.D("(Cleanup) Path %q does not exist on the local filesystem", path)
 			nx++
 
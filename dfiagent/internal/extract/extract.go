@@ -0,0 +1,163 @@
+// Package extract provides the framework of content extractors used by the
+// agent to make the text of documents searchable. Extractors of plain text,
+// Markdown, HTML, PDF and OpenDocument formats are built-in, additional
+// extractors can be added using the Register function
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/r-che/dfi/types"
+)
+
+// Extractor extracts text content and typed attributes of filesystem objects
+type Extractor interface {
+	// Name returns the name of the extractor used in logs
+	Name() string
+	// Match reports whether the extractor can handle the object
+	Match(fso *types.FSObject) bool
+	// Extract returns the content of the object placed by path. Long-term
+	// operations should be interrupted when the context ctx is done
+	Extract(ctx context.Context, path string, fso *types.FSObject) (*types.ObjContent, error)
+}
+
+// Limits of the content extraction
+type Limits struct {
+	MaxFileSize	int64			// Larger files are skipped, 0 - no limits
+	MaxTextSize	int				// Extracted text is truncated to this size in bytes, 0 - no limits
+	Timeout		time.Duration	// Maximum time of extraction of a single object, 0 - no limits
+}
+
+// Error returned when extraction does not complete within the configured timeout
+var ErrTimeout = errors.New("content extraction timed out")
+
+var (
+	extractors		[]Extractor
+	extractorsMtx	sync.RWMutex
+)
+
+// Register adds the extractor e to the list of extractors. Extractors are
+// matched in order of registration, the built-in extractors are registered first
+func Register(e Extractor) {
+	extractorsMtx.Lock()
+	defer extractorsMtx.Unlock()
+
+	extractors = append(extractors, e)
+}
+
+func init() {
+	// Register built-in extractors, more specific ones first
+	Register(&markdownExtractor{})
+	Register(&textExtractor{})
+	Register(&htmlExtractor{})
+	Register(&pdfExtractor{})
+	Register(&odfExtractor{})
+}
+
+// Find returns the first registered extractor matching the object or nil if there is no such
+func Find(fso *types.FSObject) Extractor {
+	extractorsMtx.RLock()
+	defer extractorsMtx.RUnlock()
+
+	for _, e := range extractors {
+		if e.Match(fso) {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// Run extracts content of the object placed by path using the first matching
+// extractor. Returns nil without error if no extractor matches the object or
+// the object is too large
+func Run(path string, fso *types.FSObject, limits *Limits) (*types.ObjContent, error) {
+	if limits.MaxFileSize != 0 && fso.Size > limits.MaxFileSize {
+		// Skip too large file
+		return nil, nil
+	}
+
+	e := Find(fso)
+	if e == nil {
+		// Unsupported object
+		return nil, nil
+	}
+
+	ctx, cancel := context.Background(), func() {}
+	if limits.Timeout != 0 {
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+	}
+	defer cancel()
+
+	// Run extraction in a separate goroutine to not wait for extractors that ignore the context
+	type result struct {
+		content	*types.ObjContent
+		err		error
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		content, err := e.Extract(ctx, path, fso)
+		resCh <- result{content, err}
+	}()
+
+	select {
+	case res := <-resCh:
+		if res.err != nil {
+			return nil, fmt.Errorf("%s extractor failed: %w", e.Name(), res.err)
+		}
+		if res.content != nil {
+			res.content.Text = truncateText(res.content.Text, limits.MaxTextSize)
+			// Attributes may be taken from the raw data of documents
+			res.content.Title = strings.ToValidUTF8(res.content.Title, "")
+			res.content.Author = strings.ToValidUTF8(res.content.Author, "")
+		}
+		return res.content, nil
+
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s extractor: %w", e.Name(), ErrTimeout)
+	}
+}
+
+// truncateText truncates text to the size of max bytes without splitting of multi-byte characters
+func truncateText(text string, max int) string {
+	if max == 0 || len(text) <= max {
+		return text
+	}
+
+	text = text[:max]
+	// Remove a possibly split character at the end
+	for len(text) > 0 {
+		r, size := utf8.DecodeLastRuneInString(text)
+		if r != utf8.RuneError || size != 1 {
+			break
+		}
+		text = text[:len(text) - 1]
+	}
+
+	return text
+}
+
+// normalizeSpace replaces sequences of white spaces by a single space or a
+// line break if the sequence contains a line break, also replaces invalid UTF-8
+func normalizeSpace(text string) string {
+	var sb strings.Builder
+	sb.Grow(len(text))
+
+	for _, line := range strings.Split(strings.ToValidUTF8(text, ""), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line == "" {
+			continue
+		}
+		if sb.Len() != 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(line)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,300 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/r-che/dfi/types"
+)
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name	string
+		mime	string
+		want	string
+	}{
+		{ "notes.txt",		"text/plain",								"text" },
+		{ "README.md",		"text/plain",								"markdown" },
+		{ "index.html",		"text/html",								"html" },
+		{ "paper.pdf",		"application/pdf",							"pdf" },
+		{ "report.odt",		"application/vnd.oasis.opendocument.text",	"odf" },
+		{ "image.png",		"image/png",								"" },
+	}
+
+	for i, test := range tests {
+		name := ""
+		if e := Find(&types.FSObject{Name: test.name, MIME: test.mime}); e != nil {
+			name = e.Name()
+		}
+		if name != test.want {
+			t.Errorf("[%d] %q (%s) - want extractor %q, got %q", i, test.name, test.mime, test.want, name)
+		}
+	}
+}
+
+func Test_normalizeSpace(t *testing.T) {
+	tests := []struct {
+		text	string
+		want	string
+	}{
+		{ "",										"" },
+		{ "  one  two\tthree ",						"one two three" },
+		{ "first line\n\n\n  second   line \n",		"first line\nsecond line" },
+		{ "invalid \xFF utf-8",						"invalid utf-8" },
+	}
+
+	for i, test := range tests {
+		if got := normalizeSpace(test.text); got != test.want {
+			t.Errorf("[%d] want %q, got %q", i, test.want, got)
+		}
+	}
+}
+
+func Test_truncateText(t *testing.T) {
+	tests := []struct {
+		text	string
+		max		int
+		want	string
+	}{
+		{ "short text",		0,	"short text" },
+		{ "short text",		20,	"short text" },
+		{ "short text",		5,	"short" },
+		// The second character takes 2 bytes and must not be split
+		{ "aжb",			2,	"a" },
+		{ "aжb",			3,	"aж" },
+	}
+
+	for i, test := range tests {
+		if got := truncateText(test.text, test.max); got != test.want {
+			t.Errorf("[%d] want %q, got %q", i, test.want, got)
+		}
+	}
+}
+
+func Test_markdownContent(t *testing.T) {
+	md := "# Project title\n\nSome **bold** and _plain_ text with a [link](http://example.com).\n\n" +
+		"```go\nfmt.Println()\n```\n\n- first item\n- second item\n\n## Section\n"
+
+	content := markdownContent(md)
+
+	if content.Title != "Project title" {
+		t.Errorf("want title %q, got %q", "Project title", content.Title)
+	}
+
+	want := "Project title\nSome bold and _plain_ text with a link.\nfmt.Println()\nfirst item\nsecond item\nSection"
+	if content.Text != want {
+		t.Errorf("want text:\n%q\ngot:\n%q", want, content.Text)
+	}
+}
+
+func Test_htmlContent(t *testing.T) {
+	doc := `<!DOCTYPE html><HTML><head><Title>Test &amp; page</Title>` +
+		`<meta name="author" content="John Doe"><style>body { color: red }</style>` +
+		`<SCRIPT>var x = "<p>not a text</p>";</script></head>` +
+		`<body><!-- comment --><h1>Heading</h1><p>First <b>paragraph</b>&nbsp;text</p><div>Second</div></body></HTML>`
+
+	content := htmlContent(doc)
+
+	if content.Title != "Test & page" {
+		t.Errorf("want title %q, got %q", "Test & page", content.Title)
+	}
+	if content.Author != "John Doe" {
+		t.Errorf("want author %q, got %q", "John Doe", content.Author)
+	}
+
+	want := "Test & page\nHeading\nFirst paragraph text\nSecond"
+	if content.Text != want {
+		t.Errorf("want text:\n%q\ngot:\n%q", want, content.Text)
+	}
+}
+
+// testPDF builds a minimal PDF document with the compressed content stream
+func testPDF(t *testing.T, text string) []byte {
+	t.Helper()
+
+	var stream bytes.Buffer
+	zw := zlib.NewWriter(&stream)
+	if _, err := zw.Write([]byte(text)); err != nil {
+		t.Fatalf("cannot compress content stream: %v", err)
+	}
+	zw.Close()
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	pdf.WriteString("2 0 obj\n<< /Type /Pages /Kids [3 0 R 5 0 R] /Count 2 >>\nendobj\n")
+	pdf.WriteString("3 0 obj\n<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>\nendobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", stream.Len())
+	pdf.Write(stream.Bytes())
+	pdf.WriteString("\nendstream\nendobj\n")
+	pdf.WriteString("5 0 obj\n<< /Type /Page /Parent 2 0 R >>\nendobj\n")
+	// Title in UTF-16 with byte order mark, author in PDFDocEncoding with escapes
+	pdf.WriteString("6 0 obj\n<< /Title (\xFE\xFF\x00T\x00e\x00s\x00t) /Author (Jane \\(J.\\) Smith) >>\nendobj\n")
+	pdf.WriteString("trailer\n<< /Root 1 0 R /Info 6 0 R >>\n%%EOF\n")
+
+	return pdf.Bytes()
+}
+
+func Test_pdfContent(t *testing.T) {
+	data := testPDF(t, "BT /F1 12 Tf 72 712 Td (Hello, world!) Tj 0 -14 Td " +
+		"[(Kerned) -250 (text) 30 (s)] TJ T* (Caf\\351 \\(nested (parens)\\)) Tj ET")
+
+	content, err := pdfContent(context.Background(), data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := &types.ObjContent{
+		Text:	"Hello, world!\nKerned texts\nCafé (nested (parens))",
+		Title:	"Test",
+		Author:	"Jane (J.) Smith",
+		Pages:	2,
+	}
+	if *content != *want {
+		t.Errorf("want content:\n%#v\ngot:\n%#v", want, content)
+	}
+
+	if _, err := pdfContent(context.Background(), []byte("not a pdf")); !errors.Is(err, errNotPDF) {
+		t.Errorf("want error %v, got %v", errNotPDF, err)
+	}
+
+	// Extraction must be interrupted by the canceled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := pdfContent(ctx, data); !errors.Is(err, context.Canceled) {
+		t.Errorf("want error %v, got %v", context.Canceled, err)
+	}
+}
+
+// testODF builds an OpenDocument text file with the provided content and metadata
+func testODF(t *testing.T, content, meta string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range []struct{ name, data string }{
+		{ "mimetype",		"application/vnd.oasis.opendocument.text" },
+		{ odfContentFile,	content },
+		{ odfMetaFile,		meta },
+	} {
+		if f.data == "" {
+			continue
+		}
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatalf("cannot create %s: %v", f.name, err)
+		}
+		if _, err = w.Write([]byte(f.data)); err != nil {
+			t.Fatalf("cannot write %s: %v", f.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("cannot write zip: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func Test_odfContent(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+		`xmlns:text="` + odfNSText + `"><office:body><office:text>` +
+		`<text:h text:outline-level="1">Heading</text:h>` +
+		`<text:p>First<text:s/>paragraph<text:tab/>with <text:span>styled</text:span> text</text:p>` +
+		`<text:p>Second<text:line-break/>line</text:p>` +
+		`</office:text></office:body></office:document-content>`
+	meta := `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<office:document-meta xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+		`xmlns:meta="` + odfNSMeta + `" xmlns:dc="` + odfNSDC + `"><office:meta>` +
+		`<dc:title>Annual report</dc:title><meta:initial-creator>Jane Smith</meta:initial-creator>` +
+		`<meta:document-statistic meta:page-count="3" meta:word-count="8"/>` +
+		`</office:meta></office:document-meta>`
+
+	tests := []struct {
+		content	string
+		meta	string
+		want	*types.ObjContent
+		err		error
+	}{
+		{ content, meta, &types.ObjContent{
+			Text:	"Heading\nFirst paragraph with styled text\nSecond\nline",
+			Title:	"Annual report",
+			Author:	"Jane Smith",
+			Pages:	3,
+		}, nil },
+		// Metadata is optional
+		{ content, "", &types.ObjContent{Text: "Heading\nFirst paragraph with styled text\nSecond\nline"}, nil },
+		{ "", meta, nil, errNoODFContent },
+	}
+
+	for i, test := range tests {
+		data := testODF(t, test.content, test.meta)
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("[%d] cannot read zip: %v", i, err)
+		}
+
+		got, err := odfContent(context.Background(), zr)
+		if !errors.Is(err, test.err) {
+			t.Errorf("[%d] want error %v, got %v", i, test.err, err)
+			continue
+		}
+		if test.want != nil && (got == nil || *got != *test.want) {
+			t.Errorf("[%d] want content:\n%#v\ngot:\n%#v", i, test.want, got)
+		}
+	}
+}
+
+// slowExtractor waits for the context regardless of the object
+type slowExtractor struct{}
+
+const slowMIME = "application/x-dfi-test-slow"
+
+func (e *slowExtractor) Name() string { return "slow" }
+func (e *slowExtractor) Match(fso *types.FSObject) bool { return fso.MIME == slowMIME }
+func (e *slowExtractor) Extract(ctx context.Context, _ string, _ *types.FSObject) (*types.ObjContent, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestRun(t *testing.T) {
+	Register(&slowExtractor{})
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("The quick brown fox jumps over the lazy dog\n"), 0o600); err != nil {
+		t.Fatalf("cannot write test file: %v", err)
+	}
+	fso := &types.FSObject{Name: "notes.txt", FPath: path, Size: 44, MIME: "text/plain"}
+
+	// Text is truncated to the limit
+	content, err := Run(path, fso, &Limits{MaxTextSize: 9})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content == nil || content.Text != "The quick" {
+		t.Errorf("want truncated text %q, got %#v", "The quick", content)
+	}
+
+	// Too large files are skipped
+	if content, err = Run(path, fso, &Limits{MaxFileSize: 10}); err != nil || content != nil {
+		t.Errorf("want no content and no error for too large file, got %#v, %v", content, err)
+	}
+
+	// Unsupported objects are skipped
+	if content, err = Run(path, &types.FSObject{MIME: "image/png"}, &Limits{}); err != nil || content != nil {
+		t.Errorf("want no content and no error for unsupported object, got %#v, %v", content, err)
+	}
+
+	// Extraction is interrupted by timeout
+	if _, err = Run(path, &types.FSObject{MIME: slowMIME}, &Limits{Timeout: 10 * time.Millisecond}); !errors.Is(err, ErrTimeout) {
+		t.Errorf("want error %v, got %v", ErrTimeout, err)
+	}
+}
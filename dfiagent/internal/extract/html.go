@@ -0,0 +1,147 @@
+package extract
+
+import (
+	"context"
+	"html"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/r-che/dfi/types"
+)
+
+// Elements that break the text flow, a line break is inserted in place of them
+var htmlBlockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "footer": true, "form": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hr": true, "li": true, "main": true, "nav": true, "ol": true,
+	"p": true, "pre": true, "section": true, "table": true, "td": true, "th": true,
+	"tr": true, "ul": true,
+}
+
+// Elements which content is not a visible text
+var htmlSkipTags = map[string]bool{
+	"script":	true,
+	"style":	true,
+	"noscript":	true,
+	"template":	true,
+}
+
+var (
+	htmlMetaAuthor	= regexp.MustCompile(`(?i)\bname\s*=\s*["']?author\b`)
+	htmlMetaContent	= regexp.MustCompile(`(?i)\bcontent\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+type htmlExtractor struct{}
+
+func (e *htmlExtractor) Name() string {
+	return "html"
+}
+
+func (e *htmlExtractor) Match(fso *types.FSObject) bool {
+	return fso.MIME == "text/html"
+}
+
+func (e *htmlExtractor) Extract(_ context.Context, path string, _ *types.FSObject) (*types.ObjContent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return htmlContent(string(data)), nil
+}
+
+//nolint:cyclop	// Simple state machine is clearer as a single function
+func htmlContent(doc string) *types.ObjContent {
+	content := &types.ObjContent{}
+
+	var sb strings.Builder
+	for len(doc) != 0 {
+		lt := strings.IndexByte(doc, '<')
+		if lt < 0 {
+			sb.WriteString(doc)
+			break
+		}
+		sb.WriteString(doc[:lt])
+		doc = doc[lt:]
+
+		// Skip comments and declarations
+		if strings.HasPrefix(doc, "<!--") {
+			end := strings.Index(doc, "-->")
+			if end < 0 {
+				break
+			}
+			doc = doc[end + len("-->"):]
+			continue
+		}
+
+		gt := strings.IndexByte(doc, '>')
+		if gt < 0 {
+			break
+		}
+		tag := doc[1:gt]
+		doc = doc[gt + 1:]
+
+		name := htmlTagName(tag)
+		switch {
+		case htmlSkipTags[name]:
+			// Skip everything up to the closing tag
+			doc = skipToClosing(doc, name)
+		case name == "title" && content.Title == "":
+			end := closingIndex(doc, name)
+			content.Title = normalizeSpace(html.UnescapeString(doc[:end]))
+		case name == "meta" && content.Author == "" && htmlMetaAuthor.MatchString(tag):
+			if m := htmlMetaContent.FindStringSubmatch(tag); m != nil {
+				content.Author = strings.TrimSpace(html.UnescapeString(m[1] + m[2]))
+			}
+		case htmlBlockTags[strings.TrimPrefix(name, "/")]:
+			sb.WriteByte('\n')
+		}
+	}
+
+	content.Text = normalizeSpace(html.UnescapeString(sb.String()))
+
+	return content
+}
+
+// htmlTagName returns the lower-cased name of the tag including leading slash of closing tags
+func htmlTagName(tag string) string {
+	prefix := ""
+	if strings.HasPrefix(tag, "/") {
+		prefix, tag = "/", tag[1:]
+	}
+
+	if end := strings.IndexAny(tag, " \t\r\n/"); end >= 0 {
+		tag = tag[:end]
+	}
+
+	return prefix + strings.ToLower(tag)
+}
+
+// closingIndex returns the index of the closing tag name in doc or length of doc if it is not found
+func closingIndex(doc, name string) int {
+	for off := 0; ; {
+		i := strings.Index(doc[off:], "</")
+		if i < 0 {
+			return len(doc)
+		}
+		off += i
+
+		// Tag names are case-insensitive
+		if end := off + 2 + len(name); end <= len(doc) && strings.EqualFold(doc[off + 2:end], name) {
+			return off
+		}
+		off += 2
+	}
+}
+
+// skipToClosing returns the rest of the doc after the closing tag name
+func skipToClosing(doc, name string) string {
+	doc = doc[closingIndex(doc, name):]
+	if gt := strings.IndexByte(doc, '>'); gt >= 0 {
+		return doc[gt + 1:]
+	}
+
+	return ""
+}
@@ -0,0 +1,196 @@
+package extract
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/r-che/dfi/types"
+)
+
+const (
+	odfMIMEPrefix	= "application/vnd.oasis.opendocument."
+	odfContentFile	= "content.xml"
+	odfMetaFile		= "meta.xml"
+
+	// XML namespaces of OpenDocument elements used by the extractor
+	odfNSText	= "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	odfNSMeta	= "urn:oasis:names:tc:opendocument:xmlns:meta:1.0"
+	odfNSDC		= "http://purl.org/dc/elements/1.1/"
+
+	// Maximum size of decompressed XML files, protects against decompression bombs
+	odfMaxXMLSize = 256 * 1024 * 1024
+)
+
+var errNoODFContent = errors.New("no " + odfContentFile + " in OpenDocument file")
+
+// Extractor of text documents, spreadsheets and presentations in OpenDocument format
+type odfExtractor struct{}
+
+func (e *odfExtractor) Name() string {
+	return "odf"
+}
+
+func (e *odfExtractor) Match(fso *types.FSObject) bool {
+	return strings.HasPrefix(fso.MIME, odfMIMEPrefix)
+}
+
+func (e *odfExtractor) Extract(ctx context.Context, path string, _ *types.FSObject) (*types.ObjContent, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	return odfContent(ctx, &zr.Reader)
+}
+
+func odfContent(ctx context.Context, zr *zip.Reader) (*types.ObjContent, error) {
+	content := &types.ObjContent{}
+
+	var contentFile, metaFile *zip.File
+	for _, f := range zr.File {
+		switch f.Name {
+		case odfContentFile:
+			contentFile = f
+		case odfMetaFile:
+			metaFile = f
+		}
+	}
+
+	if contentFile == nil {
+		return nil, errNoODFContent
+	}
+
+	if err := odfParse(ctx, contentFile, func(dec *xml.Decoder) error {
+		return odfText(ctx, dec, content)
+	}); err != nil {
+		return nil, err
+	}
+
+	// Metadata is optional
+	if metaFile != nil {
+		if err := odfParse(ctx, metaFile, func(dec *xml.Decoder) error {
+			return odfMeta(dec, content)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return content, nil
+}
+
+func odfParse(ctx context.Context, f *zip.File, parse func(*xml.Decoder) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err = parse(xml.NewDecoder(io.LimitReader(r, odfMaxXMLSize))); err != nil {
+		return fmt.Errorf("cannot parse %s: %w", f.Name, err)
+	}
+
+	// OK
+	return nil
+}
+
+// odfText collects the text of paragraphs and headings of the document body
+func odfText(ctx context.Context, dec *xml.Decoder, content *types.ObjContent) error {
+	var sb strings.Builder
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.CharData:
+			sb.Write(t)
+		case xml.StartElement:
+			if t.Name.Space != odfNSText {
+				continue
+			}
+			switch t.Name.Local {
+			case "s", "tab":
+				sb.WriteByte(' ')
+			case "line-break":
+				sb.WriteByte('\n')
+			}
+		case xml.EndElement:
+			if t.Name.Space == odfNSText && (t.Name.Local == "p" || t.Name.Local == "h") {
+				sb.WriteByte('\n')
+				// Paragraphs are a good place to check for cancellation
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	content.Text = normalizeSpace(sb.String())
+
+	// OK
+	return nil
+}
+
+// odfMeta reads the title, author and number of pages from the document metadata
+func odfMeta(dec *xml.Decoder, content *types.ObjContent) error {
+	// Text of the current element
+	var text strings.Builder
+	initialCreator := ""
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			text.Reset()
+			if t.Name.Space == odfNSMeta && t.Name.Local == "document-statistic" {
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "page-count" {
+						content.Pages, _ = strconv.ParseInt(attr.Value, 10, 64)
+					}
+				}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			switch {
+			case t.Name.Space == odfNSDC && t.Name.Local == "title":
+				content.Title = strings.TrimSpace(text.String())
+			case t.Name.Space == odfNSDC && t.Name.Local == "creator":
+				content.Author = strings.TrimSpace(text.String())
+			case t.Name.Space == odfNSMeta && t.Name.Local == "initial-creator":
+				initialCreator = strings.TrimSpace(text.String())
+			}
+		}
+	}
+
+	// Use the initial creator if the last modifier is unknown
+	if content.Author == "" {
+		content.Author = initialCreator
+	}
+
+	// OK
+	return nil
+}
@@ -0,0 +1,312 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/r-che/dfi/types"
+)
+
+const (
+	// Maximum size of a single decompressed stream, protects against decompression bombs
+	pdfMaxStreamSize = 64 * 1024 * 1024
+)
+
+var (
+	errNotPDF = errors.New("not a PDF document")
+
+	pdfStreamStart	= regexp.MustCompile(`stream\r?\n`)
+	pdfPage			= regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfTitle		= regexp.MustCompile(`/Title\s*\(`)
+	pdfAuthor		= regexp.MustCompile(`/Author\s*\(`)
+)
+
+// PDF extractor supports only text in simple fonts (Latin-1 compatible encodings),
+// text in composite fonts requires mapping of glyphs to Unicode and is not extracted
+type pdfExtractor struct{}
+
+func (e *pdfExtractor) Name() string {
+	return "pdf"
+}
+
+func (e *pdfExtractor) Match(fso *types.FSObject) bool {
+	return fso.MIME == "application/pdf"
+}
+
+func (e *pdfExtractor) Extract(ctx context.Context, path string, _ *types.FSObject) (*types.ObjContent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return pdfContent(ctx, data)
+}
+
+func pdfContent(ctx context.Context, data []byte) (*types.ObjContent, error) {
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return nil, errNotPDF
+	}
+
+	var text strings.Builder
+	// Raw data and decompressed object streams are used to search document information and pages
+	objData := [][]byte{data}
+
+	for _, loc := range pdfStreamStart.FindAllIndex(data, -1) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		dict := pdfStreamDict(data, loc[0])
+		if dict == nil {
+			continue
+		}
+
+		start := loc[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		stream, ok := pdfDecodeStream(dict, data[start:start + end])
+		if !ok {
+			// Unsupported filter or broken stream
+			continue
+		}
+
+		if bytes.Contains(dict, []byte("/ObjStm")) {
+			// Compressed objects
+			objData = append(objData, stream)
+			continue
+		}
+
+		if bytes.Contains(stream, []byte("BT")) {
+			// Probably, content stream with text objects
+			pdfContentText(stream, &text)
+		}
+	}
+
+	content := &types.ObjContent{Text: normalizeSpace(text.String())}
+	for _, od := range objData {
+		content.Pages += int64(len(pdfPage.FindAllIndex(od, -1)))
+		if content.Title == "" {
+			content.Title = pdfInfoString(od, pdfTitle)
+		}
+		if content.Author == "" {
+			content.Author = pdfInfoString(od, pdfAuthor)
+		}
+	}
+
+	return content, nil
+}
+
+// pdfStreamDict returns the dictionary of the stream started at pos or nil if it is not found
+func pdfStreamDict(data []byte, pos int) []byte {
+	// Dictionary directly precedes the "stream" keyword
+	dictEnd := bytes.LastIndex(data[:pos], []byte(">>"))
+	if dictEnd < 0 || len(bytes.TrimSpace(data[dictEnd + 2:pos])) != 0 {
+		return nil
+	}
+
+	objStart := bytes.LastIndex(data[:dictEnd], []byte("obj"))
+	if objStart < 0 {
+		return nil
+	}
+
+	return data[objStart:dictEnd]
+}
+
+func pdfDecodeStream(dict, raw []byte) ([]byte, bool) {
+	switch {
+	case bytes.Contains(dict, []byte("/FlateDecode")):
+		zr, err := zlib.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, false
+		}
+		defer zr.Close()
+
+		// Streams are often terminated without checksum, use whatever was decompressed
+		stream, err := io.ReadAll(io.LimitReader(zr, pdfMaxStreamSize))
+		if err != nil && len(stream) == 0 {
+			return nil, false
+		}
+		return stream, true
+
+	case bytes.Contains(dict, []byte("/Filter")):
+		// Images and other unsupported filters
+		return nil, false
+
+	default:
+		return raw, true
+	}
+}
+
+// pdfContentText appends to sb the text shown by the text operators of the content stream
+//
+//nolint:cyclop	// Tokenizer is clearer as a single function
+func pdfContentText(stream []byte, sb *strings.Builder) {
+	// Operands of the next operator
+	var operands []string
+	inArray := false
+
+	for i := 0; i < len(stream); {
+		c := stream[i]
+		switch {
+		case c == '(':
+			str, n := pdfLiteralString(stream[i:])
+			operands = append(operands, str)
+			i += n
+
+		case c == '[':
+			inArray = true
+			i++
+
+		case c == ']':
+			inArray = false
+			i++
+
+		case c == '%':
+			// Comment up to the end of line
+			if n := bytes.IndexAny(stream[i:], "\r\n"); n >= 0 {
+				i += n
+			} else {
+				i = len(stream)
+			}
+
+		case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			// Number, large negative offsets inside of TJ arrays separate words
+			n := bytes.IndexFunc(stream[i:], func(r rune) bool {
+				return r != '-' && r != '.' && (r < '0' || r > '9')
+			})
+			if n < 0 {
+				n = len(stream) - i
+			}
+			if v, err := strconv.ParseFloat(string(stream[i:i + n]), 64); inArray && err == nil && v < -200 {
+				operands = append(operands, " ")
+			}
+			i += n
+
+		case unicode.IsLetter(rune(c)) || c == '\'' || c == '"' || c == '*':
+			n := bytes.IndexFunc(stream[i:], func(r rune) bool {
+				return !unicode.IsLetter(r) && r != '*' && r != '\'' && r != '"'
+			})
+			if n < 0 {
+				n = len(stream) - i
+			}
+
+			switch string(stream[i:i + n]) {
+			case "Tj", "TJ":
+				sb.WriteString(strings.Join(operands, ""))
+			case "'", `"`:
+				sb.WriteString("\n" + strings.Join(operands, ""))
+			case "Td", "TD", "T*", "ET":
+				sb.WriteString("\n")
+			}
+			if !inArray {
+				operands = operands[:0]
+			}
+			i += n
+
+		default:
+			// Hex strings, names, dictionaries and delimiters are not needed
+			i++
+		}
+	}
+}
+
+// pdfLiteralString decodes the literal string at the beginning of data,
+// returns the decoded value and the length of the string in data
+//
+//nolint:cyclop	// Escapes handling is clearer as a single function
+func pdfLiteralString(data []byte) (string, int) {
+	var buf []byte
+	depth := 0
+
+	i := 0
+	for ; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case '(':
+			if depth++; depth == 1 {
+				continue
+			}
+		case ')':
+			if depth--; depth == 0 {
+				return pdfDecodeText(buf), i + 1
+			}
+		case '\\':
+			if i++; i == len(data) {
+				break
+			}
+			switch e := data[i]; e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// Line continuation
+				continue
+			default:
+				if e < '0' || e > '7' {
+					c = e
+					break
+				}
+				// Octal code up to 3 digits
+				end := i
+				for end < len(data) && end < i + 3 && data[end] >= '0' && data[end] <= '7' {
+					end++
+				}
+				v, _ := strconv.ParseUint(string(data[i:end]), 8, 8)
+				c = byte(v)
+				i = end - 1
+			}
+		}
+		buf = append(buf, c)
+	}
+
+	return pdfDecodeText(buf), i
+}
+
+// pdfDecodeText decodes UTF-16 strings with byte order mark, others are treated as Latin-1
+func pdfDecodeText(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		u := make([]uint16, 0, (len(b) - 2) / 2)
+		for i := 2; i + 1 < len(b); i += 2 {
+			u = append(u, uint16(b[i]) << 8 | uint16(b[i + 1]))
+		}
+		return string(utf16.Decode(u))
+	}
+
+	r := make([]rune, 0, len(b))
+	for _, c := range b {
+		r = append(r, rune(c))
+	}
+
+	return string(r)
+}
+
+// pdfInfoString returns the value of the document information entry found by re
+func pdfInfoString(data []byte, re *regexp.Regexp) string {
+	loc := re.FindIndex(data)
+	if loc == nil {
+		return ""
+	}
+
+	// The expression ends with an opening parenthesis of the string
+	str, _ := pdfLiteralString(data[loc[1] - 1:])
+
+	return strings.TrimSpace(str)
+}
@@ -0,0 +1,103 @@
+package extract
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/r-che/dfi/types"
+)
+
+// Extensions of Markdown files, they are detected as plain text by the content type
+var markdownExts = map[string]bool{
+	".md":			true,
+	".markdown":	true,
+}
+
+func isPlainText(fso *types.FSObject) bool {
+	return fso.MIME == "text/plain"
+}
+
+func isMarkdown(fso *types.FSObject) bool {
+	return isPlainText(fso) && markdownExts[strings.ToLower(filepath.Ext(fso.Name))]
+}
+
+//
+// Plain text
+//
+
+type textExtractor struct{}
+
+func (e *textExtractor) Name() string {
+	return "text"
+}
+
+func (e *textExtractor) Match(fso *types.FSObject) bool {
+	return isPlainText(fso)
+}
+
+func (e *textExtractor) Extract(_ context.Context, path string, _ *types.FSObject) (*types.ObjContent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.ObjContent{Text: normalizeSpace(string(data))}, nil
+}
+
+//
+// Markdown
+//
+
+// Markdown markup that should be removed or replaced
+var (
+	mdHeading	= regexp.MustCompile(`(?m)^\s{0,3}#{1,6}\s+`)
+	mdFence		= regexp.MustCompile("(?m)^\\s*(```|~~~).*$")
+	mdImage		= regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink		= regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdEmphasis	= regexp.MustCompile(`(\*{1,3}|_{2,3}|~~|` + "`" + `)`)
+	mdListMark	= regexp.MustCompile(`(?m)^\s*([-*+>]|\d+\.)\s+`)
+	mdTitle		= regexp.MustCompile(`(?m)^\s{0,3}#\s+(.+)$`)
+)
+
+type markdownExtractor struct{}
+
+func (e *markdownExtractor) Name() string {
+	return "markdown"
+}
+
+func (e *markdownExtractor) Match(fso *types.FSObject) bool {
+	return isMarkdown(fso)
+}
+
+func (e *markdownExtractor) Extract(_ context.Context, path string, _ *types.FSObject) (*types.ObjContent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return markdownContent(string(data)), nil
+}
+
+func markdownContent(text string) *types.ObjContent {
+	content := &types.ObjContent{}
+
+	// The first level-one heading is used as the title
+	if m := mdTitle.FindStringSubmatch(text); m != nil {
+		content.Title = strings.TrimSpace(m[1])
+	}
+
+	// Remove markup keeping the visible text
+	text = mdFence.ReplaceAllString(text, "")
+	text = mdHeading.ReplaceAllString(text, "")
+	text = mdImage.ReplaceAllString(text, "$1")
+	text = mdLink.ReplaceAllString(text, "$1")
+	text = mdListMark.ReplaceAllString(text, "")
+	text = mdEmphasis.ReplaceAllString(text, "")
+
+	content.Text = normalizeSpace(text)
+
+	return content
+}
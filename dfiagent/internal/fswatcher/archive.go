@@ -0,0 +1,98 @@
+package fswatcher
+
+import (
+	"errors"
+	"io"
+	"path"
+
+	"github.com/r-che/dfi/dfiagent/internal/archive"
+	"github.com/r-che/dfi/dfiagent/internal/cfg"
+	"github.com/r-che/dfi/types"
+	"github.com/r-che/dfi/types/dbms"
+
+	"github.com/r-che/log"
+)
+
+// isArchive reports whether members of the object should be indexed
+func isArchive(fso *types.FSObject) bool {
+	return fso.Type == types.ObjRegular && archive.IsArchive(fso.MIME) && archive.HasArchiveExt(fso.Name)
+}
+
+// archiveOps returns operations to update members of the archive arc in the database or nil if
+// arc is not an archive or indexing of archives is disabled. Members that no longer exist in the
+// archive are removed by the final DeletePrefix operation, because objects updated in the same
+// batch are not deleted by prefix
+func archiveOps(arc *types.FSObject) []*dbms.DBOperation {
+	// Get agent configuration
+	c := cfg.Config()
+
+	if !c.Archives || !isArchive(arc) {
+		return nil
+	}
+
+	dbOps := []*dbms.DBOperation{}
+
+	err := archive.Walk(arc.FPath, arc.MIME, c.MaxArcMembers, func(m *archive.Member, r io.Reader) error {
+		fso := &types.FSObject{
+			Name:		path.Base(m.Path),
+			FPath:		types.ArcMemberFPath(arc.FPath, m.Path),
+			Type:		types.ObjArcMember,
+			Size:		m.Size,
+			MTime:		m.MTime,
+			Mode:		m.Mode,
+			UID:		m.UID,
+			GID:		m.GID,
+			User:		m.User,
+			Group:		m.Group,
+			Archive:	arc.FPath,
+		}
+
+		// Get checksum but only if enabled
+		if c.CalcSums {
+			if c.MaxSumSize != 0 && fso.Size > c.MaxSumSize {
+				// Set stub because member is too large to calculate checksum
+				fso.Checksum = types.CsTooLarge
+			} else {
+				var err error
+				if fso.Checksum, err = sumReader(r); err != nil {
+					// Archive cannot be read further
+					return err
+				}
+			}
+		}
+
+		dbOps = append(dbOps, &dbms.DBOperation{Op: dbms.Update, ObjectInfo: fso})
+
+		// OK
+		return nil
+	})
+
+	switch {
+	case err == nil:
+		log.D("Found %d members of archive %q", len(dbOps), arc.FPath)
+	case errors.Is(err, archive.ErrNotArchive):
+		// Compressed file that is not an archive, remove members possibly indexed before
+		log.D("Object %q is not an archive: %v", arc.FPath, err)
+	default:
+		// Keep members found before the error
+		log.W("Cannot read all members of archive %q, %d members found: %v", arc.FPath, len(dbOps), err)
+	}
+
+	// Remove stale members
+	return append(dbOps, arcMembersDeleteOp(arc.FPath))
+}
+
+// removedArchiveOps returns operations to delete members of the removed object placed by fpath.
+// The content type of the removed object is unknown, so only the name is checked
+func removedArchiveOps(fpath string) []*dbms.DBOperation {
+	if !cfg.Config().Archives || !archive.HasArchiveExt(fpath) {
+		return nil
+	}
+
+	return []*dbms.DBOperation{arcMembersDeleteOp(fpath)}
+}
+
+// arcMembersDeleteOp returns the operation to delete all members of the archive placed by arcPath
+func arcMembersDeleteOp(arcPath string) *dbms.DBOperation {
+	return &dbms.DBOperation{Op: dbms.DeletePrefix, ObjectInfo: &types.FSObject{FPath: arcPath + types.ArcMemberSep}}
+}
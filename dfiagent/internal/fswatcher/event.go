@@ -25,4 +25,5 @@ func (et eventType) String() string {
 
 type FSEvent struct {
 	Type		eventType
+	Reindex		bool	// object was found by reindexing of the watched path, not by a real change
 }
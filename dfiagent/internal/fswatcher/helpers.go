@@ -6,8 +6,13 @@ import (
 	"io"
 	"io/fs"
 	"os"
+	"os/user"
+	"strconv"
+	"sync"
 
 	"github.com/r-che/dfi/dfiagent/internal/cfg"
+	"github.com/r-che/dfi/dfiagent/internal/extract"
+	"github.com/r-che/dfi/dfiagent/internal/media"
 	"github.com/r-che/dfi/types"
 
 	"github.com/r-che/log"
@@ -32,10 +37,13 @@ func getObjectInfo(name string) (*types.FSObject, error) {
 		Checksum:	"",
 	}
 
+	// Fill owner, permissions, inode, etc...
+	fillStat(&fso, oi)
+
 	switch {
 	case oi.Mode() & fs.ModeSymlink != 0:
-		// Resolve symbolic link value
-		if fso.RPath, err = os.Readlink(name); err != nil {
+		// Resolve symbolic link value to the absolute path and check the state of the target
+		if fso.RPath, fso.LinkState, err = resolveLink(name); err != nil {
 			log.W("Cannot resolve symbolic link object %q to real path: %v", name, err)
 		}
 
@@ -45,10 +53,36 @@ func getObjectInfo(name string) (*types.FSObject, error) {
 	case oi.IsDir():
 		// Assign proper type
 		fso.Type = types.ObjDirectory
+
+		// Parent directories may be symbolic links
+		if fso.RPath, err = resolvePath(name); err != nil {
+			log.W("Cannot resolve directory %q to real path: %v", name, err)
+		}
 	case oi.Mode().IsRegular():
 		// Assign proper type
 		fso.Type = types.ObjRegular
 
+		// Detect content type
+		if err = detectMIME(&fso); err != nil {
+			log.W("Cannot detect content type of %q: %v", name, err)
+		}
+
+		// Extract metadata of media files but only if enabled
+		if c.MediaMeta && fso.MIME != "" {
+			if info, err := media.Extract(name, fso.MIME); err != nil {
+				log.W("Cannot extract metadata of media file %q: %v", name, err)
+			} else if info != nil {
+				info.Apply(&fso)
+			}
+		}
+
+		// Extract text content of documents but only if enabled
+		if c.ExtractContent && fso.MIME != "" {
+			if fso.Content, err = extract.Run(name, &fso, &c.Extract); err != nil {
+				log.W("Cannot extract content of %q: %v", name, err)
+			}
+		}
+
 		// Get checksum but only if enabled
 		if c.CalcSums {
 			if err = calcSum(&fso, c.MaxSumSize); err != nil {
@@ -86,16 +120,88 @@ func calcSum(fso *types.FSObject, maxSize int64) error {
 	}
 	defer f.Close()
 
-	// Hash object to calculate sum
-	hash := sha1.New()
-	if _, err := io.Copy(hash, f); err != nil {
+	if fso.Checksum, err = sumReader(f); err != nil {
 		return err
 	}
 
 	log.D("Checksum of %q - done", fso.FPath)
 
-	fso.Checksum = fmt.Sprintf("%x", hash.Sum(nil))
-
 	// OK
 	return nil
 }
+
+// sumReader returns checksum of all data read from r
+func sumReader(r io.Reader) (string, error) {
+	// Hash object to calculate sum
+	hash := sha1.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// Permission bits including setuid, setgid and sticky bits in Unix format
+const permBitsMask = 0o7777
+
+// unixPerm converts Go file mode to the Unix permission bits
+func unixPerm(mode fs.FileMode) int64 {
+	perm := int64(mode.Perm())
+
+	if mode & fs.ModeSetuid != 0 {
+		perm |= 0o4000
+	}
+	if mode & fs.ModeSetgid != 0 {
+		perm |= 0o2000
+	}
+	if mode & fs.ModeSticky != 0 {
+		perm |= 0o1000
+	}
+
+	return perm
+}
+
+// Caches of resolved user and group names, shared between watchers
+var (
+	userNames	= &sync.Map{}
+	groupNames	= &sync.Map{}
+)
+
+// userName returns the name of user with identifier uid or empty string if it cannot be resolved
+func userName(uid uint32) string {
+	return cachedName(userNames, uid, func(id string) (string, error) {
+		u, err := user.LookupId(id)
+		if err != nil {
+			return "", err
+		}
+		return u.Username, nil
+	})
+}
+
+// groupName returns the name of group with identifier gid or empty string if it cannot be resolved
+func groupName(gid uint32) string {
+	return cachedName(groupNames, gid, func(id string) (string, error) {
+		g, err := user.LookupGroupId(id)
+		if err != nil {
+			return "", err
+		}
+		return g.Name, nil
+	})
+}
+
+func cachedName(cache *sync.Map, id uint32, lookup func(string) (string, error)) string {
+	if name, ok := cache.Load(id); ok {
+		return name.(string)	//nolint:forcetypeassert	// only strings are stored
+	}
+
+	name, err := lookup(strconv.FormatUint(uint64(id), 10))
+	if err != nil {
+		log.D("Cannot resolve name for identifier %d: %v", id, err)
+		// Cache empty name to avoid repeated lookups
+		name = ""
+	}
+
+	cache.Store(id, name)
+
+	return name
+}
@@ -0,0 +1,158 @@
+package fswatcher
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/r-che/dfi/types"
+)
+
+const (
+	// Maximum number of bytes considered by the content type detection
+	sniffLen = 512
+	// Content type of empty files, the same as file(1) reports
+	mimeEmpty = "inode/x-empty"
+)
+
+// Signatures of formats that are not detected by http.DetectContentType
+var extraSigs = []struct {
+	offset	int
+	magic	[]byte
+	mime	string
+}{
+	{ 0,	[]byte("fLaC"),							"audio/flac" },
+	{ 0,	[]byte("FLV\x01"),						"video/x-flv" },
+	{ 0,	[]byte("\x00\x00\x01\xBA"),				"video/mpeg" },
+	{ 0,	[]byte("\x00\x00\x01\xB3"),				"video/mpeg" },
+	{ 0,	[]byte("\x30\x26\xB2\x75\x8E\x66\xCF\x11"),	"video/x-ms-asf" },
+	{ 0,	[]byte("7z\xBC\xAF\x27\x1C"),			"application/x-7z-compressed" },
+	{ 0,	[]byte("\xFD7zXZ\x00"),					"application/x-xz" },
+	{ 0,	[]byte("BZh"),							"application/x-bzip2" },
+	{ 0,	[]byte("\x28\xB5\x2F\xFD"),				"application/zstd" },
+	{ 0,	[]byte("II*\x00"),						"image/tiff" },
+	{ 0,	[]byte("MM\x00*"),						"image/tiff" },
+	{ 257,	[]byte("ustar"),						"application/x-tar" },
+}
+
+// Brands of ISO base media files (the "ftyp" box) that are not detected by http.DetectContentType
+var ftypBrands = []struct {
+	prefix	string
+	mime	string
+}{
+	{ "qt  ",	"video/quicktime" },
+	{ "3gp",	"video/3gpp" },
+	{ "3g2",	"video/3gpp2" },
+	{ "M4A ",	"audio/mp4" },
+	{ "M4V",	"video/x-m4v" },
+	{ "heic",	"image/heic" },
+	{ "heix",	"image/heic" },
+	{ "mif1",	"image/heif" },
+	{ "avif",	"image/avif" },
+}
+
+// detectMIME detects content type of the regular file fso by the magic bytes at the beginning of it
+func detectMIME(fso *types.FSObject) error {
+	if fso.Size == 0 {
+		// Nothing to read
+		fso.MIME = mimeEmpty
+		return nil
+	}
+
+	f, err := os.Open(fso.FPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	fso.MIME = sniffMIME(buf[:n])
+
+	// OK
+	return nil
+}
+
+// sniffMIME returns content type of data without any parameters like charset
+func sniffMIME(data []byte) string {
+	// Check signatures unknown by the standard library first
+	for _, sig := range extraSigs {
+		if len(data) >= sig.offset + len(sig.magic) &&
+			bytes.Equal(data[sig.offset:sig.offset + len(sig.magic)], sig.magic) {
+			return sig.mime
+		}
+	}
+
+	if mime := sniffFtyp(data); mime != "" {
+		return mime
+	}
+
+	if mime := sniffODF(data); mime != "" {
+		return mime
+	}
+
+	// Matroska uses the same container as WebM, but http.DetectContentType recognizes only WebM
+	if bytes.HasPrefix(data, []byte("\x1A\x45\xDF\xA3")) && bytes.Contains(data, []byte("matroska")) {
+		return "video/x-matroska"
+	}
+
+	// Use the standard algorithm (https://mimesniff.spec.whatwg.org/)
+	mime, _, _ := strings.Cut(http.DetectContentType(data), ";")
+
+	return strings.TrimSpace(mime)
+}
+
+// sniffFtyp checks the major brand of ISO base media file, returns an empty
+// string if data is not such file or its brand is unknown
+func sniffFtyp(data []byte) string {
+	// Box size (4 bytes), box type "ftyp" (4 bytes), major brand (4 bytes)
+	const brandEnd = 12
+	if len(data) < brandEnd || !bytes.Equal(data[4:8], []byte("ftyp")) {
+		return ""
+	}
+
+	brand := string(data[8:brandEnd])
+	for _, fb := range ftypBrands {
+		if strings.HasPrefix(brand, fb.prefix) {
+			return fb.mime
+		}
+	}
+
+	// Unknown brand, probably MP4 that is detected by the standard library
+	return ""
+}
+
+// sniffODF returns content type of OpenDocument file stored in data or an
+// empty string if data is not such file
+func sniffODF(data []byte) string {
+	// OpenDocument is a ZIP archive with the first uncompressed entry "mimetype"
+	// containing the content type, the name starts at offset 30 of the local file header
+	const (
+		nameOff		= 30
+		name		= "mimetype"
+		mimeOff		= nameOff + len(name)
+		mimePrefix	= "application/vnd.oasis.opendocument."
+	)
+
+	if !bytes.HasPrefix(data, []byte("PK\x03\x04")) || len(data) < mimeOff + len(mimePrefix) ||
+		string(data[nameOff:mimeOff]) != name || !bytes.HasPrefix(data[mimeOff:], []byte(mimePrefix)) {
+		return ""
+	}
+
+	// Content type is followed by the next entry header
+	mime := data[mimeOff:]
+	if end := bytes.IndexFunc(mime, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '/')
+	}); end >= 0 {
+		mime = mime[:end]
+	}
+
+	return string(mime)
+}
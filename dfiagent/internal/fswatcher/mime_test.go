@@ -0,0 +1,118 @@
+package fswatcher
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/r-che/dfi/dfiagent/internal/media"
+	"github.com/r-che/dfi/types"
+)
+
+func Test_sniffMIME(t *testing.T) {
+	tests := []struct {
+		name	string
+		data	[]byte
+		want	string
+	} {
+		{ "png",		[]byte("\x89PNG\x0D\x0A\x1A\x0A\x00\x00\x00\x0DIHDR"),	"image/png" },
+		{ "jpeg",		[]byte("\xFF\xD8\xFF\xE0\x00\x10JFIF\x00"),				"image/jpeg" },
+		{ "text",		[]byte("plain text file\n"),							"text/plain" },
+		{ "mp4",		[]byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"),	"video/mp4" },
+		{ "quicktime",	[]byte("\x00\x00\x00\x14ftypqt  \x00\x00\x02\x00qt  "),	"video/quicktime" },
+		{ "heic",		[]byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00mif1heic"),	"image/heic" },
+		{ "matroska",	[]byte("\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01\x42\x82\x88matroska"),	"video/x-matroska" },
+		{ "flac",		[]byte("fLaC\x00\x00\x00\x22"),							"audio/flac" },
+		{ "tiff-le",	[]byte("II*\x00\x08\x00\x00\x00"),						"image/tiff" },
+		{ "tiff-be",	[]byte("MM\x00*\x00\x00\x00\x08"),						"image/tiff" },
+		{ "not-tiff",	[]byte("II+\x00\x10\x00\x00\x00"),						"application/octet-stream" },
+		{ "odt",		[]byte("PK\x03\x04\x14\x00\x00\x00\x00\x00\x00\x00\x00\x00\x5E\xC6\x32\x0C\x27\x00\x00\x00\x27\x00\x00\x00\x08\x00\x00\x00" +
+							"mimetypeapplication/vnd.oasis.opendocument.textPK\x03\x04"),	"application/vnd.oasis.opendocument.text" },
+		{ "zip",		[]byte("PK\x03\x04\x14\x00\x00\x00\x08\x00"),					"application/zip" },
+		{ "short-ftyp",	[]byte("\x00\x00\x00\x18ftyp"),							"application/octet-stream" },
+		{ "binary",		[]byte("\x00\x01\x02\x03\x04\x05"),						"application/octet-stream" },
+	}
+
+	for _, test := range tests {
+		if mime := sniffMIME(test.data); mime != test.want {
+			t.Errorf("[%s] sniffMIME returned %q, want - %q", test.name, mime, test.want)
+		}
+	}
+}
+
+// testTIFF returns the TIFF image without pixel data that contains only resolution and camera model
+func testTIFF(bo binary.ByteOrder) []byte {
+	const (
+		ifdOff		= 8
+		nEntries	= 3
+		model		= "NIKON D850\x00"
+		// Model is placed after the IFD: number of entries, entries, offset of the next IFD
+		modelOff	= ifdOff + 2 + nEntries * 12 + 4
+	)
+
+	data := []byte("II*\x00")
+	if bo == binary.BigEndian {
+		data = []byte("MM\x00*")
+	}
+	data = appendUint32(bo, data, ifdOff)
+	data = appendUint16(bo, data, nEntries)
+
+	for _, e := range []struct {
+		tag, typ	uint16
+		count		uint32
+		value		[]byte
+	} {
+		{ 0x0100, 3, 1, appendUint16(bo, nil, 640) },	// ImageWidth, SHORT
+		{ 0x0101, 3, 1, appendUint16(bo, nil, 480) },	// ImageLength, SHORT
+		{ 0x0110, 2, uint32(len(model)), appendUint32(bo, nil, modelOff) },	// Model, ASCII by offset
+	} {
+		data = appendUint16(bo, data, e.tag)
+		data = appendUint16(bo, data, e.typ)
+		data = appendUint32(bo, data, e.count)
+		// Values shorter than 4 bytes are left-justified
+		data = append(data, append(e.value, make([]byte, 4 - len(e.value))...)...)
+	}
+	data = appendUint32(bo, data, 0)
+
+	return append(data, model...)
+}
+
+func appendUint16(bo binary.ByteOrder, b []byte, v uint16) []byte {
+	buf := make([]byte, 2)
+	bo.PutUint16(buf, v)
+	return append(b, buf...)
+}
+
+func appendUint32(bo binary.ByteOrder, b []byte, v uint32) []byte {
+	buf := make([]byte, 4)
+	bo.PutUint32(buf, v)
+	return append(b, buf...)
+}
+
+func Test_detectMIMEMedia(t *testing.T) {
+	dir := t.TempDir()
+	for _, bo := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		data := testTIFF(bo)
+		fso := &types.FSObject{FPath: filepath.Join(dir, bo.String() + ".tif"), Size: int64(len(data))}
+		if err := os.WriteFile(fso.FPath, data, 0o600); err != nil {
+			t.Fatalf("cannot write test file: %v", err)
+		}
+
+		// Detected content type must select the parser of metadata
+		if err := detectMIME(fso); err != nil || fso.MIME != "image/tiff" {
+			t.Errorf("[%v] detectMIME - want %q, got %q (error: %v)", bo, "image/tiff", fso.MIME, err)
+			continue
+		}
+
+		info, err := media.Extract(fso.FPath, fso.MIME)
+		if err != nil || info == nil {
+			t.Errorf("[%v] Extract of TIFF returned %#v, error: %v", bo, info, err)
+			continue
+		}
+		if info.Camera != "NIKON D850" || info.Width != 640 || info.Height != 480 {
+			t.Errorf("[%v] Extract of TIFF returned camera %q, resolution %dx%d, want - %q, 640x480",
+				bo, info.Camera, info.Width, info.Height, "NIKON D850")
+		}
+	}
+}
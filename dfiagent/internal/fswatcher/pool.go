@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/r-che/dfi/dfiagent/internal/hooks"
 	"github.com/r-che/dfi/types/dbms"
 
 	"github.com/r-che/log"
@@ -17,6 +18,7 @@ type Pool struct {
 	paths			[]string					// configured paths for pool
 	dbChan			chan<- []*dbms.DBOperation	// to send operations to DB controller
 	flushInterval	time.Duration				// interval between flushing events to DB
+	hooks			*hooks.Runner				// to run hooks on events, nil if hooks are not configured
 
 	// Runtime data
 	watchers map[string]*Watcher
@@ -30,6 +32,11 @@ func NewPool(paths []string, dbChan chan<- []*dbms.DBOperation, flushInterval ti
 	}
 }
 
+// SetHooks sets the runner of hooks on events of watchers started after the call
+func (p *Pool) SetHooks(hr *hooks.Runner) {
+	p.hooks = hr
+}
+
 func (p *Pool) StartWatchers(doReindex bool) error {
 	// Set lock for all operations with watchers map
 	p.m.Lock()
@@ -55,6 +62,8 @@ func (p *Pool) StartWatchers(doReindex bool) error {
 			continue
 		}
 
+		w.hooks = p.hooks
+
 		// Add watcher to watchers map
 		p.watchers[path] = w
 
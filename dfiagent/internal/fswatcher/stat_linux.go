@@ -0,0 +1,31 @@
+package fswatcher
+
+import (
+	"io/fs"
+	"syscall"
+
+	"github.com/r-che/dfi/types"
+)
+
+// fillStat fills extended metadata of the filesystem object using the system-specific information from oi
+func fillStat(fso *types.FSObject, oi fs.FileInfo) {
+	st, ok := oi.Sys().(*syscall.Stat_t)
+	if !ok {
+		// Use only portable information
+		fso.Mode = unixPerm(oi.Mode())
+		return
+	}
+
+	// XXX Explicit conversions are required because sizes of Stat_t fields depend on architecture
+	fso.UID = int64(st.Uid)
+	fso.GID = int64(st.Gid)
+	fso.Mode = int64(st.Mode & permBitsMask)
+	fso.CTime = int64(st.Ctim.Sec)	//nolint:unconvert	// int32 on some architectures
+	fso.Inode = int64(st.Ino)
+	fso.Device = int64(st.Dev)		//nolint:unconvert	// uint32 on some architectures
+	fso.NLink = int64(st.Nlink)		//nolint:unconvert	// uint32 on some architectures
+
+	// Resolve names of the owner and group
+	fso.User = userName(st.Uid)
+	fso.Group = groupName(st.Gid)
+}
@@ -0,0 +1,113 @@
+package fswatcher
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/r-che/dfi/dfiagent/internal/cfg"
+	"github.com/r-che/dfi/types"
+
+	"github.com/r-che/log"
+)
+
+// resolvePath returns the fully resolved absolute path of the object name
+func resolvePath(name string) (string, error) {
+	rpath, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Abs(rpath)
+}
+
+// resolveLink returns the fully resolved absolute path of the target of symbolic link name
+// and the state of the target. If the target cannot be resolved, the absolute path built
+// from the link value is returned
+func resolveLink(name string) (string, string, error) {
+	target, err := os.Readlink(name)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Relative targets are relative to the directory containing the link
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(name), target)
+	}
+	target = filepath.Clean(target)
+
+	// Check that the target exists following all links in the chain
+	if _, err = os.Stat(name); err != nil {
+		switch {
+		case errors.Is(err, syscall.ELOOP):
+			return target, types.LinkLoop, nil
+		case errors.Is(err, fs.ErrNotExist), errors.Is(err, syscall.ENOTDIR):
+			return target, types.LinkDangling, nil
+		default:
+			// Some unexpected error, the state is unknown
+			return target, "", err
+		}
+	}
+
+	rpath, err := resolvePath(name)
+	if err != nil {
+		return target, "", err
+	}
+
+	return rpath, types.LinkOK, nil
+}
+
+// insidePath reports whether the path is equal to the dir or placed inside of it
+func insidePath(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, pathSeparator) + pathSeparator)
+}
+
+// followLink scans the directory pointed by the symbolic link if following of symbolic links is
+// enabled. To protect against cycles, directories inside of indexing paths and directories inside
+// of already followed ones are not followed, because they are indexed anyway
+func (w *Watcher) followLink(link string, doIndexing bool) (int, error) {
+	// Get agent configuration
+	c := cfg.Config()
+
+	if !c.FollowLinks {
+		return 0, nil
+	}
+
+	target, err := resolvePath(link)
+	if err != nil {
+		// Dangling link or loop, nothing to follow
+		return 0, nil	//nolint:nilerr	// state of the link is stored in the link object
+	}
+
+	if ti, err := os.Stat(target); err != nil || !ti.IsDir() {
+		// Only directories are followed
+		return 0, nil	//nolint:nilerr	// state of the link is stored in the link object
+	}
+
+	// Indexing paths may contain symbolic links too, so compare resolved paths
+	for _, path := range c.IdxPaths {
+		if rpath, err := resolvePath(path); err == nil {
+			path = rpath
+		}
+		if insidePath(target, path) {
+			log.D("(Watcher:%s) Skip symbolic link %q to %q inside of indexing path %q", w.path, link, target, path)
+			return 0, nil
+		}
+	}
+	for fLink, fTarget := range w.followed {
+		if fLink != link && insidePath(target, fTarget) {
+			log.D("(Watcher:%s) Skip symbolic link %q to %q inside of directory %q followed by %q",
+				w.path, link, target, fTarget, fLink)
+			return 0, nil
+		}
+	}
+
+	log.I("(Watcher:%s) Following symbolic link %q to directory %q", w.path, link, target)
+	w.followed[link] = target
+
+	// Watchers follow the link, so events inside of the directory have paths prefixed with the link
+	return w.scanDir(link, doIndexing)
+}
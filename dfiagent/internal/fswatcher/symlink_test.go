@@ -0,0 +1,96 @@
+package fswatcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/r-che/dfi/types"
+)
+
+func Test_resolveLink(t *testing.T) {
+	// Resolve temporary directory itself, it may be placed behind a symbolic link
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("cannot resolve temporary directory: %v", err)
+	}
+
+	// Prepare a tree of objects
+	if err = os.MkdirAll(filepath.Join(dir, "data", "sub"), 0o700); err != nil {
+		t.Fatalf("cannot create test directories: %v", err)
+	}
+	file := filepath.Join(dir, "data", "sub", "file")
+	if err = os.WriteFile(file, []byte("test"), 0o600); err != nil {
+		t.Fatalf("cannot write test file: %v", err)
+	}
+
+	links := []struct {
+		name	string
+		target	string
+	}{
+		{ "abs",		file },
+		{ "rel",		"data/sub/../sub/file" },
+		{ "chain",		"rel" },
+		{ "dirlink",	"data" },
+		{ "dangling",	"data/missing" },
+		{ "notdir",		"data/sub/file/missing" },
+		{ "loop1",		"loop2" },
+		{ "loop2",		"loop1" },
+	}
+	for _, link := range links {
+		if err = os.Symlink(link.target, filepath.Join(dir, link.name)); err != nil {
+			t.Fatalf("cannot create symbolic link %q: %v", link.name, err)
+		}
+	}
+
+	tests := []struct {
+		name	string
+		rpath	string
+		state	string
+	}{
+		{ "abs",		file,										types.LinkOK },
+		{ "rel",		file,										types.LinkOK },
+		{ "chain",		file,										types.LinkOK },
+		{ "dirlink",	filepath.Join(dir, "data"),					types.LinkOK },
+		{ "dangling",	filepath.Join(dir, "data", "missing"),		types.LinkDangling },
+		{ "notdir",		filepath.Join(file, "missing"),				types.LinkDangling },
+		{ "loop1",		filepath.Join(dir, "loop2"),				types.LinkLoop },
+	}
+
+	for _, test := range tests {
+		rpath, state, err := resolveLink(filepath.Join(dir, test.name))
+		if err != nil {
+			t.Errorf("%s - unexpected error: %v", test.name, err)
+			continue
+		}
+		if rpath != test.rpath || state != test.state {
+			t.Errorf("%s - want (%q, %q), got (%q, %q)", test.name, test.rpath, test.state, rpath, state)
+		}
+	}
+
+	// Not a symbolic link
+	if _, _, err = resolveLink(file); err == nil {
+		t.Errorf("resolveLink must fail on regular file, but it did not")
+	}
+}
+
+func Test_insidePath(t *testing.T) {
+	tests := []struct {
+		path	string
+		dir		string
+		want	bool
+	}{
+		{ "/data/photos",		"/data/photos",		true },
+		{ "/data/photos/2020",	"/data/photos",		true },
+		{ "/data/photos/2020",	"/data/photos/",	true },
+		{ "/data/photos-old",	"/data/photos",		false },
+		{ "/data",				"/data/photos",		false },
+		{ "/data",				"/",				true },
+	}
+
+	for i, test := range tests {
+		if got := insidePath(test.path, test.dir); got != test.want {
+			t.Errorf("[%d] insidePath(%q, %q) - want %t, got %t", i, test.path, test.dir, test.want, got)
+		}
+	}
+}
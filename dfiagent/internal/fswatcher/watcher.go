@@ -3,12 +3,14 @@ package fswatcher
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/r-che/dfi/common/tools"
+	"github.com/r-che/dfi/dfiagent/internal/hooks"
 	"github.com/r-che/dfi/types"
 	"github.com/r-che/dfi/types/dbms"
 
@@ -40,12 +42,15 @@ type Watcher struct {
 	path			string
 	flushInterval	time.Duration
 	dbChan			chan<- []*dbms.DBOperation	// to send operations to DB controller
+	hooks			*hooks.Runner				// to run hooks on events, nil if hooks are not configured
 
 	// Runtime variables
 	eMap		eventsMap
 	ctrlCh		ctrlChan
 	watchDirs	map[string]bool
+	followed	map[string]string	// followed symbolic links to directories with their targets
 	termLongVal int				// should be incremented when need to terminate long-term operation
+	reindexing	bool			// reindexing of the watched path is in progress
 
 	// fsnotify watcher object
 	w	*fsn.Watcher
@@ -76,6 +81,7 @@ func NewWatcher(path string, flushInterval time.Duration,
 		dbChan:			dbChan,
 		ctrlCh:			make(ctrlChan),
 		eMap:			eventsMap{},
+		followed:		map[string]string{},
 	}
 
 	// Create new FS watcher
@@ -103,8 +109,10 @@ func (w *Watcher) Watch(doReindex bool) error {
 	if doReindex {
 		log.I("(Watcher:%s) Starting reindexing ...", w.path)
 
-		// Do recursive scan and reindexing
+		// Do recursive scan and reindexing, found objects are marked to distinguish them from real changes
+		w.reindexing = true
 		total, err = w.scanDir(w.path, DoReindex)
+		w.reindexing = false
 		if err != nil {
 			return fmt.Errorf("(Watcher:%s) cannot reindex: %w", w.path, err)
 		}
@@ -223,6 +231,9 @@ func (w *Watcher) flushCached() error {
 	// Prepare database operations list
 	dbOps := make([]*dbms.DBOperation, 0, len(w.eMap))
 
+	// Events for hooks are fired after sending operations to keep order of changes in database and actions
+	hookEvs := make([]*hooks.Event, 0, len(w.eMap))
+
 	// Keep current termLongVal value to have ability to compare during long-term operations
 	initTermLong := w.termLongVal
 
@@ -253,11 +264,19 @@ func (w *Watcher) flushCached() error {
 
 			// Append a database operation
 			dbOps = append(dbOps, &dbms.DBOperation{Op: dbms.Update, ObjectInfo: oInfo})
+			hookEvs = append(hookEvs, w.hookEvent(event, ePath, oInfo))
+
+			// Update members if the object is an archive
+			dbOps = append(dbOps, archiveOps(oInfo)...)
 
 		// Object was removed from the filesystem
 		case EvRemove:
 			// Append database removal operation
 			dbOps = append(dbOps, &dbms.DBOperation{Op: dbms.Delete, ObjectInfo: &types.FSObject{FPath: ePath}})
+			hookEvs = append(hookEvs, w.hookEvent(event, ePath, nil))
+
+			// Remove members if the object was an archive
+			dbOps = append(dbOps, removedArchiveOps(ePath)...)
 
 		// Set of objects prefixed with name were removed from the filesystem
 		case EvRemovePrefix:
@@ -275,10 +294,43 @@ func (w *Watcher) flushCached() error {
 	// Send dbOps to database controller channel
 	w.dbChan <-dbOps
 
+	// Removal of objects by prefix is not passed to hooks, because the removed directory has own event
+	for _, ev := range hookEvs {
+		w.hooks.Fire(ev)
+	}
+
 	// No errors
 	return nil
 }
 
+// hookEvent returns event for hooks, oInfo is nil for removed objects
+func (w *Watcher) hookEvent(event *FSEvent, ePath string, oInfo *types.FSObject) *hooks.Event {
+	ev := &hooks.Event{
+		Time:		time.Now().Unix(),
+		FPath:		ePath,
+		Reindex:	event.Reindex,
+	}
+
+	switch event.Type {
+	case EvCreate:
+		ev.Kind = hooks.EvCreate
+	case EvWrite:
+		ev.Kind = hooks.EvWrite
+	default:
+		ev.Kind = hooks.EvRemove
+	}
+
+	if oInfo != nil {
+		ev.Type = oInfo.Type
+		ev.Size = oInfo.Size
+		ev.MTime = oInfo.MTime
+		ev.Checksum = oInfo.Checksum
+		ev.MIME = oInfo.MIME
+	}
+
+	return ev
+}
+
 func (w *Watcher) scanDir(dir string, doIndexing bool) (int, error) {
 	// Total number of watchers set to the dir
 	total := 0
@@ -312,7 +364,7 @@ func (w *Watcher) scanDir(dir string, doIndexing bool) (int, error) {
 		// Is indexing of objects required?
 		if doIndexing {
 			// Add each entry as newly created object to update data in DB
-			w.eMap[objName] = &FSEvent{Type: EvCreate}
+			w.eMap[objName] = &FSEvent{Type: EvCreate, Reindex: w.reindexing}
 		}
 
 		// Check that the entry is a directory
@@ -323,6 +375,13 @@ func (w *Watcher) scanDir(dir string, doIndexing bool) (int, error) {
 				log.E("(Watcher:%s) Cannot scan nested directory %q: %v", w.path, objName, err)
 			}
 			total += nw
+		} else if entry.Type() & fs.ModeSymlink != 0 {
+			// Scan directory pointed by the link if following of links is enabled
+			nw, err := w.followLink(objName, doIndexing)
+			if err != nil {
+				log.E("(Watcher:%s) Cannot scan directory pointed by symbolic link %q: %v", w.path, objName, err)
+			}
+			total += nw
 		}
 	}
 
@@ -390,8 +449,12 @@ func (w *Watcher) eventRemoveRename(event *fsn.Event) error {
 	// Unregister removed/renamed directory
 	delete(w.watchDirs, event.Name)
 
-	// Is it a rename event?
-	if event.Op & fsn.Rename != 0 {
+	// Watchers of the directory pointed by the removed symbolic link are not removed automatically
+	_, isLink := w.followed[event.Name]
+	delete(w.followed, event.Name)
+
+	// Is it a rename event or a followed symbolic link?
+	if event.Op & fsn.Rename != 0 || isLink {
 		// Remove watcher from the directory itself and from all directories in the dir hierarchy
 		if err := w.unwatchDir(event.Name); err != nil {
 			return fmt.Errorf("cannot remove watchers from directory %q with its subdirectories: %w", event.Name, err)
@@ -417,6 +480,20 @@ func (w *Watcher) eventCreate(event *fsn.Event) error {
 	isDir := oi.IsDir()
 	log.D("(Watcher:%s) Created %s %q", tools.Tern(isDir, "directory", "object"), w.path, event.Name)
 
+	// Is object a symbolic link?
+	if oi.Mode() & fs.ModeSymlink != 0 {
+		// Scan directory pointed by the link if following of links is enabled
+		if _, err = w.followLink(event.Name, DoReindex); err != nil {
+			return fmt.Errorf("cannot scan directory pointed by symbolic link %q: %w", event.Name, err)
+		}
+		if _, ok := w.followed[event.Name]; ok {
+			// Register link to handle its removal
+			w.watchDirs[event.Name] = true
+		}
+
+		return nil
+	}
+
 	// Is object not a directory?
 	if !isDir {
 		// No additional actions required, return no errors
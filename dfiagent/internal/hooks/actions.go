@@ -0,0 +1,90 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Maximum size of output of failed action included to the error
+const maxErrOutput = 512
+
+func (r *Rule) run(ctx context.Context, ev *Event) error {
+	if r.Webhook != "" {
+		return r.post(ctx, ev)
+	}
+
+	return r.exec(ctx, ev)
+}
+
+// post sends the event in JSON to the webhook URL
+func (r *Rule) post(ctx context.Context, ev *Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("cannot encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.Webhook, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("cannot create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode / 100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrOutput))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	// Read the rest of the body to reuse the connection
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	// OK
+	return nil
+}
+
+// exec runs the command with the event passed in environment variables
+func (r *Rule) exec(ctx context.Context, ev *Event) error {
+	cmd := exec.CommandContext(ctx, r.Exec[0], r.Exec[1:]...)	//nolint:gosec	// Command is set by the agent's owner
+	cmd.Env = append(os.Environ(), eventEnv(ev)...)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if len(out) > maxErrOutput {
+			out = out[:maxErrOutput]
+		}
+		return fmt.Errorf("command %q failed: %w: %s", r.Exec[0], err, strings.TrimSpace(string(out)))
+	}
+
+	// OK
+	return nil
+}
+
+// eventEnv returns the event as a list of environment variables
+func eventEnv(ev *Event) []string {
+	return []string{
+		"DFI_HOOK=" + ev.Hook,
+		"DFI_EVENT=" + ev.Kind,
+		"DFI_TIME=" + strconv.FormatInt(ev.Time, 10),
+		"DFI_HOST=" + ev.Host,
+		"DFI_FPATH=" + ev.FPath,
+		"DFI_TYPE=" + ev.Type,
+		"DFI_SIZE=" + strconv.FormatInt(ev.Size, 10),
+		"DFI_MTIME=" + strconv.FormatInt(ev.MTime, 10),
+		"DFI_CSUM=" + ev.Checksum,
+		"DFI_MIME=" + ev.MIME,
+		"DFI_REINDEX=" + strconv.FormatBool(ev.Reindex),
+	}
+}
@@ -0,0 +1,119 @@
+// Package hooks runs actions configured by rules on filesystem events handled by the agent
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Kinds of events
+const (
+	EvCreate	=	"create"
+	EvWrite		=	"write"
+	EvRemove	=	"remove"
+)
+
+// Default values of the configuration
+const (
+	defaultQueueSize	=	1000
+	defaultRetries		=	3
+	defaultRetryDelay	=	10 * time.Second
+	defaultTimeout		=	30 * time.Second
+)
+
+// Event passed to actions, encoded to JSON for webhooks
+type Event struct {
+	Hook		string	`json:"hook"`
+	Kind		string	`json:"event"`
+	Time		int64	`json:"time"`
+	Host		string	`json:"host"`
+	FPath		string	`json:"fpath"`
+	Type		string	`json:"type,omitempty"`
+	Size		int64	`json:"size"`
+	MTime		int64	`json:"mtime,omitempty"`
+	Checksum	string	`json:"csum,omitempty"`
+	MIME		string	`json:"mime,omitempty"`
+
+	// Object was found by reindexing, not by a real change
+	Reindex		bool	`json:"reindex,omitempty"`
+}
+
+// Config of hooks loaded from JSON file
+type Config struct {
+	Rate		float64		`json:"rate"`			// maximum number of actions started per second, 0 - no limits
+	QueueSize	int			`json:"queueSize"`		// maximum number of actions waiting to run
+	Retries		int			`json:"retries"`		// number of retries of failed actions
+	RetryDelay	Duration	`json:"retryDelay"`		// delay before retry of failed action
+	Rules		[]*Rule		`json:"hooks"`
+}
+
+// Duration is time.Duration decoded from JSON strings like "30s" or "1m30s"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\": %w", err)
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+
+	// OK
+	return nil
+}
+
+// LoadConfig reads configuration of hooks from the JSON file and checks it
+func LoadConfig(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read configuration of hooks: %w", err)
+	}
+
+	return ParseConfig(data)
+}
+
+// ParseConfig decodes configuration of hooks from JSON data, checks it and sets default values
+func ParseConfig(data []byte) (*Config, error) {
+	c := &Config{
+		QueueSize:	defaultQueueSize,
+		Retries:	defaultRetries,
+		RetryDelay:	Duration(defaultRetryDelay),
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("cannot decode configuration of hooks: %w", err)
+	}
+
+	switch {
+	case c.Rate < 0:
+		return nil, fmt.Errorf("invalid rate of actions %v, must be non-negative", c.Rate)
+	case c.QueueSize <= 0:
+		return nil, fmt.Errorf("invalid size of actions queue %d, must be positive", c.QueueSize)
+	case c.Retries < 0:
+		return nil, fmt.Errorf("invalid number of retries %d, must be non-negative", c.Retries)
+	case c.RetryDelay < 0:
+		return nil, fmt.Errorf("invalid retry delay %v, must be non-negative", time.Duration(c.RetryDelay))
+	case len(c.Rules) == 0:
+		return nil, fmt.Errorf("no hooks configured")
+	}
+
+	names := make(map[string]bool, len(c.Rules))
+	for i, r := range c.Rules {
+		if err := r.prepare(); err != nil {
+			return nil, fmt.Errorf("invalid hook #%d %q: %w", i + 1, r.Name, err)
+		}
+
+		if names[r.Name] {
+			return nil, fmt.Errorf("duplicate name of hook %q", r.Name)
+		}
+		names[r.Name] = true
+	}
+
+	// OK
+	return c, nil
+}
@@ -0,0 +1,224 @@
+package hooks
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/r-che/log"
+)
+
+func TestMain(m *testing.M) {
+	// Runner writes messages to the log
+	if err := log.Open(log.DefaultLog, "hooks-test", log.NoFlags); err != nil {
+		panic("cannot open log: " + err.Error())
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestParseConfig(t *testing.T) {
+	c, err := ParseConfig([]byte(`{
+		"rate": 2,
+		"hooks": [
+			{ "name": "thumbs", "paths": ["*.jpg"], "webhook": "http://localhost/thumbs" },
+			{ "name": "scan", "events": ["create", "write"], "exec": ["/bin/true"], "timeout": "1m" }
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("cannot parse valid configuration: %v", err)
+	}
+
+	if c.QueueSize != defaultQueueSize || c.Retries != defaultRetries || c.RetryDelay != Duration(defaultRetryDelay) {
+		t.Errorf("default values are not set: %#v", c)
+	}
+	if c.Rules[0].Timeout != Duration(defaultTimeout) {
+		t.Errorf("default timeout is not set: %v", time.Duration(c.Rules[0].Timeout))
+	}
+	if c.Rules[1].Timeout != Duration(time.Minute) {
+		t.Errorf("timeout %v was set, want - %v", time.Duration(c.Rules[1].Timeout), time.Minute)
+	}
+}
+
+func TestParseConfigInvalid(t *testing.T) {
+	tests := []string{
+		`{}`,
+		`{ "rate": -1, "hooks": [{ "name": "x", "exec": ["/bin/true"] }] }`,
+		`{ "queueSize": 0, "hooks": [{ "name": "x", "exec": ["/bin/true"] }] }`,
+		`{ "retryDelay": 10, "hooks": [{ "name": "x", "exec": ["/bin/true"] }] }`,
+		`{ "hooks": [{ "exec": ["/bin/true"] }] }`,
+		`{ "hooks": [{ "name": "x" }] }`,
+		`{ "hooks": [{ "name": "x", "exec": [""] }] }`,
+		`{ "hooks": [{ "name": "x", "exec": ["/bin/true"], "webhook": "http://localhost" }] }`,
+		`{ "hooks": [{ "name": "x", "webhook": "ftp://localhost" }] }`,
+		`{ "hooks": [{ "name": "x", "exec": ["/bin/true"], "events": ["rename"] }] }`,
+		`{ "hooks": [{ "name": "x", "exec": ["/bin/true"], "types": ["fifo"] }] }`,
+		`{ "hooks": [{ "name": "x", "exec": ["/bin/true"], "paths": ["[a-"] }] }`,
+		`{ "hooks": [{ "name": "x", "exec": ["/bin/true"], "minSize": 10, "maxSize": 5 }] }`,
+		`{ "hooks": [{ "name": "x", "exec": ["/bin/true"], "timeout": "-1s" }] }`,
+		`{ "hooks": [{ "name": "x", "exec": ["/bin/true"] }, { "name": "x", "exec": ["/bin/false"] }] }`,
+	}
+
+	for _, test := range tests {
+		if c, err := ParseConfig([]byte(test)); err == nil {
+			t.Errorf("invalid configuration %s was parsed without errors: %#v", test, c)
+		}
+	}
+}
+
+func TestRuleMatch(t *testing.T) {
+	rule := &Rule{
+		Name:		"test",
+		Events:		[]string{EvCreate, EvWrite},
+		Paths:		[]string{"*.jpg", "/data/docs/*"},
+		Types:		[]string{"reg"},
+		MinSize:	100,
+		MaxSize:	1000,
+		Exec:		[]string{"/bin/true"},
+	}
+	if err := rule.prepare(); err != nil {
+		t.Fatalf("cannot prepare rule: %v", err)
+	}
+
+	tests := []struct {
+		ev		Event
+		want	bool
+	}{
+		{ Event{Kind: EvCreate, FPath: "/photos/a.jpg", Type: "reg", Size: 500}, true },
+		{ Event{Kind: EvWrite, FPath: "/data/docs/a.txt", Type: "reg", Size: 100}, true },
+		{ Event{Kind: EvCreate, FPath: "/data/docs/sub/a.txt", Type: "reg", Size: 500}, false },
+		{ Event{Kind: EvRemove, FPath: "/photos/a.jpg"}, false },
+		{ Event{Kind: EvCreate, FPath: "/photos/a.png", Type: "reg", Size: 500}, false },
+		{ Event{Kind: EvCreate, FPath: "/photos/a.jpg", Type: "dir", Size: 500}, false },
+		{ Event{Kind: EvCreate, FPath: "/photos/a.jpg", Type: "reg", Size: 99}, false },
+		{ Event{Kind: EvCreate, FPath: "/photos/a.jpg", Type: "reg", Size: 1001}, false },
+		{ Event{Kind: EvCreate, FPath: "/photos/a.jpg", Type: "reg", Size: 500, Reindex: true}, false },
+	}
+
+	for i, test := range tests {
+		if got := rule.Match(&test.ev); got != test.want {
+			t.Errorf("[%d] event %#v matched: %t, want - %t", i, test.ev, got, test.want)
+		}
+	}
+
+	// Rule without conditions matches any events excluding found by reindexing
+	anyRule := &Rule{Name: "any", Exec: []string{"/bin/true"}}
+	if !anyRule.Match(&Event{Kind: EvRemove, FPath: "/x"}) {
+		t.Errorf("rule without conditions does not match removal")
+	}
+	if anyRule.Match(&Event{Kind: EvCreate, FPath: "/x", Reindex: true}) {
+		t.Errorf("rule without reindex flag matches event found by reindexing")
+	}
+	anyRule.Reindex = true
+	if !anyRule.Match(&Event{Kind: EvCreate, FPath: "/x", Reindex: true}) {
+		t.Errorf("rule with reindex flag does not match event found by reindexing")
+	}
+}
+
+func TestRunnerWebhook(t *testing.T) {
+	// Webhook fails on the first request to check retries
+	var calls int32
+	got := make(chan Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+
+		var ev Event
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &ev); err != nil {
+			t.Errorf("cannot decode event %q: %v", data, err)
+		}
+		got <- ev
+	}))
+	defer srv.Close()
+
+	c := &Config{
+		QueueSize:	10,
+		Retries:	1,
+		RetryDelay:	Duration(10 * time.Millisecond),
+		Rules:		[]*Rule{{Name: "hook", Paths: []string{"*.jpg"}, Webhook: srv.URL}},
+	}
+	if err := c.Rules[0].prepare(); err != nil {
+		t.Fatalf("cannot prepare rule: %v", err)
+	}
+
+	r := NewRunner(c, "test-host")
+	r.Run()
+	defer r.Stop()
+
+	r.Fire(&Event{Kind: EvRemove, FPath: "/skipped.png"})
+	r.Fire(&Event{Kind: EvCreate, FPath: "/photos/a.jpg", Type: "reg", Size: 10})
+
+	select {
+	case ev := <-got:
+		want := Event{Hook: "hook", Kind: EvCreate, Host: "test-host", FPath: "/photos/a.jpg", Type: "reg", Size: 10}
+		if ev != want {
+			t.Errorf("webhook received %#v, want - %#v", ev, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("webhook was not called after retry, calls: %d", atomic.LoadInt32(&calls))
+	}
+}
+
+func TestRunnerExec(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+
+	c := &Config{
+		QueueSize:	10,
+		Rules:		[]*Rule{{Name: "exec", Exec: []string{"/bin/sh", "-c", `echo "$DFI_EVENT $DFI_FPATH $DFI_SIZE" > ` + out}}},
+	}
+	if err := c.Rules[0].prepare(); err != nil {
+		t.Fatalf("cannot prepare rule: %v", err)
+	}
+
+	r := NewRunner(c, "test-host")
+	r.Run()
+
+	r.Fire(&Event{Kind: EvWrite, FPath: "/data/file", Size: 42})
+
+	// Wait for the command
+	deadline := time.Now().Add(5 * time.Second)
+	var data []byte
+	for time.Now().Before(deadline) {
+		if data, _ = os.ReadFile(out); len(data) != 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	r.Stop()
+
+	if got, want := strings.TrimSpace(string(data)), "write /data/file 42"; got != want {
+		t.Errorf("command wrote %q, want - %q", got, want)
+	}
+}
+
+func TestRunnerRate(t *testing.T) {
+	r := NewRunner(&Config{Rate: 20, QueueSize: 1}, "")
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if !r.wait() {
+			t.Fatalf("wait returned false on running runner")
+		}
+	}
+
+	// The first action starts immediately, each next waits 50ms
+	if elapsed := time.Since(start); elapsed < 100 * time.Millisecond {
+		t.Errorf("3 actions with rate 20/s were started in %v, want at least 100ms", elapsed)
+	}
+
+	r.Stop()
+	r.lastRun = time.Now()
+	if r.wait() {
+		t.Errorf("wait returned true on stopped runner")
+	}
+}
@@ -0,0 +1,146 @@
+package hooks
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/r-che/dfi/types"
+)
+
+// Rule selects events and sets the action to run on them
+type Rule struct {
+	Name	string		`json:"name"`
+
+	// Conditions, empty conditions match any events
+	Events	[]string	`json:"events"`	// kinds of events
+	Paths	[]string	`json:"paths"`		// glob patterns of paths, patterns without separators match names
+	Types	[]string	`json:"types"`		// types of objects
+	MinSize	int64		`json:"minSize"`	// minimal size of objects
+	MaxSize	int64		`json:"maxSize"`	// maximal size of objects, 0 - no limits
+	Reindex	bool		`json:"reindex"`	// also match objects found by reindexing
+
+	// Action, only one of them can be set
+	Webhook	string		`json:"webhook"`	// URL to POST event in JSON
+	Exec	[]string	`json:"exec"`		// command with arguments to run with event in environment
+
+	Timeout	Duration	`json:"timeout"`	// maximum duration of the action
+}
+
+func (r *Rule) prepare() error {
+	if r.Name == "" {
+		return fmt.Errorf("name is not set")
+	}
+
+	for _, ev := range r.Events {
+		if ev != EvCreate && ev != EvWrite && ev != EvRemove {
+			return fmt.Errorf("unsupported event %q, supported: %s, %s, %s", ev, EvCreate, EvWrite, EvRemove)
+		}
+	}
+
+	for _, pattern := range r.Paths {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern of paths %q: %w", pattern, err)
+		}
+	}
+
+	for _, t := range r.Types {
+		if !inList(t, types.ObjTypes()) {
+			return fmt.Errorf("unsupported type of objects %q, supported: %s",
+				t, strings.Join(types.ObjTypes(), ", "))
+		}
+	}
+
+	if r.MinSize < 0 || r.MaxSize < 0 {
+		return fmt.Errorf("sizes of objects must be non-negative")
+	}
+	if r.MaxSize != 0 && r.MaxSize < r.MinSize {
+		return fmt.Errorf("maximal size %d is less than minimal size %d", r.MaxSize, r.MinSize)
+	}
+
+	switch {
+	case r.Webhook == "" && len(r.Exec) == 0:
+		return fmt.Errorf("no action set, webhook or exec is required")
+	case r.Webhook != "" && len(r.Exec) != 0:
+		return fmt.Errorf("only one of webhook or exec can be set")
+	case r.Webhook != "":
+		u, err := url.Parse(r.Webhook)
+		if err != nil {
+			return fmt.Errorf("invalid webhook URL: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("unsupported scheme of webhook URL %q", u.Scheme)
+		}
+	case r.Exec[0] == "":
+		return fmt.Errorf("empty command to exec")
+	}
+
+	switch {
+	case r.Timeout < 0:
+		return fmt.Errorf("invalid timeout %v, must be non-negative", time.Duration(r.Timeout))
+	case r.Timeout == 0:
+		r.Timeout = Duration(defaultTimeout)
+	}
+
+	// OK
+	return nil
+}
+
+// Match returns true if the event matches all conditions of the rule
+func (r *Rule) Match(ev *Event) bool {
+	if ev.Reindex && !r.Reindex {
+		return false
+	}
+
+	if len(r.Events) != 0 && !inList(ev.Kind, r.Events) {
+		return false
+	}
+
+	if len(r.Paths) != 0 && !r.matchPath(ev.FPath) {
+		return false
+	}
+
+	// Type and size of removed objects are unknown, so rules with such conditions never match them
+	if len(r.Types) != 0 && !inList(ev.Type, r.Types) {
+		return false
+	}
+	if r.MinSize != 0 && (ev.Kind == EvRemove || ev.Size < r.MinSize) {
+		return false
+	}
+	if r.MaxSize != 0 && (ev.Kind == EvRemove || ev.Size > r.MaxSize) {
+		return false
+	}
+
+	return true
+}
+
+func (r *Rule) matchPath(fpath string) bool {
+	name := filepath.Base(fpath)
+
+	for _, pattern := range r.Paths {
+		// Patterns without separators are matched against names of objects
+		val := fpath
+		if !strings.Contains(pattern, string(filepath.Separator)) {
+			val = name
+		}
+
+		// Patterns were checked on loading, so errors are impossible here
+		if ok, _ := filepath.Match(pattern, val); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func inList(val string, list []string) bool {
+	for _, v := range list {
+		if v == val {
+			return true
+		}
+	}
+
+	return false
+}
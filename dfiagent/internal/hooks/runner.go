@@ -0,0 +1,163 @@
+package hooks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/r-che/log"
+)
+
+// Action of the rule queued to run on the event
+type task struct {
+	rule	*Rule
+	ev		*Event
+	attempt	int
+}
+
+// Runner runs actions of matched rules one by one with limited rate, failed actions are retried later
+type Runner struct {
+	cfg		*Config
+	host	string	// name of the agent host passed to actions
+
+	queue	chan *task
+	ctx		context.Context
+	cancel	context.CancelFunc
+	wg		sync.WaitGroup
+
+	// Minimal interval between starts of actions and the last start time
+	interval	time.Duration
+	lastRun		time.Time
+}
+
+func NewRunner(cfg *Config, host string) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := &Runner{
+		cfg:	cfg,
+		host:	host,
+		queue:	make(chan *task, cfg.QueueSize),
+		ctx:	ctx,
+		cancel:	cancel,
+	}
+
+	if cfg.Rate > 0 {
+		r.interval = time.Duration(float64(time.Second) / cfg.Rate)
+	}
+
+	return r
+}
+
+// Run starts processing of queued actions
+func (r *Runner) Run() {
+	log.I("(Hooks) Started with %d hooks", len(r.cfg.Rules))
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		for {
+			select {
+			case t := <-r.queue:
+				if !r.wait() {
+					return
+				}
+				r.run(t)
+			case <-r.ctx.Done():
+				log.I("(Hooks) Stopped, %d queued actions were dropped", len(r.queue))
+				return
+			}
+		}
+	}()
+}
+
+// Stop terminates the running action, if any, and stops processing of the queue
+func (r *Runner) Stop() {
+	if r == nil {
+		return
+	}
+
+	r.cancel()
+	r.wg.Wait()
+}
+
+// Fire queues actions of all rules matched by the event. It does not block the caller,
+// if the queue is full the actions are dropped. It is safe to call Fire on nil runner
+func (r *Runner) Fire(ev *Event) {
+	if r == nil {
+		return
+	}
+
+	for _, rule := range r.cfg.Rules {
+		if !rule.Match(ev) {
+			continue
+		}
+
+		// Each action gets its own copy of the event with the name of the hook
+		evc := *ev
+		evc.Hook = rule.Name
+		evc.Host = r.host
+
+		r.push(&task{rule: rule, ev: &evc})
+	}
+}
+
+func (r *Runner) push(t *task) {
+	select {
+	case r.queue <- t:
+	default:
+		log.W("(Hooks) Queue of actions is full, hook %q on %s of %q dropped", t.rule.Name, t.ev.Kind, t.ev.FPath)
+	}
+}
+
+// wait waits until the next action can be started according to the rate limit,
+// it returns false if the runner was stopped during waiting
+func (r *Runner) wait() bool {
+	if delay := r.interval - time.Since(r.lastRun); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-r.ctx.Done():
+			return false
+		}
+	}
+
+	r.lastRun = time.Now()
+
+	return true
+}
+
+func (r *Runner) run(t *task) {
+	ctx, cancel := context.WithTimeout(r.ctx, time.Duration(t.rule.Timeout))
+	defer cancel()
+
+	err := t.rule.run(ctx, t.ev)
+	if err == nil {
+		log.D("(Hooks) Hook %q on %s of %q completed", t.rule.Name, t.ev.Kind, t.ev.FPath)
+		return
+	}
+
+	if r.ctx.Err() != nil {
+		// Runner was stopped, no retries
+		log.W("(Hooks) Hook %q on %s of %q interrupted: %v", t.rule.Name, t.ev.Kind, t.ev.FPath, err)
+		return
+	}
+
+	if t.attempt >= r.cfg.Retries {
+		log.E("(Hooks) Hook %q on %s of %q failed, no retries left: %v", t.rule.Name, t.ev.Kind, t.ev.FPath, err)
+		return
+	}
+
+	t.attempt++
+	log.W("(Hooks) Hook %q on %s of %q failed, retry %d of %d in %v: %v", t.rule.Name, t.ev.Kind, t.ev.FPath,
+		t.attempt, r.cfg.Retries, time.Duration(r.cfg.RetryDelay), err)
+
+	// Queue the action again after the delay without blocking the runner
+	time.AfterFunc(time.Duration(r.cfg.RetryDelay), func() {
+		if r.ctx.Err() == nil {
+			r.push(t)
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+// parseAVI extracts duration and resolution from the main AVI header
+func parseAVI(f *os.File) (*Info, error) {
+	// The main header is the first chunk of the "hdrl" list:
+	// "RIFF" size "AVI " "LIST" size "hdrl" "avih" size MainAVIHeader
+	const hdrSize = 32 + 40
+	buf := make([]byte, hdrSize)
+	if _, err := io.ReadFull(f, buf); err != nil {
+		return nil, errTruncated
+	}
+
+	if !bytes.Equal(buf[0:4], []byte("RIFF")) || !bytes.Equal(buf[8:12], []byte("AVI ")) ||
+		!bytes.Equal(buf[20:24], []byte("hdrl")) || !bytes.Equal(buf[24:28], []byte("avih")) {
+		return nil, errInvalid
+	}
+
+	// Fields of the MainAVIHeader used here
+	avih := buf[32:]
+	usPerFrame := uint64(binary.LittleEndian.Uint32(avih[0:]))
+	totalFrames := uint64(binary.LittleEndian.Uint32(avih[16:]))
+
+	return &Info{
+		Duration:	int64(usPerFrame * totalFrames / 1000),
+		Width:		int64(binary.LittleEndian.Uint32(avih[32:])),
+		Height:		int64(binary.LittleEndian.Uint32(avih[36:])),
+	}, nil
+}
@@ -0,0 +1,175 @@
+package media
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+const (
+	// Seconds between 1904-01-01 (epoch of ISO base media files) and 1970-01-01
+	bmffEpochDiff = 2082844800
+	// Maximum nesting level of boxes to walk
+	bmffMaxDepth = 4
+)
+
+// parseBMFF extracts capture time, duration and resolution of ISO base media files - MP4, QuickTime, 3GP
+func parseBMFF(f *os.File) (*Info, error) {
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{}
+	// Movie header is placed inside the "moov" box that can be at the end of the file
+	if err := walkBoxes(f, 0, st.Size(), 0, func(typ string, start, end int64) (bool, error) {
+		switch typ {
+		case "moov", "trak":
+			// Walk into container
+			return true, nil
+		case "mvhd":
+			return false, parseMvhd(f, start, end, info)
+		case "tkhd":
+			return false, parseTkhd(f, start, end, info)
+		default:
+			return false, nil
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	// OK
+	return info, nil
+}
+
+// walkBoxes calls handler for each box between start and end offsets, the handler
+// gets the box type and offsets of its content and returns true to walk into the box
+func walkBoxes(r io.ReaderAt, start, end int64, depth int,
+	handler func(typ string, start, end int64) (bool, error)) error {
+	if depth > bmffMaxDepth {
+		return errInvalid
+	}
+
+	// Box header: size (4), type (4) and optional 64-bit size (8)
+	const hdrSize, largeHdrSize = 8, 16
+	hdr := make([]byte, largeHdrSize)
+
+	for off := start; off + hdrSize <= end; {
+		if _, err := r.ReadAt(hdr[:hdrSize], off); err != nil {
+			return errTruncated
+		}
+
+		size := int64(binary.BigEndian.Uint32(hdr))
+		typ := string(hdr[4:hdrSize])
+		contStart := off + hdrSize
+
+		switch size {
+		case 0:
+			// Box extends to the end of the file
+			size = end - off
+		case 1:
+			if _, err := r.ReadAt(hdr[hdrSize:], off + hdrSize); err != nil {
+				return errTruncated
+			}
+			size = int64(binary.BigEndian.Uint64(hdr[hdrSize:]))
+			contStart = off + largeHdrSize
+		}
+
+		if size < contStart - off || off + size > end {
+			return errInvalid
+		}
+
+		walkIn, err := handler(typ, contStart, off + size)
+		if err != nil {
+			return err
+		}
+		if walkIn {
+			if err := walkBoxes(r, contStart, off + size, depth + 1, handler); err != nil {
+				return err
+			}
+		}
+
+		off += size
+	}
+
+	return nil
+}
+
+// readBox reads up to n bytes of the box content
+func readBox(r io.ReaderAt, start, end int64, n int) ([]byte, error) {
+	if end - start < int64(n) {
+		return nil, errTruncated
+	}
+
+	buf := make([]byte, n)
+	if _, err := r.ReadAt(buf, start); err != nil {
+		return nil, errTruncated
+	}
+
+	return buf, nil
+}
+
+// parseMvhd parses the movie header to get creation time and duration
+func parseMvhd(r io.ReaderAt, start, end int64, info *Info) error {
+	// Version 0: version+flags (4), creation (4), modification (4), timescale (4), duration (4)
+	// Version 1: version+flags (4), creation (8), modification (8), timescale (4), duration (8)
+	const v0Size, v1Size = 20, 32
+	buf, err := readBox(r, start, end, v0Size)
+	if err != nil {
+		return err
+	}
+
+	var created, timescale, duration uint64
+	if buf[0] == 1 {
+		if buf, err = readBox(r, start, end, v1Size); err != nil {
+			return err
+		}
+		created = binary.BigEndian.Uint64(buf[4:])
+		timescale = uint64(binary.BigEndian.Uint32(buf[20:]))
+		duration = binary.BigEndian.Uint64(buf[24:])
+	} else {
+		created = uint64(binary.BigEndian.Uint32(buf[4:]))
+		timescale = uint64(binary.BigEndian.Uint32(buf[12:]))
+		duration = uint64(binary.BigEndian.Uint32(buf[16:]))
+	}
+
+	// Creation time is often not set by encoders
+	if created > bmffEpochDiff {
+		info.Taken = int64(created - bmffEpochDiff)
+	}
+	if timescale != 0 {
+		info.Duration = int64(duration * 1000 / timescale)
+	}
+
+	// OK
+	return nil
+}
+
+// parseTkhd parses the track header to get resolution of the first video track
+func parseTkhd(r io.ReaderAt, start, end int64, info *Info) error {
+	if info.Width != 0 {
+		// Resolution already found
+		return nil
+	}
+
+	// Width and height (fixed-point 16.16) are placed at the end of the header,
+	// which size depends on the version: 84 bytes for version 0, 96 for version 1
+	const v0Size, v1Size = 84, 96
+	buf, err := readBox(r, start, end, v0Size)
+	if err != nil {
+		return err
+	}
+	if buf[0] == 1 {
+		if buf, err = readBox(r, start, end, v1Size); err != nil {
+			return err
+		}
+	}
+
+	// Audio tracks have zero resolution
+	dims := buf[len(buf) - 8:]
+	info.Width = int64(binary.BigEndian.Uint32(dims) >> 16)
+	info.Height = int64(binary.BigEndian.Uint32(dims[4:]) >> 16)
+
+	// OK
+	return nil
+}
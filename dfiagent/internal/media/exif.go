@@ -0,0 +1,361 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/r-che/dfi/types"
+)
+
+// JPEG markers
+const (
+	jpegMarkerPrefix	= 0xFF
+	jpegSOI				= 0xD8	// start of image
+	jpegSOS				= 0xDA	// start of scan - the image data follows
+	jpegEOI				= 0xD9	// end of image
+	jpegAPP1			= 0xE1	// application segment containing EXIF
+)
+
+// Header of the EXIF data in the APP1 segment
+var exifHeader = []byte("Exif\x00\x00")
+
+// TIFF tags used to extract metadata
+const (
+	tagImageWidth		= 0x0100
+	tagImageLength		= 0x0101
+	tagMake				= 0x010F
+	tagModel			= 0x0110
+	tagDateTime			= 0x0132
+	tagExifIFD			= 0x8769
+	tagGPSIFD			= 0x8825
+	tagDateTimeOriginal	= 0x9003
+	tagOffsetTimeOrig	= 0x9011
+	tagGPSLatRef		= 0x0001
+	tagGPSLat			= 0x0002
+	tagGPSLonRef		= 0x0003
+	tagGPSLon			= 0x0004
+)
+
+// TIFF field types
+const (
+	tiffASCII		= 2
+	tiffShort		= 3
+	tiffLong		= 4
+	tiffRational	= 5
+)
+
+const (
+	// Limits to protect against broken files
+	maxIFDEntries	= 1024
+	maxValueSize	= 64 * 1024
+	// Format of date/time values in EXIF
+	exifTimeFmt		= "2006:01:02 15:04:05"
+)
+
+// parseJPEG extracts EXIF metadata and resolution of JPEG image
+func parseJPEG(f *os.File) (*Info, error) {
+	exif, err := jpegExif(bufio.NewReader(f))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{}
+	if exif != nil {
+		if info, err = parseExif(bytes.NewReader(exif)); err != nil {
+			return nil, fmt.Errorf("cannot parse EXIF: %w", err)
+		}
+	}
+
+	// Get resolution using the standard decoder, need to read from the beginning
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	conf, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return nil, err
+	}
+	info.Width, info.Height = int64(conf.Width), int64(conf.Height)
+
+	// OK
+	return info, nil
+}
+
+// jpegExif returns the content of EXIF segment without the header or nil if EXIF is not found
+func jpegExif(r *bufio.Reader) ([]byte, error) {
+	marker := make([]byte, 2)
+	if _, err := io.ReadFull(r, marker); err != nil || marker[0] != jpegMarkerPrefix || marker[1] != jpegSOI {
+		return nil, errInvalid
+	}
+
+	for {
+		if _, err := io.ReadFull(r, marker); err != nil {
+			return nil, errTruncated
+		}
+		if marker[0] != jpegMarkerPrefix {
+			return nil, errInvalid
+		}
+		if marker[1] == jpegSOS || marker[1] == jpegEOI {
+			// No EXIF before the image data
+			return nil, nil
+		}
+
+		// Segment length includes the length field itself
+		var length uint16
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+			return nil, errTruncated
+		}
+		if length < 2 {
+			return nil, errInvalid
+		}
+		length -= 2
+
+		if marker[1] != jpegAPP1 {
+			// Skip segment
+			if _, err := r.Discard(int(length)); err != nil {
+				return nil, errTruncated
+			}
+			continue
+		}
+
+		segment := make([]byte, length)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return nil, errTruncated
+		}
+		// APP1 can be used also by XMP, check the header
+		if bytes.HasPrefix(segment, exifHeader) {
+			return segment[len(exifHeader):], nil
+		}
+	}
+}
+
+// parseTIFF extracts EXIF metadata and resolution of TIFF image
+func parseTIFF(f *os.File) (*Info, error) {
+	return parseExif(f)
+}
+
+// IFD entry without resolved value
+type ifdEntry struct {
+	typ		uint16
+	count	uint32
+	raw		[]byte	// value or offset of the value
+}
+
+// Reader of TIFF structures
+type tiffReader struct {
+	r	io.ReaderAt
+	bo	binary.ByteOrder
+}
+
+// parseExif parses TIFF structure of EXIF data provided by r
+func parseExif(r io.ReaderAt) (*Info, error) {
+	hdr := make([]byte, 8)
+	if _, err := r.ReadAt(hdr, 0); err != nil {
+		return nil, errTruncated
+	}
+
+	tr := &tiffReader{r: r}
+	switch string(hdr[:4]) {
+	case "II*\x00":
+		tr.bo = binary.LittleEndian
+	case "MM\x00*":
+		tr.bo = binary.BigEndian
+	default:
+		return nil, errInvalid
+	}
+
+	ifd0, err := tr.readIFD(tr.bo.Uint32(hdr[4:]))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{
+		Camera:	cameraName(tr.ascii(ifd0[tagMake]), tr.ascii(ifd0[tagModel])),
+		Width:	int64(tr.uintVal(ifd0[tagImageWidth])),
+		Height:	int64(tr.uintVal(ifd0[tagImageLength])),
+	}
+
+	// Capture time is placed in the EXIF sub-IFD, use modification time of the image as a fallback
+	dt, offset := tr.ascii(ifd0[tagDateTime]), ""
+	if e, ok := ifd0[tagExifIFD]; ok {
+		exifIFD, err := tr.readIFD(tr.uintVal(e))
+		if err != nil {
+			return nil, err
+		}
+		if dto := tr.ascii(exifIFD[tagDateTimeOriginal]); dto != "" {
+			dt, offset = dto, tr.ascii(exifIFD[tagOffsetTimeOrig])
+		}
+	}
+	info.Taken = exifTime(dt, offset)
+
+	if e, ok := ifd0[tagGPSIFD]; ok {
+		gpsIFD, err := tr.readIFD(tr.uintVal(e))
+		if err != nil {
+			return nil, err
+		}
+		info.GPS = tr.gps(gpsIFD)
+	}
+
+	// OK
+	return info, nil
+}
+
+func (tr *tiffReader) readIFD(offset uint32) (map[uint16]ifdEntry, error) {
+	buf := make([]byte, 2)
+	if _, err := tr.r.ReadAt(buf, int64(offset)); err != nil {
+		return nil, errTruncated
+	}
+	num := tr.bo.Uint16(buf)
+	if num > maxIFDEntries {
+		return nil, errInvalid
+	}
+
+	// Each entry: tag (2), type (2), count (4), value or offset (4)
+	const entrySize = 12
+	buf = make([]byte, int(num) * entrySize)
+	if _, err := tr.r.ReadAt(buf, int64(offset) + 2); err != nil {
+		return nil, errTruncated
+	}
+
+	entries := make(map[uint16]ifdEntry, num)
+	for i := 0; i < int(num); i++ {
+		e := buf[i * entrySize:(i + 1) * entrySize]
+		entries[tr.bo.Uint16(e)] = ifdEntry{
+			typ:	tr.bo.Uint16(e[2:]),
+			count:	tr.bo.Uint32(e[4:]),
+			raw:	e[8:],
+		}
+	}
+
+	return entries, nil
+}
+
+// value returns raw bytes of the entry value or nil if it cannot be read
+func (tr *tiffReader) value(e ifdEntry, elemSize int) []byte {
+	size := int(e.count) * elemSize
+	if size <= 0 || size > maxValueSize {
+		return nil
+	}
+	if size <= len(e.raw) {
+		// Value is placed inside the entry
+		return e.raw[:size]
+	}
+
+	buf := make([]byte, size)
+	if _, err := tr.r.ReadAt(buf, int64(tr.bo.Uint32(e.raw))); err != nil && !errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	return buf
+}
+
+func (tr *tiffReader) ascii(e ifdEntry) string {
+	if e.typ != tiffASCII {
+		return ""
+	}
+
+	return strings.TrimSpace(strings.TrimRight(string(tr.value(e, 1)), "\x00"))
+}
+
+func (tr *tiffReader) uintVal(e ifdEntry) uint32 {
+	switch e.typ {
+	case tiffShort:
+		return uint32(tr.bo.Uint16(e.raw))
+	case tiffLong:
+		return tr.bo.Uint32(e.raw)
+	default:
+		return 0
+	}
+}
+
+func (tr *tiffReader) rationals(e ifdEntry) []float64 {
+	if e.typ != tiffRational {
+		return nil
+	}
+
+	// Each rational consists of numerator (4) and denominator (4)
+	const ratSize = 8
+	val := tr.value(e, ratSize)
+	rv := make([]float64, 0, len(val) / ratSize)
+	for i := 0; i + ratSize <= len(val); i += ratSize {
+		num, den := tr.bo.Uint32(val[i:]), tr.bo.Uint32(val[i + 4:])
+		if den == 0 {
+			return nil
+		}
+		rv = append(rv, float64(num) / float64(den))
+	}
+
+	return rv
+}
+
+// gps returns coordinates from GPS IFD or nil if they are incomplete
+func (tr *tiffReader) gps(ifd map[uint16]ifdEntry) *types.GeoPoint {
+	lat, ok := dmsToDegrees(tr.rationals(ifd[tagGPSLat]), tr.ascii(ifd[tagGPSLatRef]), "S")
+	if !ok {
+		return nil
+	}
+	lon, ok := dmsToDegrees(tr.rationals(ifd[tagGPSLon]), tr.ascii(ifd[tagGPSLonRef]), "W")
+	if !ok {
+		return nil
+	}
+
+	return &types.GeoPoint{Lat: lat, Lon: lon}
+}
+
+// dmsToDegrees converts degrees, minutes and seconds to decimal degrees,
+// the value is negative if ref is equal to negRef (south or west)
+func dmsToDegrees(dms []float64, ref, negRef string) (float64, bool) {
+	//nolint:gomnd	// Degrees, minutes, seconds
+	if len(dms) != 3 || ref == "" {
+		return 0, false
+	}
+
+	deg := dms[0] + dms[1] / 60 + dms[2] / 3600
+	if ref == negRef {
+		deg = -deg
+	}
+
+	return deg, true
+}
+
+// exifTime converts EXIF date/time to Unix timestamp. EXIF values do not contain
+// a time zone, so if offset is not set, the local time zone of the agent is used
+func exifTime(dt, offset string) int64 {
+	if dt == "" {
+		return 0
+	}
+
+	var ts time.Time
+	var err error
+	if offset != "" {
+		ts, err = time.Parse(exifTimeFmt + "-07:00", dt + offset)
+	} else {
+		ts, err = time.ParseInLocation(exifTimeFmt, dt, time.Local)
+	}
+	if err != nil {
+		// Unset or broken value, e.g. "0000:00:00 00:00:00"
+		return 0
+	}
+
+	return ts.Unix()
+}
+
+// cameraName joins vendor and model, avoiding duplication of the vendor
+// because many vendors include their names in the model, like "Canon EOS 5D"
+func cameraName(vendor, model string) string {
+	switch {
+	case model == "":
+		return vendor
+	case vendor == "" || strings.HasPrefix(strings.ToLower(model), strings.ToLower(vendor)):
+		return model
+	default:
+		return vendor + " " + model
+	}
+}
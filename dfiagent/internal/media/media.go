@@ -0,0 +1,83 @@
+// Package media extracts metadata of photos and videos - capture time, camera,
+// location, duration and resolution. Only pure-Go parsers of the most common
+// formats are used, unsupported formats are silently skipped
+package media
+
+import (
+	"errors"
+	"image"
+	"os"
+
+	// Register decoders to get resolution of images
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/r-che/dfi/types"
+)
+
+// Metadata of the media file
+type Info struct {
+	Taken		int64		// Capture time, Unix timestamp
+	Camera		string		// Camera make and model
+	GPS			*types.GeoPoint
+	Duration	int64		// Duration in milliseconds
+	Width		int64
+	Height		int64
+}
+
+// Errors returned by parsers on invalid or truncated data
+var (
+	errInvalid = errors.New("invalid media data")
+	errTruncated = errors.New("truncated media data")
+)
+
+// Extract returns metadata of the media file placed by path. The content type
+// mime is used to select the parser, nil is returned if the type is not supported
+func Extract(path, mime string) (*Info, error) {
+	var parser func(*os.File) (*Info, error)
+
+	switch mime {
+	case "image/jpeg":
+		parser = parseJPEG
+	case "image/png", "image/gif":
+		parser = parseImage
+	case "image/tiff":
+		parser = parseTIFF
+	case "video/mp4", "video/quicktime", "video/3gpp", "video/3gpp2", "video/x-m4v":
+		parser = parseBMFF
+	case "video/avi":
+		parser = parseAVI
+	default:
+		// Unsupported type
+		return nil, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parser(f)
+}
+
+// Apply copies extracted metadata to the filesystem object
+func (i *Info) Apply(fso *types.FSObject) {
+	fso.Taken = i.Taken
+	fso.Camera = i.Camera
+	fso.GPS = i.GPS
+	fso.Duration = i.Duration
+	fso.Width = i.Width
+	fso.Height = i.Height
+}
+
+// parseImage returns only resolution of image formats that do not contain EXIF data
+func parseImage(f *os.File) (*Info, error) {
+	conf, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Info{Width: int64(conf.Width), Height: int64(conf.Height)}, nil
+}
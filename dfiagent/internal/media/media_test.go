@@ -0,0 +1,293 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Helpers to serialize integers, the binary.Append* functions require Go 1.19
+func appendLE16(b []byte, v uint16) []byte { return append(b, byte(v), byte(v >> 8)) }
+func appendLE32(b []byte, v uint32) []byte { return append(appendLE16(b, uint16(v)), byte(v >> 16), byte(v >> 24)) }
+func appendBE16(b []byte, v uint16) []byte { return append(b, byte(v >> 8), byte(v)) }
+func appendBE32(b []byte, v uint32) []byte { return appendBE16(appendBE16(b, uint16(v >> 16)), uint16(v)) }
+
+// TIFF tag used to build test data
+type testTag struct {
+	tag		uint16
+	typ		uint16
+	count	uint32
+	data	[]byte
+}
+
+func asciiTag(tag uint16, val string) testTag {
+	return testTag{tag, tiffASCII, uint32(len(val) + 1), append([]byte(val), 0)}
+}
+
+func longTag(tag uint16, val uint32) testTag {
+	return testTag{tag, tiffLong, 1, appendLE32(nil, val)}
+}
+
+func rationalsTag(tag uint16, vals ...uint32) testTag {
+	data := []byte{}
+	for _, v := range vals {
+		data = appendLE32(data, v)
+	}
+	return testTag{tag, tiffRational, uint32(len(vals) / 2), data}
+}
+
+// ifdSize returns the size of the serialized IFD including data placed outside of entries
+func ifdSize(tags []testTag) uint32 {
+	size := uint32(2 + 12 * len(tags) + 4)
+	for _, t := range tags {
+		if len(t.data) > 4 {
+			size += uint32(len(t.data))
+		}
+	}
+	return size
+}
+
+// tiffIFD serializes IFD placed at offset off in little-endian byte order
+func tiffIFD(off uint32, tags []testTag) []byte {
+	ifd := appendLE16(nil, uint16(len(tags)))
+	data := []byte{}
+	dataOff := off + uint32(2 + 12 * len(tags) + 4)
+
+	for _, t := range tags {
+		ifd = appendLE16(ifd, t.tag)
+		ifd = appendLE16(ifd, t.typ)
+		ifd = appendLE32(ifd, t.count)
+		if len(t.data) > 4 {
+			ifd = appendLE32(ifd, dataOff + uint32(len(data)))
+			data = append(data, t.data...)
+		} else {
+			ifd = append(ifd, append(t.data, make([]byte, 4 - len(t.data))...)...)
+		}
+	}
+
+	// No next IFD
+	ifd = appendLE32(ifd, 0)
+
+	return append(ifd, data...)
+}
+
+func testExif() []byte {
+	exifTags := []testTag{
+		asciiTag(tagDateTimeOriginal, "2023:07:15 10:20:30"),
+		asciiTag(tagOffsetTimeOrig, "+02:00"),
+	}
+	gpsTags := []testTag{
+		asciiTag(tagGPSLatRef, "N"),
+		rationalsTag(tagGPSLat, 55, 1, 45, 1, 2100, 100),
+		asciiTag(tagGPSLonRef, "W"),
+		rationalsTag(tagGPSLon, 37, 1, 30, 1, 0, 1),
+	}
+
+	const ifd0Off = 8
+	ifd0Tags := []testTag{
+		asciiTag(tagMake, "Canon"),
+		asciiTag(tagModel, "Canon EOS 5D"),
+		asciiTag(tagDateTime, "2023:08:01 00:00:00"),
+		longTag(tagExifIFD, 0),
+		longTag(tagGPSIFD, 0),
+	}
+	exifOff := ifd0Off + ifdSize(ifd0Tags)
+	gpsOff := exifOff + ifdSize(exifTags)
+	ifd0Tags[3] = longTag(tagExifIFD, exifOff)
+	ifd0Tags[4] = longTag(tagGPSIFD, gpsOff)
+
+	data := []byte("II*\x00")
+	data = appendLE32(data, ifd0Off)
+	data = append(data, tiffIFD(ifd0Off, ifd0Tags)...)
+	data = append(data, tiffIFD(exifOff, exifTags)...)
+
+	return append(data, tiffIFD(gpsOff, gpsTags)...)
+}
+
+func testJPEG() []byte {
+	exif := append([]byte("Exif\x00\x00"), testExif()...)
+
+	data := []byte{0xFF, jpegSOI}
+	// Some other application segment that should be skipped
+	data = append(data, 0xFF, 0xE0, 0x00, 0x04, 'J', 'F')
+	data = append(data, 0xFF, jpegAPP1)
+	data = appendBE16(data, uint16(len(exif) + 2))
+	data = append(data, exif...)
+	// Start of frame of grayscale image 32x16
+	data = append(data, 0xFF, 0xC0, 0x00, 0x0B, 0x08, 0x00, 0x10, 0x00, 0x20, 0x01, 0x01, 0x11, 0x00)
+
+	// Start of scan, the image data is not required to get the configuration
+	return append(data, 0xFF, jpegSOS, 0x00, 0x08, 0x01, 0x01, 0x00, 0x00, 0x3F, 0x00)
+}
+
+// bmffBox makes ISO base media box with type typ containing content
+func bmffBox(typ string, content ...[]byte) []byte {
+	c := bytes.Join(content, nil)
+	box := appendBE32(nil, uint32(8 + len(c)))
+	box = append(box, typ...)
+	return append(box, c...)
+}
+
+func testMP4(created int64) []byte {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[4:], uint32(created + bmffEpochDiff))
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)	// timescale
+	binary.BigEndian.PutUint32(mvhd[16:], 5500)	// duration
+
+	// Audio track without resolution
+	audio := make([]byte, 84)
+	// Video track, version 1
+	video := make([]byte, 96)
+	video[0] = 1
+	binary.BigEndian.PutUint32(video[88:], 1920 << 16)
+	binary.BigEndian.PutUint32(video[92:], 1080 << 16)
+
+	return bytes.Join([][]byte{
+		bmffBox("ftyp", []byte("isom\x00\x00\x02\x00isommp41")),
+		bmffBox("mdat", make([]byte, 64)),
+		bmffBox("moov",
+			bmffBox("mvhd", mvhd),
+			bmffBox("trak", bmffBox("tkhd", audio)),
+			bmffBox("trak", bmffBox("tkhd", video)),
+		),
+	}, nil)
+}
+
+func testAVI() []byte {
+	data := []byte("RIFF\x00\x00\x00\x00AVI LIST\x00\x00\x00\x00hdrlavih\x38\x00\x00\x00")
+	avih := make([]byte, 56)
+	binary.LittleEndian.PutUint32(avih[0:], 40000)	// 25 fps
+	binary.LittleEndian.PutUint32(avih[16:], 250)	// frames
+	binary.LittleEndian.PutUint32(avih[32:], 640)
+	binary.LittleEndian.PutUint32(avih[36:], 480)
+
+	return append(data, avih...)
+}
+
+func TestExtract(t *testing.T) {
+	created := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC).Unix()
+	tests := []struct {
+		name	string
+		mime	string
+		data	[]byte
+		want	*Info
+		wantErr	bool
+	} {
+		{
+			name:	"jpeg",
+			mime:	"image/jpeg",
+			data:	testJPEG(),
+			want:	&Info{
+				Taken:	time.Date(2023, 7, 15, 10, 20, 30, 0, time.FixedZone("", 2 * 3600)).Unix(),
+				Camera:	"Canon EOS 5D",
+				Width:	32,
+				Height:	16,
+			},
+		},
+		{
+			name:	"tiff",
+			mime:	"image/tiff",
+			data:	testExif(),
+			want:	&Info{
+				Taken:	time.Date(2023, 7, 15, 10, 20, 30, 0, time.FixedZone("", 2 * 3600)).Unix(),
+				Camera:	"Canon EOS 5D",
+			},
+		},
+		{
+			name:	"mp4",
+			mime:	"video/mp4",
+			data:	testMP4(created),
+			want:	&Info{Taken: created, Duration: 5500, Width: 1920, Height: 1080},
+		},
+		{
+			name:	"avi",
+			mime:	"video/avi",
+			data:	testAVI(),
+			want:	&Info{Duration: 10000, Width: 640, Height: 480},
+		},
+		{
+			name:	"unsupported",
+			mime:	"text/plain",
+			data:	[]byte("text"),
+		},
+		{
+			name:	"broken-jpeg",
+			mime:	"image/jpeg",
+			data:	testJPEG()[:20],
+			wantErr: true,
+		},
+		{
+			name:	"broken-mp4",
+			mime:	"video/mp4",
+			data:	append(bmffBox("moov"), 0xFF, 0xFF, 0xFF, 0xFF, 'm', 'v', 'h', 'd'),
+			wantErr: true,
+		},
+	}
+
+	dir := t.TempDir()
+	for _, test := range tests {
+		path := filepath.Join(dir, test.name)
+		if err := os.WriteFile(path, test.data, 0o600); err != nil {
+			t.Fatalf("cannot write test file: %v", err)
+		}
+
+		info, err := Extract(path, test.mime)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("[%s] Extract must fail, but it did not", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%s] Extract returned unexpected error: %v", test.name, err)
+			continue
+		}
+
+		if test.want == nil {
+			if info != nil {
+				t.Errorf("[%s] Extract returned %#v, want - nil", test.name, info)
+			}
+			continue
+		}
+
+		if info == nil {
+			t.Errorf("[%s] Extract returned nil, want - %#v", test.name, test.want)
+			continue
+		}
+
+		// Check coordinates separately
+		if test.name == "jpeg" || test.name == "tiff" {
+			if info.GPS == nil ||
+				math.Abs(info.GPS.Lat - 55.7558333) > 1e-6 || math.Abs(info.GPS.Lon + 37.5) > 1e-6 {
+				t.Errorf("[%s] Extract returned coordinates %#v, want - 55.7558333, -37.5", test.name, info.GPS)
+			}
+			info.GPS = nil
+		}
+
+		if *info != *test.want {
+			t.Errorf("[%s] Extract returned %#v, want - %#v", test.name, info, test.want)
+		}
+	}
+}
+
+func Test_cameraName(t *testing.T) {
+	tests := []struct {
+		vendor, model, want string
+	} {
+		{ "Canon", "Canon EOS 5D", "Canon EOS 5D" },
+		{ "NIKON CORPORATION", "NIKON D750", "NIKON CORPORATION NIKON D750" },
+		{ "Apple", "iPhone 12", "Apple iPhone 12" },
+		{ "", "iPhone 12", "iPhone 12" },
+		{ "Apple", "", "Apple" },
+	}
+
+	for _, test := range tests {
+		if name := cameraName(test.vendor, test.model); name != test.want {
+			t.Errorf("cameraName(%q, %q) returned %q, want - %q", test.vendor, test.model, name, test.want)
+		}
+	}
+}
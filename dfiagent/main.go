@@ -7,6 +7,8 @@ import (
 	"github.com/r-che/dfi/dfiagent/internal/cfg"
 	"github.com/r-che/dfi/dfiagent/internal/cleanup"
 	"github.com/r-che/dfi/dfiagent/internal/fswatcher"
+	"github.com/r-che/dfi/dfiagent/internal/hooks"
+	"github.com/r-che/dfi/types/dbms"
 
 	"github.com/r-che/log"
 )
@@ -48,13 +50,40 @@ func main() {
 	// Check if read-only mode is required
 	if c.DBReadOnly {
 		dbc.SetReadOnly(true)
+	} else if c.StateFile != "" {
+		// Check that the last batch of the previous run was committed
+		landed, err := dbc.CheckLastBatch(c.StateFile)
+		switch {
+		case err != nil:
+			log.E("Cannot check the last batch committed to the database: %v", err)
+		case !landed && !c.Reindex:
+			log.W("The last batch of the previous run was not committed to the database, reindexing will be performed")
+			c.Reindex = true
+		}
 	}
+	// Register this host in the database and update the information periodically
+	dbc.SetHostInfo(&dbms.HostInfo{
+		Host:		c.DBCfg.CliHost,
+		Version:	ProgVers,
+		IdxPaths:	c.IdxPaths,
+		CalcSums:	c.CalcSums,
+		MaxSumSize:	c.MaxSumSize,
+	}, c.HeartbeatPeriod)
+
 	// Run DB controller
 	dbc.Run()
 
 	// Create new watchers pool
 	wp := fswatcher.NewPool(c.IdxPaths, dbc.Channel(), c.FlushPeriod)
 
+	// Run hooks on filesystem events if configured
+	var hr *hooks.Runner
+	if c.Hooks != nil {
+		hr = hooks.NewRunner(c.Hooks, c.DBCfg.CliHost)
+		hr.Run()
+		wp.SetHooks(hr)
+	}
+
 	// Start watchers asynchronously to avoid delays in cleaning and
 	// signal processing if the configured directories contain many
 	// entries (files, dirs and so on) that can take a long time
@@ -75,7 +104,7 @@ func main() {
 	}
 
 	// Wait for external events (signals)
-	newSignalsHandler(dbc, wp).wait()
+	newSignalsHandler(dbc, wp, hr).wait()
 
 	// Finish, cleanup operations
 	log.I("%s %s finished normally", ProgNameLong, ProgVers)
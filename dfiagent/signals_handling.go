@@ -8,6 +8,7 @@ import (
 	"github.com/r-che/dfi/dbi"
 	"github.com/r-che/dfi/dfiagent/internal/cleanup"
 	"github.com/r-che/dfi/dfiagent/internal/fswatcher"
+	"github.com/r-che/dfi/dfiagent/internal/hooks"
 
 	"github.com/r-che/log"
 )
@@ -33,16 +34,18 @@ type signalsHandler struct {
 	// Pointers to controlled objects
 	dbc	*dbi.DBController
 	wp	*fswatcher.Pool
+	hr	*hooks.Runner	// nil if hooks are not configured
 
 	// Flags
 	reindexRun	bool
 	cleanupRun	bool
 }
 
-func newSignalsHandler(dbc *dbi.DBController, wp *fswatcher.Pool) *signalsHandler {
+func newSignalsHandler(dbc *dbi.DBController, wp *fswatcher.Pool, hr *hooks.Runner) *signalsHandler {
 	sh := signalsHandler{
 		dbc:	dbc,
 		wp:		wp,
+		hr:		hr,
 	}
 
 	sh.chStopApp = make(chan os.Signal, 1)		// Stop application
@@ -83,6 +86,9 @@ func (sh *signalsHandler) wait() {
 			// Stop all watchers
 			sh.wp.StopWatchers()
 
+			// Stop hooks, events of the last flush are already queued
+			sh.hr.Stop()
+
 			// Stop database controller
 			sh.dbc.Stop()
 
@@ -12,4 +12,6 @@ type SearchFlags struct {
 	OnlyTags	bool
 	OnlyDescr	bool
 	DeepSearch	bool
+	LiteralSP	bool	// Search phrases are matched as is instead of sets of words
+	RawQuery	bool	// Search phrases are queries in the native syntax of the DBMS
 }
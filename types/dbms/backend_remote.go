@@ -0,0 +1,4 @@
+//go:build dbi_remote
+package dbms
+
+const Backend = `Remote`
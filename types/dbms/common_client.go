@@ -2,6 +2,7 @@ package dbms
 
 import (
 	"context"
+	"time"
 
 	"github.com/r-che/log"
 )
@@ -40,11 +41,35 @@ func (cc *CommonClient) SetReadOnly(ro bool) {
 	cc.ReadOnly = true
 }
 
+// SetCliHost sets the client host on behalf of which operations are performed,
+// it allows a service to apply operations of multiple hosts by a single client
+func (cc *CommonClient) SetCliHost(host string) {
+	cc.Cfg.CliHost = host
+}
+
 func (cc *CommonClient) TermLong() {
 	log.W("(%sCli:TermLong) Terminating long operations...", Backend)
 	cc.TermLongVal++
 }
 
+// ChunkSize returns the configured maximum number of operations sent to DB at once or dflt if it is not set
+func (cc *CommonClient) ChunkSize(dflt int) int {
+	if cc.Cfg.ChunkSize <= 0 {
+		return dflt
+	}
+
+	return cc.Cfg.ChunkSize
+}
+
+// HistSince returns the Unix time of the oldest history record that should be kept at now, 0 - keep all records
+func (cc *CommonClient) HistSince(now int64) int64 {
+	if cc.Cfg.HistKeep <= 0 {
+		return 0
+	}
+
+	return now - int64(cc.Cfg.HistKeep / time.Second)
+}
+
 func (cc *CommonClient) Stop() {
 	cc.stop()
 }
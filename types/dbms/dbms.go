@@ -1,8 +1,11 @@
 package dbms
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/r-che/dfi/common/parse"
 	"github.com/r-che/dfi/types"
@@ -14,10 +17,16 @@ type ClientController interface {
 	UpdateObj(fso *types.FSObject) error
 	DeleteObj(fso *types.FSObject) error
 	DeleteFPathPref(fso *types.FSObject) (int64, error)
-	Commit() (updated, deleted int64, err error)
+	// Commit applies all queued operations atomically, non-zero seq is stored as the sequence number of the batch
+	Commit(seq int64) (updated, deleted int64, err error)
+	// LastBatchSeq returns the sequence number of the last batch committed by this client host, 0 if none
+	LastBatchSeq() (seq int64, err error)
+	// UpdateHost stores information about the client host, the number of objects is counted by the database
+	UpdateHost(hi *HostInfo) error
 
 	// Management methods
 	SetReadOnly(ro bool)
+	SetCliHost(host string)
 	TermLong()
 	Stop()
 }
@@ -27,14 +36,23 @@ type Client interface {
 	// Search methods
 	Query(qa *QueryArgs, retFields []string) (qr QueryResults, err error)
 	QueryAIIIds(qa *QueryArgs) (ids []string, err error)
+	QueryContentIds(qa *QueryArgs) (ids []string, err error)
 
 	// Get objects/identifiers without search
 	GetObjects(ids, retFields []string) (qr QueryResults, err error)
 	GetAIIs(ids, retFields  []string) (qr QueryResultsAII, err error)
 	GetAIIIds(withFields []string) (ids []string, err error)
+	GetHosts() (hosts []*HostInfo, err error)
+	GetHistory(ha *HistArgs) (hr HistResults, err error)
+
+	// Aggregation methods
+	DiskUsage(qa *QueryArgs, dua *DUArgs) (dur DUResults, err error)
 
 	// Modification of AII
 	ModifyAII(DBOperator, *AIIArgs, []string, bool) (tagsUpdated, descrsUpdated int64, err error)
+
+	// Subscribe calls handler for changes of objects committed by agents until ctx is done
+	Subscribe(ctx context.Context, handler ChangesHandler) error
 }
 
 // Standard database connection configuration
@@ -46,6 +64,13 @@ type DBConfig struct {
 	// Database specific information
 	ID			string			// Database identifier - name, number, etc...
 	PrivCfg		map[string]any	// Private configuration loaded from JSON
+
+	// Tuning of batch operations
+	ChunkSize	int				// Maximum number of operations sent to DB at once, 0 - use the backend default
+
+	// History of changes of objects
+	History		bool			// Record changes of objects committed by the client to the history
+	HistKeep	time.Duration	// Retention period of history records, 0 - keep forever
 }
 
 // Supported operators on database
@@ -73,6 +98,49 @@ type DBOperation struct {
 
 type DBChan chan []*DBOperation
 
+// ObjChange describes a change of the indexed object committed by an agent
+type ObjChange struct {
+	Op		DBOperator	// Update or Delete
+	ID		string
+	Host	string		// Host and path are empty if they are unknown to the backend
+	FPath	string
+}
+
+// ChangesHandler is called by Client.Subscribe() for each portion of committed changes,
+// the returned error stops the subscription
+type ChangesHandler func(changes []*ObjChange) error
+
+// Reasons of failures of operations, errors of clients wrap them to be distinguished from errors of the database
+var (
+	ErrInvalidArgs	= errors.New("invalid arguments")	// the operation cannot be performed with the arguments
+	ErrForbidden	= errors.New("permission denied")	// the operation is not permitted to the caller
+)
+
+// OpError describes a failure of a single queued database operation
+type OpError struct {
+	Op	DBOperator
+	Key	string	// key or identifier of the object on which the operation failed
+	Err	error
+}
+func (oe *OpError) Error() string {
+	return fmt.Sprintf("%v %s: %v", oe.Op, oe.Key, oe.Err)
+}
+func (oe *OpError) Unwrap() error {
+	return oe.Err
+}
+
+// OpErrors is returned by ClientController.Commit() if some of the queued operations
+// failed, but the rest of the batch was applied to the database successfully
+type OpErrors []*OpError
+func (oes OpErrors) Error() string {
+	msgs := make([]string, 0, len(oes))
+	for _, oe := range oes {
+		msgs = append(msgs, oe.Error())
+	}
+
+	return fmt.Sprintf("%d operation(s) failed: %s", len(oes), strings.Join(msgs, "; "))
+}
+
 // Additional information item (AII) arguments
 type AIIArgs struct {
 	Tags	[]string
@@ -139,6 +207,25 @@ const (
 	FieldSize = "size"		// Size of object in bytes, if applicable
 	FieldMTime = "mtime"	// Object modifications time
 	FieldChecksum = "csum"	// Message digest, if enabled by indexer settings
+	FieldUID = "uid"		// Owner user identifier
+	FieldGID = "gid"		// Owner group identifier
+	FieldUser = "user"		// Owner user name, if can be resolved on the agent side
+	FieldGroup = "group"	// Owner group name, if can be resolved on the agent side
+	FieldMode = "mode"		// Permission bits in Unix format
+	FieldCTime = "ctime"	// Inode change time
+	FieldInode = "inode"	// Inode number
+	FieldDevice = "dev"		// Device identifier, together with inode identifies the hard links group
+	FieldNLink = "nlink"	// Number of hard links
+	FieldMIME = "mime"		// Content type of regular file detected by the agent
+	FieldTaken = "taken"	// Capture time of photo or video
+	FieldCamera = "camera"	// Camera make and model
+	FieldLat = "lat"		// Latitude of location where the photo was taken
+	FieldLon = "lon"		// Longitude of location where the photo was taken
+	FieldDuration = "duration"	// Duration of video in milliseconds
+	FieldWidth = "width"	// Width of image or video in pixels
+	FieldHeight = "height"	// Height of image or video in pixels
+	FieldArchive = "archive"	// Found path of the archive containing the member
+	FieldLinkState = "lstate"	// State of the target of symbolic link
 )
 // UVObjFields returns user valuable object fields
 func UVObjFields() []string {
@@ -150,6 +237,25 @@ func UVObjFields() []string {
 		FieldSize,
 		FieldMTime,
 		FieldChecksum,
+		FieldUID,
+		FieldGID,
+		FieldUser,
+		FieldGroup,
+		FieldMode,
+		FieldCTime,
+		FieldInode,
+		FieldDevice,
+		FieldNLink,
+		FieldMIME,
+		FieldTaken,
+		FieldCamera,
+		FieldLat,
+		FieldLon,
+		FieldDuration,
+		FieldWidth,
+		FieldHeight,
+		FieldArchive,
+		FieldLinkState,
 	}
 }
 
@@ -166,6 +272,7 @@ const (
 	AIIAllTags		=	"ALL"
 	AIIDelDescr		=	"\u0000\u0000DELETE DESCRIPTION\u0000\u0000"
 )
+
 // UVAIIFields returns user valuable AII fields
 func UVAIIFields() []string {
 	return []string {
@@ -173,3 +280,25 @@ func UVAIIFields() []string {
 		AIIFieldDescr,
 	}
 }
+
+// Content fields, stored separately from objects with the same identifiers
+const (
+	ContentFieldText	=	"text"
+	ContentFieldTitle	=	"title"
+	ContentFieldAuthor	=	"author"
+	ContentFieldPages	=	"pages"
+)
+
+// Agent metadata fields, stored per client host
+const (
+	MetaFieldBatchSeq	=	"batchseq"	// Sequence number of the last committed batch
+	MetaFieldVersion	=	"version"	// Version of the agent
+	MetaFieldPaths		=	"paths"		// Indexing paths
+	MetaFieldCalcSums	=	"csums"		// Checksums of regular files are calculated
+	MetaFieldMaxSumSize	=	"maxcsumsize"	// Maximum size of file to calculate checksum
+	MetaFieldStarted	=	"started"	// Start time of the agent
+	MetaFieldHeartbeat	=	"heartbeat"	// Time of the last heartbeat
+	MetaFieldHBPeriod	=	"hbperiod"	// Period between heartbeats in seconds
+	MetaFieldLastFlush	=	"lastflush"	// Time of the last batch committed since start
+	MetaFieldObjects	=	"objects"	// Number of indexed objects
+)
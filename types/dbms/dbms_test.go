@@ -1,6 +1,8 @@
 package dbms
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
 	"sort"
 	"testing"
@@ -14,6 +16,25 @@ func TestUVObjFields(t *testing.T) {
 		FieldSize,
 		FieldMTime,
 		FieldChecksum,
+		FieldUID,
+		FieldGID,
+		FieldUser,
+		FieldGroup,
+		FieldMode,
+		FieldCTime,
+		FieldInode,
+		FieldDevice,
+		FieldNLink,
+		FieldMIME,
+		FieldTaken,
+		FieldCamera,
+		FieldLat,
+		FieldLon,
+		FieldDuration,
+		FieldWidth,
+		FieldHeight,
+		FieldArchive,
+		FieldLinkState,
 	}
 	// Sort it by real values
 	sort.Strings(want)
@@ -73,3 +94,30 @@ func Test_DBOperatorUnsupported(t *testing.T) {
 
 	str = op.String()
 }
+
+func TestOpErrors(t *testing.T) {
+	errBad := errors.New("bad value")
+	oes := OpErrors{
+		&OpError{Op: Update, Key: "obj:host:/path/to/file", Err: errBad},
+		&OpError{Op: Delete, Key: "obj:host:/path/to/dir", Err: errors.New("not permitted")},
+	}
+
+	want := `2 operation(s) failed: Update obj:host:/path/to/file: bad value;` +
+		` Delete obj:host:/path/to/dir: not permitted`
+	if str := oes.Error(); str != want {
+		t.Errorf("OpErrors returned error string %q, want - %q", str, want)
+	}
+
+	// Check that OpErrors can be extracted from wrapped error
+	var extracted OpErrors
+	if err := fmt.Errorf("commit failed: %w", oes); !errors.As(err, &extracted) {
+		t.Errorf("cannot extract OpErrors from wrapped error %v", err)
+	} else if len(extracted) != len(oes) {
+		t.Errorf("extracted OpErrors contains %d items, want - %d", len(extracted), len(oes))
+	}
+
+	// Check that the original error of the operation is accessible
+	if !errors.Is(oes[0], errBad) {
+		t.Errorf("OpError %v does not wrap the original error %v", oes[0], errBad)
+	}
+}
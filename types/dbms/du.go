@@ -0,0 +1,177 @@
+package dbms
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/r-che/dfi/types"
+)
+
+// Criteria of grouping objects by disk usage reports
+type DUGroupBy string
+const (
+	DUByPath	= DUGroupBy("path")
+	DUByHost	= DUGroupBy("host")
+	DUByType	= DUGroupBy("type")
+	DUByTag		= DUGroupBy("tag")
+)
+
+// DUGroups returns all supported grouping criteria of disk usage reports
+func DUGroups() []string {
+	return []string{string(DUByPath), string(DUByHost), string(DUByType), string(DUByTag)}
+}
+
+// ParseDUGroupBy returns grouping criterion named by val
+func ParseDUGroupBy(val string) (DUGroupBy, error) {
+	for _, g := range DUGroups() {
+		if val == g {
+			return DUGroupBy(g), nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported disk usage grouping %q, possible values: %s",
+		val, strings.Join(DUGroups(), ", "))
+}
+
+// Disk usage report arguments
+type DUArgs struct {
+	GroupBy	DUGroupBy
+	Depth	int		// Maximum number of path components of directories used as keys of groups by path, 0 - no limit
+}
+
+// Disk usage of a group of objects
+type DUItem struct {
+	Key		string	// Host:directory, host, type or tag, depending on the grouping criterion
+	Size	int64	// Total size of objects in bytes
+	Count	int64	// Number of objects, hard links to the same file are counted once
+}
+
+type DUResults []*DUItem
+
+// Sort sorts the results by size in descending order, groups with the same size are sorted by keys
+func (dur DUResults) Sort() {
+	sort.Slice(dur, func(i, j int) bool {
+		if dur[i].Size != dur[j].Size {
+			return dur[i].Size > dur[j].Size
+		}
+		return dur[i].Key < dur[j].Key
+	})
+}
+
+// DUPathPrefix returns the path of the directory containing fpath limited by depth path
+// components from the root, objects placed upper than depth are grouped by their directories
+func DUPathPrefix(fpath string, depth int) string {
+	dir := path.Dir(fpath)
+	if depth == 0 || dir == "/" {
+		return dir
+	}
+
+	parts := strings.Split(strings.TrimPrefix(dir, "/"), "/")
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+
+	return "/" + strings.Join(parts, "/")
+}
+
+// Object data required to aggregate disk usage
+type DUObject struct {
+	ID		string
+	Host	string
+	FPath	string
+	Type	string
+	Size	int64
+	Device	int64
+	Inode	int64
+	Tags	[]string
+}
+
+// Identifier of the physical file, the inode is zero if it is unknown
+type duPhysID struct {
+	host	string
+	id		string	// Object identifier, used only if inode is unknown
+	dev		int64
+	inode	int64
+}
+
+// DUAggregator aggregates disk usage of objects on the client side,
+// used by backends that cannot aggregate data on the server side
+type DUAggregator struct {
+	dua		*DUArgs
+	groups	map[string]*DUItem
+	seen	map[string]map[duPhysID]bool
+}
+
+func NewDUAggregator(dua *DUArgs) *DUAggregator {
+	return &DUAggregator{
+		dua:	dua,
+		groups:	map[string]*DUItem{},
+		seen:	map[string]map[duPhysID]bool{},
+	}
+}
+
+// Add adds the object to the groups it belongs to. Members of archives are skipped,
+// because their sizes are already taken into account by the sizes of the archives
+func (da *DUAggregator) Add(obj *DUObject) {
+	if obj.Type == types.ObjArcMember {
+		return
+	}
+
+	phys := duPhysID{host: obj.Host, dev: obj.Device, inode: obj.Inode}
+	if obj.Inode == 0 {
+		// Physical file is unknown, the object is counted as a unique file
+		phys = duPhysID{host: obj.Host, id: obj.ID}
+	}
+
+	for _, key := range da.keys(obj) {
+		if da.seen[key] == nil {
+			da.seen[key] = map[duPhysID]bool{}
+		}
+		if da.seen[key][phys] {
+			// Hard link to the file already counted in this group
+			continue
+		}
+		da.seen[key][phys] = true
+
+		item, ok := da.groups[key]
+		if !ok {
+			item = &DUItem{Key: key}
+			da.groups[key] = item
+		}
+		item.Size += obj.Size
+		item.Count++
+	}
+}
+
+// Results returns aggregated groups sorted by size
+func (da *DUAggregator) Results() DUResults {
+	dur := make(DUResults, 0, len(da.groups))
+	for _, item := range da.groups {
+		dur = append(dur, item)
+	}
+	dur.Sort()
+
+	return dur
+}
+
+// keys returns keys of groups the object belongs to
+func (da *DUAggregator) keys(obj *DUObject) []string {
+	switch da.dua.GroupBy {
+	case DUByPath:
+		return []string{obj.Host + ":" + DUPathPrefix(obj.FPath, da.dua.Depth)}
+	case DUByHost:
+		return []string{obj.Host}
+	case DUByType:
+		return []string{obj.Type}
+	case DUByTag:
+		if len(obj.Tags) == 0 {
+			// Untagged objects are grouped by the empty key
+			return []string{""}
+		}
+		return obj.Tags
+	default:
+		panic(fmt.Sprintf("unsupported disk usage grouping %q", da.dua.GroupBy))
+	}
+}
@@ -0,0 +1,122 @@
+package dbms
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/r-che/dfi/types"
+)
+
+func TestParseDUGroupBy(t *testing.T) {
+	for _, g := range DUGroups() {
+		got, err := ParseDUGroupBy(g)
+		if err != nil {
+			t.Errorf("%q - unexpected error: %v", g, err)
+			continue
+		}
+		if string(got) != g {
+			t.Errorf("%q - want %q, got %q", g, g, got)
+		}
+	}
+
+	if _, err := ParseDUGroupBy("owner"); err == nil {
+		t.Errorf("ParseDUGroupBy must fail on unsupported grouping, but it did not")
+	}
+}
+
+func TestDUPathPrefix(t *testing.T) {
+	tests := []struct {
+		fpath	string
+		depth	int
+		want	string
+	}{
+		{ "/data/photos/2020/img.jpg",	0,	"/data/photos/2020" },
+		{ "/data/photos/2020/img.jpg",	1,	"/data" },
+		{ "/data/photos/2020/img.jpg",	2,	"/data/photos" },
+		{ "/data/photos/2020/img.jpg",	3,	"/data/photos/2020" },
+		{ "/data/photos/2020/img.jpg",	5,	"/data/photos/2020" },
+		{ "/data/file",					2,	"/data" },
+		{ "/file",						2,	"/" },
+		{ "/file",						0,	"/" },
+	}
+
+	for i, test := range tests {
+		if got := DUPathPrefix(test.fpath, test.depth); got != test.want {
+			t.Errorf("[%d] DUPathPrefix(%q, %d) - want %q, got %q", i, test.fpath, test.depth, test.want, got)
+		}
+	}
+}
+
+func TestDUAggregator(t *testing.T) {
+	objs := []*DUObject{
+		{ ID: "1", Host: "h1", FPath: "/data/a/f1", Type: types.ObjRegular, Size: 100, Device: 1, Inode: 10,
+			Tags: []string{"work"} },
+		// Hard link to the f1
+		{ ID: "2", Host: "h1", FPath: "/data/b/f1", Type: types.ObjRegular, Size: 100, Device: 1, Inode: 10 },
+		// The same inode on the other device
+		{ ID: "3", Host: "h1", FPath: "/data/b/f2", Type: types.ObjRegular, Size: 50, Device: 2, Inode: 10,
+			Tags: []string{"work", "old"} },
+		// Inode is unknown
+		{ ID: "4", Host: "h2", FPath: "/data/a/f3", Type: types.ObjRegular, Size: 30 },
+		{ ID: "5", Host: "h2", FPath: "/data/a/f4", Type: types.ObjRegular, Size: 20 },
+		{ ID: "6", Host: "h2", FPath: "/data", Type: types.ObjDirectory, Size: 4096, Device: 1, Inode: 2 },
+		// Member of archive must be skipped
+		{ ID: "7", Host: "h2", FPath: "/data/a.zip!/big", Type: types.ObjArcMember, Size: 1000 },
+	}
+
+	tests := []struct {
+		dua		DUArgs
+		want	DUResults
+	}{
+		{ DUArgs{GroupBy: DUByHost}, DUResults{
+			{ "h2",		4146,	3 },
+			{ "h1",		150,	2 },
+		} },
+		{ DUArgs{GroupBy: DUByType}, DUResults{
+			{ types.ObjDirectory,	4096,	1 },
+			// Hard links in different groups are counted in each group
+			{ types.ObjRegular,		200,	4 },
+		} },
+		{ DUArgs{GroupBy: DUByPath, Depth: 1}, DUResults{
+			{ "h1:/data",	150,	2 },
+			{ "h2:/",		4096,	1 },
+			{ "h2:/data",	50,		2 },
+		} },
+		{ DUArgs{GroupBy: DUByPath}, DUResults{
+			{ "h2:/",		4096,	1 },
+			{ "h1:/data/a",	100,	1 },
+			{ "h1:/data/b",	150,	2 },
+			{ "h2:/data/a",	50,		2 },
+		} },
+		{ DUArgs{GroupBy: DUByTag}, DUResults{
+			{ "",		4246,	4 },
+			{ "work",	150,	2 },
+			{ "old",	50,		1 },
+		} },
+	}
+
+	for i, test := range tests {
+		da := NewDUAggregator(&test.dua)
+		for _, obj := range objs {
+			da.Add(obj)
+		}
+
+		// Sort expected results to not depend on the order of the test table
+		test.want.Sort()
+
+		if got := da.Results(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("[%d] %s - want %s, got %s", i, test.dua.GroupBy, duString(test.want), duString(got))
+		}
+	}
+}
+
+func duString(dur DUResults) string {
+	items := make([]string, 0, len(dur))
+	for _, item := range dur {
+		items = append(items, fmt.Sprintf("%+v", *item))
+	}
+
+	return strings.Join(items, " ")
+}
@@ -0,0 +1,516 @@
+package dbms
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/r-che/dfi/common/tools"
+	"github.com/r-che/dfi/types"
+)
+
+// Pseudo-fields of conditions of query expressions that are not fields of objects
+const (
+	ExprFieldPath		=	"path"		// found and real paths of objects
+	ExprFieldTarget		=	"target"	// symbolic links pointing to the path or into it
+	ExprFieldContent	=	"content"	// content of documents
+)
+
+// Types of nodes of query expressions
+type ExprOp int
+const (
+	ExprCond = ExprOp(iota)	// condition on the field
+	ExprAnd					// all operands are true
+	ExprOr					// any of operands is true
+	ExprNot					// the operand is false
+	ExprConst				// constant value
+)
+
+// Comparison operators of conditions
+type CmpOp int
+const (
+	CmpEq = CmpOp(iota)	// equal to any of values, for text fields - contains all words of any of phrases
+	CmpLt
+	CmpLe
+	CmpGt
+	CmpGe
+	CmpAllBits			// all bits of the value are set, only for permissions
+	CmpGlob				// matches the shell pattern, only for names and paths
+	CmpRegex			// matches the regular expression, only for names and paths
+)
+func (op CmpOp) String() string {
+	switch op {
+	case CmpEq:			return ":"
+	case CmpLt:			return "<"
+	case CmpLe:			return "<="
+	case CmpGt:			return ">"
+	case CmpGe:			return ">="
+	case CmpAllBits:	return ":-"
+	case CmpGlob:		return "~"
+	case CmpRegex:		return "=~"
+	default:
+		panic(fmt.Sprintf("Unsupported comparison operator %d", op))
+	}
+}
+
+// Expr is the node of the query expression tree. Nodes are plain structures
+// to pass expressions to the remote backend without additional encoding
+type Expr struct {
+	Op		ExprOp
+	Args	[]*Expr	// operands of AND, OR and NOT
+	Cond	*Cond	// condition, only for ExprCond
+	Val		bool	// value, only for ExprConst
+}
+
+// Cond is the condition on the field of objects
+type Cond struct {
+	Field	string		// field of objects or one of ExprField* pseudo-fields
+	Cmp		CmpOp
+	Ints	[]int64		// values of numeric fields
+	Strs	[]string	// values of string fields
+}
+
+func NewCondExpr(field string, cmp CmpOp, ints []int64, strs []string) *Expr {
+	return &Expr{Op: ExprCond, Cond: &Cond{Field: field, Cmp: cmp, Ints: ints, Strs: strs}}
+}
+
+func NewConstExpr(val bool) *Expr {
+	return &Expr{Op: ExprConst, Val: val}
+}
+
+// NewAndExpr joins non-nil expressions by AND, it returns nil if there are no expressions
+func NewAndExpr(exprs ...*Expr) *Expr {
+	return newJoinExpr(ExprAnd, exprs)
+}
+
+// NewOrExpr joins non-nil expressions by OR, it returns nil if there are no expressions
+func NewOrExpr(exprs ...*Expr) *Expr {
+	return newJoinExpr(ExprOr, exprs)
+}
+
+func newJoinExpr(op ExprOp, exprs []*Expr) *Expr {
+	args := make([]*Expr, 0, len(exprs))
+	for _, e := range exprs {
+		if e != nil {
+			args = append(args, e)
+		}
+	}
+
+	switch len(args) {
+	case 0:
+		return nil
+	case 1:
+		return args[0]
+	}
+
+	return &Expr{Op: op, Args: args}
+}
+
+func NewNotExpr(e *Expr) *Expr {
+	return &Expr{Op: ExprNot, Args: []*Expr{e}}
+}
+
+func (e *Expr) Clone() *Expr {
+	if e == nil {
+		return nil
+	}
+
+	rv := *e
+
+	if e.Args != nil {
+		rv.Args = make([]*Expr, len(e.Args))
+		for i, arg := range e.Args {
+			rv.Args[i] = arg.Clone()
+		}
+	}
+
+	if e.Cond != nil {
+		cond := *e.Cond
+		if e.Cond.Ints != nil {
+			cond.Ints = append([]int64{}, e.Cond.Ints...)
+		}
+		if e.Cond.Strs != nil {
+			cond.Strs = append([]string{}, e.Cond.Strs...)
+		}
+		rv.Cond = &cond
+	}
+
+	return &rv
+}
+
+// Walk calls fn for each condition of the expression, fn can replace the node
+// of the condition by other expression by returning it, nil keeps the node
+func (e *Expr) Walk(fn func(*Cond) (*Expr, error)) error {
+	if e == nil {
+		return nil
+	}
+
+	if e.Op == ExprCond {
+		repl, err := fn(e.Cond)
+		if err != nil {
+			return err
+		}
+		if repl != nil {
+			*e = *repl
+		}
+
+		// OK
+		return nil
+	}
+
+	for _, arg := range e.Args {
+		if err := arg.Walk(fn); err != nil {
+			return err
+		}
+	}
+
+	// OK
+	return nil
+}
+
+// Conjuncts returns operands of top-level ANDs of the expression, or the expression itself
+func (e *Expr) Conjuncts() []*Expr {
+	if e == nil {
+		return nil
+	}
+	if e.Op != ExprAnd {
+		return []*Expr{e}
+	}
+
+	rv := make([]*Expr, 0, len(e.Args))
+	for _, arg := range e.Args {
+		rv = append(rv, arg.Conjuncts()...)
+	}
+
+	return rv
+}
+
+// Contains reports whether the expression contains conditions matched by fn
+func (e *Expr) Contains(fn func(*Cond) bool) bool {
+	found := false
+	_ = e.Walk(func(c *Cond) (*Expr, error) {
+		found = found || fn(c)
+		return nil, nil
+	})
+
+	return found
+}
+
+// IsPattern reports whether the condition matches names or paths by shell patterns or regular expressions
+func (c *Cond) IsPattern() bool {
+	return c.Cmp == CmpGlob || c.Cmp == CmpRegex
+}
+
+// patternMatcher returns the function that reports whether the object with the found path fpath
+// is matched by the pattern condition, patterns of paths are matched to found paths of objects
+func (c *Cond) patternMatcher() (func(fpath string) bool, error) {
+	subject := func(fpath string) string { return fpath }
+	if c.Field == FieldName {
+		subject = path.Base
+	}
+
+	if c.Cmp == CmpGlob {
+		for _, pattern := range c.Strs {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid shell pattern %q: %w", pattern, err)
+			}
+		}
+
+		return func(fpath string) bool {
+			for _, pattern := range c.Strs {
+				if ok, _ := path.Match(pattern, subject(fpath)); ok {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+
+	res := make([]*regexp.Regexp, 0, len(c.Strs))
+	for _, expr := range c.Strs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", expr, err)
+		}
+		res = append(res, re)
+	}
+
+	return func(fpath string) bool {
+		for _, re := range res {
+			if re.MatchString(subject(fpath)) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// GlobRegexp converts the valid shell pattern to the anchored regular expression with the
+// same semantics as path.Match has - wildcards do not match the path separator
+func GlobRegexp(pattern string) string {
+	var re strings.Builder
+	re.WriteString(`^`)
+
+	inClass := false
+	for rs := []rune(pattern); len(rs) != 0; rs = rs[1:] {
+		switch r := rs[0]; {
+		case r == '\\' && len(rs) > 1:
+			// Escaped character is matched literally
+			rs = rs[1:]
+			re.WriteString(regexp.QuoteMeta(string(rs[0])))
+		case inClass && r == ']':
+			inClass = false
+			re.WriteRune(r)
+		case inClass && r == '-':
+			// Range of characters
+			re.WriteRune(r)
+		case inClass:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+		case r == '[':
+			inClass = true
+			re.WriteRune(r)
+			if len(rs) > 1 && rs[1] == '^' {
+				// Negated class
+				rs = rs[1:]
+				re.WriteRune('^')
+			}
+		case r == '*':
+			re.WriteString(`[^/]*`)
+		case r == '?':
+			re.WriteString(`[^/]`)
+		default:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	re.WriteString(`$`)
+
+	return re.String()
+}
+
+// PatternsMatcher returns the function that reports whether the object with the found path fpath is matched
+// by the expression that consists only of pattern conditions joined by logical operators. Regular expressions
+// are compiled once, so the function can be used to filter a large number of objects
+func (e *Expr) PatternsMatcher() (func(fpath string) bool, error) {
+	switch e.Op {
+	case ExprCond:
+		if !e.Cond.IsPattern() {
+			return nil, fmt.Errorf("condition %s is not a pattern condition", e.Cond)
+		}
+		return e.Cond.patternMatcher()
+
+	case ExprAnd, ExprOr, ExprNot:
+		matchers := make([]func(string) bool, 0, len(e.Args))
+		for _, arg := range e.Args {
+			m, err := arg.PatternsMatcher()
+			if err != nil {
+				return nil, err
+			}
+			matchers = append(matchers, m)
+		}
+
+		if e.Op == ExprNot {
+			return func(fpath string) bool { return !matchers[0](fpath) }, nil
+		}
+
+		// AND is true if no operands are false, OR is true if any of operands is true
+		stop := e.Op == ExprOr
+		return func(fpath string) bool {
+			for _, m := range matchers {
+				if m(fpath) == stop {
+					return stop
+				}
+			}
+			return !stop
+		}, nil
+
+	default:
+		return func(string) bool { return e.Val }, nil
+	}
+}
+
+// SplitPatterns splits the expression to the part without pattern conditions and the part that consists
+// only of pattern conditions, both parts are joined by AND. It is used by backends that cannot match names
+// and paths by shell patterns or regular expressions, so they are matched on the client side. If patterns
+// cannot be separated from other conditions, e.g. they are joined by OR, ok is false
+func (e *Expr) SplitPatterns() (rest, patterns *Expr, ok bool) {
+	onlyPatterns := func(x *Expr) bool { return !x.Contains(func(c *Cond) bool { return !c.IsPattern() }) }
+
+	var restArgs, patternArgs []*Expr
+	for _, arg := range e.Conjuncts() {
+		switch {
+		case !arg.Contains((*Cond).IsPattern):
+			restArgs = append(restArgs, arg)
+		case onlyPatterns(arg):
+			patternArgs = append(patternArgs, arg)
+		default:
+			return nil, nil, false
+		}
+	}
+
+	return NewAndExpr(restArgs...), NewAndExpr(patternArgs...), true
+}
+
+// CheckPatterns returns an error if pattern conditions of the expression cannot be separated from other
+// conditions by SplitPatterns. Such expressions are rejected by all backends, so the same queries are
+// supported regardless of whether the backend matches patterns itself or on the client side
+func (e *Expr) CheckPatterns() error {
+	if _, _, ok := e.SplitPatterns(); !ok {
+		return fmt.Errorf("%w: matching of names and paths by patterns can only be joined with other conditions by AND",
+			ErrInvalidArgs)
+	}
+
+	// OK
+	return nil
+}
+
+func (e *Expr) String() string {
+	if e == nil {
+		return ""
+	}
+
+	switch e.Op {
+	case ExprCond:
+		return e.Cond.String()
+	case ExprAnd, ExprOr:
+		sep := tools.Tern(e.Op == ExprAnd, " and ", " or ")
+		args := make([]string, 0, len(e.Args))
+		for _, arg := range e.Args {
+			args = append(args, arg.String())
+		}
+		return "(" + strings.Join(args, sep) + ")"
+	case ExprNot:
+		return "not " + e.Args[0].String()
+	case ExprConst:
+		return tools.Tern(e.Val, "true", "false")
+	default:
+		panic(fmt.Sprintf("Unsupported expression type %d", e.Op))
+	}
+}
+
+func (c *Cond) String() string {
+	vals := make([]string, 0, len(c.Ints) + len(c.Strs))
+	for _, v := range c.Ints {
+		vals = append(vals, strconv.FormatInt(v, tools.Tern(c.Field == FieldMode, 8, 10)))
+	}
+	for _, v := range c.Strs {
+		vals = append(vals, strconv.Quote(v))
+	}
+
+	return c.Field + c.Cmp.String() + strings.Join(vals, ",")
+}
+
+// ArgsExpr returns the expression made from conditions set by query arguments like mtime, type of object
+// and so on, joined according to the OrExpr and NegExpr flags. It returns nil if there are no conditions
+//
+//nolint:cyclop	// Each condition is simple, splitting does not make it clearer
+func (qa *QueryArgs) ArgsExpr() *Expr {
+	conds := []*Expr{}
+
+	if qa.IsMtime() {
+		conds = append(conds, setRangeExpr(FieldMTime, qa.MtimeStart, qa.MtimeEnd, qa.MtimeSet))
+	}
+	if qa.IsSize() {
+		conds = append(conds, setRangeExpr(FieldSize, qa.SizeStart, qa.SizeEnd, qa.SizeSet))
+	}
+	if qa.IsType() {
+		conds = append(conds, NewCondExpr(FieldType, CmpEq, nil, qa.Types))
+	}
+	if qa.IsChecksum() {
+		conds = append(conds, NewCondExpr(FieldChecksum, CmpEq, nil, qa.CSums))
+	}
+	if qa.IsHost() {
+		conds = append(conds, NewCondExpr(FieldHost, CmpEq, nil, qa.Hosts))
+	}
+	if qa.IsCtime() {
+		conds = append(conds, setRangeExpr(FieldCTime, qa.CtimeStart, qa.CtimeEnd, qa.CtimeSet))
+	}
+	if qa.IsNLink() {
+		conds = append(conds, setRangeExpr(FieldNLink, qa.NLinkStart, qa.NLinkEnd, qa.NLinkSet))
+	}
+	if qa.IsUID() {
+		conds = append(conds, NewCondExpr(FieldUID, CmpEq, qa.UIDs, nil))
+	}
+	if qa.IsGID() {
+		conds = append(conds, NewCondExpr(FieldGID, CmpEq, qa.GIDs, nil))
+	}
+	if qa.IsUser() {
+		conds = append(conds, NewCondExpr(FieldUser, CmpEq, nil, qa.Users))
+	}
+	if qa.IsGroup() {
+		conds = append(conds, NewCondExpr(FieldGroup, CmpEq, nil, qa.Groups))
+	}
+	if qa.IsPerm() {
+		conds = append(conds, NewCondExpr(FieldMode, tools.Tern(qa.PermMatch == PermAll, CmpAllBits, CmpEq),
+			[]int64{qa.Perm}, nil))
+	}
+	if qa.IsInode() {
+		conds = append(conds, NewCondExpr(FieldInode, CmpEq, qa.Inodes, nil))
+	}
+	if qa.IsMIME() {
+		conds = append(conds, NewCondExpr(FieldMIME, CmpEq, nil, qa.MIMEs))
+	}
+	if qa.IsTaken() {
+		conds = append(conds, setRangeExpr(FieldTaken, qa.TakenStart, qa.TakenEnd, qa.TakenSet))
+	}
+	if qa.IsCamera() {
+		conds = append(conds, NewCondExpr(FieldCamera, CmpEq, nil, qa.Cameras))
+	}
+	if qa.IsDangling() {
+		conds = append(conds, NewCondExpr(FieldLinkState, CmpEq, nil, []string{types.LinkDangling, types.LinkLoop}))
+	}
+	if qa.IsPointsTo() {
+		conds = append(conds, NewCondExpr(ExprFieldTarget, CmpEq, nil, []string{qa.PointsTo}))
+	}
+
+	if len(conds) == 0 {
+		return nil
+	}
+
+	// Conditions are always grouped to keep the structure of queries made by backends
+	expr := &Expr{Op: tools.Tern(qa.OrExpr, ExprOr, ExprAnd), Args: conds}
+	if qa.NegExpr {
+		return NewNotExpr(expr)
+	}
+
+	return expr
+}
+
+// PatternsExpr returns the expression made from patterns of paths and names set by query arguments,
+// it returns nil if there are no patterns. Patterns are filters, they are not affected by OrExpr and NegExpr
+func (qa *QueryArgs) PatternsExpr() *Expr {
+	var glob, regex *Expr
+	if qa.IsPathGlob() {
+		glob = NewCondExpr(ExprFieldPath, CmpGlob, nil, []string{qa.PathGlob})
+	}
+	if qa.IsNameRegex() {
+		regex = NewCondExpr(FieldName, CmpRegex, nil, []string{qa.NameRegex})
+	}
+
+	return NewAndExpr(glob, regex)
+}
+
+// FilterExpr returns the expression made from query arguments joined with patterns and the query expression by AND
+func (qa *QueryArgs) FilterExpr() *Expr {
+	return NewAndExpr(qa.ArgsExpr(), qa.PatternsExpr(), qa.Expr.Clone())
+}
+
+// setRangeExpr makes the expression to match values of the field by the set
+// of values or by the range, zero start or end of the range is not limited
+func setRangeExpr(field string, start, end int64, set []int64) *Expr {
+	if len(set) != 0 {
+		return NewCondExpr(field, CmpEq, set, nil)
+	}
+
+	var lo, hi *Expr
+	if start != 0 {
+		lo = NewCondExpr(field, CmpGe, []int64{start}, nil)
+	}
+	if end != 0 {
+		hi = NewCondExpr(field, CmpLe, []int64{end}, nil)
+	}
+
+	return NewAndExpr(lo, hi)
+}
@@ -0,0 +1,493 @@
+package dbms
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/r-che/dfi/common/parse"
+	"github.com/r-che/dfi/common/tools"
+	"github.com/r-che/dfi/types"
+)
+
+// Kinds of fields of query expressions, they define allowed operators and parsers of values
+type exprKind int
+const (
+	kindInt		= exprKind(iota)	// numeric values, sets and ranges like options of the dfi utility
+	kindTime						// the same as kindInt, values are timestamps
+	kindTag							// exact string values, comma-separated sets
+	kindText						// words of phrases, names and paths also can be matched by patterns
+	kindPerm						// permissions in the format of the --perm option
+	kindPath						// single absolute path
+	kindResolved					// matched by the separate search, resolved to identifiers of objects
+)
+
+type exprField struct {
+	field	string
+	kind	exprKind
+	parser	func(string) (int64, error)	// parser of numeric values
+	check	func([]string) error		// checker of string values
+	lower	bool						// values are stored in lower case
+}
+
+// Fields of query expressions by their names
+var exprFields = map[string]exprField{
+	"size":		{ field: FieldSize,		kind: kindInt,	parser: parseSize },
+	"mtime":	{ field: FieldMTime,	kind: kindTime,	parser: parseTime },
+	"ctime":	{ field: FieldCTime,	kind: kindTime,	parser: parseTime },
+	"taken":	{ field: FieldTaken,	kind: kindTime,	parser: parseTime },
+	"nlink":	{ field: FieldNLink,	kind: kindInt,	parser: parseInt },
+	"uid":		{ field: FieldUID,		kind: kindInt,	parser: parseInt },
+	"gid":		{ field: FieldGID,		kind: kindInt,	parser: parseInt },
+	"inode":	{ field: FieldInode,	kind: kindInt,	parser: parseInt },
+	"perm":		{ field: FieldMode,		kind: kindPerm },
+	"type":		{ field: FieldType,		kind: kindTag,	check: checkTypes },
+	"host":		{ field: FieldHost,		kind: kindTag,	lower: true },
+	"user":		{ field: FieldUser,		kind: kindTag },
+	"group":	{ field: FieldGroup,	kind: kindTag },
+	"csum":		{ field: FieldChecksum,	kind: kindTag },
+	"mime":		{ field: FieldMIME,		kind: kindTag,	check: checkMIMEs,	lower: true },
+	"id":		{ field: FieldID,		kind: kindTag },
+	"name":		{ field: FieldName,		kind: kindText },
+	"path":		{ field: ExprFieldPath,	kind: kindText },
+	"camera":	{ field: FieldCamera,	kind: kindText },
+	"target":	{ field: ExprFieldTarget,	kind: kindPath },
+	"tag":		{ field: AIIFieldTags,		kind: kindResolved },
+	"descr":	{ field: AIIFieldDescr,		kind: kindResolved },
+	"content":	{ field: ExprFieldContent,	kind: kindResolved },
+}
+
+// ExprFields returns sorted names of fields that can be used in query expressions
+func ExprFields() []string {
+	names := make([]string, 0, len(exprFields))
+	for name := range exprFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ParseExpr parses the query expression like:
+//
+//	size>1G and (mtime<2020-01-01 or tag:archive) and not host:nas2 and name~"*.iso"
+//
+// Conditions have the form FIELD OPERATOR VALUE, supported operators:
+//
+//	: or =   - equal to the value, the value can be a comma-separated set or a range like in options
+//	!=       - not equal to the value
+//	< <= > >= - comparison of numeric values, sizes and times
+//	~        - matching of names and paths by shell patterns
+//	=~       - matching of names and paths by regular expressions in the Go syntax
+//
+// Conditions are joined by "and", "or" and "not" operators and grouped by parentheses,
+// conditions without operators between them are joined by "and". Values containing white spaces,
+// parentheses or quotes should be enclosed in double quotes, quotes inside them are escaped by backslash
+func ParseExpr(s string) (*Expr, error) {
+	p := &exprParser{in: s}
+
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid query expression: %w", err)
+	}
+	if p.skipSpaces(); !p.eof() {
+		return nil, fmt.Errorf("invalid query expression: unexpected %q at position %d", p.rest(), p.pos)
+	}
+
+	// OK
+	return e, nil
+}
+
+// ParseExpr parses the query expression and sets it to the query arguments
+func (qa *QueryArgs) ParseExpr(val string) error {
+	e, err := ParseExpr(val)
+	if err != nil {
+		return err
+	}
+	if err := e.CheckPatterns(); err != nil {
+		return fmt.Errorf("invalid query expression: %w", err)
+	}
+
+	qa.Expr = e
+
+	// OK
+	return nil
+}
+
+type exprParser struct {
+	in	string
+	pos	int
+}
+
+func (p *exprParser) eof() bool {
+	return p.pos >= len(p.in)
+}
+
+func (p *exprParser) rest() string {
+	const maxRest = 16
+	if rest := p.in[p.pos:]; len(rest) > maxRest {
+		return rest[:maxRest] + "..."
+	}
+
+	return p.in[p.pos:]
+}
+
+func (p *exprParser) skipSpaces() {
+	for !p.eof() {
+		r, n := utf8.DecodeRuneInString(p.in[p.pos:])
+		if !unicode.IsSpace(r) {
+			return
+		}
+		p.pos += n
+	}
+}
+
+// keyword consumes the keyword if it is the next word of the input
+func (p *exprParser) keyword(kw string) bool {
+	p.skipSpaces()
+
+	end := p.pos + len(kw)
+	if end > len(p.in) || !strings.EqualFold(p.in[p.pos:end], kw) {
+		return false
+	}
+
+	// The keyword must be a separate word
+	if end < len(p.in) && isWordByte(p.in[end]) {
+		return false
+	}
+
+	p.pos = end
+	return true
+}
+
+func (p *exprParser) parseOr() (*Expr, error) {
+	args := []*Expr{}
+	for {
+		e, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, e)
+
+		if !p.keyword("or") {
+			return NewOrExpr(args...), nil
+		}
+	}
+}
+
+func (p *exprParser) parseAnd() (*Expr, error) {
+	args := []*Expr{}
+	for {
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, e)
+
+		// The "and" operator can be omitted, the end of the group or "or" finish the sequence
+		if p.keyword("and") {
+			continue
+		}
+		if p.skipSpaces(); p.eof() || p.in[p.pos] == ')' {
+			return NewAndExpr(args...), nil
+		}
+		if save := p.pos; p.keyword("or") {
+			p.pos = save
+			return NewAndExpr(args...), nil
+		}
+	}
+}
+
+func (p *exprParser) parseUnary() (*Expr, error) {
+	if p.keyword("not") {
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return NewNotExpr(e), nil
+	}
+
+	if p.skipSpaces(); p.eof() {
+		return nil, fmt.Errorf("unexpected end of expression, condition expected")
+	}
+
+	if p.in[p.pos] == '(' {
+		start := p.pos
+		p.pos++
+
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.skipSpaces(); p.eof() || p.in[p.pos] != ')' {
+			return nil, fmt.Errorf("unclosed parenthesis at position %d", start)
+		}
+		p.pos++
+
+		return e, nil
+	}
+
+	return p.parseCond()
+}
+
+func (p *exprParser) parseCond() (*Expr, error) {
+	start := p.pos
+
+	// Field name
+	for !p.eof() && isWordByte(p.in[p.pos]) {
+		p.pos++
+	}
+	name := strings.ToLower(p.in[start:p.pos])
+	if name == "" {
+		return nil, fmt.Errorf("field name expected at position %d, got %q", start, p.rest())
+	}
+	f, ok := exprFields[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q at position %d", name, start)
+	}
+
+	// Operator
+	op := ""
+	for _, v := range []string{"!=", "<=", ">=", "=~", ":", "=", "<", ">", "~"} {
+		if strings.HasPrefix(p.in[p.pos:], v) {
+			op = v
+			break
+		}
+	}
+	if op == "" {
+		return nil, fmt.Errorf("operator expected after field %q at position %d", name, p.pos)
+	}
+	p.pos += len(op)
+
+	// Value
+	val, err := p.parseValue()
+	if err != nil {
+		return nil, fmt.Errorf("invalid value of field %q: %w", name, err)
+	}
+
+	e, err := f.cond(op, val)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %s%s%q: %w", name, op, val, err)
+	}
+
+	// OK
+	return e, nil
+}
+
+// parseValue parses the quoted value or the sequence of characters up to a white space or a parenthesis
+func (p *exprParser) parseValue() (string, error) {
+	if p.eof() {
+		return "", fmt.Errorf("unexpected end of expression")
+	}
+
+	if p.in[p.pos] != '"' {
+		start := p.pos
+		for !p.eof() {
+			r, n := utf8.DecodeRuneInString(p.in[p.pos:])
+			if unicode.IsSpace(r) || r == '(' || r == ')' || r == '"' {
+				break
+			}
+			p.pos += n
+		}
+		if p.pos == start {
+			return "", fmt.Errorf("value expected at position %d", start)
+		}
+
+		return p.in[start:p.pos], nil
+	}
+
+	// Quoted value
+	start := p.pos
+	p.pos++
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.in[p.pos]
+		p.pos++
+
+		switch c {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if p.eof() {
+				return "", fmt.Errorf("unfinished escape sequence at position %d", p.pos - 1)
+			}
+			// Only quotes and backslashes are escaped, other sequences are kept, e.g. for shell patterns
+			if next := p.in[p.pos]; next == '"' || next == '\\' {
+				c = next
+				p.pos++
+			}
+		}
+		sb.WriteByte(c)
+	}
+
+	return "", fmt.Errorf("unclosed quote at position %d", start)
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_'
+}
+
+// cond makes the condition on the field by the operator and the value
+//
+//nolint:cyclop	// Checks of operators are simple, splitting them does not make it clearer
+func (f exprField) cond(op, val string) (*Expr, error) {
+	// Inequality is a negation of equality
+	if op == "!=" {
+		e, err := f.cond(":", val)
+		if err != nil {
+			return nil, err
+		}
+		return NewNotExpr(e), nil
+	}
+
+	cmp, ok := map[string]CmpOp{":": CmpEq, "=": CmpEq, "<": CmpLt, "<=": CmpLe,
+		">": CmpGt, ">=": CmpGe, "~": CmpGlob, "=~": CmpRegex}[op]
+	if !ok {
+		return nil, fmt.Errorf("unsupported operator %q", op)
+	}
+
+	switch f.kind {
+	case kindInt, kindTime:
+		if cmp == CmpGlob || cmp == CmpRegex {
+			break
+		}
+		return f.numCond(cmp, val)
+
+	case kindPerm:
+		if cmp != CmpEq {
+			break
+		}
+		var qa QueryArgs
+		if err := qa.ParsePerm(val); err != nil {
+			return nil, err
+		}
+		return NewCondExpr(f.field, tools.Tern(qa.PermMatch == PermAll, CmpAllBits, CmpEq), []int64{qa.Perm}, nil), nil
+
+	case kindTag:
+		if cmp != CmpEq {
+			break
+		}
+		return f.tagCond(val)
+
+	case kindText:
+		switch {
+		case cmp == CmpEq:
+			if strings.TrimSpace(val) == "" {
+				return nil, fmt.Errorf("empty phrase")
+			}
+			return NewCondExpr(f.field, CmpEq, nil, []string{strings.TrimSpace(val)}), nil
+		case cmp == CmpGlob && f.field != FieldCamera:
+			if _, err := path.Match(val, ""); err != nil {
+				return nil, fmt.Errorf("invalid shell pattern: %w", err)
+			}
+			return NewCondExpr(f.field, CmpGlob, nil, []string{val}), nil
+		case cmp == CmpRegex && f.field != FieldCamera:
+			if _, err := regexp.Compile(val); err != nil {
+				return nil, fmt.Errorf("invalid regular expression: %w", err)
+			}
+			return NewCondExpr(f.field, CmpRegex, nil, []string{val}), nil
+		}
+
+	case kindPath:
+		if cmp != CmpEq {
+			break
+		}
+		var qa QueryArgs
+		if err := qa.ParsePointsTo(val); err != nil {
+			return nil, err
+		}
+		return NewCondExpr(f.field, CmpEq, nil, []string{qa.PointsTo}), nil
+
+	case kindResolved:
+		if cmp != CmpEq {
+			break
+		}
+		if strings.TrimSpace(val) == "" {
+			return nil, fmt.Errorf("empty value")
+		}
+		return NewCondExpr(f.field, CmpEq, nil, []string{strings.TrimSpace(val)}), nil
+	}
+
+	return nil, fmt.Errorf("operator %q is not supported by the field", op)
+}
+
+// numCond makes the condition on the numeric field
+func (f exprField) numCond(cmp CmpOp, val string) (*Expr, error) {
+	if cmp == CmpEq {
+		// Sets and ranges in the same format as the options
+		var start, end int64
+		var set []int64
+		if err := parseIntsSetRange(f.field, val, f.parser, &start, &end, &set); err != nil {
+			return nil, err
+		}
+		// Objects without known capture time should not match the range open from the left
+		if f.field == FieldTaken && len(set) == 0 && start == 0 {
+			start = 1
+		}
+
+		return setRangeExpr(f.field, start, end, set), nil
+	}
+
+	v, err := f.parser(strings.TrimSpace(val))
+	if err != nil {
+		return nil, err
+	}
+	e := NewCondExpr(f.field, cmp, []int64{v}, nil)
+
+	// The same for comparisons
+	if f.field == FieldTaken && (cmp == CmpLt || cmp == CmpLe) {
+		return NewAndExpr(NewCondExpr(f.field, CmpGe, []int64{1}, nil), e), nil
+	}
+
+	return e, nil
+}
+
+// tagCond makes the condition on the field with exact string values
+func (f exprField) tagCond(val string) (*Expr, error) {
+	if f.lower {
+		val = strings.ToLower(val)
+	}
+
+	var vals []string
+	if err := parse.StringsSet(&vals, f.field, val); err != nil {
+		return nil, err
+	}
+	if f.check != nil {
+		if err := f.check(vals); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewCondExpr(f.field, CmpEq, nil, vals), nil
+}
+
+func checkTypes(vals []string) error {
+	allowed := types.ObjTypes()
+	for _, v := range vals {
+		if !inStrings(v, allowed) {
+			return fmt.Errorf("unknown type %q, allowed types: %s", v, strings.Join(allowed, ", "))
+		}
+	}
+
+	// OK
+	return nil
+}
+
+func checkMIMEs(vals []string) error {
+	var qa QueryArgs
+	return qa.ParseMIMEs(strings.Join(vals, ","))
+}
+
+func inStrings(v string, list []string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,354 @@
+package dbms
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestParseExpr(t *testing.T) {
+	tests := []struct { input, want string } {
+		{	// 0
+			input:	`size>1G and (mtime<2020-01-01 or tag:archive) and not host:nas2 and name~"*.iso"`,
+			want:	`(size>1073741824 and (mtime<1577836800 or tags:"archive") and not host:"nas2" and name~"*.iso")`,
+		},
+		{	// 1 - implicit AND, precedence of AND over OR
+			input:	`type:reg size<=10k or type:dir`,
+			want:	`((type:"reg" and size<=10240) or type:"dir")`,
+		},
+		{	// 2 - keywords and fields are case-insensitive
+			input:	`NOT Host=NAS1,nas2 AND UID:0`,
+			want:	`(not host:"nas1","nas2" and uid:0)`,
+		},
+		{	// 3 - ranges
+			input:	`size:1k..2k`,
+			want:	`(size>=1024 and size<=2048)`,
+		},
+		{	// 4 - not equal
+			input:	`user!=root`,
+			want:	`not user:"root"`,
+		},
+		{	// 5 - quoted values with escaped quotes and backslashes
+			input:	`camera:"Canon \"EOS\" \\ R"`,
+			want:	`camera:"Canon \"EOS\" \\ R"`,
+		},
+		{	// 6 - permissions
+			input:	`perm:-644 or perm:755`,
+			want:	`(mode:-644 or mode:755)`,
+		},
+		{	// 7 - values with colons
+			input:	`target:/usr/lib mtime>="2020-01-02 03:04:05"`,
+			want:	`(target:"/usr/lib" and mtime>=1577934245)`,
+		},
+		{	// 8 - capture time open from the left does not match unknown times
+			input:	`taken<2020-01-01`,
+			want:	`(taken>=1 and taken<1577836800)`,
+		},
+		{	// 9 - nested parentheses, MIME wildcards
+			input:	`((mime:image/*) or (not (path:"holiday photos")))`,
+			want:	`(mime:"image/*" or not path:"holiday photos")`,
+		},
+		{	// 10 - words that start with keywords are not keywords
+			input:	`name:order or name:notes`,
+			want:	`(name:"order" or name:"notes")`,
+		},
+		{	// 11 - regular expressions, only quotes and backslashes are unescaped
+			input:	`name=~"^IMG_\\d+\\.(jpe?g|png)$" or path=~/backup/\d`,
+			want:	`(name=~"^IMG_\\d+\\.(jpe?g|png)$" or path=~"/backup/\\d")`,
+		},
+	}
+
+	for i, test := range tests {
+		e, err := ParseExpr(test.input)
+		if err != nil {
+			t.Errorf("[%d] ParseExpr(%q) returned unexpected error: %v", i, test.input, err)
+			continue
+		}
+
+		if got := e.String(); got != test.want {
+			t.Errorf("[%d] ParseExpr(%q) - want %s, got %s", i, test.input, test.want, got)
+		}
+	}
+}
+
+func TestParseExprErrors(t *testing.T) {
+	tests := []struct { input, want string } {
+		{ ``, `unexpected end` },
+		{ `size`, `operator expected` },
+		{ `size>`, `unexpected end` },
+		{ `unknown:1`, `unknown field "unknown"` },
+		{ `size>abc`, `invalid size` },
+		{ `size~1`, `not supported` },
+		{ `host>a`, `not supported` },
+		{ `camera~"*canon*"`, `not supported` },
+		{ `type:file`, `unknown type` },
+		{ `mime:image`, `invalid MIME type` },
+		{ `name~"[a-"`, `invalid shell pattern` },
+		{ `name=~"(a"`, `invalid regular expression` },
+		{ `camera=~canon`, `not supported` },
+		{ `size=~1`, `not supported` },
+		{ `target:relative/path`, `must be absolute` },
+		{ `(size>1`, `unclosed parenthesis` },
+		{ `size>1)`, `unexpected ")"` },
+		{ `name:"unclosed`, `unclosed quote` },
+		{ `size>1 and`, `unexpected end` },
+		{ `size>1 or or size<2`, `unknown field "or"` },
+		{ `size>1 or !size<2`, `field name expected` },
+		{ `host:a,,b`, `empty host value` },
+		{ `tag:" "`, `empty value` },
+	}
+
+	for i, test := range tests {
+		e, err := ParseExpr(test.input)
+		switch {
+		case err == nil:
+			t.Errorf("[%d] ParseExpr(%q) returned no error, result: %s", i, test.input, e)
+		case !strings.Contains(err.Error(), test.want):
+			t.Errorf("[%d] ParseExpr(%q) - want error containing %q, got %q", i, test.input, test.want, err)
+		}
+	}
+}
+
+func TestArgsExpr(t *testing.T) {
+	qa := NewQueryArgs()
+	if e := qa.ArgsExpr(); e != nil {
+		t.Errorf("ArgsExpr of empty arguments returned %s, want nil", e)
+	}
+
+	if err := qa.ParseSizes("1k.."); err != nil {
+		t.Fatalf("ParseSizes failed: %v", err)
+	}
+	if err := qa.ParseHosts("nas1,nas2"); err != nil {
+		t.Fatalf("ParseHosts failed: %v", err)
+	}
+	if err := qa.ParsePerm("-600"); err != nil {
+		t.Fatalf("ParsePerm failed: %v", err)
+	}
+
+	tests := []struct {
+		or, not	bool
+		want	string
+	} {
+		{ want: `(size>=1024 and host:"nas1","nas2" and mode:-600)` },
+		{ or: true, want: `(size>=1024 or host:"nas1","nas2" or mode:-600)` },
+		{ not: true, want: `not (size>=1024 and host:"nas1","nas2" and mode:-600)` },
+		{ or: true, not: true, want: `not (size>=1024 or host:"nas1","nas2" or mode:-600)` },
+	}
+
+	for i, test := range tests {
+		qa.OrExpr, qa.NegExpr = test.or, test.not
+		if got := qa.ArgsExpr().String(); got != test.want {
+			t.Errorf("[%d] want %s, got %s", i, test.want, got)
+		}
+	}
+
+	// The query expression is joined by AND
+	qa.OrExpr, qa.NegExpr = false, false
+	var err error
+	if qa.Expr, err = ParseExpr(`type:reg or type:dir`); err != nil {
+		t.Fatalf("ParseExpr failed: %v", err)
+	}
+	want := `((size>=1024 and host:"nas1","nas2" and mode:-600) and (type:"reg" or type:"dir"))`
+	if got := qa.FilterExpr().String(); got != want {
+		t.Errorf("FilterExpr - want %s, got %s", want, got)
+	}
+
+	// Patterns are joined by AND regardless of OR-ing and negation of other conditions
+	qa.OrExpr, qa.NegExpr = true, true
+	qa.PathGlob, qa.NameRegex = `/data/*`, `^a`
+	want = `(not (size>=1024 or host:"nas1","nas2" or mode:-600) and (path~"/data/*" and name=~"^a") and ` +
+		`(type:"reg" or type:"dir"))`
+	if got := qa.FilterExpr().String(); got != want {
+		t.Errorf("FilterExpr with patterns - want %s, got %s", want, got)
+	}
+}
+
+func TestSplitPatterns(t *testing.T) {
+	tests := []struct {
+		input			string
+		rest, patterns	string
+		ok				bool
+	} {
+		{ `size>1 and name~"*.iso"`, `size>1`, `name~"*.iso"`, true },
+		{ `size>1 and not (name~"*.tmp" or path~"/tmp/*") and type:reg`,
+			`(size>1 and type:"reg")`, `not (name~"*.tmp" or path~"/tmp/*")`, true },
+		{ `size>1 and (type:dir and name~"a*")`, `(size>1 and type:"dir")`, `name~"a*"`, true },
+		{ `size>1`, `size>1`, ``, true },
+		{ `name~"*.iso"`, ``, `name~"*.iso"`, true },
+		{ `size>1 and name=~"^a" and path~"/data/*"`, `size>1`, `(name=~"^a" and path~"/data/*")`, true },
+		{ `size>1 or name=~"^a"`, ``, ``, false },
+		{ `size>1 or name~"*.iso"`, ``, ``, false },
+		{ `not (size>1 and name~"*.iso")`, ``, ``, false },
+	}
+
+	for i, test := range tests {
+		e, err := ParseExpr(test.input)
+		if err != nil {
+			t.Fatalf("[%d] ParseExpr(%q) failed: %v", i, test.input, err)
+		}
+
+		rest, patterns, ok := e.SplitPatterns()
+		if ok != test.ok {
+			t.Errorf("[%d] SplitPatterns(%s) - want ok %t, got %t", i, e, test.ok, ok)
+			continue
+		}
+		if rest.String() != test.rest || patterns.String() != test.patterns {
+			t.Errorf("[%d] SplitPatterns(%s) - want %q, %q, got %q, %q",
+				i, e, test.rest, test.patterns, rest, patterns)
+		}
+	}
+}
+
+func TestPatternsMatcher(t *testing.T) {
+	e, err := ParseExpr(`name~"*.iso" and not path~"/tmp/*" and (name=~"^[a-z]+\\." or path=~"/iso/")`)
+	if err != nil {
+		t.Fatalf("ParseExpr failed: %v", err)
+	}
+
+	match, err := e.PatternsMatcher()
+	if err != nil {
+		t.Fatalf("PatternsMatcher failed: %v", err)
+	}
+
+	for fpath, want := range map[string]bool{
+		"/data/image.iso":	true,
+		"/data/image.img":	false,
+		"/tmp/image.iso":	false,
+		// The asterisk does not match the path separator
+		"/tmp/dir/image.iso":	true,
+		// The regular expression of names is not matched to directories
+		"/data/image/1.iso":	false,
+		"/iso/1.iso":			true,
+	} {
+		if got := match(fpath); got != want {
+			t.Errorf("PatternsMatcher()(%q) - want %t, got %t", fpath, want, got)
+		}
+	}
+
+	if _, err := NewCondExpr(FieldSize, CmpGt, []int64{1}, nil).PatternsMatcher(); err == nil {
+		t.Errorf("PatternsMatcher returned no error for the condition without patterns")
+	}
+}
+
+func TestExprWalk(t *testing.T) {
+	e, err := ParseExpr(`tag:archive or (size>1 and descr:"old backup")`)
+	if err != nil {
+		t.Fatalf("ParseExpr failed: %v", err)
+	}
+
+	// Replace conditions on AII by identifiers
+	err = e.Walk(func(c *Cond) (*Expr, error) {
+		switch c.Field {
+		case AIIFieldTags:
+			return NewCondExpr(FieldID, CmpEq, nil, []string{"id1", "id2"}), nil
+		case AIIFieldDescr:
+			return NewConstExpr(false), nil
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("Walk returned unexpected error: %v", err)
+	}
+
+	want := `(id:"id1","id2" or (size>1 and false))`
+	if got := e.String(); got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+}
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct {
+		pattern	string
+		match	[]string
+		noMatch	[]string
+	} {
+		{ `*.iso`, []string{`a.iso`, `.iso`}, []string{`a/b.iso`, `a.isoo`, `aXiso`} },
+		{ `/data/?/*`, []string{`/data/a/b`}, []string{`/data/ab/c`, `/data/a/b/c`} },
+		{ `[a-c]x[^0-9]`, []string{`axy`, `cx/`}, []string{`dxy`, `ax1`} },
+		{ `a\*b(c)+`, []string{`a*b(c)+`}, []string{`aXb(c)+`, `a*bcc`} },
+		{ `[\]]`, []string{`]`}, []string{`\`} },
+	}
+
+	for i, test := range tests {
+		re, err := regexp.Compile(GlobRegexp(test.pattern))
+		if err != nil {
+			t.Errorf("[%d] GlobRegexp(%q) returned invalid regular expression: %v", i, test.pattern, err)
+			continue
+		}
+
+		check := func(s string, want bool) {
+			// Results must be the same as path.Match returns
+			if ok, _ := path.Match(test.pattern, s); ok != want {
+				t.Fatalf("[%d] invalid test: path.Match(%q, %q) returned %t", i, test.pattern, s, ok)
+			}
+			if got := re.MatchString(s); got != want {
+				t.Errorf("[%d] %q (%s) matching %q - want %t, got %t", i, test.pattern, re, s, want, got)
+			}
+		}
+		for _, s := range test.match {
+			check(s, true)
+		}
+		for _, s := range test.noMatch {
+			check(s, false)
+		}
+	}
+}
+
+func FuzzParseExpr(f *testing.F) {
+	for _, seed := range []string{
+		`x`, `snow ball`, `a) or (b`, `x"y`, `\`, `\"`, `" or size>1`, `*.iso`, `\*`, "tab\tnew\nline", "\xff",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, val string) {
+		// Escape quotes and backslashes of the value to put it in the quoted value
+		quoted := `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(val) + `"`
+
+		e, err := ParseExpr(`camera:` + quoted + ` or not size>1`)
+		if strings.TrimSpace(val) == "" {
+			if err == nil {
+				t.Errorf("empty value %q was accepted", val)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("cannot parse expression with value %q: %v", val, err)
+		}
+
+		// Value must not change the structure of the expression
+		if e.Op != ExprOr || len(e.Args) != 2 || e.Args[0].Op != ExprCond || e.Args[1].Op != ExprNot {
+			t.Fatalf("value %q changed the structure of the expression: %s", val, e)
+		}
+		if c := e.Args[0].Cond; c.Field != FieldCamera || len(c.Strs) != 1 || c.Strs[0] != strings.TrimSpace(val) {
+			t.Errorf("value %q is parsed as %s", val, c)
+		}
+	})
+}
+
+func TestParseExprPatterns(t *testing.T) {
+	tests := []struct {
+		input	string
+		ok		bool
+	} {
+		{ `size>1 and name~"*.iso"`, true },
+		{ `not (name~"*.tmp" or path~"/tmp/*") and type:reg`, true },
+		{ `name~"*.iso" or name=~"^a"`, true },
+		// Patterns cannot be separated from other conditions
+		{ `size>1 or name~"*.iso"`, false },
+		{ `not (size>1 and name~"*.iso")`, false },
+		{ `type:dir or (size>1 and path~"/data/*")`, false },
+	}
+
+	for i, test := range tests {
+		qa := NewQueryArgs()
+		err := qa.ParseExpr(test.input)
+		if test.ok && err != nil {
+			t.Errorf("[%d] ParseExpr(%q) returned unexpected error: %v", i, test.input, err)
+		}
+		if !test.ok && (err == nil || !strings.Contains(err.Error(), "only be joined with other conditions by AND")) {
+			t.Errorf("[%d] ParseExpr(%q) - want error of joined patterns, got %v", i, test.input, err)
+		}
+	}
+}
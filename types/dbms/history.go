@@ -0,0 +1,153 @@
+package dbms
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of changes recorded to the history of objects
+const (
+	HistCreated		=	"created"	// object was added to the index
+	HistModified	=	"modified"	// indexed object was updated
+	HistDeleted		=	"deleted"	// object was removed from the index
+)
+
+// History record fields, the host and the found path use the same names as object fields
+const (
+	HistFieldTime		=	"time"		// Time of the change
+	HistFieldChange		=	"change"	// Kind of the change
+	HistFieldOID		=	"oid"		// Identifier of the changed object
+	HistFieldHost		=	FieldHost
+	HistFieldFPath		=	FieldFPath
+	HistFieldOldSize	=	"osize"		// Size of the object before the change
+	HistFieldNewSize	=	"nsize"		// Size of the object after the change
+	HistFieldOldCsum	=	"ocsum"		// Checksum of the object before the change
+	HistFieldNewCsum	=	"ncsum"		// Checksum of the object after the change
+)
+
+// HistRecord describes a single change of the indexed object committed by the agent
+type HistRecord struct {
+	Time		int64	// Unix time of the commit of the change
+	Change		string	// One of Hist* kinds of changes
+	ID			string	// Identifier of the object
+	Host		string
+	FPath		string
+	OldSize		int64	// Values before the change, not set for created objects
+	OldChecksum	string
+	NewSize		int64	// Values after the change, not set for deleted objects
+	NewChecksum	string
+}
+
+// HistResults is a list of history records
+type HistResults []*HistRecord
+
+// Sort sorts records by time, records of the same time are sorted by host and path
+func (hr HistResults) Sort() {
+	sort.SliceStable(hr, func(i, j int) bool {
+		if hr[i].Time != hr[j].Time {
+			return hr[i].Time < hr[j].Time
+		}
+		if hr[i].Host != hr[j].Host {
+			return hr[i].Host < hr[j].Host
+		}
+		return hr[i].FPath < hr[j].FPath
+	})
+}
+
+// HistArgs - history query arguments, empty sets match any values
+type HistArgs struct {
+	Ids		[]string	// identifiers of objects
+	Paths	[]string	// found paths of objects
+	Hosts	[]string
+	Since	int64		// Unix time of the oldest change, 0 - from the oldest record
+}
+
+func (ha *HistArgs) IsIds() bool {
+	return len(ha.Ids) != 0
+}
+
+func (ha *HistArgs) IsPaths() bool {
+	return len(ha.Paths) != 0
+}
+
+func (ha *HistArgs) IsHosts() bool {
+	return len(ha.Hosts) != 0
+}
+
+// Match returns true if the record matches the arguments, the record matches
+// identifiers and paths if any of them is matched. It is used by backends
+// that cannot select records by the arguments on the database side
+func (ha *HistArgs) Match(hr *HistRecord) bool {
+	if hr.Time < ha.Since {
+		return false
+	}
+
+	if ha.IsHosts() && !inList(ha.Hosts, hr.Host) {
+		return false
+	}
+
+	if !ha.IsIds() && !ha.IsPaths() {
+		// Records of any objects are matched
+		return true
+	}
+
+	return inList(ha.Ids, hr.ID) || inList(ha.Paths, hr.FPath)
+}
+
+func inList(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Units of the age accepted by ParseSince in addition to units of time.ParseDuration
+var sinceUnits = map[string]time.Duration{
+	"d":	24 * time.Hour,
+	"w":	7 * 24 * time.Hour,
+}
+
+// ParseSince converts the start of the history period to Unix time, the value can be
+// either the age like 2d, 1w, 12h, 30m relative to the now or the timestamp
+// in one of formats returned by TSFormats()
+func ParseSince(val string, now time.Time) (int64, error) {
+	val = strings.TrimSpace(val)
+
+	if val == "" {
+		return 0, fmt.Errorf("empty history period")
+	}
+
+	// Check for the age in days or weeks, that are not supported by time.ParseDuration
+	if mult, ok := sinceUnits[val[len(val)-1:]]; ok {
+		n, err := strconv.ParseUint(val[:len(val)-1], 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid history period %q - %w", val, err)
+		}
+
+		return now.Add(-time.Duration(n) * mult).Unix(), nil
+	}
+
+	// Check for the age in standard units, the plain number is treated as timestamp below
+	if _, err := strconv.ParseInt(val, 10, 64); err != nil {
+		if age, err := time.ParseDuration(val); err == nil {
+			if age < 0 {
+				return 0, fmt.Errorf("invalid history period %q - age cannot be negative", val)
+			}
+
+			return now.Add(-age).Unix(), nil
+		}
+	}
+
+	ts, err := parseTime(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid history period %q - neither age nor timestamp", val)
+	}
+
+	return ts, nil
+}
@@ -0,0 +1,89 @@
+package dbms
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		val		string
+		want	int64
+	}{
+		{ "2d",					1_700_000_000 - 2 * 86400 },
+		{ "1w",					1_700_000_000 - 7 * 86400 },
+		{ "0d",					1_700_000_000 },
+		{ "12h",				1_700_000_000 - 12 * 3600 },
+		{ "1h30m",				1_700_000_000 - 5400 },
+		{ " 30s ",				1_700_000_000 - 30 },
+		{ "1699000000",			1_699_000_000 },
+		{ "2023-11-01 UTC",		1_698_796_800 },
+	}
+
+	for i, test := range tests {
+		got, err := ParseSince(test.val, now)
+		if err != nil {
+			t.Errorf("[%d] %q - unexpected error: %v", i, test.val, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("[%d] %q - want %d, got %d", i, test.val, test.want, got)
+		}
+	}
+
+	for _, val := range []string{ "", "d", "-2d", "2.5d", "-1h", "yesterday" } {
+		if _, err := ParseSince(val, now); err == nil {
+			t.Errorf("ParseSince must fail on %q, but it did not", val)
+		}
+	}
+}
+
+func TestHistArgsMatch(t *testing.T) {
+	rec := &HistRecord{Time: 100, Change: HistDeleted, ID: "id1", Host: "h1", FPath: "/data/f1"}
+
+	tests := []struct {
+		ha		HistArgs
+		want	bool
+	}{
+		{ HistArgs{},											true },
+		{ HistArgs{Since: 100},									true },
+		{ HistArgs{Since: 101},									false },
+		{ HistArgs{Hosts: []string{"h2", "h1"}},				true },
+		{ HistArgs{Hosts: []string{"h2"}},						false },
+		{ HistArgs{Ids: []string{"id1"}},						true },
+		{ HistArgs{Ids: []string{"id2"}},						false },
+		{ HistArgs{Paths: []string{"/data/f1"}},				true },
+		{ HistArgs{Paths: []string{"/data"}},					false },
+		// Any of identifiers or paths is matched
+		{ HistArgs{Ids: []string{"id2"}, Paths: []string{"/data/f1"}},	true },
+		{ HistArgs{Ids: []string{"id1"}, Paths: []string{"/data/f2"}},	true },
+		// Hosts are checked regardless of identifiers and paths
+		{ HistArgs{Ids: []string{"id1"}, Hosts: []string{"h2"}},		false },
+	}
+
+	for i, test := range tests {
+		if got := test.ha.Match(rec); got != test.want {
+			t.Errorf("[%d] %+v - want %t, got %t", i, test.ha, test.want, got)
+		}
+	}
+}
+
+func TestHistResultsSort(t *testing.T) {
+	hr := HistResults{
+		{ Time: 200, Host: "h1", FPath: "/b" },
+		{ Time: 100, Host: "h2", FPath: "/a" },
+		{ Time: 200, Host: "h1", FPath: "/a" },
+		{ Time: 100, Host: "h1", FPath: "/z" },
+	}
+	hr.Sort()
+
+	want := []string{ "100 h1 /z", "100 h2 /a", "200 h1 /a", "200 h1 /b" }
+	for i, rec := range hr {
+		if got := fmt.Sprintf("%d %s %s", rec.Time, rec.Host, rec.FPath); got != want[i] {
+			t.Errorf("[%d] want %q, got %q", i, want[i], got)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package dbms
+
+// Number of missed heartbeats after which the agent of the host is considered stale
+const HostStaleHeartbeats = 3
+
+// Information about the host with the running agent, registered by the agent
+// on startup and periodically updated by heartbeats. Times are Unix timestamps
+type HostInfo struct {
+	Host		string
+	Version		string		// Version of the agent
+	IdxPaths	[]string	// Indexing paths
+	CalcSums	bool		// Checksums of regular files are calculated
+	MaxSumSize	int64		// Maximum size of file to calculate checksum, 0 - no limit
+	Started		int64		// Start time of the agent
+	Heartbeat	int64		// Time of the last heartbeat, 0 - the agent does not send heartbeats
+	HBPeriod	int64		// Period between heartbeats in seconds
+	LastFlush	int64		// Time of the last batch committed since start, 0 - none
+	Objects		int64		// Number of indexed objects of the host
+}
+
+// Stale reports whether the agent of the host has not sent heartbeats during the window in seconds
+// at the time now. If window is 0, HostStaleHeartbeats periods of the host are used as the window.
+// Hosts without heartbeats are always stale
+func (hi *HostInfo) Stale(now, window int64) bool {
+	if hi.Heartbeat == 0 || hi.HBPeriod <= 0 {
+		return true
+	}
+
+	if window == 0 {
+		window = HostStaleHeartbeats * hi.HBPeriod
+	}
+
+	return now - hi.Heartbeat > window
+}
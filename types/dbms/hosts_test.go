@@ -0,0 +1,32 @@
+package dbms
+
+import "testing"
+
+func TestHostInfoStale(t *testing.T) {
+	const now = 1_700_000_000
+
+	tests := []struct {
+		hi		HostInfo
+		window	int64
+		want	bool
+	}{
+		{ HostInfo{Heartbeat: now, HBPeriod: 60},			0,		false },
+		{ HostInfo{Heartbeat: now - 180, HBPeriod: 60},		0,		false },
+		{ HostInfo{Heartbeat: now - 181, HBPeriod: 60},		0,		true },
+		{ HostInfo{Heartbeat: now - 3600, HBPeriod: 60},	0,		true },
+		// Window is set explicitly
+		{ HostInfo{Heartbeat: now - 181, HBPeriod: 60},		600,	false },
+		{ HostInfo{Heartbeat: now - 601, HBPeriod: 60},		600,	true },
+		{ HostInfo{Heartbeat: now - 61, HBPeriod: 60},		60,		true },
+		// No heartbeats - host registered by agent that does not send them
+		{ HostInfo{},										0,		true },
+		{ HostInfo{},										600,	true },
+		{ HostInfo{Heartbeat: now},							0,		true },
+	}
+
+	for i, test := range tests {
+		if got := test.hi.Stale(now, test.window); got != test.want {
+			t.Errorf("[%d] %+v, window %d - want stale %t, got %t", i, test.hi, test.window, test.want, got)
+		}
+	}
+}
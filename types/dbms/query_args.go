@@ -2,6 +2,8 @@ package dbms
 
 import (
 	"fmt"
+	"path"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -27,16 +29,72 @@ type QueryArgs struct {
 	SizeEnd		int64
 	SizeSet		[]int64
 
+	// Ctime related
+	CtimeStart	int64
+	CtimeEnd	int64
+	CtimeSet	[]int64
+
+	// Number of hard links related
+	NLinkStart	int64
+	NLinkEnd	int64
+	NLinkSet	[]int64
+
+	// Capture time related
+	TakenStart	int64
+	TakenEnd	int64
+	TakenSet	[]int64
+
+	// Ownership related
+	UIDs		[]int64
+	GIDs		[]int64
+	Users		[]string
+	Groups		[]string
+
+	// Permissions related
+	Perm		int64
+	PermMatch	PermMatch
+
 	Types		[]string
 	CSums		[]string
 	Ids			[]string
 	Hosts		[]string
+	Inodes		[]int64
+	MIMEs		[]string
+	Cameras		[]string
+
+	// Symbolic links related
+	Dangling	bool	// Match dangling symbolic links and links in loops
+	PointsTo	string	// Absolute path, match symbolic links pointing to it or into it
+
+	// Patterns of paths and names, joined with other conditions by AND
+	PathGlob	string	// Shell pattern matched to the whole found path, wildcards do not match "/"
+	NameRegex	string	// Regular expression in the Go syntax matched to any part of the name
+
+	// Content related
+	Content		string		// Phrase to search in the content of documents
+	ContentIds	[]string	// Identifiers of objects with matched content, restrict the search results
 	AIIFields	[]string
 
+	// Query expression joined with other conditions by AND, see ParseExpr
+	Expr		*Expr
+
+	// Hosts excluded from the search results, regardless of negation and OR-ing of other conditions
+	ExclHosts	[]string
+	// Identifiers of objects to which the search results are restricted, also regardless of negation and OR-ing
+	OnlyIds		[]string
+
 	types.SearchFlags
 	types.CommonFlags
 }
 
+// Modes of matching permission bits
+type PermMatch int
+const (
+	PermNone = PermMatch(iota)	// permissions are not matched
+	PermExact					// permission bits should be exactly equal
+	PermAll						// all specified permission bits should be set
+)
+
 func NewQueryArgs() *QueryArgs {
 	return &QueryArgs{}
 }
@@ -63,6 +121,21 @@ func (qa *QueryArgs) Clone() *QueryArgs {
 	copy(rv.MtimeSet, qa.MtimeSet)
 	rv.SizeSet = make([]int64, len(qa.SizeSet))
 	copy(rv.SizeSet, qa.SizeSet)
+	rv.CtimeSet = make([]int64, len(qa.CtimeSet))
+	copy(rv.CtimeSet, qa.CtimeSet)
+	rv.NLinkSet = make([]int64, len(qa.NLinkSet))
+	copy(rv.NLinkSet, qa.NLinkSet)
+	rv.TakenSet = make([]int64, len(qa.TakenSet))
+	copy(rv.TakenSet, qa.TakenSet)
+
+	rv.UIDs = make([]int64, len(qa.UIDs))
+	copy(rv.UIDs, qa.UIDs)
+	rv.GIDs = make([]int64, len(qa.GIDs))
+	copy(rv.GIDs, qa.GIDs)
+	rv.Users = make([]string, len(qa.Users))
+	copy(rv.Users, qa.Users)
+	rv.Groups = make([]string, len(qa.Groups))
+	copy(rv.Groups, qa.Groups)
 
 	rv.Types = make([]string, len(qa.Types))
 	copy(rv.Types, qa.Types)
@@ -76,9 +149,29 @@ func (qa *QueryArgs) Clone() *QueryArgs {
 	rv.Hosts = make([]string, len(qa.Hosts))
 	copy(rv.Hosts, qa.Hosts)
 
+	rv.Inodes = make([]int64, len(qa.Inodes))
+	copy(rv.Inodes, qa.Inodes)
+
+	rv.MIMEs = make([]string, len(qa.MIMEs))
+	copy(rv.MIMEs, qa.MIMEs)
+
+	rv.Cameras = make([]string, len(qa.Cameras))
+	copy(rv.Cameras, qa.Cameras)
+
+	rv.ContentIds = make([]string, len(qa.ContentIds))
+	copy(rv.ContentIds, qa.ContentIds)
+
 	rv.AIIFields = make([]string, len(qa.AIIFields))
 	copy(rv.AIIFields, qa.AIIFields)
 
+	rv.ExclHosts = make([]string, len(qa.ExclHosts))
+	copy(rv.ExclHosts, qa.ExclHosts)
+
+	rv.OnlyIds = make([]string, len(qa.OnlyIds))
+	copy(rv.OnlyIds, qa.OnlyIds)
+
+	rv.Expr = qa.Expr.Clone()
+
 	return &rv
 }
 
@@ -90,6 +183,86 @@ func (qa *QueryArgs) IsSize() bool {
 	return len(qa.SizeSet) != 0 || qa.SizeStart != 0 || qa.SizeEnd != 0
 }
 
+func (qa *QueryArgs) IsCtime() bool {
+	return len(qa.CtimeSet) != 0 || qa.CtimeStart != 0 || qa.CtimeEnd != 0
+}
+
+func (qa *QueryArgs) IsNLink() bool {
+	return len(qa.NLinkSet) != 0 || qa.NLinkStart != 0 || qa.NLinkEnd != 0
+}
+
+func (qa *QueryArgs) IsTaken() bool {
+	return len(qa.TakenSet) != 0 || qa.TakenStart != 0 || qa.TakenEnd != 0
+}
+
+func (qa *QueryArgs) IsCamera() bool {
+	return len(qa.Cameras) != 0
+}
+
+func (qa *QueryArgs) IsDangling() bool {
+	return qa.Dangling
+}
+
+func (qa *QueryArgs) IsPointsTo() bool {
+	return qa.PointsTo != ""
+}
+
+func (qa *QueryArgs) IsPathGlob() bool {
+	return qa.PathGlob != ""
+}
+
+func (qa *QueryArgs) IsNameRegex() bool {
+	return qa.NameRegex != ""
+}
+
+func (qa *QueryArgs) IsContent() bool {
+	return qa.Content != ""
+}
+
+func (qa *QueryArgs) IsContentIds() bool {
+	return len(qa.ContentIds) != 0
+}
+
+func (qa *QueryArgs) IsExpr() bool {
+	return qa.Expr != nil
+}
+
+func (qa *QueryArgs) IsExclHosts() bool {
+	return len(qa.ExclHosts) != 0
+}
+
+func (qa *QueryArgs) IsOnlyIds() bool {
+	return len(qa.OnlyIds) != 0
+}
+
+func (qa *QueryArgs) IsUID() bool {
+	return len(qa.UIDs) != 0
+}
+
+func (qa *QueryArgs) IsGID() bool {
+	return len(qa.GIDs) != 0
+}
+
+func (qa *QueryArgs) IsUser() bool {
+	return len(qa.Users) != 0
+}
+
+func (qa *QueryArgs) IsGroup() bool {
+	return len(qa.Groups) != 0
+}
+
+func (qa *QueryArgs) IsPerm() bool {
+	return qa.PermMatch != PermNone
+}
+
+func (qa *QueryArgs) IsInode() bool {
+	return len(qa.Inodes) != 0
+}
+
+func (qa *QueryArgs) IsMIME() bool {
+	return len(qa.MIMEs) != 0
+}
+
 func (qa *QueryArgs) IsType() bool {
 	return len(qa.Types) != 0
 }
@@ -124,7 +297,12 @@ func (qa *QueryArgs) CanSearch(searchPhrases []string) bool {
 	}
 
 	if qa.IsMtime() || qa.IsSize() || qa.IsType() ||
-	   qa.IsChecksum() || qa.IsHost() || qa.IsAIIFields() {
+	   qa.IsChecksum() || qa.IsHost() || qa.IsAIIFields() ||
+	   qa.IsCtime() || qa.IsNLink() || qa.IsUID() || qa.IsGID() ||
+	   qa.IsUser() || qa.IsGroup() || qa.IsPerm() || qa.IsInode() ||
+	   qa.IsMIME() || qa.IsTaken() || qa.IsCamera() || qa.IsContent() ||
+	   qa.IsDangling() || qa.IsPointsTo() || qa.IsExpr() ||
+	   qa.IsPathGlob() || qa.IsNameRegex() {
 		// Sufficient conditions to search query
 		return true
 	}
@@ -138,6 +316,29 @@ func (qa *QueryArgs) UseAII() bool {
 }
 
 func (qa *QueryArgs) ParseMtimes(mtimeLine string) error {
+	return parseTimes("mtime", mtimeLine, &qa.MtimeStart, &qa.MtimeEnd, &qa.MtimeSet)
+}
+
+func (qa *QueryArgs) ParseCtimes(ctimeLine string) error {
+	return parseTimes("ctime", ctimeLine, &qa.CtimeStart, &qa.CtimeEnd, &qa.CtimeSet)
+}
+
+func (qa *QueryArgs) ParseTakens(takenLine string) error {
+	if err := parseTimes("taken", takenLine, &qa.TakenStart, &qa.TakenEnd, &qa.TakenSet); err != nil {
+		return err
+	}
+
+	// Objects without known capture time have zero value of the taken field,
+	// they should not match the range open from the left
+	if qa.TakenStart == 0 && qa.TakenEnd != 0 {
+		qa.TakenStart = 1
+	}
+
+	// OK
+	return nil
+}
+
+func parseTimes(name, timesLine string, start, end *int64, set *[]int64) error {
 	// Possible variants:
 	// * ts1[,ts2,ts3...]
 	// * ts1..ts2
@@ -145,86 +346,86 @@ func (qa *QueryArgs) ParseMtimes(mtimeLine string) error {
 	// * ..ts2
 
 	// Need to determine format - set or range?
-	if strings.Contains(mtimeLine, dataRangeSep) {
+	if strings.Contains(timesLine, dataRangeSep) {
 		// Range provided
-		return qa.parseMtimesRange(mtimeLine)
+		return parseRange(name, timesLine, parseTime, start, end)
 	}
 
 	// Set of times provided, parse it and return
-	return qa.parseMtimeSet(mtimeLine)
+	return parseTimeSet(name, timesLine, set)
+}
+
+func parseTimeSet(name, timeSet string, set *[]int64) error {
+	// Replace each escaped comma by fake delimiter to avoid wrong split of TS containing a comma
+	const fakeDelim = "\u0000|\u0000"
+	timeSet = strings.ReplaceAll(timeSet, `\,`, fakeDelim)
+
+	// Split set and parse one by one
+	*set = []int64{}
+	for _, timeStr := range strings.Split(timeSet, ",") {
+		ts, err := parseTime(
+			// Return commas back if they were replaced by escape character
+			strings.ReplaceAll(timeStr, fakeDelim, ","))
+		if err != nil {
+			return fmt.Errorf("invalid %ss set: %w", name, err)
+		}
+
+		// Append parsed TS
+		*set = append(*set, ts)
+	}
+
+	// OK
+	return nil
 }
 
 //nolint:cyclop	// Simplifying the code will not make it clearer
-func (qa *QueryArgs) parseMtimesRange(mtimeRange string) error {
+func parseRange(name, line string, parser func(string) (int64, error), start, end *int64) error {
 	// Split to check correctness
-	tsRange := strings.Split(mtimeRange, dataRangeSep)
+	vRange := strings.Split(line, dataRangeSep)
 	//nolint:gomnd	// Check for range length - it always should be == 2
-	if len(tsRange) != 2 {
-		return fmt.Errorf("invalid mtime range %q", mtimeRange)
+	if len(vRange) != 2 {
+		return fmt.Errorf("invalid %s range %q", name, line)
 	}
 
-	start, end := tsRange[0], tsRange[1]
+	startStr, endStr := vRange[0], vRange[1]
 
 	var err error
 	// Need to select correct case of ranges
 	switch {
 	// Start and end both set
-	case start != "" && end != "":
-		if qa.MtimeStart, err = parseTime(start); err != nil {
-			return fmt.Errorf("invalid mtime range start in %q: %w", mtimeRange, err)
+	case startStr != "" && endStr != "":
+		if *start, err = parser(startStr); err != nil {
+			return fmt.Errorf("invalid %s range start in %q: %w", name, line, err)
 		}
-		if qa.MtimeEnd, err = parseTime(end); err != nil {
-			return fmt.Errorf("invalid mtime range end in %q: %w", mtimeRange, err)
+		if *end, err = parser(endStr); err != nil {
+			return fmt.Errorf("invalid %s range end in %q: %w", name, line, err)
 		}
 
 		// Check that start < end
-		if qa.MtimeEnd <= qa.MtimeStart {
-			return fmt.Errorf("invalid mtime range %q - end of the range must be greater than start", mtimeRange)
+		if *end <= *start {
+			return fmt.Errorf("invalid %s range %q - end of the range must be greater than start", name, line)
 		}
 
 	// Only start is set
-	case start != "" && end == "":
-		if qa.MtimeStart, err = parseTime(start); err != nil {
-			return fmt.Errorf("invalid mtime range start in %q: %w", mtimeRange, err)
+	case startStr != "" && endStr == "":
+		if *start, err = parser(startStr); err != nil {
+			return fmt.Errorf("invalid %s range start in %q: %w", name, line, err)
 		}
 
 	// Only end is set
-	case start == "" && end != "":
-		if qa.MtimeEnd, err = parseTime(end); err != nil {
-			return fmt.Errorf("invalid mtime range end in %q: %w", mtimeRange, err)
+	case startStr == "" && endStr != "":
+		if *end, err = parser(endStr); err != nil {
+			return fmt.Errorf("invalid %s range end in %q: %w", name, line, err)
 		}
 
 	default:
-		return fmt.Errorf("invalid mtime range %q", mtimeRange)
+		return fmt.Errorf("invalid %s range %q", name, line)
 	}
 
 	// OK, range parsed successfully
 	return nil
 }
 
-func (qa *QueryArgs) parseMtimeSet(mtimeSet string) error {
-	// Replace each escaped comma by fake delimiter to avoid wrong split of TS containing a comma
-	const fakeDelim = "\u0000|\u0000"
-	mtimeSet = strings.ReplaceAll(mtimeSet, `\,`, fakeDelim)
-
-	// Split set and parse one by one
-	qa.MtimeSet = []int64{}
-	for _, timeStr := range strings.Split(mtimeSet, ",") {
-		ts, err := parseTime(
-			// Return commas back if they were replaced by escape character
-			strings.ReplaceAll(timeStr, fakeDelim, ","))
-		if err != nil {
-			return fmt.Errorf("invalid mtimes set: %w", err)
-		}
-
-		// Append parsed TS
-		qa.MtimeSet = append(qa.MtimeSet, ts)
-	}
-
-	// OK
-	return nil
-}
-
 // Supported formats of timestamps
 func TSFormats() []string {
 	return []string{
@@ -291,78 +492,54 @@ func parseTime(timeStr string) (int64, error) {
 }
 
 func (qa *QueryArgs) ParseSizes(sizeLine string) error {
+	return parseIntsSetRange("size", sizeLine, parseSize, &qa.SizeStart, &qa.SizeEnd, &qa.SizeSet)
+}
+
+func (qa *QueryArgs) ParseNLinks(nlinkLine string) error {
+	return parseIntsSetRange("number of links", nlinkLine, parseInt, &qa.NLinkStart, &qa.NLinkEnd, &qa.NLinkSet)
+}
+
+func parseIntsSetRange(name, line string, parser func(string) (int64, error), start, end *int64, set *[]int64) error {
 	// Possible variants:
-	// * size1[,size2,size3...]
-	// * size1..size2
-	// * size1..
-	// * ..size2
+	// * val1[,val2,val3...]
+	// * val1..val2
+	// * val1..
+	// * ..val2
 
 	// Need to determine format - set or range?
-	if strings.Contains(sizeLine, dataRangeSep) {
-		// Sizes rane provided
-		return qa.parseSizesRange(sizeLine)
+	if strings.Contains(line, dataRangeSep) {
+		// Range provided
+		return parseRange(name, line, parser, start, end)
 	}
 
-	// Set of sizes provided
+	// Set of values provided
+	return parseIntsSet(name, line, parser, set)
+}
 
+func parseIntsSet(name, line string, parser func(string) (int64, error), set *[]int64) error {
 	// Split set and parse one by one
-	qa.SizeSet = []int64{}
-	for _, sizeStr := range strings.Split(sizeLine, ",") {
-		ts, err := parseSize(sizeStr)
+	*set = []int64{}
+	for _, valStr := range strings.Split(line, ",") {
+		val, err := parser(valStr)
 		if err != nil {
-			return fmt.Errorf("invalid sizes set %q: %w", sizeLine, err)
+			return fmt.Errorf("invalid %s set %q: %w", name, line, err)
 		}
 
-		// Append parsed TS
-		qa.SizeSet = append(qa.SizeSet, ts)
+		// Append parsed value
+		*set = append(*set, val)
 	}
 
 	// OK, set parsed successfully
 	return nil
 }
 
-//nolint:cyclop	// Simplifying the code will not make it clearer
-func (qa *QueryArgs) parseSizesRange(sizeLine string) error {
-	// It should be a range, split to check
-	sizeRange := strings.Split(sizeLine, dataRangeSep)
-	//nolint:gomnd	// Check for range length - it always should be == 2
-	if len(sizeRange) != 2 {
-		return fmt.Errorf("invalid mtime range %q", sizeLine)
-	}
-
-	start, end := sizeRange[0], sizeRange[1]
-
-	var err error
-	// Need to select correct case of ranges
-	switch {
-	// Start and end both set
-	case start != "" && end != "":
-		if qa.SizeStart, err = parseSize(start); err != nil {
-			return fmt.Errorf("invalid size range start in %q: %w", sizeLine, err)
-		}
-		if qa.SizeEnd, err = parseSize(end); err != nil {
-			return fmt.Errorf("invalid size range end in %q: %w", sizeLine, err)
-		}
-		// Check that start < end
-		if qa.SizeEnd <= qa.SizeStart {
-			return fmt.Errorf("invalid size range %q - end of the range must be greater than start", sizeLine)
-		}
-	// Only start is set
-	case start != "" && end == "":
-		if qa.SizeStart, err = parseSize(start); err != nil {
-			return fmt.Errorf("invalid size range start in %q: %w", sizeLine, err)
-		}
-	// Only end is set
-	case start == "" && end != "":
-		if qa.SizeEnd, err = parseSize(end); err != nil {
-			return fmt.Errorf("invalid size range end in %q: %w", sizeLine, err)
-		}
-	default:
-		return fmt.Errorf("invalid size range %q", sizeLine)
+func parseInt(valStr string) (int64, error) {
+	val, err := strconv.ParseInt(strings.TrimSpace(valStr), 10, 64)
+	if err != nil || val < 0 {
+		return -1, fmt.Errorf("invalid value %q", valStr)
 	}
 
-	// OK, range parsed successfully
-	return nil
+	return val, nil
 }
 
 const (
@@ -420,6 +597,171 @@ func (qa *QueryArgs) ParseHosts(val string) error {
 	return parse.StringsSet(&qa.Hosts, "host", val)
 }
 
+func (qa *QueryArgs) ParseCameras(val string) error {
+	if err := parse.StringsSet(&qa.Cameras, "camera", val); err != nil {
+		return err
+	}
+
+	for _, camera := range qa.Cameras {
+		if strings.TrimSpace(camera) == "" {
+			qa.Cameras = nil
+			return fmt.Errorf("empty camera value in the input string: %q", val)
+		}
+	}
+
+	// OK
+	return nil
+}
+
+func (qa *QueryArgs) ParseUsers(val string) error {
+	return parse.StringsSet(&qa.Users, "user", val)
+}
+
+func (qa *QueryArgs) ParseGroups(val string) error {
+	return parse.StringsSet(&qa.Groups, "group", val)
+}
+
+func (qa *QueryArgs) ParseUIDs(val string) error {
+	return parseIntsSet("uid", val, parseInt, &qa.UIDs)
+}
+
+func (qa *QueryArgs) ParseGIDs(val string) error {
+	return parseIntsSet("gid", val, parseInt, &qa.GIDs)
+}
+
+func (qa *QueryArgs) ParseInodes(val string) error {
+	return parseIntsSet("inode", val, parseInt, &qa.Inodes)
+}
+
+// Wildcard that can be used instead of MIME subtype to match all subtypes of the type
+const mimeAnySubtype = "*"
+
+func (qa *QueryArgs) ParsePointsTo(val string) error {
+	// Real paths of symbolic links are absolute and cleaned by the agent
+	if !path.IsAbs(val) {
+		return fmt.Errorf("invalid target path %q, must be absolute", val)
+	}
+
+	qa.PointsTo = path.Clean(val)
+
+	// OK
+	return nil
+}
+
+// PointsToPrefix returns the prefix of real paths of objects placed inside of the PointsTo path
+func (qa *QueryArgs) PointsToPrefix() string {
+	return PathPrefix(qa.PointsTo)
+}
+
+// PathPrefix returns the prefix of paths of objects placed inside of the directory dir
+func PathPrefix(dir string) string {
+	return strings.TrimSuffix(dir, "/") + "/"
+}
+
+func (qa *QueryArgs) ParsePathGlob(val string) error {
+	// Found paths are absolute, a relative pattern cannot match any of them
+	if !strings.HasPrefix(val, "/") {
+		return fmt.Errorf("invalid path pattern %q, must be absolute", val)
+	}
+	if _, err := path.Match(val, ""); err != nil {
+		return fmt.Errorf("invalid path pattern %q: %w", val, err)
+	}
+
+	qa.PathGlob = val
+
+	// OK
+	return nil
+}
+
+func (qa *QueryArgs) ParseNameRegex(val string) error {
+	if val == "" {
+		return fmt.Errorf("empty regular expression of names")
+	}
+	if _, err := regexp.Compile(val); err != nil {
+		return fmt.Errorf("invalid regular expression of names %q: %w", val, err)
+	}
+
+	qa.NameRegex = val
+
+	// OK
+	return nil
+}
+
+func (qa *QueryArgs) ParseMIMEs(val string) error {
+	// Content types are case-insensitive, the agent stores them in lower case
+	if err := parse.StringsSet(&qa.MIMEs, "MIME type", strings.ToLower(val)); err != nil {
+		return err
+	}
+
+	for _, mime := range qa.MIMEs {
+		// Possible variants:
+		// * type/subtype	- exact content type
+		// * type/*			- any subtype of the type
+		mType, subType, found := strings.Cut(mime, "/")
+		if !found || !isMIMEToken(mType) ||
+			(subType != mimeAnySubtype && !isMIMEToken(subType)) {
+			qa.MIMEs = nil
+			return fmt.Errorf("invalid MIME type %q, expected value in form type/subtype or type/*", mime)
+		}
+	}
+
+	// OK
+	return nil
+}
+
+// MIMEWildcard checks that the MIME type pattern mime matches all subtypes
+// of some type. If so, it returns the prefix of matching types like "image/"
+func MIMEWildcard(mime string) (prefix string, ok bool) {
+	if !strings.HasSuffix(mime, "/" + mimeAnySubtype) {
+		return "", false
+	}
+
+	return strings.TrimSuffix(mime, mimeAnySubtype), true
+}
+
+// isMIMEToken checks that the val is a non-empty token allowed in MIME type names (RFC 6838)
+func isMIMEToken(val string) bool {
+	if val == "" {
+		return false
+	}
+
+	for _, c := range val {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case strings.ContainsRune("!#$&^_.+-", c):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// Maximum value of permission bits including setuid, setgid and sticky bits
+const maxPermBits = 0o7777
+
+func (qa *QueryArgs) ParsePerm(val string) error {
+	// Possible variants, like find(1) does:
+	// * MODE  - permission bits are exactly MODE
+	// * -MODE - all of the permission bits MODE are set
+	qa.PermMatch = PermExact
+	if strings.HasPrefix(val, "-") {
+		qa.PermMatch = PermAll
+		val = val[1:]
+	}
+
+	perm, err := strconv.ParseInt(val, 8, 64)
+	if err != nil || perm < 0 || perm > maxPermBits {
+		qa.PermMatch = PermNone
+		return fmt.Errorf("invalid permissions value %q, octal value between 0 and %o expected", val, maxPermBits)
+	}
+
+	qa.Perm = perm
+
+	// OK
+	return nil
+}
+
 func (qa *QueryArgs) ParseAIIFields(val string, allowed []string) error {
 	return parse.StringsSet(&qa.AIIFields, "field name", val, allowed...)
 }
@@ -429,6 +771,21 @@ func (qa *QueryArgs) AddIds(ids ...string) *QueryArgs {
 	return qa
 }
 
+func (qa *QueryArgs) SetContentIds(ids ...string) *QueryArgs {
+	qa.ContentIds = ids
+	return qa
+}
+
+func (qa *QueryArgs) SetExclHosts(hosts ...string) *QueryArgs {
+	qa.ExclHosts = hosts
+	return qa
+}
+
+func (qa *QueryArgs) SetOnlyIds(ids ...string) *QueryArgs {
+	qa.OnlyIds = ids
+	return qa
+}
+
 func (qa *QueryArgs) AddChecksums(csums ...string) *QueryArgs {
 	qa.CSums = append(qa.CSums, csums...)
 	return qa
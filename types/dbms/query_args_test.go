@@ -32,6 +32,15 @@ func TestClone(t *testing.T) {
 					return types.SearchFlags{}
 				case types.CommonFlags:
 					return types.CommonFlags{}
+				case PermMatch:
+					return PermNone
+				case *Expr:
+					return NewAndExpr(NewCondExpr(FieldSize, CmpGe, []int64{1}, nil),
+						NewCondExpr(FieldHost, CmpEq, nil, []string{"original"}))
+				case string:
+					return "original"
+				case bool:
+					return false
 				}
 				return nil
 			}
@@ -39,9 +48,20 @@ func TestClone(t *testing.T) {
 	).AddChangers(
 		func(v reflect.Value) bool {
 			if _, ok := v.Interface().(types.SearchFlags); ok {
-				v.Set(reflect.ValueOf(types.SearchFlags{true, true, true, true, true, true}))
+				v.Set(reflect.ValueOf(types.SearchFlags{true, true, true, true, true, true, true, true}))
 			} else if _, ok  := v.Interface().(types.CommonFlags); ok {
 				v.Set(reflect.ValueOf(types.CommonFlags{true, true}))
+			} else if _, ok  := v.Interface().(PermMatch); ok {
+				v.Set(reflect.ValueOf(PermAll))
+			} else if e, ok  := v.Interface().(*Expr); ok {
+				// Change the nested values, the clone must not share them
+				e.Args[0].Cond.Ints[0]++
+				e.Args[1].Cond.Strs[0] += " changed"
+				e.Args[1].Op = ExprConst
+			} else if s, ok  := v.Interface().(string); ok {
+				v.Set(reflect.ValueOf(s + " changed"))
+			} else if b, ok  := v.Interface().(bool); ok {
+				v.Set(reflect.ValueOf(!b))
 			} else {
 				return false
 			}
@@ -137,3 +157,222 @@ func TestParseMtimes(t *testing.T) {
 		}
 	}
 }
+
+func TestParsePerm(t *testing.T) {
+	tests := []struct {
+		val		string
+		perm	int64
+		match	PermMatch
+		wantErr	bool
+	} {
+		{ val: "644",	perm: 0o644,	match: PermExact },
+		{ val: "0755",	perm: 0o755,	match: PermExact },
+		{ val: "-002",	perm: 0o002,	match: PermAll },
+		{ val: "-4000",	perm: 0o4000,	match: PermAll },
+		{ val: "0",		perm: 0,		match: PermExact },
+		// Invalid values
+		{ val: "",		wantErr: true },
+		{ val: "-",		wantErr: true },
+		{ val: "rwx",	wantErr: true },
+		{ val: "789",	wantErr: true },
+		{ val: "17777",	wantErr: true },
+		{ val: "--002",	wantErr: true },
+	}
+
+	for i, test := range tests {
+		qa := NewQueryArgs()
+		err := qa.ParsePerm(test.val)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("[%d] ParsePerm(%q) must fail, but it did not", i, test.val)
+			}
+			if qa.IsPerm() {
+				t.Errorf("[%d] permissions must not be set after ParsePerm(%q) failure", i, test.val)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[%d] ParsePerm(%q) returned unexpected error: %v", i, test.val, err)
+			continue
+		}
+		if qa.Perm != test.perm || qa.PermMatch != test.match {
+			t.Errorf("[%d] ParsePerm(%q) set perm %o, match %d, want - perm %o, match %d",
+				i, test.val, qa.Perm, qa.PermMatch, test.perm, test.match)
+		}
+	}
+}
+
+func TestParseNLinks(t *testing.T) {
+	tests := []struct {
+		val			string
+		start, end	int64
+		set			[]int64
+		wantErr		bool
+	} {
+		{ val: "2..",		start: 2 },
+		{ val: "..3",		end: 3 },
+		{ val: "2..5",		start: 2, end: 5 },
+		{ val: "1,2,3",		set: []int64{1, 2, 3} },
+		// Invalid values
+		{ val: "5..2",		wantErr: true },
+		{ val: "..",		wantErr: true },
+		{ val: "1..2..3",	wantErr: true },
+		{ val: "1,x",		wantErr: true },
+		{ val: "-1",		wantErr: true },
+		{ val: "1K",		wantErr: true },
+	}
+
+	for i, test := range tests {
+		qa := NewQueryArgs()
+		err := qa.ParseNLinks(test.val)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("[%d] ParseNLinks(%q) must fail, but it did not", i, test.val)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[%d] ParseNLinks(%q) returned unexpected error: %v", i, test.val, err)
+			continue
+		}
+		if qa.NLinkStart != test.start || qa.NLinkEnd != test.end ||
+			(test.set != nil && !reflect.DeepEqual(qa.NLinkSet, test.set)) {
+			t.Errorf("[%d] ParseNLinks(%q) set range %d..%d, set %v, want - range %d..%d, set %v",
+				i, test.val, qa.NLinkStart, qa.NLinkEnd, qa.NLinkSet, test.start, test.end, test.set)
+		}
+	}
+}
+
+func TestParseMIMEs(t *testing.T) {
+	tests := []struct {
+		val		string
+		want	[]string
+		wantErr	bool
+	} {
+		{ val: "video/mp4",					want: []string{"video/mp4"} },
+		{ val: "Image/*,video/*",			want: []string{"image/*", "video/*"} },
+		{ val: "application/vnd.ms-excel",	want: []string{"application/vnd.ms-excel"} },
+		// Invalid values
+		{ val: "",				wantErr: true },
+		{ val: "image",			wantErr: true },
+		{ val: "image/",		wantErr: true },
+		{ val: "*/*",			wantErr: true },
+		{ val: "image/jp*",		wantErr: true },
+		{ val: "image/png,",	wantErr: true },
+		{ val: "a/b/c",			wantErr: true },
+	}
+
+	for i, test := range tests {
+		qa := NewQueryArgs()
+		err := qa.ParseMIMEs(test.val)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("[%d] ParseMIMEs(%q) must fail, but it did not", i, test.val)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[%d] ParseMIMEs(%q) returned unexpected error: %v", i, test.val, err)
+			continue
+		}
+		if !reflect.DeepEqual(qa.MIMEs, test.want) {
+			t.Errorf("[%d] ParseMIMEs(%q) set %v, want - %v", i, test.val, qa.MIMEs, test.want)
+		}
+	}
+}
+
+func TestMIMEWildcard(t *testing.T) {
+	for mime, want := range map[string]string{
+		"image/*":		"image/",
+		"image/png":	"",
+		"image/x-*":	"",
+	} {
+		prefix, ok := MIMEWildcard(mime)
+		if prefix != want || ok != (want != "") {
+			t.Errorf("MIMEWildcard(%q) returned (%q, %t), want - (%q, %t)", mime, prefix, ok, want, want != "")
+		}
+	}
+}
+
+func TestParseTakens(t *testing.T) {
+	// Range open from the left should not match objects without capture time
+	qa := NewQueryArgs()
+	if err := qa.ParseTakens("..946771200"); err != nil {
+		t.Fatalf("ParseTakens returned unexpected error: %v", err)
+	}
+	if qa.TakenStart != 1 || qa.TakenEnd != 946771200 {
+		t.Errorf("ParseTakens set range %d..%d, want - 1..946771200", qa.TakenStart, qa.TakenEnd)
+	}
+
+	// Range open from the right should be kept as is
+	qa = NewQueryArgs()
+	if err := qa.ParseTakens("946771200.."); err != nil {
+		t.Fatalf("ParseTakens returned unexpected error: %v", err)
+	}
+	if qa.TakenStart != 946771200 || qa.TakenEnd != 0 {
+		t.Errorf("ParseTakens set range %d..%d, want - 946771200..0", qa.TakenStart, qa.TakenEnd)
+	}
+}
+
+func TestParsePointsTo(t *testing.T) {
+	tests := []struct {
+		val		string
+		want	string
+		prefix	string
+		wantErr	bool
+	} {
+		{ val: "/data/photos",		want: "/data/photos",	prefix: "/data/photos/" },
+		{ val: "/data//photos/",	want: "/data/photos",	prefix: "/data/photos/" },
+		{ val: "/",					want: "/",				prefix: "/" },
+		// Invalid values
+		{ val: "",				wantErr: true },
+		{ val: "data/photos",	wantErr: true },
+	}
+
+	for i, test := range tests {
+		qa := NewQueryArgs()
+		err := qa.ParsePointsTo(test.val)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("[%d] ParsePointsTo(%q) must fail, but it did not", i, test.val)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[%d] ParsePointsTo(%q) returned unexpected error: %v", i, test.val, err)
+			continue
+		}
+		if qa.PointsTo != test.want || qa.PointsToPrefix() != test.prefix {
+			t.Errorf("[%d] ParsePointsTo(%q) set %q (prefix %q), want - %q (prefix %q)",
+				i, test.val, qa.PointsTo, qa.PointsToPrefix(), test.want, test.prefix)
+		}
+	}
+}
+
+func TestParsePatterns(t *testing.T) {
+	for val, ok := range map[string]bool{
+		"/data/*/photos/*.jpg":	true,
+		"/data/[a-":				false,
+		"data/*":				false,
+		"":						false,
+	} {
+		if err := NewQueryArgs().ParsePathGlob(val); (err == nil) != ok {
+			t.Errorf("ParsePathGlob(%q) - want success %t, got error: %v", val, ok, err)
+		}
+	}
+
+	for val, ok := range map[string]bool{
+		`^IMG_\d+\.jpe?g$`:	true,
+		`(?i)report`:		true,
+		`(unclosed`:			false,
+		``:					false,
+	} {
+		if err := NewQueryArgs().ParseNameRegex(val); (err == nil) != ok {
+			t.Errorf("ParseNameRegex(%q) - want success %t, got error: %v", val, ok, err)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package dbms
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Characters that separate words of values of text fields in addition to white spaces
+// and control characters, the same as default separators of RediSearch
+const TextSeparators = ",.<>{}[]\"':;!@#$%^&*()-+=~|/\\"
+
+// TextWords splits the phrase to lowercase words. Text fields (names, paths and cameras) are matched by
+// phrases by whole words - the field matches the phrase if it contains all words of the phrase in any order,
+// case-insensitively, so all backends must split values of fields and phrases to words the same way
+//
+// XXX Words are converted to lowercase because RediSearch does not
+// XXX fully support case insensitivity for non-English locales
+func TextWords(phrase string) []string {
+	return strings.FieldsFunc(strings.ToLower(phrase), IsTextSeparator)
+}
+
+// IsTextSeparator reports whether the character separates words of values of text fields
+func IsTextSeparator(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsControl(r) || strings.ContainsRune(TextSeparators, r)
+}
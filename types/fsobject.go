@@ -1,5 +1,7 @@
 package types
 
+import "strings"
+
 const (
 	// Stubs to fill checksum field on special cases
 	CsTooLarge = `<FILE TOO LARGE>`
@@ -13,13 +15,56 @@ type FSObject struct {
 	// XXX Do not forget to update FSObjectFieldsNum on changing number of fields in this structure
 	Name		string
 	FPath		string	// Found path
-	RPath		string	// Real object path
+	RPath		string	// Real object path, fully resolved absolute path for directories and symbolic links
 	Type		string	// Regular file, directory, symbolic link, etc...
+	LinkState	string	// State of the target, only for symbolic links
 	Size		int64
 	MTime		int64
 	Checksum	string
+
+	// Extended metadata, filled if supported by the agent's platform
+	UID			int64
+	GID			int64
+	User		string	// Owner name resolved from UID, empty if cannot be resolved
+	Group		string	// Group name resolved from GID, empty if cannot be resolved
+	Mode		int64	// Permission bits including setuid, setgid and sticky in Unix format
+	CTime		int64	// Inode change time
+	Inode		int64
+	Device		int64	// Identifier of device containing the object
+	NLink		int64	// Number of hard links
+
+	MIME		string	// Content type detected by the magic bytes, only for regular files
+
+	// Metadata of media files, filled only if extraction is enabled on the agent
+	Taken		int64		// Capture time of photo or video
+	Camera		string		// Camera make and model
+	GPS			*GeoPoint	// Location where the photo was taken, nil if unknown
+	Duration	int64		// Duration of video in milliseconds
+	Width		int64		// Resolution of image or video
+	Height		int64
+
+	// Text content of the document, stored separately from the object, nil if was not extracted
+	Content		*ObjContent
+
+	// Found path of the archive containing the object, only for archive members
+	Archive		string
+}
+const FSObjectFieldsNum = 26
+
+// Text content of the document extracted by the agent
+type ObjContent struct {
+	Text	string
+	// Typed attributes of the document, zero values mean unknown
+	Title	string
+	Author	string
+	Pages	int64
+}
+
+// Geographic coordinates in decimal degrees
+type GeoPoint struct {
+	Lat	float64
+	Lon	float64
 }
-const FSObjectFieldsNum = 7
 
 // Supported object types
 const (
@@ -27,6 +72,7 @@ const (
 	ObjRegular		=	"reg"
 	ObjDirectory	=	"dir"
 	ObjSymlink		=	"sym"
+	ObjArcMember	=	"arc-member"	// Regular file stored in an archive
 )
 
 func ObjTypes() []string {
@@ -34,9 +80,32 @@ func ObjTypes() []string {
 		ObjRegular,
 		ObjDirectory,
 		ObjSymlink,
+		ObjArcMember,
 	}
 }
 
+// States of targets of symbolic links
+const (
+	LinkOK			=	"ok"
+	LinkDangling	=	"dangling"	// Target does not exist
+	LinkLoop		=	"loop"		// Target cannot be resolved due to a loop of symbolic links
+)
+
+// Separator between the path of archive and the path of member inside of it
+// in found paths of archive members, e.g: /backups/etc.tar.gz!/etc/hosts
+const ArcMemberSep = "!/"
+
+// ArcMemberFPath returns the found path of the member with relative path member of the archive arcPath
+func ArcMemberFPath(arcPath, member string) string {
+	return arcPath + ArcMemberSep + member
+}
+
+// SplitArcMemberFPath splits the found path of the archive member to the path of archive and the
+// relative path of member, returns false if fpath does not contain the separator
+func SplitArcMemberFPath(fpath string) (arcPath, member string, ok bool) {
+	return strings.Cut(fpath, ArcMemberSep)
+}
+
 //
 // Filesystem object key
 //
@@ -49,3 +49,24 @@ func TestObjKeyLess(t *testing.T) {
 		t.Errorf("Object key %#v not lesser than %#v, but must", ok9, ok10)
 	}
 }
+
+func TestSplitArcMemberFPath(t *testing.T) {
+	tests := []struct {
+		fpath	string
+		arc		string
+		member	string
+		ok		bool
+	}{
+		{ ArcMemberFPath("/backups/x.tar.gz", "etc/hosts"), "/backups/x.tar.gz", "etc/hosts", true },
+		{ "/backups/x.zip!/dir/file.txt",	"/backups/x.zip",	"dir/file.txt",	true },
+		{ "/data/regular/file",				"/data/regular/file",	"",			false },
+	}
+
+	for i, test := range tests {
+		arc, member, ok := SplitArcMemberFPath(test.fpath)
+		if arc != test.arc || member != test.member || ok != test.ok {
+			t.Errorf("[%d] %q - want (%q, %q, %t), got (%q, %q, %t)",
+				i, test.fpath, test.arc, test.member, test.ok, arc, member, ok)
+		}
+	}
+}